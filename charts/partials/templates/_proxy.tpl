@@ -201,6 +201,10 @@ env:
   value: {{$trustDomain}}
 - name: LINKERD2_PROXY_IDENTITY_DIR
   value: /var/run/linkerd/identity/end-entity
+{{ if .Values.proxy.identityKeyAlgorithm -}}
+- name: LINKERD2_PROXY_IDENTITY_KEY_ALGORITHM
+  value: {{.Values.proxy.identityKeyAlgorithm | quote}}
+{{ end -}}
 - name: LINKERD2_PROXY_IDENTITY_TRUST_ANCHORS
 {{- /*
 Pods in the `linkerd` namespace are not injected by the proxy injector and instead obtain
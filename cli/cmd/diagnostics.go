@@ -31,6 +31,9 @@ This command provides subcommands to diagnose the functionality of Linkerd.`,
 	diagnosticsCmd.AddCommand(newCmdMetrics())
 	diagnosticsCmd.AddCommand(newCmdPolicy())
 	diagnosticsCmd.AddCommand(newCmdDiagnosticsProfile())
+	diagnosticsCmd.AddCommand(newCmdDiagnosticsProxyDrift())
+	diagnosticsCmd.AddCommand(newCmdDiagnosticsProxyResources())
+	diagnosticsCmd.AddCommand(newCmdDiagnosticsInjection())
 
 	return diagnosticsCmd
 }
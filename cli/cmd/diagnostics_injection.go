@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// injectionResponsesMetric counts the proxy injector's admission responses,
+// labelled with whether the proxy injection was skipped and why
+const injectionResponsesMetric = "proxy_inject_admission_responses_total"
+
+type injectionOptions struct {
+	namespace string
+	wait      time.Duration
+	output    string
+}
+
+// injectionSummary aggregates the proxy injector's admission responses for
+// the pods of a namespace owned by a given kind of workload
+type injectionSummary struct {
+	Namespace   string            `json:"namespace"`
+	OwnerKind   string            `json:"ownerKind"`
+	Injected    uint64            `json:"injected"`
+	Skipped     uint64            `json:"skipped"`
+	SkipReasons map[string]uint64 `json:"skipReasons,omitempty"`
+}
+
+func newInjectionOptions() *injectionOptions {
+	return &injectionOptions{
+		wait:   30 * time.Second,
+		output: tableOutput,
+	}
+}
+
+func (o *injectionOptions) validate() error {
+	if o.output != tableOutput && o.output != jsonOutput {
+		return fmt.Errorf("--output must be one of: %s, %s", tableOutput, jsonOutput)
+	}
+	return nil
+}
+
+func newCmdDiagnosticsInjection() *cobra.Command {
+	options := newInjectionOptions()
+
+	cmd := &cobra.Command{
+		Use:   "injection [flags]",
+		Short: "Summarize the pods injected and skipped by the proxy injector",
+		Long: `Summarize the pods injected and skipped by the proxy injector.
+
+This command fetches the admission metrics of the proxy injector replicas
+(through the same port-forward as 'linkerd diagnostics controller-metrics') and
+renders, per namespace and kind of owner workload, how many pods were injected
+and how many were skipped, and why. The counts cover the admissions handled
+since each replica started.`,
+		Example: `  # Summarize the injection of all the pods
+  linkerd diagnostics injection
+
+  # Summarize the injection of the pods in the emojivoto namespace
+  linkerd diagnostics injection -n emojivoto`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validate(); err != nil {
+				return err
+			}
+			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+
+			pods, err := k8sAPI.CoreV1().Pods(controlPlaneNamespace).List(cmd.Context(), metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("%s=proxy-injector", k8s.ControllerComponentLabel),
+			})
+			if err != nil {
+				return err
+			}
+			if len(pods.Items) == 0 {
+				return fmt.Errorf("no proxy injector pods found in namespace %s", controlPlaneNamespace)
+			}
+
+			metrics := [][]byte{}
+			for _, result := range getMetrics(k8sAPI, pods.Items, k8s.AdminHTTPPortNameSuffix, options.wait, verbose) {
+				if result.err != nil {
+					fmt.Fprintf(os.Stderr, "%s failed to get the metrics of pod %s: %s\n", warnStatus, result.pod, result.err)
+					continue
+				}
+				metrics = append(metrics, result.metrics)
+			}
+			if len(metrics) == 0 {
+				return fmt.Errorf("failed to get the metrics of the proxy injector")
+			}
+
+			summaries, err := summarizeInjection(metrics, options.namespace)
+			if err != nil {
+				return err
+			}
+			return renderInjectionSummaries(os.Stdout, summaries, options.output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.namespace, "namespace", "n", options.namespace, "Only summarize the injection of the pods in this namespace")
+	cmd.Flags().DurationVarP(&options.wait, "wait", "w", options.wait, "Time allowed to fetch the metrics")
+	cmd.Flags().StringVarP(&options.output, "output", "o", options.output, "Output format. One of: table, json")
+
+	pkgcmd.ConfigureNamespaceFlagCompletion(cmd, []string{"namespace"},
+		kubeconfigPath, impersonate, impersonateGroup, kubeContext)
+
+	return cmd
+}
+
+// summarizeInjection aggregates the admission responses counted by each
+// proxy injector replica, keeping only those of the given namespace if not
+// empty
+func summarizeInjection(metrics [][]byte, namespace string) ([]injectionSummary, error) {
+	type key struct{ namespace, ownerKind string }
+	summaries := map[key]*injectionSummary{}
+
+	for _, m := range metrics {
+		parser := expfmt.NewTextParser(model.LegacyValidation)
+		families, err := parser.TextToMetricFamilies(bytes.NewReader(m))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the proxy injector metrics: %w", err)
+		}
+		family, ok := families[injectionResponsesMetric]
+		if !ok {
+			continue
+		}
+		for _, sample := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range sample.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if namespace != "" && labels["namespace"] != namespace {
+				continue
+			}
+
+			k := key{labels["namespace"], labels["owner_kind"]}
+			summary, ok := summaries[k]
+			if !ok {
+				summary = &injectionSummary{Namespace: k.namespace, OwnerKind: k.ownerKind}
+				summaries[k] = summary
+			}
+			count := uint64(math.Round(sample.GetCounter().GetValue()))
+			if labels["skip"] != "true" {
+				summary.Injected += count
+				continue
+			}
+			summary.Skipped += count
+			if summary.SkipReasons == nil {
+				summary.SkipReasons = map[string]uint64{}
+			}
+			for _, reason := range strings.Split(labels["skip_reason"], ",") {
+				if reason != "" {
+					summary.SkipReasons[reason] += count
+				}
+			}
+		}
+	}
+
+	result := make([]injectionSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].OwnerKind < result[j].OwnerKind
+	})
+	return result, nil
+}
+
+func renderInjectionSummaries(w io.Writer, summaries []injectionSummary, output string) error {
+	if output == jsonOutput {
+		out, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	}
+
+	if len(summaries) == 0 {
+		_, err := fmt.Fprintln(w, "No admissions handled by the proxy injector")
+		return err
+	}
+
+	orNone := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tOWNER KIND\tINJECTED\tSKIPPED\tSKIP REASONS")
+	seen := map[string]struct{}{}
+	for _, s := range summaries {
+		reasons := make([]string, 0, len(s.SkipReasons))
+		for reason := range s.SkipReasons {
+			reasons = append(reasons, reason)
+			seen[reason] = struct{}{}
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			if s.SkipReasons[reasons[i]] != s.SkipReasons[reasons[j]] {
+				return s.SkipReasons[reasons[i]] > s.SkipReasons[reasons[j]]
+			}
+			return reasons[i] < reasons[j]
+		})
+		for i, reason := range reasons {
+			reasons[i] = fmt.Sprintf("%s=%d", reason, s.SkipReasons[reason])
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", orNone(s.Namespace), orNone(s.OwnerKind), s.Injected, s.Skipped, orNone(strings.Join(reasons, ", ")))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+	reasons := make([]string, 0, len(seen))
+	for reason := range seen {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	fmt.Fprintln(w, "\nSkip reasons:")
+	for _, reason := range reasons {
+		if description, ok := inject.Reasons[reason]; ok {
+			fmt.Fprintf(w, "  %s: %s\n", reason, description)
+		}
+	}
+	return nil
+}
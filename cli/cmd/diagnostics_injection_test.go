@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+const injectorMetrics = `# HELP proxy_inject_admission_responses_total A counter for number of admission responses from proxy injector.
+# TYPE proxy_inject_admission_responses_total counter
+proxy_inject_admission_responses_total{annotation_at="namespace",namespace="emojivoto",owner_kind="deployment",skip="false",skip_reason=""} 4
+proxy_inject_admission_responses_total{annotation_at="workload",namespace="emojivoto",owner_kind="deployment",skip="true",skip_reason="injection_disable_annotation_present"} 1
+proxy_inject_admission_responses_total{annotation_at="namespace",namespace="emojivoto",owner_kind="job",skip="true",skip_reason="host_network_enabled,udp_ports_enabled"} 2
+proxy_inject_admission_responses_total{annotation_at="",namespace="default",owner_kind="pod",skip="true",skip_reason="injection_enable_annotation_absent"} 3
+`
+
+func TestSummarizeInjection(t *testing.T) {
+	replica := `# HELP proxy_inject_admission_responses_total A counter for number of admission responses from proxy injector.
+# TYPE proxy_inject_admission_responses_total counter
+proxy_inject_admission_responses_total{annotation_at="namespace",namespace="emojivoto",owner_kind="deployment",skip="false",skip_reason=""} 2
+`
+
+	t.Run("aggregates all namespaces and replicas", func(t *testing.T) {
+		summaries, err := summarizeInjection([][]byte{[]byte(injectorMetrics), []byte(replica)}, "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		expected := []injectionSummary{
+			{Namespace: "default", OwnerKind: "pod", Skipped: 3, SkipReasons: map[string]uint64{"injection_enable_annotation_absent": 3}},
+			{Namespace: "emojivoto", OwnerKind: "deployment", Injected: 6, Skipped: 1, SkipReasons: map[string]uint64{"injection_disable_annotation_present": 1}},
+			{Namespace: "emojivoto", OwnerKind: "job", Skipped: 2, SkipReasons: map[string]uint64{"host_network_enabled": 2, "udp_ports_enabled": 2}},
+		}
+		if diff := deep.Equal(summaries, expected); diff != nil {
+			t.Errorf("%+v", diff)
+		}
+	})
+
+	t.Run("filters by namespace", func(t *testing.T) {
+		summaries, err := summarizeInjection([][]byte{[]byte(injectorMetrics)}, "default")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(summaries) != 1 || summaries[0].Namespace != "default" {
+			t.Errorf("Expected only the default namespace, got %+v", summaries)
+		}
+	})
+}
+
+func TestRenderInjectionSummaries(t *testing.T) {
+	summaries, err := summarizeInjection([][]byte{[]byte(injectorMetrics)}, "emojivoto")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := renderInjectionSummaries(&buf, summaries, tableOutput); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := `NAMESPACE   OWNER KIND   INJECTED   SKIPPED   SKIP REASONS
+emojivoto   deployment   4          1         injection_disable_annotation_present=1
+emojivoto   job          0          2         host_network_enabled=2, udp_ports_enabled=2
+
+Skip reasons:
+  host_network_enabled: hostNetwork is enabled
+  injection_disable_annotation_present: pod has the annotation "linkerd.io/inject:disabled"
+  udp_ports_enabled: UDP port(s) configured on pod spec
+`
+	if diff := deep.Equal(buf.String(), expected); diff != nil {
+		t.Errorf("%+v", diff)
+	}
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
+	"github.com/linkerd/linkerd2/pkg/healthcheck"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type proxyDriftOptions struct {
+	namespace     string
+	allNamespaces bool
+	output        string
+}
+
+// proxyDriftReport lists the reasons why the pods of a workload don't match
+// what the proxy injector would produce today
+type proxyDriftReport struct {
+	Namespace string   `json:"namespace"`
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Pods      []string `json:"pods"`
+	Reasons   []string `json:"reasons"`
+}
+
+func newProxyDriftOptions() *proxyDriftOptions {
+	return &proxyDriftOptions{
+		output: tableOutput,
+	}
+}
+
+func (o *proxyDriftOptions) validate() error {
+	if o.output != tableOutput && o.output != jsonOutput {
+		return fmt.Errorf("--output must be one of: %s, %s", tableOutput, jsonOutput)
+	}
+	return nil
+}
+
+func newCmdDiagnosticsProxyDrift() *cobra.Command {
+	options := newProxyDriftOptions()
+
+	cmd := &cobra.Command{
+		Use:   "proxy-drift [flags]",
+		Short: "List the workloads whose meshed pods need a restart to pick up the current proxy configuration",
+		Long: `List the workloads whose meshed pods need a restart to pick up the current proxy configuration.
+
+Existing pods keep the proxy they were injected with until they're restarted,
+even after an upgrade or a change to their config annotations. This command
+generates the injection patch the proxy injector would produce today for the
+pod template of the owner of each running meshed pod, and compares the
+resulting proxy and init containers to the pod's.`,
+		Example: `  # List the workloads needing a restart in the emojivoto namespace
+  linkerd diagnostics proxy-drift -n emojivoto
+
+  # List the workloads needing a restart in all namespaces, as JSON
+  linkerd diagnostics proxy-drift -A -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validate(); err != nil {
+				return err
+			}
+			if options.namespace == "" && !options.allNamespaces {
+				options.namespace = pkgcmd.GetDefaultNamespace(kubeconfigPath, kubeContext)
+			}
+			if options.allNamespaces {
+				options.namespace = ""
+			}
+
+			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+			_, values, err := healthcheck.FetchCurrentConfiguration(cmd.Context(), k8sAPI, controlPlaneNamespace)
+			if err != nil {
+				return fmt.Errorf("failed to fetch the Linkerd configuration: %w", err)
+			}
+			// The proxy injector reads the trust anchors from their own
+			// ConfigMap rather than from the values.
+			bundle, err := healthcheck.FetchTrustBundle(cmd.Context(), *k8sAPI, controlPlaneNamespace)
+			if err != nil {
+				return fmt.Errorf("failed to fetch the trust anchors: %w", err)
+			}
+			values.IdentityTrustAnchorsPEM = bundle
+
+			reports, err := proxyDrift(cmd.Context(), k8sAPI, values, options.namespace)
+			if err != nil {
+				return err
+			}
+			return renderProxyDrift(os.Stdout, reports, options.output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.namespace, "namespace", "n", options.namespace, "Namespace of the pods to check")
+	cmd.Flags().BoolVarP(&options.allNamespaces, "all-namespaces", "A", options.allNamespaces, "Check the pods in all namespaces")
+	cmd.Flags().StringVarP(&options.output, "output", "o", options.output, "Output format. One of: table, json")
+
+	pkgcmd.ConfigureNamespaceFlagCompletion(cmd, []string{"namespace"},
+		kubeconfigPath, impersonate, impersonateGroup, kubeContext)
+	pkgcmd.ConfigureOutputFlagCompletion(cmd)
+
+	return cmd
+}
+
+// proxyDrift returns a report for each workload having running meshed pods
+// which differ from what the proxy injector would produce today, in the
+// given namespace or in all namespaces if it's empty
+func proxyDrift(ctx context.Context, k8sAPI *k8s.KubernetesAPI, values *linkerd2.Values, namespace string) ([]proxyDriftReport, error) {
+	pods, err := k8sAPI.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// The pods of a workload share their owner, which is only retrieved once.
+	type podOwner struct {
+		kind, name string
+		template   *corev1.PodTemplateSpec
+	}
+	owners := map[string]podOwner{}
+
+	nsAnnotations := map[string]map[string]string{}
+	reports := []proxyDriftReport{}
+	indexes := map[string]int{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || !isMeshed(pod) {
+			continue
+		}
+
+		ref := metav1.GetControllerOf(pod)
+		if ref == nil {
+			continue
+		}
+		ownerKey := fmt.Sprintf("%s/%s/%s", pod.Namespace, ref.Kind, ref.Name)
+		owner, ok := owners[ownerKey]
+		if !ok {
+			kind, name, template, err := k8s.GetPodTemplateOwner(ctx, k8sAPI, pod)
+			if err != nil {
+				return nil, fmt.Errorf("failed to retrieve the owner of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+			owner = podOwner{kind, name, template}
+			owners[ownerKey] = owner
+		}
+		if owner.template == nil {
+			continue
+		}
+
+		ann, ok := nsAnnotations[pod.Namespace]
+		if !ok {
+			ns, err := k8sAPI.CoreV1().Namespaces().Get(ctx, pod.Namespace, metav1.GetOptions{})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return nil, err
+			}
+			if ns != nil {
+				ann = ns.GetAnnotations()
+			}
+			nsAnnotations[pod.Namespace] = ann
+		}
+
+		reasons, err := podProxyDrift(ctx, k8sAPI, values, pod, owner.template, ann)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, owner.kind, owner.name)
+		idx, ok := indexes[key]
+		if !ok {
+			idx = len(reports)
+			indexes[key] = idx
+			reports = append(reports, proxyDriftReport{
+				Namespace: pod.Namespace,
+				Kind:      owner.kind,
+				Name:      owner.name,
+			})
+		}
+		report := &reports[idx]
+		report.Pods = append(report.Pods, pod.Name)
+		for _, reason := range reasons {
+			if !slices.Contains(report.Reasons, reason) {
+				report.Reasons = append(report.Reasons, reason)
+			}
+		}
+	}
+	return reports, nil
+}
+
+// podProxyDrift injects the pod's template as the proxy injector would and
+// returns why the result differs from the pod
+func podProxyDrift(
+	ctx context.Context,
+	k8sAPI *k8s.KubernetesAPI,
+	values *linkerd2.Values,
+	pod *corev1.Pod,
+	template *corev1.PodTemplateSpec,
+	nsAnnotations map[string]string,
+) ([]string, error) {
+	templatePod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: *template.ObjectMeta.DeepCopy(),
+		Spec:       *template.Spec.DeepCopy(),
+	}
+	templatePod.Namespace = pod.Namespace
+	templatePod.Name = pod.Name
+	templatePod.OwnerReferences = pod.OwnerReferences
+
+	// Pods injected through `linkerd inject --manual` already have the proxy
+	// in their template.
+	if isMeshed(templatePod) {
+		return inject.InjectedContainersDrift(&templatePod.Spec, &pod.Spec), nil
+	}
+
+	podJSON, err := json.Marshal(templatePod)
+	if err != nil {
+		return nil, err
+	}
+	conf := inject.NewResourceConfig(values, inject.OriginWebhook, controlPlaneNamespace).
+		WithNsAnnotations(nsAnnotations).
+		WithProxyConfigRetriever(proxyConfigRetriever(ctx, k8sAPI, pod.Namespace)).
+		WithKind("Pod")
+	report, err := conf.ParseMetaAndYAML(podJSON)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := report.Injectable(); !ok {
+		return []string{fmt.Sprintf("would no longer be injected: %s", strings.Join(report.SkipReasons(), ", "))}, nil
+	}
+
+	// As the proxy injector does, inject the pod without its ProxyConfig if
+	// it can't be resolved.
+	_ = conf.AppendInjectorAnnotations()
+	expected, err := conf.InjectedPod(podJSON, inject.GetOverriddenValues)
+	if err != nil {
+		return nil, err
+	}
+	return inject.InjectedContainersDrift(&expected.Spec, &pod.Spec), nil
+}
+
+func renderProxyDrift(w io.Writer, reports []proxyDriftReport, output string) error {
+	if output == jsonOutput {
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	}
+
+	if len(reports) == 0 {
+		_, err := fmt.Fprintln(w, "All running meshed pods match the current proxy configuration")
+		return err
+	}
+	for i, report := range reports {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		pods := "pod"
+		if len(report.Pods) > 1 {
+			pods = "pods"
+		}
+		fmt.Fprintf(w, "%s %s/%s in namespace %s needs a restart (%d %s):\n", warnStatus, report.Kind, report.Name, report.Namespace, len(report.Pods), pods)
+		for _, reason := range report.Reasons {
+			fmt.Fprintf(w, "    * %s\n", reason)
+		}
+	}
+	return nil
+}
+
+func isMeshed(pod *corev1.Pod) bool {
+	for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		if c.Name == k8s.ProxyContainerName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func TestProxyDrift(t *testing.T) {
+	values, err := linkerd2.NewValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	values.IdentityTrustAnchorsPEM = "trust-anchors"
+
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"app": "web"},
+			Annotations: map[string]string{k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: "web:v1"}},
+		},
+	}
+	controller := true
+	deploy := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "emojivoto", UID: "web"},
+		Spec:       appsv1.DeploymentSpec{Template: template},
+	}
+	rs := &appsv1.ReplicaSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-5f8b",
+			Namespace: "emojivoto",
+			UID:       "web-5f8b",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web", Controller: &controller},
+			},
+		},
+		Spec: appsv1.ReplicaSetSpec{Template: template},
+	}
+
+	// current is injected as the proxy injector would today, while outdated
+	// runs an older proxy
+	current := injectedDriftTestPod(t, values, template, "web-5f8b-current")
+	outdated := injectedDriftTestPod(t, values, template, "web-5f8b-outdated")
+	for i := range outdated.Spec.InitContainers {
+		if outdated.Spec.InitContainers[i].Name == k8s.ProxyContainerName {
+			outdated.Spec.InitContainers[i].Image = "cr.l5d.io/linkerd/proxy:old"
+		}
+	}
+
+	configs := []string{`apiVersion: v1
+kind: Namespace
+metadata:
+  name: emojivoto
+`}
+	for _, obj := range []interface{}{deploy, rs, current, outdated} {
+		config, err := yaml.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs = append(configs, string(config))
+	}
+	k8sAPI, err := k8s.NewFakeAPI(configs...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reports, err := proxyDrift(context.Background(), k8sAPI, values, "emojivoto")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []proxyDriftReport{{
+		Namespace: "emojivoto",
+		Kind:      k8s.Deployment,
+		Name:      "web",
+		Pods:      []string{"web-5f8b-outdated"},
+		Reasons: []string{
+			"linkerd-proxy: image changed from cr.l5d.io/linkerd/proxy:old to " + values.Proxy.Image.Name + ":" + values.LinkerdVersion,
+		},
+	}}
+	if diff := deep.Equal(reports, expected); diff != nil {
+		t.Errorf("%+v", diff)
+	}
+
+	// The owner shared by both pods is only retrieved once.
+	gets := 0
+	for _, action := range k8sAPI.Interface.(*fake.Clientset).Actions() {
+		if action.Matches("get", "replicasets") {
+			gets++
+		}
+	}
+	if gets != 1 {
+		t.Errorf("Expected the ReplicaSet to be retrieved once, got %d", gets)
+	}
+}
+
+func injectedDriftTestPod(t *testing.T, values *linkerd2.Values, template corev1.PodTemplateSpec, name string) *corev1.Pod {
+	t.Helper()
+	controller := true
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: *template.ObjectMeta.DeepCopy(),
+		Spec:       *template.Spec.DeepCopy(),
+	}
+	pod.Name = name
+	pod.Namespace = "emojivoto"
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5f8b", UID: "web-5f8b", Controller: &controller},
+	}
+	podJSON, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := inject.NewResourceConfig(values, inject.OriginWebhook, controlPlaneNamespace).WithKind("Pod")
+	if _, err := conf.ParseMetaAndYAML(podJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.AppendInjectorAnnotations(); err != nil {
+		t.Fatal(err)
+	}
+	injected, err := conf.InjectedPod(podJSON, inject.GetOverriddenValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injected.Status.Phase = corev1.PodRunning
+	return injected
+}
@@ -0,0 +1,511 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// patchOutput prints the config annotations applying the recommendations as
+// a patch of the workloads' pod template
+const patchOutput = "patch"
+
+const (
+	// Lowest recommended values, below which the proxy's usage is dominated by
+	// noise
+	minProxyCPUMillis   = 10
+	minProxyMemoryBytes = 20 * 1024 * 1024
+
+	// The metrics exposed by the proxy about its own process
+	proxyCPUSecondsMetric     = "process_cpu_seconds_total"
+	proxyResidentMemoryMetric = "process_resident_memory_bytes"
+
+	// Percentile of the observed usage on which requests are based
+	requestPercentile = 0.9
+)
+
+type proxyResourcesOptions struct {
+	namespace string
+	samples   int
+	interval  time.Duration
+	headroom  float64
+	output    string
+}
+
+// proxyUsage holds the resource usage of the proxies of a workload's
+// replicas, in cores and bytes, as sampled over time
+type proxyUsage struct {
+	cpu    []float64
+	memory []float64
+}
+
+// resourceRecommendation compares the current request and limit of a proxy
+// resource to the recommended ones
+type resourceRecommendation struct {
+	CurrentRequest string `json:"currentRequest,omitempty"`
+	CurrentLimit   string `json:"currentLimit,omitempty"`
+	Peak           string `json:"peak"`
+	Request        string `json:"request"`
+	Limit          string `json:"limit"`
+}
+
+// proxyResourcesRecommendation holds the recommended proxy resources of a
+// workload
+type proxyResourcesRecommendation struct {
+	Namespace string                 `json:"namespace"`
+	Workload  string                 `json:"workload"`
+	Pods      int                    `json:"pods"`
+	Samples   int                    `json:"samples"`
+	CPU       resourceRecommendation `json:"cpu"`
+	Memory    resourceRecommendation `json:"memory"`
+	Warnings  []string               `json:"warnings,omitempty"`
+}
+
+func newProxyResourcesOptions() *proxyResourcesOptions {
+	return &proxyResourcesOptions{
+		samples:  6,
+		interval: 10 * time.Second,
+		headroom: 0.3,
+		output:   tableOutput,
+	}
+}
+
+func (o *proxyResourcesOptions) validate(workloads []string) error {
+	if o.output != tableOutput && o.output != jsonOutput && o.output != patchOutput {
+		return fmt.Errorf("--output must be one of: %s, %s, %s", tableOutput, jsonOutput, patchOutput)
+	}
+	// A patch only applies to a single workload
+	if o.output == patchOutput && len(workloads) > 1 {
+		return fmt.Errorf("--output %s only supports a single workload, got %d", patchOutput, len(workloads))
+	}
+	if o.samples < 2 {
+		return fmt.Errorf("--samples must be at least 2, got %d", o.samples)
+	}
+	if o.interval <= 0 {
+		return fmt.Errorf("--interval must be positive, got %s", o.interval)
+	}
+	if o.headroom < 0 {
+		return fmt.Errorf("--headroom can't be negative, got %v", o.headroom)
+	}
+	return nil
+}
+
+func newCmdDiagnosticsProxyResources() *cobra.Command {
+	options := newProxyResourcesOptions()
+
+	cmd := &cobra.Command{
+		Use:   "proxy-resources [flags] (RESOURCE)...",
+		Short: "Recommend the proxy CPU and memory requests and limits of workloads",
+		Long: `Recommend the proxy CPU and memory requests and limits of workloads.
+
+This command samples the resource usage of the proxies of each workload's
+replicas, from the process metrics they expose (through the same port-forward
+as 'linkerd diagnostics proxy-metrics'). When those are missing, it falls back
+to the proxy containers' usage reported by the metrics-server, if available.
+The two sources are never mixed. Requests are based on the 90th
+percentile of the observed usage and limits on its peak, both increased by the
+headroom, twice so for limits.
+
+The recommendations only reflect the load observed while sampling; sample
+during representative traffic.
+
+The RESOURCE argument specifies the target workloads: (TYPE/NAME). The patch
+output only supports a single workload.
+
+Examples:
+  * deploy/my-deploy
+  * ds/my-daemonset
+  * sts/my-statefulset`,
+		Example: `  # Recommend the proxy resources of the web deployment in the emojivoto namespace
+  linkerd diagnostics proxy-resources -n emojivoto deploy/web
+
+  # Sample for 5 minutes and apply the recommendations
+  kubectl -n emojivoto patch deploy/web -p "$(
+    linkerd diagnostics proxy-resources -n emojivoto deploy/web \
+      --samples 31 --interval 10s -o patch
+  )"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validate(args); err != nil {
+				return err
+			}
+			if options.namespace == "" {
+				options.namespace = pkgcmd.GetDefaultNamespace(kubeconfigPath, kubeContext)
+			}
+			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+
+			recommendations := []proxyResourcesRecommendation{}
+			for _, workload := range args {
+				pods, err := k8s.GetPodsFor(cmd.Context(), k8sAPI, options.namespace, workload)
+				if err != nil {
+					return err
+				}
+				pods = meshedRunningPods(pods)
+				if len(pods) == 0 {
+					return fmt.Errorf("no running meshed pods found for %s", workload)
+				}
+
+				usage, warnings, err := sampleProxyUsage(cmd.Context(), k8sAPI, pods, options.samples, options.interval)
+				if err != nil {
+					return err
+				}
+				recommendation, err := recommendProxyResources(usage, options.headroom)
+				if err != nil {
+					return fmt.Errorf("failed to recommend resources for %s: %w", workload, err)
+				}
+				recommendation.Namespace = options.namespace
+				recommendation.Workload = workload
+				recommendation.Pods = len(pods)
+				recommendation.Samples = options.samples
+				recommendation.Warnings = warnings
+				setCurrentProxyResources(recommendation, pods[0])
+				recommendations = append(recommendations, *recommendation)
+			}
+
+			return renderProxyResources(os.Stdout, recommendations, options.output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.namespace, "namespace", "n", options.namespace, "Namespace of the workloads")
+	cmd.Flags().IntVar(&options.samples, "samples", options.samples, "Number of samples taken from each proxy")
+	cmd.Flags().DurationVar(&options.interval, "interval", options.interval, "Interval between samples")
+	cmd.Flags().Float64Var(&options.headroom, "headroom", options.headroom, "Ratio added to the observed usage for requests, and twice for limits")
+	cmd.Flags().StringVarP(&options.output, "output", "o", options.output, "Output format. One of: table, json, patch")
+
+	pkgcmd.ConfigureNamespaceFlagCompletion(cmd, []string{"namespace"},
+		kubeconfigPath, impersonate, impersonateGroup, kubeContext)
+
+	return cmd
+}
+
+func meshedRunningPods(pods []corev1.Pod) []corev1.Pod {
+	var meshed []corev1.Pod
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning && isMeshed(&pods[i]) {
+			meshed = append(meshed, pods[i])
+		}
+	}
+	return meshed
+}
+
+// sampleProxyUsage samples the resource usage of the pods' proxies, returning
+// warnings about the samples that couldn't be taken. The usage is read from
+// the proxies' process metrics, falling back to the metrics-server's only for
+// the pods whose metrics are missing; the usage of a single source is
+// returned, preferring the proxies' metrics
+func sampleProxyUsage(ctx context.Context, k8sAPI *k8s.KubernetesAPI, pods []corev1.Pod, samples int, interval time.Duration) (*proxyUsage, []string, error) {
+	type cpuSample struct {
+		seconds float64
+		at      time.Time
+	}
+	processUsage := &proxyUsage{}
+	serverUsage := &proxyUsage{}
+	warnings := []string{}
+	warn := func(format string, args ...interface{}) {
+		if warning := fmt.Sprintf(format, args...); !slices.Contains(warnings, warning) {
+			warnings = append(warnings, warning)
+		}
+	}
+	previous := map[string]cpuSample{}
+	useServerMetrics := true
+
+	for i := 0; i < samples; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		now := time.Now()
+		missing := map[string]struct{}{}
+		for _, result := range getMetrics(k8sAPI, pods, k8s.ProxyAdminPortName, 30*time.Second, verbose) {
+			if result.err != nil {
+				warn("failed to get the metrics of pod %s: %s", result.pod, result.err)
+				missing[result.pod] = struct{}{}
+				continue
+			}
+			cpuSeconds, memory, err := parseProxyProcessMetrics(result.metrics)
+			if err != nil {
+				warn("failed to parse the metrics of pod %s: %s", result.pod, err)
+				missing[result.pod] = struct{}{}
+				continue
+			}
+			processUsage.memory = append(processUsage.memory, memory)
+			if prev, ok := previous[result.pod]; ok && now.After(prev.at) && cpuSeconds >= prev.seconds {
+				processUsage.cpu = append(processUsage.cpu, (cpuSeconds-prev.seconds)/now.Sub(prev.at).Seconds())
+			}
+			previous[result.pod] = cpuSample{cpuSeconds, now}
+		}
+
+		if !useServerMetrics {
+			continue
+		}
+		for _, pod := range pods {
+			if _, ok := missing[pod.Name]; !ok {
+				continue
+			}
+			cpu, memory, err := getProxyContainerUsage(ctx, k8sAPI, pod)
+			if err != nil {
+				// The metrics-server is optional
+				warn("not using pod resource usage: %s", err)
+				useServerMetrics = false
+				break
+			}
+			serverUsage.cpu = append(serverUsage.cpu, cpu)
+			serverUsage.memory = append(serverUsage.memory, memory)
+		}
+	}
+
+	if len(processUsage.cpu) == 0 || len(processUsage.memory) == 0 {
+		if len(serverUsage.cpu) > 0 && len(serverUsage.memory) > 0 {
+			warn("using the pod resource usage from the metrics-server, as the proxy metrics are missing")
+			return serverUsage, warnings, nil
+		}
+	}
+	return processUsage, warnings, nil
+}
+
+// parseProxyProcessMetrics returns the CPU time, in seconds, and the resident
+// memory, in bytes, of the proxy process from its metrics
+func parseProxyProcessMetrics(metrics []byte) (float64, float64, error) {
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(metrics))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	cpu, ok := families[proxyCPUSecondsMetric]
+	if !ok || len(cpu.GetMetric()) == 0 {
+		return 0, 0, fmt.Errorf("metric %s not found", proxyCPUSecondsMetric)
+	}
+	memory, ok := families[proxyResidentMemoryMetric]
+	if !ok || len(memory.GetMetric()) == 0 {
+		return 0, 0, fmt.Errorf("metric %s not found", proxyResidentMemoryMetric)
+	}
+
+	cpuMetric := cpu.GetMetric()[0]
+	cpuSeconds := cpuMetric.GetCounter().GetValue()
+	if cpuMetric.GetCounter() == nil {
+		cpuSeconds = cpuMetric.GetUntyped().GetValue()
+	}
+	memoryMetric := memory.GetMetric()[0]
+	memoryBytes := memoryMetric.GetGauge().GetValue()
+	if memoryMetric.GetGauge() == nil {
+		memoryBytes = memoryMetric.GetUntyped().GetValue()
+	}
+	return cpuSeconds, memoryBytes, nil
+}
+
+// podMetrics is the subset of the metrics.k8s.io PodMetrics resource read to
+// get the proxy container's usage
+type podMetrics struct {
+	Containers []struct {
+		Name  string              `json:"name"`
+		Usage corev1.ResourceList `json:"usage"`
+	} `json:"containers"`
+}
+
+// getProxyContainerUsage returns the CPU, in cores, and memory, in bytes,
+// used by the pod's proxy container according to the metrics-server
+func getProxyContainerUsage(ctx context.Context, k8sAPI *k8s.KubernetesAPI, pod corev1.Pod) (float64, float64, error) {
+	data, err := k8sAPI.Discovery().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", pod.Namespace, "pods", pod.Name).
+		DoRaw(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	var metrics podMetrics
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return 0, 0, err
+	}
+	for _, c := range metrics.Containers {
+		if c.Name != k8s.ProxyContainerName {
+			continue
+		}
+		return c.Usage.Cpu().AsApproximateFloat64(), c.Usage.Memory().AsApproximateFloat64(), nil
+	}
+	return 0, 0, fmt.Errorf("no metrics found for the proxy of pod %s", pod.Name)
+}
+
+// recommendProxyResources recommends requests based on the 90th percentile
+// of the usage plus the headroom, and limits based on the peak usage plus
+// twice the headroom
+func recommendProxyResources(usage *proxyUsage, headroom float64) (*proxyResourcesRecommendation, error) {
+	if len(usage.cpu) == 0 || len(usage.memory) == 0 {
+		return nil, fmt.Errorf("not enough samples")
+	}
+
+	cpuRequest := percentile(usage.cpu, requestPercentile) * (1 + headroom)
+	cpuPeak := percentile(usage.cpu, 1)
+	memoryRequest := percentile(usage.memory, requestPercentile) * (1 + headroom)
+	memoryPeak := percentile(usage.memory, 1)
+
+	return &proxyResourcesRecommendation{
+		CPU: resourceRecommendation{
+			Peak:    formatCPU(cpuPeak, 0),
+			Request: formatCPU(cpuRequest, minProxyCPUMillis),
+			Limit:   formatCPU(cpuPeak*(1+2*headroom), minProxyCPUMillis),
+		},
+		Memory: resourceRecommendation{
+			Peak:    formatMemory(memoryPeak, 0),
+			Request: formatMemory(memoryRequest, minProxyMemoryBytes),
+			Limit:   formatMemory(memoryPeak*(1+2*headroom), minProxyMemoryBytes),
+		},
+	}, nil
+}
+
+// percentile returns the nearest-rank percentile of the values
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// roundingTolerance keeps floating point errors from rounding values up
+const roundingTolerance = 1e-9
+
+// formatCPU formats the cores rounded up to the millicore
+func formatCPU(cores float64, minMillis int64) string {
+	millis := int64(math.Ceil(cores*1000 - roundingTolerance))
+	if millis < minMillis {
+		millis = minMillis
+	}
+	return resource.NewMilliQuantity(millis, resource.DecimalSI).String()
+}
+
+// formatMemory formats the bytes rounded up to the mebibyte
+func formatMemory(b float64, minBytes int64) string {
+	const mebibyte = 1024 * 1024
+	rounded := int64(math.Ceil(b/mebibyte-roundingTolerance)) * mebibyte
+	if rounded < minBytes {
+		rounded = minBytes
+	}
+	return resource.NewQuantity(rounded, resource.BinarySI).String()
+}
+
+// setCurrentProxyResources sets the current requests and limits of the pod's
+// proxy container in the recommendation
+func setCurrentProxyResources(r *proxyResourcesRecommendation, pod corev1.Pod) {
+	proxy := findProxyContainer(pod)
+	if proxy == nil {
+		return
+	}
+	quantity := func(list corev1.ResourceList, name corev1.ResourceName) string {
+		if q, ok := list[name]; ok {
+			return q.String()
+		}
+		return ""
+	}
+	r.CPU.CurrentRequest = quantity(proxy.Resources.Requests, corev1.ResourceCPU)
+	r.CPU.CurrentLimit = quantity(proxy.Resources.Limits, corev1.ResourceCPU)
+	r.Memory.CurrentRequest = quantity(proxy.Resources.Requests, corev1.ResourceMemory)
+	r.Memory.CurrentLimit = quantity(proxy.Resources.Limits, corev1.ResourceMemory)
+}
+
+func findProxyContainer(pod corev1.Pod) *corev1.Container {
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			if containers[i].Name == k8s.ProxyContainerName {
+				return &containers[i]
+			}
+		}
+	}
+	return nil
+}
+
+// proxyResourcesPatch returns the patch of a workload's pod template setting
+// the config annotations of the recommended resources
+func proxyResourcesPatch(r proxyResourcesRecommendation) ([]byte, error) {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						k8s.ProxyCPURequestAnnotation:    r.CPU.Request,
+						k8s.ProxyCPULimitAnnotation:      r.CPU.Limit,
+						k8s.ProxyMemoryRequestAnnotation: r.Memory.Request,
+						k8s.ProxyMemoryLimitAnnotation:   r.Memory.Limit,
+					},
+				},
+			},
+		},
+	}
+	return json.Marshal(patch)
+}
+
+func renderProxyResources(w io.Writer, recommendations []proxyResourcesRecommendation, output string) error {
+	switch output {
+	case jsonOutput:
+		out, err := json.MarshalIndent(recommendations, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+
+	case patchOutput:
+		if len(recommendations) != 1 {
+			return fmt.Errorf("--output %s only supports a single workload, got %d", patchOutput, len(recommendations))
+		}
+		patch, err := proxyResourcesPatch(recommendations[0])
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", patch)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tWORKLOAD\tRESOURCE\tPEAK\tREQUEST\tRECOMMENDED REQUEST\tLIMIT\tRECOMMENDED LIMIT")
+	orNone := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	for _, r := range recommendations {
+		for _, res := range []struct {
+			name string
+			rec  resourceRecommendation
+		}{{"cpu", r.CPU}, {"memory", r.Memory}} {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				r.Namespace, r.Workload, res.name, res.rec.Peak,
+				orNone(res.rec.CurrentRequest), res.rec.Request,
+				orNone(res.rec.CurrentLimit), res.rec.Limit)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, r := range recommendations {
+		for _, warning := range r.Warnings {
+			fmt.Fprintf(w, "%s %s: %s\n", warnStatus, r.Workload, warning)
+		}
+	}
+	return nil
+}
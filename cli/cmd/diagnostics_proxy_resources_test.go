@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseProxyProcessMetrics(t *testing.T) {
+	metrics := []byte(`# HELP process_cpu_seconds_total Total user and system CPU time spent in seconds.
+# TYPE process_cpu_seconds_total counter
+process_cpu_seconds_total 12.5
+# HELP process_resident_memory_bytes Resident memory size in bytes.
+# TYPE process_resident_memory_bytes gauge
+process_resident_memory_bytes 15728640
+# HELP request_total Total count of HTTP requests.
+# TYPE request_total counter
+request_total{direction="inbound"} 42
+`)
+	cpu, memory, err := parseProxyProcessMetrics(metrics)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if cpu != 12.5 || memory != 15728640 {
+		t.Errorf("Expected 12.5s and 15728640 bytes, got %vs and %v bytes", cpu, memory)
+	}
+
+	if _, _, err := parseProxyProcessMetrics([]byte("request_total 42\n")); err == nil {
+		t.Error("Expected an error for metrics without process metrics")
+	}
+}
+
+func TestRecommendProxyResources(t *testing.T) {
+	t.Run("recommends requests and limits", func(t *testing.T) {
+		usage := &proxyUsage{
+			cpu:    []float64{0.01, 0.02, 0.02, 0.03, 0.03, 0.04, 0.04, 0.05, 0.05, 0.1},
+			memory: []float64{30 << 20, 32 << 20, 40 << 20},
+		}
+		recommendation, err := recommendProxyResources(usage, 0.5)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		expected := &proxyResourcesRecommendation{
+			CPU: resourceRecommendation{
+				Peak:    "100m",
+				Request: "75m",
+				Limit:   "200m",
+			},
+			Memory: resourceRecommendation{
+				Peak:    "40Mi",
+				Request: "60Mi",
+				Limit:   "80Mi",
+			},
+		}
+		if diff := deep.Equal(recommendation, expected); diff != nil {
+			t.Errorf("%+v", diff)
+		}
+	})
+
+	t.Run("doesn't recommend below the minimums", func(t *testing.T) {
+		usage := &proxyUsage{cpu: []float64{0.001}, memory: []float64{1 << 20}}
+		recommendation, err := recommendProxyResources(usage, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if recommendation.CPU.Request != "10m" || recommendation.Memory.Request != "20Mi" {
+			t.Errorf("Expected the minimum requests, got %s and %s", recommendation.CPU.Request, recommendation.Memory.Request)
+		}
+		if recommendation.CPU.Peak != "1m" || recommendation.Memory.Peak != "1Mi" {
+			t.Errorf("Expected the actual peaks, got %s and %s", recommendation.CPU.Peak, recommendation.Memory.Peak)
+		}
+	})
+
+	t.Run("fails without samples", func(t *testing.T) {
+		if _, err := recommendProxyResources(&proxyUsage{memory: []float64{1 << 20}}, 0); err == nil {
+			t.Error("Expected an error without CPU samples")
+		}
+	})
+}
+
+func TestRenderProxyResources(t *testing.T) {
+	recommendations := []proxyResourcesRecommendation{{
+		Namespace: "emojivoto",
+		Workload:  "deploy/web",
+		Pods:      2,
+		Samples:   6,
+		CPU: resourceRecommendation{
+			CurrentRequest: "100m",
+			Peak:           "30m",
+			Request:        "25m",
+			Limit:          "50m",
+		},
+		Memory: resourceRecommendation{
+			CurrentRequest: "20Mi",
+			CurrentLimit:   "250Mi",
+			Peak:           "40Mi",
+			Request:        "45Mi",
+			Limit:          "64Mi",
+		},
+		Warnings: []string{"not using pod resource usage: the server could not find the requested resource"},
+	}}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := renderProxyResources(&buf, recommendations, tableOutput); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		expected := `NAMESPACE   WORKLOAD     RESOURCE   PEAK   REQUEST   RECOMMENDED REQUEST   LIMIT   RECOMMENDED LIMIT
+emojivoto   deploy/web   cpu        30m    100m      25m                   -       50m
+emojivoto   deploy/web   memory     40Mi   20Mi      45Mi                  250Mi   64Mi
+‼ deploy/web: not using pod resource usage: the server could not find the requested resource
+`
+		if diff := deep.Equal(buf.String(), expected); diff != nil {
+			t.Errorf("%+v", diff)
+		}
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := renderProxyResources(&buf, recommendations, patchOutput); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		expected := `{"spec":{"template":{"metadata":{"annotations":{"config.linkerd.io/proxy-cpu-limit":"50m","config.linkerd.io/proxy-cpu-request":"25m","config.linkerd.io/proxy-memory-limit":"64Mi","config.linkerd.io/proxy-memory-request":"45Mi"}}}}}
+`
+		if diff := deep.Equal(buf.String(), expected); diff != nil {
+			t.Errorf("%+v", diff)
+		}
+
+		several := append(recommendations, recommendations[0])
+		if err := renderProxyResources(&buf, several, patchOutput); err == nil {
+			t.Error("Expected an error patching several workloads")
+		}
+	})
+}
+
+func TestProxyResourcesOptionsValidate(t *testing.T) {
+	options := newProxyResourcesOptions()
+	options.output = patchOutput
+	if err := options.validate([]string{"deploy/web"}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if err := options.validate([]string{"deploy/web", "deploy/emoji"}); err == nil {
+		t.Error("Expected an error patching several workloads")
+	}
+}
@@ -264,6 +264,14 @@ func generateAnnotationsDocs() []annotationDoc {
 			Name:        k8s.ProxyWaitBeforeExitSecondsAnnotation,
 			Description: "Adds a preStop hook to the proxy container to delay receiving SIGTERM signal from Kubernetes but no longer than pod's `terminationGracePeriodSeconds`. Defaults to `0`",
 		},
+		{
+			Name:        k8s.ProxyPortInferenceAnnotation,
+			Description: "When set to `enabled` on a workload or namespace, and the opaque ports aren't set, marks as opaque the ports whose name, or Service `appProtocol`, is a well-known opaque protocol such as `postgresql` or `redis`. The outbound ports to skip are inferred separately, see `" + k8s.ProxySkipPortInferenceAnnotation + "`",
+		},
+		{
+			Name:        k8s.ProxySkipPortInferenceAnnotation,
+			Description: "When set to `enabled` on a workload or namespace, and the outbound ports to skip aren't set, has the proxy injector skip, along with the default ones, the ports of the `ExternalName` Services referred to in the containers' environment variables whose `appProtocol`, or name, is a well-known opaque protocol such as `postgresql` or `smtp`. The peers of those Services run outside of the cluster and can't be meshed",
+		},
 		{
 			Name:        k8s.ProxyAwait,
 			Description: "The application container will not start until the proxy is ready; accepted values are `enabled` and `disabled`",
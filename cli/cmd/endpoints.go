@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"text/tabwriter"
@@ -191,6 +192,15 @@ func requestEndpointsFromAPI(client destinationPb.DestinationClient, token strin
 			// we only care about the first error
 			return nil, err
 		case event := <-events:
+			// Federated services withdraw the endpoints of less preferred
+			// clusters, so removals must be applied to the collected state.
+			if remove := event.GetRemove(); remove != nil {
+				for _, tcpAddr := range remove.GetAddrs() {
+					info.remove(tcpAddr.String())
+				}
+				continue
+			}
+
 			addressSet := event.GetAdd()
 			labels := addressSet.GetMetricLabels()
 			serviceID := labels["service"] + "." + labels["namespace"]
@@ -205,6 +215,8 @@ func requestEndpointsFromAPI(client destinationPb.DestinationClient, token strin
 				if info[serviceID][port] == nil {
 					info[serviceID][port] = make([]podData, 0)
 				}
+				// An address which is added again replaces its previous entry.
+				info.remove(tcpAddr.String())
 
 				labels := addr.GetMetricLabels()
 				info[serviceID][port] = append(info[serviceID][port], podData{
@@ -222,6 +234,16 @@ func requestEndpointsFromAPI(client destinationPb.DestinationClient, token strin
 	}
 }
 
+func (info endpointsInfo) remove(address string) {
+	for serviceID, ports := range info {
+		for port, pods := range ports {
+			info[serviceID][port] = slices.DeleteFunc(pods, func(pod podData) bool {
+				return pod.address == address
+			})
+		}
+	}
+}
+
 func getIP(tcpAddr *netPb.TcpAddress) string {
 	ip := addr.FromProxyAPI(tcpAddr.GetIp())
 	if ip == nil {
@@ -333,6 +355,18 @@ func printEndpointsTable(namespace string, rows []rowEndpoint, w *tabwriter.Writ
 		podHeader + strings.Repeat(" ", maxPodLength-len(podHeader)),
 		"SERVICE",
 	}...)
+
+	// The endpoints of federated services also show the cluster they belong
+	// to and their weight, which depend on the federated service's cluster
+	// preferences and weights.
+	federated := slices.ContainsFunc(rows, func(row rowEndpoint) bool {
+		_, ok := row.Labels[k8s.FederatedClusterLabel]
+		return ok
+	})
+	if federated {
+		headers = append(headers, "CLUSTER", "WEIGHT")
+		templateString = strings.TrimSuffix(templateString, "\n") + "\t%s\t%d\n"
+	}
 	fmt.Fprintln(w, strings.Join(headers, "\t"))
 
 	for _, row := range rows {
@@ -343,6 +377,9 @@ func printEndpointsTable(namespace string, rows []rowEndpoint, w *tabwriter.Writ
 			row.Pod,
 			row.Service,
 		}
+		if federated {
+			values = append(values, row.Labels[k8s.FederatedClusterLabel], row.Weight)
+		}
 
 		fmt.Fprintf(w, templateString, values...)
 	}
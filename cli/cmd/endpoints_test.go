@@ -5,6 +5,7 @@ import (
 
 	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
 	"github.com/linkerd/linkerd2/controller/api/util"
+	"github.com/linkerd/linkerd2/pkg/k8s"
 )
 
 type endpointsExp struct {
@@ -81,6 +82,36 @@ func TestEndpoints(t *testing.T) {
 		}, t)
 	})
 
+	t.Run("Returns endpoints of federated service", func(t *testing.T) {
+		testEndpointsCall(endpointsExp{
+			options:     options,
+			authorities: []string{"bb-federated.test.svc.cluster.local:8080"},
+			endpoints: []util.AuthorityEndpoints{
+				{
+					Namespace: "test",
+					ServiceID: "bb",
+					Pods: []util.PodDetails{
+						{
+							Name:   "bb-west-1",
+							IP:     2886795265,
+							Port:   8080,
+							Weight: 20000,
+							Labels: map[string]string{k8s.FederatedClusterLabel: "local"},
+						},
+						{
+							Name:   "bb-east-1",
+							IP:     2886795521,
+							Port:   8080,
+							Weight: 10000,
+							Labels: map[string]string{k8s.FederatedClusterLabel: "east"},
+						},
+					},
+				},
+			},
+			file: "endpoints_federated_output.golden",
+		}, t)
+	})
+
 	options.outputFormat = jsonOutput
 	t.Run("Returns endpoints same namespace (json)", func(t *testing.T) {
 		testEndpointsCall(endpointsExp{
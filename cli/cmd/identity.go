@@ -7,12 +7,17 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/grantae/certinfo"
 	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
+	"github.com/linkerd/linkerd2/pkg/healthcheck"
+	"github.com/linkerd/linkerd2/pkg/identity"
 	"github.com/linkerd/linkerd2/pkg/k8s"
+	pkgtls "github.com/linkerd/linkerd2/pkg/tls"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -30,12 +35,14 @@ type identityOptions struct {
 	pod       string
 	namespace string
 	selector  string
+	output    string
 }
 
 func newIdentityOptions() *identityOptions {
 	return &identityOptions{
 		pod:      "",
 		selector: "",
+		output:   textOutput,
 	}
 }
 
@@ -49,6 +56,8 @@ func newCmdIdentity() *cobra.Command {
 		Long: `Display the certificate(s) of one or more selected pod(s).
 
 This command initiates a port-forward to a given pod or a set of pods and fetches the TLS certificate.
+Each certificate chain is verified against the control plane's trust bundle, and its expiry and whether its
+identity matches the pod's ServiceAccount are reported.
 		`,
 		Example: `
  # Get certificate from pod foo-bar in the default namespace.
@@ -56,6 +65,9 @@ This command initiates a port-forward to a given pod or a set of pods and fetche
 
  # Get certificate from all pods with the label name=nginx
  linkerd identity -l name=nginx
+
+ # Summarize the certificates of all pods in the emojivoto namespace as JSON
+ linkerd identity -n emojivoto -l linkerd.io/control-plane-ns -o json
 		`,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
@@ -97,17 +109,46 @@ This command initiates a port-forward to a given pod or a set of pods and fetche
 				return err
 			}
 
+			if options.output != textOutput && options.output != tableOutput && options.output != jsonOutput {
+				return fmt.Errorf("--output must be one of: %s, %s, %s", textOutput, tableOutput, jsonOutput)
+			}
+
+			denyList, err := fetchDenyList(cmd.Context(), k8sAPI)
+			if err != nil {
+				return err
+			}
+
+			var anchors *x509.CertPool
+			bundle, err := healthcheck.FetchTrustBundle(cmd.Context(), *k8sAPI, controlPlaneNamespace)
+			if err == nil {
+				anchors, err = pkgtls.DecodePEMCertPool(bundle)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Could not load the trust anchors; certificate chains will not be verified: %s\n", err)
+			}
+
 			resultCerts := getCertificate(k8sAPI, pods, k8s.ProxyAdminPortName, emitLog)
 			if len(resultCerts) == 0 {
 				fmt.Print("Could not fetch Certificate. Ensure that the pod(s) are meshed by running `linkerd inject`\n")
 				return nil
 			}
+
+			now := time.Now()
+			reports := make([]identityReport, len(resultCerts))
+			for i, resultCert := range resultCerts {
+				reports[i] = newIdentityReport(pods[i], resultCert, anchors, denyList, now)
+			}
+			if options.output != textOutput {
+				return renderIdentityReports(os.Stdout, reports, options.output)
+			}
+
 			for i, resultCert := range resultCerts {
 				fmt.Printf("\nPOD %s (%d of %d)\n\n", resultCert.pod, i+1, len(resultCerts))
 				if resultCert.err != nil {
 					fmt.Printf("\n%s\n", resultCert.err)
-					return nil
+					continue
 				}
+				renderIdentitySummary(os.Stdout, reports[i])
 				for _, cert := range resultCert.Certificate {
 					if cert.IsCA {
 						continue
@@ -127,12 +168,40 @@ This command initiates a port-forward to a given pod or a set of pods and fetche
 	cmd.PersistentFlags().StringVarP(&options.namespace, "namespace", "n", options.namespace, "Namespace of the pod")
 	cmd.PersistentFlags().StringVarP(&options.selector, "selector", "l", options.selector, "Selector (label query) to filter on, supports ‘=’, ‘==’, and ‘!=’ ")
 
+	cmd.Flags().StringVarP(&options.output, "output", "o", options.output, "Output format. One of: text, table, json")
+
 	pkgcmd.ConfigureNamespaceFlagCompletion(cmd, []string{"namespace"},
 		kubeconfigPath, impersonate, impersonateGroup, kubeContext)
 
+	cmd.AddCommand(newCmdIdentityRotate())
+
 	return cmd
 }
 
+// fetchDenyList returns the identity deny-list of the control plane, which is
+// empty if it doesn't exist.
+func fetchDenyList(ctx context.Context, k8sAPI *k8s.KubernetesAPI) (*identity.DenyList, error) {
+	cm, err := k8sAPI.CoreV1().ConfigMaps(controlPlaneNamespace).Get(ctx, k8s.IdentityDenyListConfigMapName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return identity.NewDenyList(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the identity deny-list: %w", err)
+	}
+	return identity.DenyListFromConfigMap(cm), nil
+}
+
+func revocationStatus(denyList *identity.DenyList, cert *x509.Certificate) string {
+	switch {
+	case denyList.DeniesSerial(cert.SerialNumber):
+		return "REVOKED (serial number)"
+	case len(cert.DNSNames) > 0 && denyList.DeniesIdentity(cert.DNSNames[0]):
+		return "REVOKED (identity)"
+	default:
+		return "not revoked"
+	}
+}
+
 func getCertificate(k8sAPI *k8s.KubernetesAPI, pods []corev1.Pod, portName string, emitLog bool) []certificate {
 	var certificates []certificate
 	for _, pod := range pods {
@@ -142,7 +211,7 @@ func getCertificate(k8sAPI *k8s.KubernetesAPI, pods []corev1.Pod, portName strin
 				pod: pod.GetName(),
 				err: err,
 			})
-			return certificates
+			continue
 		}
 		cert, err := getContainerCertificate(k8sAPI, pod, container, portName, emitLog)
 		certificates = append(certificates, certificate{
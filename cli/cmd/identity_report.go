@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/linkerd/linkerd2/cli/table"
+	"github.com/linkerd/linkerd2/pkg/identity"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const textOutput = "text"
+
+// identityReport summarizes the certificate presented by a pod's proxy.
+type identityReport struct {
+	Namespace        string     `json:"namespace"`
+	Pod              string     `json:"pod"`
+	Identity         string     `json:"identity,omitempty"`
+	ExpectedIdentity string     `json:"expectedIdentity,omitempty"`
+	IdentityMatches  bool       `json:"identityMatches"`
+	SANs             []string   `json:"sans,omitempty"`
+	Issuer           string     `json:"issuer,omitempty"`
+	Serial           string     `json:"serial,omitempty"`
+	NotBefore        *time.Time `json:"notBefore,omitempty"`
+	NotAfter         *time.Time `json:"notAfter,omitempty"`
+	ExpiresInSeconds int64      `json:"expiresInSeconds"`
+	ChainVerified    bool       `json:"chainVerified"`
+	ChainError       string     `json:"chainError,omitempty"`
+	Revocation       string     `json:"revocation,omitempty"`
+	Error            string     `json:"error,omitempty"`
+}
+
+// newIdentityReport verifies the certificate chain presented by a pod's proxy
+// against the trust anchors. A nil anchors pool skips the verification.
+func newIdentityReport(pod corev1.Pod, cert certificate, anchors *x509.CertPool, denyList *identity.DenyList, now time.Time) identityReport {
+	report := identityReport{
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+	}
+	if expected, err := k8s.PodIdentity(&pod); err == nil {
+		report.ExpectedIdentity = expected
+	}
+	if cert.err != nil {
+		report.Error = cert.err.Error()
+		return report
+	}
+	if len(cert.Certificate) == 0 {
+		report.Error = "the proxy presented no certificate"
+		return report
+	}
+
+	leaf := cert.Certificate[0]
+	report.SANs = leaf.DNSNames
+	if len(leaf.DNSNames) > 0 {
+		report.Identity = leaf.DNSNames[0]
+	}
+	report.IdentityMatches = report.Identity != "" && report.Identity == report.ExpectedIdentity
+	report.Issuer = leaf.Issuer.CommonName
+	report.Serial = leaf.SerialNumber.Text(16)
+	report.NotBefore = &leaf.NotBefore
+	report.NotAfter = &leaf.NotAfter
+	report.ExpiresInSeconds = int64(leaf.NotAfter.Sub(now).Seconds())
+	report.Revocation = revocationStatus(denyList, leaf)
+
+	if anchors == nil {
+		report.ChainError = "trust anchors unavailable"
+		return report
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range cert.Certificate[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         anchors,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		report.ChainError = err.Error()
+	} else {
+		report.ChainVerified = true
+	}
+	return report
+}
+
+func (r *identityReport) chainStatus() string {
+	switch {
+	case r.ChainVerified:
+		return "verified"
+	case r.ChainError != "":
+		return fmt.Sprintf("FAILED: %s", r.ChainError)
+	default:
+		return "-"
+	}
+}
+
+func (r *identityReport) expiresIn() string {
+	if r.NotAfter == nil {
+		return "-"
+	}
+	d := time.Duration(r.ExpiresInSeconds) * time.Second
+	if d <= 0 {
+		return "EXPIRED"
+	}
+	return d.Round(time.Second).String()
+}
+
+func (r *identityReport) identityStatus() string {
+	switch {
+	case r.Identity == "":
+		return "-"
+	case r.IdentityMatches:
+		return "yes"
+	default:
+		return fmt.Sprintf("NO (expected %s)", r.ExpectedIdentity)
+	}
+}
+
+// renderIdentitySummary writes the verification results of a single report,
+// as a preamble to the certificate's text.
+func renderIdentitySummary(w io.Writer, r identityReport) {
+	fmt.Fprintf(w, "Chain verification: %s\n", r.chainStatus())
+	fmt.Fprintf(w, "Expires in: %s\n", r.expiresIn())
+	fmt.Fprintf(w, "Matches service account identity: %s\n", r.identityStatus())
+	fmt.Fprintf(w, "Revocation status: %s\n\n", r.Revocation)
+}
+
+func renderIdentityReports(w io.Writer, reports []identityReport, format string) error {
+	if format == jsonOutput {
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	}
+
+	rows := make([]table.Row, 0, len(reports))
+	for _, r := range reports {
+		if r.Error != "" {
+			rows = append(rows, table.Row{r.Namespace, r.Pod, "-", "-", "-", "-", "-", r.Error})
+			continue
+		}
+		rows = append(rows, table.Row{r.Namespace, r.Pod, r.Identity, r.identityStatus(), r.chainStatus(), r.expiresIn(), r.Issuer, r.Revocation})
+	}
+	cols := []table.Column{
+		table.NewColumn("NAMESPACE").WithLeftAlign(),
+		table.NewColumn("POD").WithLeftAlign(),
+		table.NewColumn("IDENTITY").WithLeftAlign(),
+		table.NewColumn("SA MATCH").WithLeftAlign(),
+		table.NewColumn("CHAIN").WithLeftAlign(),
+		table.NewColumn("EXPIRES IN").WithLeftAlign(),
+		table.NewColumn("ISSUER").WithLeftAlign(),
+		table.NewColumn("REVOCATION").WithLeftAlign(),
+	}
+	t := table.NewTable(cols, rows)
+	t.Render(w)
+	return nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/identity"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/linkerd/linkerd2/pkg/tls"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIdentityReport(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := root.GenerateCA("identity.linkerd.cluster.local", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRoot, err := tls.GenerateRootCAWithDefaults("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "emojivoto"},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "web",
+			Containers: []corev1.Container{{
+				Name: k8s.ProxyContainerName,
+				Env: []corev1.EnvVar{{
+					Name:  "LINKERD2_PROXY_IDENTITY_LOCAL_NAME",
+					Value: "$(_pod_sa).$(_pod_ns).serviceaccount.identity.linkerd.cluster.local",
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	chain := func(id string) certificate {
+		t.Helper()
+		key, err := tls.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crt, err := issuer.IssueEndEntityCrt(csr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return certificate{pod: pod.Name, Certificate: []*x509.Certificate{crt.Certificate, issuer.Cred.Crt.Certificate}}
+	}
+
+	now := time.Now()
+	expected := "web.emojivoto.serviceaccount.identity.linkerd.cluster.local"
+
+	t.Run("valid certificate", func(t *testing.T) {
+		r := newIdentityReport(pod, chain(expected), root.Cred.Crt.CertPool(), identity.NewDenyList(), now)
+		if !r.ChainVerified || r.ChainError != "" {
+			t.Errorf("expected the chain to be verified, got %q", r.ChainError)
+		}
+		if !r.IdentityMatches || r.Identity != expected {
+			t.Errorf("expected identity %s to match, got %s", expected, r.Identity)
+		}
+		if r.Issuer != "identity.linkerd.cluster.local" {
+			t.Errorf("unexpected issuer %s", r.Issuer)
+		}
+		if r.ExpiresInSeconds <= 0 || r.expiresIn() == "EXPIRED" {
+			t.Errorf("expected the certificate not to be expired, got %d seconds", r.ExpiresInSeconds)
+		}
+	})
+
+	t.Run("untrusted chain and mismatched identity", func(t *testing.T) {
+		r := newIdentityReport(pod, chain("other.emojivoto.serviceaccount.identity.linkerd.cluster.local"), otherRoot.Cred.Crt.CertPool(), nil, now)
+		if r.ChainVerified || !strings.HasPrefix(r.chainStatus(), "FAILED") {
+			t.Errorf("expected the chain verification to fail, got %s", r.chainStatus())
+		}
+		if r.IdentityMatches {
+			t.Error("expected the identity not to match the service account")
+		}
+	})
+
+	t.Run("expired certificate", func(t *testing.T) {
+		r := newIdentityReport(pod, chain(expected), root.Cred.Crt.CertPool(), nil, now.Add(2*365*24*time.Hour))
+		if r.expiresIn() != "EXPIRED" || r.ChainVerified {
+			t.Errorf("expected the certificate to be expired, got %s (chain %s)", r.expiresIn(), r.chainStatus())
+		}
+	})
+
+	t.Run("unreachable proxy", func(t *testing.T) {
+		r := newIdentityReport(pod, certificate{pod: pod.Name, err: errors.New("port-forward failed")}, nil, nil, now)
+		var buf bytes.Buffer
+		if err := renderIdentityReports(&buf, []identityReport{r}, jsonOutput); err != nil {
+			t.Fatal(err)
+		}
+		var reports []identityReport
+		if err := json.Unmarshal(buf.Bytes(), &reports); err != nil {
+			t.Fatalf("invalid JSON output: %s", err)
+		}
+		if len(reports) != 1 || reports[0].Error != "port-forward failed" || reports[0].ExpectedIdentity != expected {
+			t.Errorf("unexpected report: %+v", reports)
+		}
+	})
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/healthcheck"
+	"github.com/linkerd/linkerd2/pkg/issuercerts"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/linkerd/linkerd2/pkg/tls"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	rotationPhaseBundle   = ""
+	rotationPhaseIssuer   = "issuer"
+	rotationPhaseFinalize = "finalize"
+	rotationPhaseDone     = "done"
+
+	defaultRotationStateFile = "linkerd-identity-rotation.json"
+)
+
+// rotationState is persisted between invocations of `linkerd identity rotate`
+// so that a rotation can be resumed from the phase it was left in.
+type rotationState struct {
+	Phase           string `json:"phase"`
+	OldAnchorsPEM   string `json:"oldAnchorsPEM"`
+	NewAnchorPEM    string `json:"newAnchorPEM"`
+	NewAnchorKeyPEM string `json:"newAnchorKeyPEM,omitempty"`
+	BundlePEM       string `json:"bundlePEM"`
+}
+
+type identityRotateOptions struct {
+	stateFile        string
+	newAnchorFile    string
+	newAnchorKeyFile string
+	issuerCrtFile    string
+	issuerKeyFile    string
+	output           string
+}
+
+func newCmdIdentityRotate() *cobra.Command {
+	options := &identityRotateOptions{
+		stateFile: defaultRotationStateFile,
+		output:    yamlOutput,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rotate [flags]",
+		Short: "Rotate the trust anchor of the control plane in phases",
+		Long: `Rotate the trust anchor of the control plane in phases.
+
+Each invocation performs one phase of the rotation, outputs the manifest to
+apply for that phase and records its progress in a state file, so that the next
+invocation picks up where the previous one left off:
+
+  1. A new trust anchor is generated (or read from --new-anchor-file) and a
+     manifest is output whose trust bundle contains both the old and the new
+     anchors.
+  2. Once every meshed pod has been restarted with the bundled anchors, a
+     manifest is output with a new issuer certificate signed by the new anchor.
+  3. Once the new issuer certificate is in use, a manifest is output whose
+     trust bundle only contains the new anchor.
+
+The state file contains the private key of the new trust anchor when it is
+generated by this command and must be kept secret.`,
+		Example: `  # Start a rotation and apply the bundled trust anchors.
+  linkerd identity rotate | kubectl apply -f -
+
+  # Restart all meshed workloads, then issue a new issuer certificate.
+  linkerd identity rotate | kubectl apply -f -
+
+  # Once proxies have been issued new certificates, drop the old anchor.
+  linkerd identity rotate | kubectl apply -f -`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+			return runIdentityRotate(cmd.Context(), k8sAPI, options, os.Stdout, os.Stderr)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.stateFile, "state-file", options.stateFile, "Path of the file used to record the progress of the rotation")
+	cmd.Flags().StringVar(&options.newAnchorFile, "new-anchor-file", options.newAnchorFile, "A path to a PEM-encoded trust anchor to rotate to; generated if not set")
+	cmd.Flags().StringVar(&options.newAnchorKeyFile, "new-anchor-key-file", options.newAnchorKeyFile, "A path to the PEM-encoded private key of --new-anchor-file, used to sign the new issuer certificate")
+	cmd.Flags().StringVar(&options.issuerCrtFile, "identity-issuer-certificate-file", options.issuerCrtFile, "A path to a PEM-encoded issuer certificate signed by the new trust anchor")
+	cmd.Flags().StringVar(&options.issuerKeyFile, "identity-issuer-key-file", options.issuerKeyFile, "A path to the PEM-encoded private key of --identity-issuer-certificate-file")
+	cmd.Flags().StringVarP(&options.output, "output", "o", options.output, "Output format. One of: json|yaml")
+
+	return cmd
+}
+
+func runIdentityRotate(ctx context.Context, k *k8s.KubernetesAPI, options *identityRotateOptions, stdout, stderr io.Writer) error {
+	state, err := readRotationState(options.stateFile)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	msg, err := rotateIdentity(ctx, k, options, state, &buf)
+	if err != nil {
+		return err
+	}
+
+	// The state is only advanced once the manifest for the phase has been
+	// rendered, so that a failed invocation can simply be retried.
+	if err := writeRotationState(options.stateFile, state); err != nil {
+		return err
+	}
+	if _, err := buf.WriteTo(stdout); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "\n%s\n", msg)
+	return nil
+}
+
+// rotateIdentity performs the current phase of the rotation described by
+// state, writing the manifest for the phase to w and advancing state. It
+// returns instructions on how to proceed.
+func rotateIdentity(ctx context.Context, k *k8s.KubernetesAPI, options *identityRotateOptions, state *rotationState, w io.Writer) (string, error) {
+	values, err := loadStoredValues(ctx, k)
+	if err != nil {
+		return "", fmt.Errorf("failed to load stored values: %w", err)
+	}
+	if values == nil {
+		return "", errors.New(
+			`Could not find the linkerd-config-overrides secret.
+			If Linkerd was installed with Helm, please rotate the trust anchor with Helm`)
+	}
+	external := values.Identity.Issuer.Scheme == string(corev1.SecretTypeTLS)
+
+	switch state.Phase {
+	case rotationPhaseBundle:
+		current, err := healthcheck.FetchTrustBundle(ctx, *k, controlPlaneNamespace)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch the current trust bundle: %w", err)
+		}
+		if err := loadNewAnchor(options, values.IdentityTrustDomain, state); err != nil {
+			return "", err
+		}
+		if strings.Contains(current, state.NewAnchorPEM) {
+			return "", errors.New("the new trust anchor is already part of the trust bundle")
+		}
+		state.OldAnchorsPEM = current
+		state.BundlePEM = strings.TrimSpace(current) + "\n" + state.NewAnchorPEM
+
+		values.IdentityTrustAnchorsPEM = state.BundlePEM
+		if err := validateValues(ctx, k, values); err != nil {
+			return "", err
+		}
+		if err := renderControlPlane(w, values, nil, options.output); err != nil {
+			return "", err
+		}
+		state.Phase = rotationPhaseIssuer
+		return fmt.Sprintf("%s Apply the manifest above, restart all meshed workloads and run this command again to rotate the issuer certificate.", okStatus), nil
+
+	case rotationPhaseIssuer:
+		if err := checkRotationBundle(ctx, k, state); err != nil {
+			return "", err
+		}
+
+		state.Phase = rotationPhaseFinalize
+		if external {
+			return fmt.Sprintf("%s All meshed pods trust the new anchor. Have your certificate manager reissue the %s secret with the new anchor, then run this command again to remove the old anchor.", okStatus, k8s.IdentityIssuerSecretName), nil
+		}
+
+		crt, key, err := newRotationIssuer(options, state)
+		if err != nil {
+			return "", err
+		}
+		values.IdentityTrustAnchorsPEM = state.BundlePEM
+		values.Identity.Issuer.TLS.CrtPEM = crt
+		values.Identity.Issuer.TLS.KeyPEM = key
+		if err := validateValues(ctx, k, values); err != nil {
+			return "", err
+		}
+		if err := renderControlPlane(w, values, nil, options.output); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s Apply the manifest above and wait for all proxies to be issued new certificates (or restart all meshed workloads), then run this command again to remove the old anchor.", okStatus), nil
+
+	case rotationPhaseFinalize:
+		if err := checkRotationBundle(ctx, k, state); err != nil {
+			return "", err
+		}
+
+		var issuer *issuercerts.IssuerCertData
+		if external {
+			issuer, err = issuercerts.FetchExternalIssuerData(ctx, k, controlPlaneNamespace)
+		} else {
+			issuer, err = issuercerts.FetchIssuerData(ctx, k, state.BundlePEM, controlPlaneNamespace)
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := verifyWithAnchor(issuer.IssuerCrt, state.NewAnchorPEM); err != nil {
+			return "", fmt.Errorf("the issuer certificate in use is not signed by the new trust anchor; apply the manifest of the previous phase first: %w", err)
+		}
+
+		values.IdentityTrustAnchorsPEM = state.NewAnchorPEM
+		if err := validateValues(ctx, k, values); err != nil {
+			return "", err
+		}
+		if err := renderControlPlane(w, values, nil, options.output); err != nil {
+			return "", err
+		}
+		state.Phase = rotationPhaseDone
+		return fmt.Sprintf("%s Apply the manifest above and restart all meshed workloads to complete the rotation.", okStatus), nil
+
+	case rotationPhaseDone:
+		return "", fmt.Errorf("the rotation recorded in %s has completed; remove the file to start a new rotation", options.stateFile)
+
+	default:
+		return "", fmt.Errorf("unknown rotation phase %q in %s", state.Phase, options.stateFile)
+	}
+}
+
+// loadNewAnchor reads the new trust anchor from the options, or generates it
+// if none was given, and records it in state.
+func loadNewAnchor(options *identityRotateOptions, trustDomain string, state *rotationState) error {
+	if options.newAnchorFile == "" {
+		if options.newAnchorKeyFile != "" {
+			return errors.New("--new-anchor-key-file requires --new-anchor-file")
+		}
+		root, err := tls.GenerateRootCAWithDefaults(issuerName(trustDomain))
+		if err != nil {
+			return fmt.Errorf("failed to generate root certificate for identity: %w", err)
+		}
+		state.NewAnchorPEM = root.Cred.Crt.EncodeCertificatePEM()
+		state.NewAnchorKeyPEM = root.Cred.EncodePrivateKeyPEM()
+		return nil
+	}
+
+	crt, err := loadCrtPEM(options.newAnchorFile)
+	if err != nil {
+		return err
+	}
+	state.NewAnchorPEM = crt
+	if options.newAnchorKeyFile != "" {
+		key, err := loadKeyPEM(options.newAnchorKeyFile)
+		if err != nil {
+			return err
+		}
+		if _, err := tls.ValidateAndCreateCreds(crt, key); err != nil {
+			return fmt.Errorf("invalid new trust anchor: %w", err)
+		}
+		state.NewAnchorKeyPEM = key
+	}
+	return nil
+}
+
+// newRotationIssuer returns the issuer certificate and key to rotate to. They
+// are read from the options if given; otherwise the new trust anchor is used
+// as the issuer, as `linkerd install` does when it generates credentials.
+func newRotationIssuer(options *identityRotateOptions, state *rotationState) (string, string, error) {
+	if options.issuerCrtFile != "" || options.issuerKeyFile != "" {
+		if options.issuerCrtFile == "" || options.issuerKeyFile == "" {
+			return "", "", errors.New("--identity-issuer-certificate-file and --identity-issuer-key-file must be set together")
+		}
+		crt, err := loadCrtPEM(options.issuerCrtFile)
+		if err != nil {
+			return "", "", err
+		}
+		key, err := loadKeyPEM(options.issuerKeyFile)
+		if err != nil {
+			return "", "", err
+		}
+		if err := verifyWithAnchor(crt, state.NewAnchorPEM); err != nil {
+			return "", "", fmt.Errorf("the issuer certificate is not signed by the new trust anchor: %w", err)
+		}
+		return crt, key, nil
+	}
+
+	if state.NewAnchorKeyPEM == "" {
+		return "", "", errors.New("the private key of the new trust anchor is unknown; provide an issuer certificate signed by it with --identity-issuer-certificate-file and --identity-issuer-key-file")
+	}
+	return state.NewAnchorPEM, state.NewAnchorKeyPEM, nil
+}
+
+// checkRotationBundle verifies that the bundled trust anchors have been
+// applied and that every meshed pod has been restarted with them.
+func checkRotationBundle(ctx context.Context, k *k8s.KubernetesAPI, state *rotationState) error {
+	current, err := healthcheck.FetchTrustBundle(ctx, *k, controlPlaneNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the current trust bundle: %w", err)
+	}
+	if strings.TrimSpace(current) != strings.TrimSpace(state.BundlePEM) {
+		return errors.New("the bundled trust anchors have not been applied yet; apply the manifest of the previous phase first")
+	}
+	return healthcheck.CheckPodsProxiesCertificate(ctx, *k, "", controlPlaneNamespace)
+}
+
+func verifyWithAnchor(crtPEM, anchorPEM string) error {
+	crt, err := tls.DecodePEMCrt(crtPEM)
+	if err != nil {
+		return err
+	}
+	anchors, err := tls.DecodePEMCertPool(anchorPEM)
+	if err != nil {
+		return err
+	}
+	return crt.Verify(anchors, "", time.Time{})
+}
+
+func readRotationState(path string) (*rotationState, error) {
+	state := &rotationState{}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid rotation state in %s: %w", path, err)
+	}
+	return state, nil
+}
+
+func writeRotationState(path string, state *rotationState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Clean(path), data, 0600)
+}
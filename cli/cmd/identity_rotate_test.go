@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linkerd/linkerd2/pkg/k8s"
+)
+
+func TestIdentityRotate(t *testing.T) {
+	values, _ := testOptions(t)
+	oldAnchors := strings.TrimSpace(values.IdentityTrustAnchorsPEM)
+	options := &identityRotateOptions{
+		stateFile: filepath.Join(t.TempDir(), "rotation.json"),
+		output:    yamlOutput,
+	}
+
+	rotate := func(manifest, podAnchors string) (string, error) {
+		t.Helper()
+		pod := podWithSidecar(issuerCerts{ca: strings.TrimSpace(podAnchors)})
+		k, err := k8s.NewFakeAPIFromManifests([]io.Reader{strings.NewReader(manifest + pod)})
+		if err != nil {
+			t.Fatalf("failed to initialize fake API: %s", err)
+		}
+		var stdout, stderr bytes.Buffer
+		err = runIdentityRotate(context.Background(), k, options, &stdout, &stderr)
+		return stdout.String(), err
+	}
+
+	var buf bytes.Buffer
+	if err := renderControlPlane(&buf, values, nil, "yaml"); err != nil {
+		t.Fatalf("could not render install manifests: %s", err)
+	}
+	install := buf.String()
+
+	// Phase 1: the old and new anchors are bundled.
+	bundled, err := rotate(install, oldAnchors)
+	if err != nil {
+		t.Fatalf("unexpected error bundling anchors: %s", err)
+	}
+	state, err := readRotationState(options.stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Phase != rotationPhaseIssuer {
+		t.Fatalf("expected phase %q, got %q", rotationPhaseIssuer, state.Phase)
+	}
+	if !strings.Contains(state.BundlePEM, oldAnchors) || !strings.Contains(state.BundlePEM, state.NewAnchorPEM) {
+		t.Fatalf("expected the bundle to contain the old and new anchors, got:\n%s", state.BundlePEM)
+	}
+
+	// Phase 2 is refused until the bundle is applied and pods restarted.
+	if _, err := rotate(install, oldAnchors); err == nil || !strings.Contains(err.Error(), "have not been applied") {
+		t.Fatalf("expected the unapplied bundle to be reported, got %v", err)
+	}
+	if _, err := rotate(bundled, oldAnchors); err == nil || !strings.Contains(err.Error(), "some-namespace/backend-wrong-anchors") {
+		t.Fatalf("expected the pod with the old anchors to be reported, got %v", err)
+	}
+	rotated, err := rotate(bundled, state.BundlePEM)
+	if err != nil {
+		t.Fatalf("unexpected error rotating the issuer: %s", err)
+	}
+
+	// Phase 3 is refused until the new issuer is applied.
+	if _, err := rotate(bundled, state.BundlePEM); err == nil || !strings.Contains(err.Error(), "not signed by the new trust anchor") {
+		t.Fatalf("expected the old issuer to be reported, got %v", err)
+	}
+	final, err := rotate(rotated, state.BundlePEM)
+	if err != nil {
+		t.Fatalf("unexpected error removing the old anchor: %s", err)
+	}
+	k, err := k8s.NewFakeAPIFromManifests([]io.Reader{strings.NewReader(final)})
+	if err != nil {
+		t.Fatalf("failed to initialize fake API: %s", err)
+	}
+	finalValues, err := loadStoredValues(context.Background(), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(finalValues.IdentityTrustAnchorsPEM) != strings.TrimSpace(state.NewAnchorPEM) {
+		t.Fatalf("expected only the new anchor to be trusted, got:\n%s", finalValues.IdentityTrustAnchorsPEM)
+	}
+
+	if _, err := rotate(final, state.NewAnchorPEM); err == nil || !strings.Contains(err.Error(), "has completed") {
+		t.Fatalf("expected the completed rotation to be reported, got %v", err)
+	}
+}
@@ -55,7 +55,7 @@ func NewCmdInject(overrider inject.ValueOverrider) *cobra.Command {
 	}
 	flags, proxyFlagSet := makeProxyFlags(defaults)
 	injectFlags, injectFlagSet := makeInjectFlags(defaults)
-	var manualOption, enableDebugSidecar bool
+	var manualOption, enableDebugSidecar, explain, inPlace bool
 	var closeWaitTimeout time.Duration
 	var output string
 
@@ -65,7 +65,13 @@ func NewCmdInject(overrider inject.ValueOverrider) *cobra.Command {
 		Long: `Add the Linkerd proxy to a Kubernetes config.
 
 You can inject resources contained in a single file, inside a folder and its
-sub-folders, or coming from stdin.`,
+sub-folders, or coming from stdin.
+
+With --in-place, the YAML files of the given file or folder are rewritten
+instead, preserving the layout of the tree. Documents that aren't changed by
+the injection, such as non-workload resources, are left untouched, and the
+comments and key order of the injected ones are kept. Documents that aren't
+valid YAML, such as unrendered Helm templates, are skipped with a warning.`,
 		Example: `  # Inject all the deployments in the default namespace.
   kubectl get deploy -o yaml | linkerd inject - | kubectl apply -f -
 
@@ -73,12 +79,26 @@ sub-folders, or coming from stdin.`,
   linkerd inject https://url.to/yml | kubectl apply -f -
 
   # Inject all the resources inside a folder and its sub-folders.
-  linkerd inject <folder> | kubectl apply -f -`,
+  linkerd inject <folder> | kubectl apply -f -
+
+  # Inject the manifests rendered from a Helm chart, rewriting them in place.
+  helm template my-app ./chart --output-dir rendered
+  linkerd inject --in-place rendered
+
+  # Explain how a live deployment would be injected, and where each proxy
+  # setting comes from.
+  kubectl get deploy/web -o yaml | linkerd inject --explain -`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
 				return fmt.Errorf("please specify a kubernetes resource file")
 			}
 
+			if inPlace {
+				if err := validateInPlace(args[0], output, explain); err != nil {
+					return err
+				}
+			}
+
 			values := defaults
 			if !ignoreCluster {
 				values, err = fetchConfigs(cmd.Context())
@@ -96,11 +116,6 @@ sub-folders, or coming from stdin.`,
 				return err
 			}
 
-			in, err := read(args[0])
-			if err != nil {
-				return err
-			}
-
 			overrideAnnotations := getOverrideAnnotations(values, baseValues)
 
 			transformer := &resourceTransformerInject{
@@ -112,6 +127,30 @@ sub-folders, or coming from stdin.`,
 				closeWaitTimeout:    closeWaitTimeout,
 				overrider:           overrider,
 			}
+
+			if inPlace {
+				os.Exit(runInjectInPlaceCmd(args[0], stderr, transformer))
+			}
+
+			in, err := read(args[0])
+			if err != nil {
+				return err
+			}
+
+			if explain {
+				explainer := &injectExplainer{
+					values:              values,
+					overrideAnnotations: overrideAnnotations,
+				}
+				if !ignoreCluster {
+					explainer.k8sAPI, err = k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+					if err != nil {
+						return err
+					}
+				}
+				os.Exit(runInjectExplainCmd(cmd.Context(), in, stderr, stdout, explainer, output))
+			}
+
 			exitCode := uninjectAndInject(in, stderr, stdout, transformer, output)
 			os.Exit(exitCode)
 			return nil
@@ -135,6 +174,16 @@ sub-folders, or coming from stdin.`,
 		&closeWaitTimeout, "close-wait-timeout", closeWaitTimeout,
 		"Sets nf_conntrack_tcp_timeout_close_wait")
 
+	cmd.Flags().BoolVar(
+		&explain, "explain", explain,
+		"Instead of injecting, print whether each resource would be injected, or why it would be skipped, and the value and source of every proxy setting (default false)",
+	)
+
+	cmd.Flags().BoolVar(
+		&inPlace, "in-place", inPlace,
+		"Rewrite the YAML files of the given file or folder instead of printing the injected resources, keeping untouched documents, comments and key order (default false)",
+	)
+
 	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "Output format, one of: json|yaml")
 
 	cmd.Flags().AddFlagSet(proxyFlagSet)
@@ -173,6 +222,9 @@ func (rt resourceTransformerInject) transform(bytes []byte) ([]byte, []inject.Re
 
 	if conf.IsService() {
 		opaquePorts, ok := rt.overrideAnnotations[k8s.ProxyOpaquePortsAnnotation]
+		if !ok && len(report.InferredOpaquePorts) > 0 {
+			opaquePorts, ok = report.InferredOpaquePortsValue, true
+		}
 		if ok {
 			annotations := map[string]string{k8s.ProxyOpaquePortsAnnotation: opaquePorts}
 			bytes, err = conf.AnnotateService(annotations)
@@ -197,6 +249,11 @@ func (rt resourceTransformerInject) transform(bytes []byte) ([]byte, []inject.Re
 		return bytes, []inject.Report{*report}, nil
 	}
 
+	if len(report.InferredOpaquePorts) > 0 {
+		// pin the inferred opaque ports in the manifest
+		conf.AppendPodAnnotation(k8s.ProxyOpaquePortsAnnotation, report.InferredOpaquePortsValue)
+	}
+
 	if rt.injectProxy {
 		// delete the inject annotation if present as its not needed in the manual case
 		// prevents injector from taking a different code path in the ingress mode
@@ -352,6 +409,18 @@ func (resourceTransformerInject) generateReport(reports []inject.Report, output
 		}
 	}
 
+	for _, r := range reports {
+		if len(r.InferredOpaquePorts) == 0 {
+			continue
+		}
+		ports := make([]string, len(r.InferredOpaquePorts))
+		for i, p := range r.InferredOpaquePorts {
+			ports[i] = p.String()
+		}
+		output.Write([]byte(fmt.Sprintf("%s \"%s\": inferred opaque ports %s, pinned with \"%s: %s\"\n",
+			r.Kind, r.Name, strings.Join(ports, ", "), k8s.ProxyOpaquePortsAnnotation, r.InferredOpaquePortsValue)))
+	}
+
 	// Trailing newline to separate from kubectl output if piping
 	output.Write([]byte("\n"))
 }
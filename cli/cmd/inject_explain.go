@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	"github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	yamlDecoder "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// injectExplainer explains how the resources given to `linkerd inject` would
+// be injected, instead of injecting them
+type injectExplainer struct {
+	values              *linkerd2.Values
+	overrideAnnotations map[string]string
+
+	// k8sAPI is used to resolve namespace annotations and ProxyConfigs. It's
+	// nil when the cluster is ignored.
+	k8sAPI *k8s.KubernetesAPI
+}
+
+// runInjectExplainCmd writes the explanation of every resource in inputs to
+// outWriter, returning the os.Exit code
+func runInjectExplainCmd(ctx context.Context, inputs []io.Reader, errWriter, outWriter io.Writer, explainer *injectExplainer, output string) int {
+	explanations := []*inject.Explanation{}
+	for _, input := range inputs {
+		reader := yamlDecoder.NewYAMLReader(bufio.NewReaderSize(input, 4096))
+		for {
+			bytes, err := reader.Read()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				fmt.Fprintf(errWriter, "Error reading resources: %s\n", err)
+				return 1
+			}
+			exps, err := explainer.explainDocument(ctx, bytes)
+			if err != nil {
+				fmt.Fprintf(errWriter, "Error explaining resources: %s\n", err)
+				return 1
+			}
+			explanations = append(explanations, exps...)
+		}
+	}
+
+	if output == jsonOutput {
+		out, err := json.MarshalIndent(explanations, "", "  ")
+		if err != nil {
+			fmt.Fprintf(errWriter, "Error rendering explanations: %s\n", err)
+			return 1
+		}
+		fmt.Fprintf(outWriter, "%s\n", out)
+		return 0
+	}
+	fmt.Fprint(outWriter, renderExplanations(explanations))
+	return 0
+}
+
+func (ie *injectExplainer) explainDocument(ctx context.Context, bytes []byte) ([]*inject.Explanation, error) {
+	isList, err := kindIsList(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !isList {
+		explanation, err := ie.explain(ctx, bytes)
+		if err != nil {
+			return nil, err
+		}
+		return []*inject.Explanation{explanation}, nil
+	}
+
+	var list corev1.List
+	if err := yaml.Unmarshal(bytes, &list); err != nil {
+		return nil, err
+	}
+	explanations := []*inject.Explanation{}
+	for _, item := range list.Items {
+		explanation, err := ie.explain(ctx, item.Raw)
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations, nil
+}
+
+func (ie *injectExplainer) explain(ctx context.Context, bytes []byte) (*inject.Explanation, error) {
+	conf := inject.NewResourceConfig(ie.values, inject.OriginCLI, controlPlaneNamespace)
+	report, err := conf.ParseMetaAndYAML(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if ie.k8sAPI != nil && conf.HasPodTemplate() {
+		namespace := conf.GetWorkloadNamespace()
+		if namespace == "" {
+			namespace = corev1.NamespaceDefault
+		}
+		ns, err := ie.k8sAPI.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			conf.WithNsAnnotations(ns.GetAnnotations())
+		case !kerrors.IsNotFound(err):
+			return nil, err
+		}
+		conf.WithProxyConfigRetriever(proxyConfigRetriever(ctx, ie.k8sAPI, namespace))
+	}
+
+	return conf.Explain(report, ie.overrideAnnotations)
+}
+
+// proxyConfigRetriever resolves ProxyConfigs from the cluster, looking first
+// for a ProxyConfig in the workload's namespace and then for a
+// ClusterProxyConfig of the same name
+func proxyConfigRetriever(ctx context.Context, k8sAPI *k8s.KubernetesAPI, namespace string) inject.ProxyConfigRetrieverFunc {
+	return func(name string) (*pcv1alpha1.ProxyConfigSpec, error) {
+		client := k8sAPI.L5dCrdClient.ProxyconfigV1alpha1()
+		pc, err := client.ProxyConfigs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return &pc.Spec, nil
+		}
+		if !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		cpc, err := client.ClusterProxyConfigs().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &cpc.Spec, nil
+	}
+}
+
+func renderExplanations(explanations []*inject.Explanation) string {
+	var buffer bytes.Buffer
+	for i, explanation := range explanations {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		if explanation.Kind == "" {
+			buffer.WriteString("document missing \"kind\" field\n")
+			continue
+		}
+		name := fmt.Sprintf("%s/%s", explanation.Kind, explanation.Name)
+		if explanation.Injectable {
+			fmt.Fprintf(&buffer, "%s %s would be injected\n", okStatus, name)
+		} else {
+			fmt.Fprintf(&buffer, "%s %s would be skipped\n", failStatus, name)
+			for _, reason := range explanation.SkipReasons {
+				fmt.Fprintf(&buffer, "    %s\n", reason)
+			}
+		}
+		if explanation.ProxyConfig != "" {
+			fmt.Fprintf(&buffer, "    using ProxyConfig %s\n", explanation.ProxyConfig)
+		}
+		for _, warning := range explanation.Warnings {
+			fmt.Fprintf(&buffer, "%s %s\n", warnStatus, warning)
+		}
+		if len(explanation.Settings) == 0 {
+			continue
+		}
+
+		buffer.WriteString("\n")
+		w := tabwriter.NewWriter(&buffer, 0, 0, padding, ' ', 0)
+		fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+		for _, setting := range explanation.Settings {
+			value := setting.Value
+			if value == "" {
+				value = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Annotation, value, setting.Source)
+		}
+		w.Flush()
+	}
+	return buffer.String()
+}
@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/linkerd/linkerd2/pkg/inject"
+	goyaml "go.yaml.in/yaml/v3"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// yamlSeparatorRE matches the lines separating the documents of a YAML
+	// stream
+	yamlSeparatorRE = regexp.MustCompile(`^---(\s.*)?$`)
+
+	// indentedKeyRE matches the first indented mapping key following a line
+	// opening a nested block
+	indentedKeyRE = regexp.MustCompile(`(?m):[ \t]*\n( +)[^\s#-]`)
+)
+
+// inPlaceFile is a manifest file to be rewritten by `linkerd inject --in-place`
+type inPlaceFile struct {
+	path    string
+	mode    fs.FileMode
+	content []byte
+	updated []byte
+}
+
+// runInjectInPlaceCmd injects the resources of every YAML file under path,
+// writing back only the files containing resources whose injection changed
+// them. The layout of the tree is preserved, and so are the comments, key
+// order and formatting of the documents that didn't change. It returns the
+// os.Exit code.
+func runInjectInPlaceCmd(path string, errWriter io.Writer, transformer *resourceTransformerInject) int {
+	files, err := readInPlaceFiles(path)
+	if err != nil {
+		fmt.Fprintf(errWriter, "Error reading resources: %s\n", err)
+		return 1
+	}
+
+	reports := []inject.Report{}
+	errs := []error{}
+	for _, file := range files {
+		updated, irs, skipped, err := injectInPlace(file.content, transformer)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.path, err))
+			continue
+		}
+		for _, doc := range skipped {
+			fmt.Fprintf(errWriter, "%s %s document %d skipped: not valid YAML; Helm chart templates must be rendered first, e.g. with `helm template --output-dir`\n", warnStatus, file.path, doc)
+		}
+		reports = append(reports, irs...)
+		file.updated = updated
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(errWriter, "Error transforming resources:\n%v\n", concatErrors(errs, "\n"))
+		return 1
+	}
+
+	for _, file := range files {
+		if file.updated == nil || bytes.Equal(file.content, file.updated) {
+			continue
+		}
+		if err := os.WriteFile(file.path, file.updated, file.mode); err != nil {
+			fmt.Fprintf(errWriter, "Error writing %s: %s\n", file.path, err)
+			return 1
+		}
+		fmt.Fprintf(errWriter, "%s updated\n", file.path)
+	}
+
+	transformer.generateReport(reports, errWriter)
+	return 0
+}
+
+// readInPlaceFiles reads the file at path or, if path is a directory, every
+// YAML file in its tree
+func readInPlaceFiles(path string) ([]*inPlaceFile, error) {
+	root := filepath.Clean(path)
+	stat, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*inPlaceFile{}
+	readFile := func(path string, mode fs.FileMode) error {
+		content, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		files = append(files, &inPlaceFile{path: path, mode: mode.Perm(), content: content})
+		return nil
+	}
+
+	if !stat.IsDir() {
+		return files, readFile(root, stat.Mode())
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+		default:
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return readFile(path, info.Mode())
+	})
+	return files, err
+}
+
+// injectInPlace injects the resources of the given YAML stream, returning it
+// with the documents that changed updated and all the others untouched.
+// Documents that can't be parsed, e.g. unrendered Helm templates, are kept as
+// is and their 1-based positions in the stream are returned as skipped.
+func injectInPlace(content []byte, transformer *resourceTransformerInject) ([]byte, []inject.Report, []int, error) {
+	reports := []inject.Report{}
+	skipped := []int{}
+	var out bytes.Buffer
+	doc := 0
+	for _, chunk := range splitYAMLDocuments(content) {
+		if yamlSeparatorRE.Match(bytes.TrimRight(chunk, "\r\n")) {
+			out.Write(chunk)
+			continue
+		}
+		doc++
+		var original any
+		if err := yaml.Unmarshal(chunk, &original); err != nil {
+			skipped = append(skipped, doc)
+			out.Write(chunk)
+			continue
+		}
+		updated, irs, err := injectDocumentInPlace(chunk, original, transformer)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		out.Write(updated)
+		reports = append(reports, irs...)
+	}
+	return out.Bytes(), reports, skipped, nil
+}
+
+// splitYAMLDocuments splits a YAML stream into its documents and the
+// separator lines between them, keeping every byte so that joining the
+// chunks back yields the original stream
+func splitYAMLDocuments(content []byte) [][]byte {
+	chunks := [][]byte{}
+	var doc []byte
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if yamlSeparatorRE.Match(bytes.TrimRight(line, "\r\n")) {
+			if len(doc) > 0 {
+				chunks = append(chunks, doc)
+				doc = nil
+			}
+			chunks = append(chunks, line)
+			continue
+		}
+		doc = append(doc, line...)
+	}
+	if len(doc) > 0 {
+		chunks = append(chunks, doc)
+	}
+	return chunks
+}
+
+// injectDocumentInPlace injects the resource in the given YAML document, whose
+// parsed contents are passed as original. If the injection doesn't change the
+// resource, e.g. because it isn't a workload or is already injected, the
+// document is returned as is. Otherwise the changes are merged into the
+// original document so that its comments and key order are kept.
+func injectDocumentInPlace(doc []byte, original any, transformer *resourceTransformerInject) ([]byte, []inject.Report, error) {
+	if original == nil {
+		// Only comments
+		return doc, nil, nil
+	}
+
+	uninjected, _, err := transformDocument(doc, resourceTransformerUninjectSilent{transformer.values})
+	if err != nil {
+		return nil, nil, err
+	}
+	injected, reports, err := transformDocument(uninjected, transformer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result any
+	if err := yaml.Unmarshal(injected, &result); err != nil {
+		return nil, nil, err
+	}
+	if reflect.DeepEqual(original, result) {
+		return doc, reports, nil
+	}
+
+	updated, err := mergeYAMLDocument(doc, injected)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, reports, nil
+}
+
+func transformDocument(doc []byte, rt resourceTransformer) ([]byte, []inject.Report, error) {
+	isList, err := kindIsList(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isList {
+		return processList(doc, rt)
+	}
+	return rt.transform(doc)
+}
+
+// mergeYAMLDocument returns the original document updated with the contents
+// of the updated one, reusing the original's indentation and leading and
+// trailing blank lines
+func mergeYAMLDocument(original, updated []byte) ([]byte, error) {
+	var dst, src goyaml.Node
+	if err := goyaml.Unmarshal(original, &dst); err != nil {
+		return nil, err
+	}
+	if err := goyaml.Unmarshal(updated, &src); err != nil {
+		return nil, err
+	}
+	mergeYAMLNode(&dst, &src)
+
+	var buf bytes.Buffer
+	enc := goyaml.NewEncoder(&buf)
+	indent := 2
+	if m := indentedKeyRE.FindSubmatch(original); m != nil {
+		indent = len(m[1])
+	}
+	enc.SetIndent(indent)
+	if usesCompactSequences(original) {
+		enc.CompactSeqIndent()
+	}
+	if err := enc.Encode(&dst); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	leading := original[:len(original)-len(bytes.TrimLeft(original, "\r\n"))]
+	trailing := original[len(bytes.TrimRight(original, " \t\r\n")):]
+	if !bytes.Contains(trailing, []byte("\n")) {
+		trailing = []byte("\n")
+	}
+
+	out := append([]byte{}, leading...)
+	out = append(out, bytes.TrimRight(buf.Bytes(), "\n")...)
+	return append(out, trailing...), nil
+}
+
+// usesCompactSequences returns whether the first block sequence of the given
+// document isn't indented relative to the mapping key it belongs to
+func usesCompactSequences(doc []byte) bool {
+	var prev []byte
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 || trimmed[0] == '#' {
+			continue
+		}
+		if prev != nil && bytes.HasSuffix(bytes.TrimSpace(prev), []byte(":")) &&
+			(bytes.HasPrefix(trimmed, []byte("- ")) || bytes.Equal(trimmed, []byte("-"))) {
+			return yamlIndent(line) == yamlIndent(prev)
+		}
+		prev = line
+	}
+	return false
+}
+
+func yamlIndent(line []byte) int {
+	return len(line) - len(bytes.TrimLeft(line, " "))
+}
+
+// mergeYAMLNode updates dst in place so that it holds the same contents as
+// src. Mapping keys found in dst keep their position, comments and style, new
+// keys are appended, and sequence items are matched by name when they all
+// have one, or else by position.
+func mergeYAMLNode(dst, src *goyaml.Node) {
+	if dst.Kind != src.Kind {
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+		return
+	}
+
+	switch dst.Kind {
+	case goyaml.DocumentNode:
+		if len(dst.Content) == 1 && len(src.Content) == 1 {
+			mergeYAMLNode(dst.Content[0], src.Content[0])
+		}
+	case goyaml.MappingNode:
+		srcValues := map[string]*goyaml.Node{}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			srcValues[src.Content[i].Value] = src.Content[i+1]
+		}
+		dstKeys := map[string]bool{}
+		content := []*goyaml.Node{}
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			key, value := dst.Content[i], dst.Content[i+1]
+			srcValue, ok := srcValues[key.Value]
+			if !ok {
+				continue
+			}
+			dstKeys[key.Value] = true
+			mergeYAMLNode(value, srcValue)
+			content = append(content, key, value)
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if !dstKeys[src.Content[i].Value] {
+				content = append(content, src.Content[i], src.Content[i+1])
+			}
+		}
+		dst.Content = content
+	case goyaml.SequenceNode:
+		dstItems, dstOk := itemsByName(dst)
+		_, srcOk := itemsByName(src)
+		content := []*goyaml.Node{}
+		for i, srcItem := range src.Content {
+			var item *goyaml.Node
+			if dstOk && srcOk {
+				item = dstItems[yamlItemName(srcItem)]
+			} else if i < len(dst.Content) {
+				item = dst.Content[i]
+			}
+			if item == nil {
+				content = append(content, srcItem)
+				continue
+			}
+			mergeYAMLNode(item, srcItem)
+			content = append(content, item)
+		}
+		dst.Content = content
+	case goyaml.ScalarNode:
+		if dst.Value != src.Value || dst.ShortTag() != src.ShortTag() {
+			dst.Value, dst.Tag, dst.Style = src.Value, src.Tag, src.Style
+		}
+	default:
+		*dst = *src
+	}
+}
+
+// itemsByName indexes the items of a sequence by their name field, returning
+// false if any of them hasn't got one
+func itemsByName(seq *goyaml.Node) (map[string]*goyaml.Node, bool) {
+	items := map[string]*goyaml.Node{}
+	for _, item := range seq.Content {
+		name := yamlItemName(item)
+		if name == "" {
+			return nil, false
+		}
+		if _, ok := items[name]; ok {
+			return nil, false
+		}
+		items[name] = item
+	}
+	return items, true
+}
+
+func yamlItemName(node *goyaml.Node) string {
+	if node.Kind != goyaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "name" && node.Content[i+1].Kind == goyaml.ScalarNode {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// validateInPlace checks the arguments given to `linkerd inject --in-place`
+func validateInPlace(path, output string, explain bool) error {
+	if path == "-" {
+		return errors.New("--in-place cannot be used when reading from stdin")
+	}
+	if _, ok := toURL(path); ok {
+		return errors.New("--in-place cannot be used with URLs")
+	}
+	if explain {
+		return errors.New("--in-place cannot be used with --explain")
+	}
+	if output != yamlOutput {
+		return errors.New("--in-place only supports the yaml output")
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linkerd/linkerd2/pkg/inject"
+)
+
+func TestInjectInPlace(t *testing.T) {
+	values, err := testInstallValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	transformer := &resourceTransformerInject{
+		allowNsInject: true,
+		values:        values,
+		overrider:     inject.GetOverriddenValues,
+	}
+
+	dir := t.TempDir()
+	resources := filepath.Join("testdata", "inject-in-place", "resources")
+	if err := os.CopyFS(dir, os.DirFS(resources)); err != nil {
+		t.Fatal(err)
+	}
+
+	errBuf := &bytes.Buffer{}
+	if exitCode := runInjectInPlaceCmd(dir, errBuf, transformer); exitCode != 0 {
+		t.Fatalf("Unexpected exit code %d: %s", exitCode, errBuf)
+	}
+	stderr := strings.ReplaceAll(errBuf.String(), dir+string(filepath.Separator), "")
+	testDataDiffer.DiffTestdata(t, filepath.Join("inject-in-place", "expected", "in_place.stderr.golden"), stderr)
+
+	app, err := os.ReadFile(filepath.Join(dir, "app.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDataDiffer.DiffTestdata(t, filepath.Join("inject-in-place", "expected", "app.yaml.golden"), string(app))
+
+	// A rendered template string doesn't prevent injecting its document, and
+	// the unrendered document following it is kept as is
+	voteBot, err := os.ReadFile(filepath.Join(dir, "vote-bot.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDataDiffer.DiffTestdata(t, filepath.Join("inject-in-place", "expected", "vote-bot.yaml.golden"), string(voteBot))
+
+	for _, file := range []string{"README.md", "config/settings.yml", "templates/deployment.yaml"} {
+		expected, err := os.ReadFile(filepath.Join(resources, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("Expected %s to be left untouched, got:\n%s", file, actual)
+		}
+	}
+
+	t.Run("is idempotent", func(t *testing.T) {
+		errBuf := &bytes.Buffer{}
+		if exitCode := runInjectInPlaceCmd(dir, errBuf, transformer); exitCode != 0 {
+			t.Fatalf("Unexpected exit code %d: %s", exitCode, errBuf)
+		}
+		if strings.Contains(errBuf.String(), "updated") {
+			t.Errorf("Expected no file to be updated, got:\n%s", errBuf)
+		}
+		actual, err := os.ReadFile(filepath.Join(dir, "app.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(app, actual) {
+			t.Errorf("Expected app.yaml not to change, got:\n%s", actual)
+		}
+	})
+}
+
+func TestMergeYAMLDocument(t *testing.T) {
+	original := `kind: Pod
+metadata:
+  name: web # the frontend
+spec:
+  containers:
+  # the app
+  - name: web
+    image: web:v1
+`
+	updated := `kind: Pod
+metadata:
+  annotations:
+    linkerd.io/inject: enabled
+  name: web
+spec:
+  containers:
+  - image: proxy:v2
+    name: linkerd-proxy
+  - image: web:v2
+    name: web
+`
+	expected := `kind: Pod
+metadata:
+  name: web # the frontend
+  annotations:
+    linkerd.io/inject: enabled
+spec:
+  containers:
+  - image: proxy:v2
+    name: linkerd-proxy
+  # the app
+  - name: web
+    image: web:v2
+`
+	actual, err := mergeYAMLDocument([]byte(original), []byte(updated))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(actual) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestValidateInPlace(t *testing.T) {
+	for _, tc := range []struct {
+		path    string
+		output  string
+		explain bool
+		valid   bool
+	}{
+		{path: "manifests", output: yamlOutput, valid: true},
+		{path: "-", output: yamlOutput},
+		{path: "https://example.com/app.yaml", output: yamlOutput},
+		{path: "manifests", output: jsonOutput},
+		{path: "manifests", output: yamlOutput, explain: true},
+	} {
+		err := validateInPlace(tc.path, tc.output, tc.explain)
+		if tc.valid != (err == nil) {
+			t.Errorf("Unexpected result for %+v: %v", tc, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -319,6 +320,12 @@ func TestUninjectAndInject(t *testing.T) {
 			injectProxy:      true,
 			testInjectConfig: opaquePortsConfig,
 		},
+		{
+			inputFileName:    "inject_port_inference.input.yml",
+			goldenFileName:   "inject_port_inference.golden.yml",
+			reportFileName:   "inject_port_inference.report",
+			testInjectConfig: defaultValues,
+		},
 		{
 			inputFileName:    "inject_emojivoto_pod.input.yml",
 			goldenFileName:   "inject_emojivoto_pod_ingress.golden.yml",
@@ -862,3 +869,43 @@ func diffOverrides(t *testing.T, expectedOverrides map[string]string, actualOver
 		}
 	}
 }
+
+func TestRunInjectExplainCmd(t *testing.T) {
+	testCases := []struct {
+		inputFileName  string
+		goldenFileName string
+		output         string
+	}{
+		{"inject_emojivoto_deployment_config_overrides.input.yml", "inject_explain_config_overrides.golden", yamlOutput},
+		{"inject_emojivoto_deployment_config_overrides.input.yml", "inject_explain_config_overrides.golden.json", jsonOutput},
+		{"inject_emojivoto_deployment_hostNetwork_true.input.yml", "inject_explain_hostNetwork_true.golden", yamlOutput},
+	}
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.goldenFileName, func(t *testing.T) {
+			values := defaultConfig()
+			base, err := values.DeepCopy()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			values.Proxy.LogLevel = "debug"
+
+			in, err := os.Open(filepath.Join("testdata", tc.inputFileName))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer in.Close()
+
+			errBuffer := &bytes.Buffer{}
+			outBuffer := &bytes.Buffer{}
+			explainer := &injectExplainer{
+				values:              values,
+				overrideAnnotations: getOverrideAnnotations(values, base),
+			}
+			if exitCode := runInjectExplainCmd(context.Background(), []io.Reader{in}, errBuffer, outBuffer, explainer, tc.output); exitCode != 0 {
+				t.Fatalf("Unexpected exit code %d: %s", exitCode, errBuffer)
+			}
+			testDataDiffer.DiffTestdata(t, tc.goldenFileName, outBuffer.String())
+		})
+	}
+}
@@ -64,6 +64,7 @@ var (
 		"templates/gateway.networking.k8s.io_tlsroutes.yaml",
 		"templates/gateway.networking.k8s.io_tcproutes.yaml",
 		"templates/workload/external-workload.yaml",
+		"templates/config/proxy-config.yaml",
 	}
 
 	TemplatesControlPlane = []string{
@@ -293,6 +293,8 @@ func TestRender(t *testing.T) {
 		{defaultValues, "install_custom_domain.golden", values.Options{}},
 		{defaultValues, "install_values_file.golden", values.Options{ValueFiles: []string{filepath.Join("testdata", "install_config.yaml")}}},
 		{defaultValues, "install_default_token.golden", values.Options{Values: []string{"identity.serviceAccountTokenProjection=false"}}},
+		{defaultValues, "install_auto_rollout.golden", values.Options{Values: []string{"proxyInjector.autoRollout.enabled=true", "proxyInjector.autoRollout.dryRun=true"}}},
+		{defaultValues, "install_mesh_required.golden", values.Options{Values: []string{"proxyInjector.meshRequired.enabled=true", "proxyInjector.meshRequired.exemptions={payments/job/*,monitoring/daemonset/node-exporter}"}}},
 		{gidValues, "install_gid_output.golden", values.Options{}},
 		{tracingValues, "install_tracing.golden", values.Options{}},
 	}
@@ -962,6 +964,75 @@ func TestValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("Validates the identity key algorithms", func(t *testing.T) {
+		testCases := []struct {
+			issuerKeyAlgorithm string
+			csrKeyAlgorithms   []string
+			proxyKeyAlgorithm  string
+			expectedError      string
+		}{
+			{"ecdsa-p256", []string{"ecdsa-p256"}, "", ""},
+			{"", []string{"ecdsa-p256", "ed25519"}, "ed25519", ""},
+			{"ed25519", []string{"ecdsa-p256"}, "", "failed to validate issuer credentials: key algorithm ecdsa-p256 is not allowed, must be one of: ed25519"},
+			{"dsa", []string{"ecdsa-p256"}, "", "invalid --identity-issuer-key-algorithm: unsupported key algorithm \"dsa\", must be one of: ecdsa-p256, rsa-2048, rsa-4096, ed25519"},
+			{"ecdsa-p256", []string{"ecdsa-p256"}, "rsa-2048", "the proxy identity key algorithm rsa-2048 must be one of --identity-csr-key-algorithms"},
+		}
+
+		for _, tc := range testCases {
+			values, err := testInstallOptions()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v\n", err)
+			}
+			values.Identity.Issuer.KeyAlgorithm = tc.issuerKeyAlgorithm
+			values.Identity.CSRKeyAlgorithms = tc.csrKeyAlgorithms
+			values.Proxy.IdentityKeyAlgorithm = tc.proxyKeyAlgorithm
+
+			err = validateValues(context.Background(), nil, values)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error '%s', got nothing", tc.expectedError)
+				}
+				if err.Error() != tc.expectedError {
+					t.Fatalf("Expected error string\"%s\", got \"%s\"", tc.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error but got \"%s\"", err)
+			}
+		}
+	})
+
+	t.Run("Validates the short-lived issuer lifetime", func(t *testing.T) {
+		testCases := []struct {
+			lifetime      string
+			expectedError string
+		}{
+			{"", ""},
+			{"6h0m0s", ""},
+			{"1m0s", "--identity-short-lived-issuer-lifetime must be at least 10m0s"},
+			{"soon", "invalid --identity-short-lived-issuer-lifetime: time: invalid duration \"soon\""},
+		}
+
+		for _, tc := range testCases {
+			values, err := testInstallOptions()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v\n", err)
+			}
+			values.Identity.Issuer.ShortLivedIssuerLifetime = tc.lifetime
+
+			err = validateValues(context.Background(), nil, values)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error '%s', got nothing", tc.expectedError)
+				}
+				if err.Error() != tc.expectedError {
+					t.Fatalf("Expected error string\"%s\", got \"%s\"", tc.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error but got \"%s\"", err)
+			}
+		}
+	})
+
 	t.Run("Rejects identity cert files data when external issuer is set", func(t *testing.T) {
 
 		values, err := testInstallOptionsNoCerts(false)
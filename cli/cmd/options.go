@@ -8,6 +8,7 @@ import (
 
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	l5dcharts "github.com/linkerd/linkerd2/pkg/charts/linkerd2"
 	"github.com/linkerd/linkerd2/pkg/cmd"
 	flagspkg "github.com/linkerd/linkerd2/pkg/flags"
+	"github.com/linkerd/linkerd2/pkg/identity"
 	"github.com/linkerd/linkerd2/pkg/inject"
 	"github.com/linkerd/linkerd2/pkg/issuercerts"
 	"github.com/linkerd/linkerd2/pkg/k8s"
@@ -114,6 +116,37 @@ func makeInstallUpgradeFlags(defaults *l5dcharts.Values) ([]flag.Flag, *pflag.Fl
 				return nil
 			}),
 
+		flag.NewDurationFlag(installUpgradeFlags, "identity-short-lived-issuer-lifetime", 0,
+			"When set, the identity controller mints issuers valid for this amount of time, signed with the issuer certificate, and rotates them before they expire",
+			func(values *l5dcharts.Values, value time.Duration) error {
+				values.Identity.Issuer.ShortLivedIssuerLifetime = ""
+				if value != 0 {
+					values.Identity.Issuer.ShortLivedIssuerLifetime = value.String()
+				}
+				return nil
+			}),
+
+		flag.NewStringFlag(installUpgradeFlags, "identity-issuer-key-algorithm", defaults.Identity.Issuer.KeyAlgorithm,
+			"Algorithm of the Linkerd Identity issuer key, used when generating the issuer certificate and to validate a provided one. One of: ecdsa-p256, rsa-2048, rsa-4096, ed25519",
+			func(values *l5dcharts.Values, value string) error {
+				values.Identity.Issuer.KeyAlgorithm = value
+				return nil
+			}),
+
+		flag.NewStringSliceFlag(installUpgradeFlags, "identity-csr-key-algorithms", defaults.Identity.CSRKeyAlgorithms,
+			"Key algorithms the Linkerd Identity issuer accepts in the proxies' certificate signing requests",
+			func(values *l5dcharts.Values, value []string) error {
+				values.Identity.CSRKeyAlgorithms = value
+				return nil
+			}),
+
+		flag.NewStringFlag(installUpgradeFlags, "proxy-identity-key-algorithm", defaults.Proxy.IdentityKeyAlgorithm,
+			"Algorithm of the proxies' identity keys, which must be one of --identity-csr-key-algorithms (default ecdsa-p256)",
+			func(values *l5dcharts.Values, value string) error {
+				values.Proxy.IdentityKeyAlgorithm = value
+				return nil
+			}),
+
 		flag.NewStringFlag(installUpgradeFlags, "identity-issuer-certificate-file", "",
 			"A path to a PEM-encoded file containing the Linkerd Identity issuer certificate (generated by default)",
 			func(values *l5dcharts.Values, value string) error {
@@ -515,11 +548,27 @@ func validateValues(ctx context.Context, k *k8s.KubernetesAPI, values *l5dcharts
 		}
 	}
 
+	issuerKeyAlgorithms, err := validateKeyAlgorithms(values)
+	if err != nil {
+		return err
+	}
+
+	if lifetime := values.Identity.Issuer.ShortLivedIssuerLifetime; lifetime != "" {
+		d, err := time.ParseDuration(lifetime)
+		if err != nil {
+			return fmt.Errorf("invalid --identity-short-lived-issuer-lifetime: %w", err)
+		}
+		if d < identity.MinShortLivedIssuerLifetime {
+			return fmt.Errorf("--identity-short-lived-issuer-lifetime must be at least %s", identity.MinShortLivedIssuerLifetime)
+		}
+	}
+
 	if values.Identity.Issuer.Scheme == string(corev1.SecretTypeTLS) && k != nil {
 		externalIssuerData, err := issuercerts.FetchExternalIssuerData(ctx, k, controlPlaneNamespace)
 		if err != nil {
 			return err
 		}
+		externalIssuerData.KeyAlgorithms = issuerKeyAlgorithms
 		_, err = externalIssuerData.VerifyAndBuildCreds()
 		if err != nil {
 			return fmt.Errorf("failed to validate issuer credentials: %w", err)
@@ -528,9 +577,10 @@ func validateValues(ctx context.Context, k *k8s.KubernetesAPI, values *l5dcharts
 
 	if values.Identity.Issuer.Scheme == k8s.IdentityIssuerSchemeLinkerd {
 		issuerData := issuercerts.IssuerCertData{
-			IssuerCrt:    values.Identity.Issuer.TLS.CrtPEM,
-			IssuerKey:    values.Identity.Issuer.TLS.KeyPEM,
-			TrustAnchors: values.IdentityTrustAnchorsPEM,
+			IssuerCrt:     values.Identity.Issuer.TLS.CrtPEM,
+			IssuerKey:     values.Identity.Issuer.TLS.KeyPEM,
+			TrustAnchors:  values.IdentityTrustAnchorsPEM,
+			KeyAlgorithms: issuerKeyAlgorithms,
 		}
 		_, err := issuerData.VerifyAndBuildCreds()
 		if err != nil {
@@ -545,6 +595,30 @@ func validateValues(ctx context.Context, k *k8s.KubernetesAPI, values *l5dcharts
 	return nil
 }
 
+// validateKeyAlgorithms checks the configured identity key algorithms,
+// returning the ones allowed for the issuer key.
+func validateKeyAlgorithms(values *l5dcharts.Values) ([]tls.KeyAlgorithm, error) {
+	issuerKeyAlgorithms, err := tls.ParseKeyAlgorithms(values.Identity.Issuer.KeyAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --identity-issuer-key-algorithm: %w", err)
+	}
+
+	csrKeyAlgorithms, err := tls.ParseKeyAlgorithms(strings.Join(values.Identity.CSRKeyAlgorithms, ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --identity-csr-key-algorithms: %w", err)
+	}
+
+	proxyKeyAlgorithm, err := tls.ParseKeyAlgorithm(values.Proxy.IdentityKeyAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --proxy-identity-key-algorithm: %w", err)
+	}
+	if len(csrKeyAlgorithms) > 0 && !slices.Contains(csrKeyAlgorithms, proxyKeyAlgorithm) {
+		return nil, fmt.Errorf("the proxy identity key algorithm %s must be one of --identity-csr-key-algorithms", proxyKeyAlgorithm)
+	}
+
+	return issuerKeyAlgorithms, nil
+}
+
 func validateProxyValues(values *l5dcharts.Values) error {
 	networks := strings.Split(values.ClusterNetworks, ",")
 	for _, network := range networks {
@@ -659,7 +733,11 @@ func initializeIssuerCredentials(ctx context.Context, k *k8s.KubernetesAPI, valu
 		}
 	} else {
 		// No credentials have been supplied so we will generate them.
-		root, err := tls.GenerateRootCAWithDefaults(issuerName(values.IdentityTrustDomain))
+		alg, err := tls.ParseKeyAlgorithm(values.Identity.Issuer.KeyAlgorithm)
+		if err != nil {
+			return err
+		}
+		root, err := tls.GenerateRootCA(issuerName(values.IdentityTrustDomain), alg)
 		if err != nil {
 			return fmt.Errorf("failed to generate root certificate for identity: %w", err)
 		}
@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
 	"github.com/linkerd/linkerd2/pkg/inject"
+	"github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/spf13/cobra"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 type resourceTransformerUninject struct {
@@ -18,6 +25,17 @@ type resourceTransformerUninjectSilent struct {
 	values *linkerd2.Values
 }
 
+// resourceTransformerUninjectCheck uninjects resources like
+// resourceTransformerUninject, and warns about the Servers and authorizations
+// relying on the proxies of the uninjected workloads
+type resourceTransformerUninjectCheck struct {
+	resourceTransformerUninject
+	ctx       context.Context
+	k8sAPI    *k8s.KubernetesAPI
+	namespace string
+	warnings  *[]string
+}
+
 func runUninjectCmd(inputs []io.Reader, errWriter, outWriter io.Writer, values *linkerd2.Values, output string) int {
 	return transformInput(inputs, errWriter, outWriter, resourceTransformerUninject{values}, output)
 }
@@ -28,6 +46,7 @@ func runUninjectSilentCmd(inputs []io.Reader, errWriter, outWriter io.Writer, va
 
 func newCmdUninject() *cobra.Command {
 	var output string
+	var checkTraffic bool
 
 	cmd := &cobra.Command{
 		Use:   "uninject [flags] CONFIG-FILE",
@@ -35,7 +54,12 @@ func newCmdUninject() *cobra.Command {
 		Long: `Remove the Linkerd proxy from a Kubernetes config.
 
 You can uninject resources contained in a single file, inside a folder and its
-sub-folders, or coming from stdin.`,
+sub-folders, or coming from stdin.
+
+With --check-traffic, the workloads being uninjected are looked up in the
+cluster, and a warning is printed for each Server selecting their pods, whose
+policy won't be enforced anymore once the proxy is removed, and for each
+authorization requiring meshed clients to use mTLS to reach them.`,
 		Example: `  # Uninject all the deployments in the default namespace.
   kubectl get deploy -o yaml | linkerd uninject - | kubectl apply -f -
 
@@ -43,7 +67,10 @@ sub-folders, or coming from stdin.`,
   curl http://url.to/yml | linkerd uninject - | kubectl apply -f -
 
   # Uninject all the resources inside a folder and its sub-folders.
-  linkerd uninject <folder> | kubectl apply -f -`,
+  linkerd uninject <folder> | kubectl apply -f -
+
+  # Uninject the web deployment, warning about the policies relying on its proxy.
+  kubectl get deploy web -o yaml | linkerd uninject --check-traffic - | kubectl apply -f -`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 
 			if len(args) < 1 {
@@ -55,13 +82,29 @@ sub-folders, or coming from stdin.`,
 				return err
 			}
 
-			exitCode := runUninjectCmd(in, os.Stderr, os.Stdout, nil, output)
+			if !checkTraffic {
+				exitCode := runUninjectCmd(in, os.Stderr, os.Stdout, nil, output)
+				os.Exit(exitCode)
+			}
+
+			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+			transformer := resourceTransformerUninjectCheck{
+				ctx:       cmd.Context(),
+				k8sAPI:    k8sAPI,
+				namespace: pkgcmd.GetDefaultNamespace(kubeconfigPath, kubeContext),
+				warnings:  &[]string{},
+			}
+			exitCode := transformInput(in, os.Stderr, os.Stdout, transformer, output)
 			os.Exit(exitCode)
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "Output format, one of: json|yaml")
+	cmd.Flags().BoolVar(&checkTraffic, "check-traffic", false, "Warn about the Servers and authorizations relying on the proxies of the uninjected workloads")
 
 	return cmd
 }
@@ -112,3 +155,94 @@ func (resourceTransformerUninject) generateReport(reports []inject.Report, outpu
 
 func (resourceTransformerUninjectSilent) generateReport(reports []inject.Report, output io.Writer) {
 }
+
+func (rt resourceTransformerUninjectCheck) transform(bytes []byte) ([]byte, []inject.Report, error) {
+	output, reports, err := rt.resourceTransformerUninject.transform(bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var meta metav1.PartialObjectMetadata
+	if err := yaml.Unmarshal(bytes, &meta); err != nil {
+		return nil, nil, err
+	}
+	namespace := meta.GetNamespace()
+	if namespace == "" {
+		namespace = rt.namespace
+	}
+
+	for _, r := range reports {
+		if !r.Uninjected.Proxy {
+			continue
+		}
+		warnings, err := checkUninjectTraffic(rt.ctx, rt.k8sAPI, namespace, r.ResName())
+		if err != nil {
+			warnings = []string{fmt.Sprintf("couldn't check the traffic relying on its proxy: %s", err)}
+		}
+		for _, w := range warnings {
+			*rt.warnings = append(*rt.warnings, fmt.Sprintf("%s \"%s\": %s", r.Kind, r.Name, w))
+		}
+	}
+	return output, reports, nil
+}
+
+func (rt resourceTransformerUninjectCheck) generateReport(reports []inject.Report, output io.Writer) {
+	rt.resourceTransformerUninject.generateReport(reports, output)
+
+	if len(*rt.warnings) == 0 {
+		return
+	}
+	for _, w := range *rt.warnings {
+		output.Write([]byte(fmt.Sprintf("%s %s\n", warnStatus, w)))
+	}
+	output.Write([]byte("\n"))
+}
+
+// checkUninjectTraffic returns warnings about the Servers selecting the pods of
+// the given workload, whose policy won't be enforced once its proxy is removed,
+// and about the meshed clients their authorizations require to use mTLS. No
+// warnings are returned if the workload hasn't been deployed.
+func checkUninjectTraffic(ctx context.Context, k8sAPI *k8s.KubernetesAPI, namespace, resource string) ([]string, error) {
+	pods, err := k8s.GetPodsFor(ctx, k8sAPI, namespace, resource)
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, nil
+	}
+
+	warnings := []string{}
+	servers, err := k8s.ServersForResource(ctx, k8sAPI, namespace, resource, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		warnings = append(warnings, fmt.Sprintf("Server \"%s\" selects its pods, its policy won't be enforced once the proxy is removed", server))
+	}
+
+	authzs, err := k8s.AuthorizationsForResource(ctx, k8sAPI, namespace, resource)
+	if err != nil {
+		return nil, err
+	}
+	for _, authz := range authzs {
+		clients, err := k8s.MeshTLSClientsForAuthorization(ctx, k8sAPI, namespace, authz)
+		if err != nil {
+			return nil, err
+		}
+		if len(clients) == 0 {
+			continue
+		}
+		by := fmt.Sprintf("AuthorizationPolicy \"%s\"", authz.AuthorizationPolicy)
+		if authz.ServerAuthorization != "" {
+			by = fmt.Sprintf("ServerAuthorization \"%s\"", authz.ServerAuthorization)
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"%s requires the meshed clients %s to use mTLS to reach Server \"%s\", their connections won't be authenticated once the proxy is removed",
+			by, strings.Join(clients, ", "), authz.Server,
+		))
+	}
+	return warnings, nil
+}
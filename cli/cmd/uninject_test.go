@@ -3,12 +3,21 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"testing"
 
+	"github.com/go-test/deep"
+	policyv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/policy/v1alpha1"
+	serverv1beta3 "github.com/linkerd/linkerd2/controller/gen/apis/server/v1beta3"
+	l5dfake "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/fake"
 	charts "github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	gatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 // TestUninjectYAML does the reverse of TestInjectYAML.
@@ -128,3 +137,77 @@ func TestUninjectYAML(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckUninjectTraffic(t *testing.T) {
+	k8sAPI, err := k8s.NewFakeAPI(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: emojivoto
+  labels:
+    app: web-svc
+spec:
+  containers:
+  - name: web-svc
+    ports:
+    - containerPort: 8080
+      name: http`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	k8sAPI.L5dCrdClient = l5dfake.NewSimpleClientset(
+		&serverv1beta3.Server{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-http", Namespace: "emojivoto"},
+			Spec: serverv1beta3.ServerSpec{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web-svc"}},
+				Port:        intstr.FromString("http"),
+			},
+		},
+		&policyv1alpha1.AuthorizationPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-authz", Namespace: "emojivoto"},
+			Spec: policyv1alpha1.AuthorizationPolicySpec{
+				TargetRef: gatewayapiv1alpha2.PolicyTargetReference{
+					Group: k8s.PolicyAPIGroup,
+					Kind:  k8s.ServerKind,
+					Name:  "web-http",
+				},
+				RequiredAuthenticationRefs: []gatewayapiv1alpha2.PolicyTargetReference{{
+					Group: k8s.PolicyAPIGroup,
+					Kind:  k8s.MeshTLSAuthenticationKind,
+					Name:  "web-clients",
+				}},
+			},
+		},
+		&policyv1alpha1.MeshTLSAuthentication{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-clients", Namespace: "emojivoto"},
+			Spec: policyv1alpha1.MeshTLSAuthenticationSpec{
+				Identities: []string{"vote-bot.emojivoto.serviceaccount.identity.linkerd.cluster.local"},
+				IdentityRefs: []gatewayapiv1alpha2.PolicyTargetReference{{
+					Kind: k8s.ServiceAccountKind,
+					Name: "web",
+				}},
+			},
+		},
+	)
+
+	warnings, err := checkUninjectTraffic(context.Background(), k8sAPI, "emojivoto", "pod/web")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []string{
+		`Server "web-http" selects its pods, its policy won't be enforced once the proxy is removed`,
+		`AuthorizationPolicy "web-authz" requires the meshed clients vote-bot.emojivoto.serviceaccount.identity.linkerd.cluster.local, serviceaccount/emojivoto/web to use mTLS to reach Server "web-http", their connections won't be authenticated once the proxy is removed`,
+	}
+	if diff := deep.Equal(warnings, expected); diff != nil {
+		t.Errorf("%+v", diff)
+	}
+
+	warnings, err = checkUninjectTraffic(context.Background(), k8sAPI, "emojivoto", "pod/missing")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a workload that isn't deployed, got %v", warnings)
+	}
+}
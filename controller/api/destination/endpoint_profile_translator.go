@@ -163,6 +163,11 @@ func (ept *endpointProfileTranslator) createEndpoint(address watcher.Address, op
 	var err error
 	if address.ExternalWorkload != nil {
 		weightedAddr, err = createWeightedAddrForExternalWorkload(address, ept.forceOpaqueTransport, opaquePorts, ept.meshedHttp2ClientParams)
+	} else if address.Pod == nil {
+		// Addresses not backed by a pod may still carry metadata, e.g. remote
+		// endpoints of headless mirrors in direct mode, which are reached
+		// through the gateway.
+		weightedAddr, err = createWeightedAddrForRemote(address, ept.enableH2Upgrade, ept.meshedHttp2ClientParams)
 	} else {
 		weightedAddr, err = createWeightedAddr(address, opaquePorts,
 			ept.forceOpaqueTransport, ept.enableH2Upgrade, ept.identityTrustDomain, ept.controllerNS, ept.meshedHttp2ClientParams)
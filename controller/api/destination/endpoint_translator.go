@@ -10,6 +10,7 @@ import (
 	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
 	"github.com/linkerd/linkerd2/controller/k8s"
 	"github.com/linkerd/linkerd2/pkg/addr"
+	"github.com/linkerd/linkerd2/pkg/identity"
 	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -45,6 +46,17 @@ type (
 
 		meshedHTTP2ClientParams *pb.Http2ClientParams
 
+		denyList *identity.DenyList
+		// unsubscribeDenyList stops deny-list updates from being enqueued.
+		unsubscribeDenyList func()
+		// available holds every translated address of the current snapshot
+		// with its TLS identity, and advertised the subset which was sent to
+		// the client, i.e. excluding endpoints with a revoked identity. They
+		// are only accessed from the goroutine started by Start.
+		available  watcher.AddressSet
+		identities map[watcher.ID]string
+		advertised map[watcher.ID]struct{}
+
 		stream          pb.Destination_GetServer
 		endStream       chan struct{}
 		log             *logging.Entry
@@ -61,6 +73,8 @@ type (
 	removeUpdate struct {
 		set watcher.AddressSet
 	}
+
+	denyListUpdate struct{}
 )
 
 var updatesQueueOverflowCounter = promauto.NewCounterVec(
@@ -80,6 +94,7 @@ func newEndpointTranslator(
 	enableH2Upgrade,
 	extEndpointZoneWeights bool,
 	meshedHTTP2ClientParams *pb.Http2ClientParams,
+	denyList *identity.DenyList,
 	service string,
 	srcNodeName string,
 	defaultOpaquePorts map[uint32]struct{},
@@ -113,6 +128,11 @@ func newEndpointTranslator(
 		enableH2Upgrade,
 		extEndpointZoneWeights,
 		meshedHTTP2ClientParams,
+		denyList,
+		func() {},
+		watcher.AddressSet{Addresses: map[watcher.ID]watcher.Address{}},
+		map[watcher.ID]string{},
+		map[watcher.ID]struct{}{},
 
 		stream,
 		endStream,
@@ -161,6 +181,9 @@ func (et *endpointTranslator) enqueueUpdate(update interface{}) {
 // appropriate. The goroutine calls several non-thread-safe functions (including
 // Send) and therefore, Start must not be called more than once.
 func (et *endpointTranslator) Start() {
+	et.unsubscribeDenyList = et.denyList.Subscribe(func() {
+		et.enqueueUpdate(&denyListUpdate{})
+	})
 	go func() {
 		for {
 			select {
@@ -178,12 +201,14 @@ func (et *endpointTranslator) Start() {
 
 // Stop terminates the goroutine started by Start.
 func (et *endpointTranslator) Stop() {
+	et.unsubscribeDenyList()
 	close(et.stop)
 }
 
 // DrainAndStop closes the updates channel, causing the goroutine started by
 // Start to terminate after processing all remaining updates.
 func (et *endpointTranslator) DrainAndStop() {
+	et.unsubscribeDenyList()
 	close(et.updates)
 }
 
@@ -193,12 +218,51 @@ func (et *endpointTranslator) processUpdate(update interface{}) {
 		et.sendClientAdd(update.set)
 	case *removeUpdate:
 		et.sendClientRemove(update.set)
+	case *denyListUpdate:
+		et.reevaluateDenyList()
+	}
+}
+
+// reevaluateDenyList withdraws the advertised endpoints whose identity has
+// been revoked since they were sent, and advertises the ones which were
+// filtered out but whose identity is no longer revoked.
+func (et *endpointTranslator) reevaluateDenyList() {
+	revoked := watcher.AddressSet{Addresses: map[watcher.ID]watcher.Address{}}
+	restored := watcher.AddressSet{
+		Addresses: map[watcher.ID]watcher.Address{},
+		Labels:    et.available.Labels,
+	}
+	for id, address := range et.available.Addresses {
+		_, advertised := et.advertised[id]
+		denied := et.denyList.DeniesIdentity(et.identities[id])
+		switch {
+		case advertised && denied:
+			revoked.Addresses[id] = address
+		case !advertised && !denied:
+			restored.Addresses[id] = address
+		}
+	}
+
+	if len(revoked.Addresses) > 0 {
+		et.log.Warnf("Withdrawing %d endpoints with a revoked identity", len(revoked.Addresses))
+		// The withdrawn endpoints stay available so that they are advertised
+		// again if their identity is restored.
+		for id := range revoked.Addresses {
+			delete(et.advertised, id)
+		}
+		et.sendRemove(revoked)
+	}
+	if len(restored.Addresses) > 0 {
+		et.sendClientAdd(restored)
 	}
 }
 
 func (et *endpointTranslator) sendClientAdd(set watcher.AddressSet) {
+	et.available.Labels = set.Labels
 	addrs := []*pb.WeightedAddr{}
-	for _, address := range set.Addresses {
+	for id, address := range set.Addresses {
+		et.available.Addresses[id] = address
+
 		var (
 			wa          *pb.WeightedAddr
 			opaquePorts map[uint32]struct{}
@@ -220,46 +284,21 @@ func (et *endpointTranslator) sendClientAdd(set watcher.AddressSet) {
 				continue
 			}
 		} else {
-			// When there's no associated pod, we may still need to set metadata
-			// (especially for remote multi-cluster services).
-			var addr *net.TcpAddress
-			addr, err = toAddr(address)
+			wa, err = createWeightedAddrForRemote(address, et.enableH2Upgrade, et.meshedHTTP2ClientParams)
 			if err != nil {
 				et.log.Errorf("Failed to translate endpoints to weighted addr: %s", err)
 				continue
 			}
+		}
 
-			var authOverride *pb.AuthorityOverride
-			if address.AuthorityOverride != "" {
-				authOverride = &pb.AuthorityOverride{
-					AuthorityOverride: address.AuthorityOverride,
-				}
-			}
-			wa = &pb.WeightedAddr{
-				Addr:              addr,
-				Weight:            defaultWeight,
-				AuthorityOverride: authOverride,
-				MetricLabels:      map[string]string{},
-			}
-
-			if address.Identity != "" {
-				wa.TlsIdentity = &pb.TlsIdentity{
-					Strategy: &pb.TlsIdentity_DnsLikeIdentity_{
-						DnsLikeIdentity: &pb.TlsIdentity_DnsLikeIdentity{
-							Name: address.Identity,
-						},
-					},
-				}
-				if et.enableH2Upgrade {
-					wa.ProtocolHint = &pb.ProtocolHint{
-						Protocol: &pb.ProtocolHint_H2_{
-							H2: &pb.ProtocolHint_H2{},
-						},
-					}
-				}
-				wa.Http2 = et.meshedHTTP2ClientParams
-			}
+		tlsIdentity := wa.GetTlsIdentity().GetDnsLikeIdentity().GetName()
+		et.identities[id] = tlsIdentity
+		if et.denyList.DeniesIdentity(tlsIdentity) {
+			et.log.Warnf("Not advertising endpoint %s:%d with revoked identity %s", address.IP, address.Port, tlsIdentity)
+			delete(et.advertised, id)
+			continue
 		}
+		et.advertised[id] = struct{}{}
 
 		if et.nodeTopologyZone != "" && address.Zone != nil {
 			if *address.Zone == et.nodeTopologyZone {
@@ -294,6 +333,15 @@ func (et *endpointTranslator) sendClientAdd(set watcher.AddressSet) {
 }
 
 func (et *endpointTranslator) sendClientRemove(set watcher.AddressSet) {
+	for id := range set.Addresses {
+		delete(et.available.Addresses, id)
+		delete(et.identities, id)
+		delete(et.advertised, id)
+	}
+	et.sendRemove(set)
+}
+
+func (et *endpointTranslator) sendRemove(set watcher.AddressSet) {
 	addrs := []*net.TcpAddress{}
 	for _, address := range set.Addresses {
 		tcpAddr, err := toAddr(address)
@@ -327,6 +375,54 @@ func toAddr(address watcher.Address) (*net.TcpAddress, error) {
 	}, nil
 }
 
+// createWeightedAddrForRemote creates a WeightedAddr for an address that is
+// not backed by a pod or an externalworkload. We may still need to set
+// metadata, especially for remote multi-cluster services, whose addresses
+// point to the gateway.
+func createWeightedAddrForRemote(
+	address watcher.Address,
+	enableH2Upgrade bool,
+	meshedHttp2 *pb.Http2ClientParams,
+) (*pb.WeightedAddr, error) {
+	addr, err := toAddr(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var authOverride *pb.AuthorityOverride
+	if address.AuthorityOverride != "" {
+		authOverride = &pb.AuthorityOverride{
+			AuthorityOverride: address.AuthorityOverride,
+		}
+	}
+	weightedAddr := pb.WeightedAddr{
+		Addr:              addr,
+		Weight:            defaultWeight,
+		AuthorityOverride: authOverride,
+		MetricLabels:      map[string]string{},
+	}
+
+	if address.Identity != "" {
+		weightedAddr.TlsIdentity = &pb.TlsIdentity{
+			Strategy: &pb.TlsIdentity_DnsLikeIdentity_{
+				DnsLikeIdentity: &pb.TlsIdentity_DnsLikeIdentity{
+					Name: address.Identity,
+				},
+			},
+		}
+		if enableH2Upgrade {
+			weightedAddr.ProtocolHint = &pb.ProtocolHint{
+				Protocol: &pb.ProtocolHint_H2_{
+					H2: &pb.ProtocolHint_H2{},
+				},
+			}
+		}
+		weightedAddr.Http2 = meshedHttp2
+	}
+
+	return &weightedAddr, nil
+}
+
 func createWeightedAddrForExternalWorkload(
 	address watcher.Address,
 	forceOpaqueTransport bool,
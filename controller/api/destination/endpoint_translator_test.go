@@ -14,6 +14,7 @@ import (
 	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
 	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
 	"github.com/linkerd/linkerd2/pkg/addr"
+	"github.com/linkerd/linkerd2/pkg/identity"
 	"github.com/linkerd/linkerd2/pkg/k8s"
 	"google.golang.org/protobuf/proto"
 	corev1 "k8s.io/api/core/v1"
@@ -974,6 +975,77 @@ func TestEndpointTranslatorExternalWorkloads(t *testing.T) {
 	})
 }
 
+func TestEndpointTranslatorDenyList(t *testing.T) {
+	pod1Identity := "serviceaccount-name.ns.serviceaccount.identity.linkerd.trust.domain"
+	denyPod1 := &corev1.ConfigMap{Data: map[string]string{identity.DenyListIdentitiesKey: pod1Identity}}
+
+	t.Run("Does not advertise endpoints with a revoked identity", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslatorWithDenyList(t, identity.DenyListFromConfigMap(denyPod1))
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForPods(t, pod1, pod2))
+
+		addressesAdded := (<-mockGetServer.updatesReceived).GetAdd().Addrs
+		if len(addressesAdded) != 1 {
+			t.Fatalf("Expecting [1] address to be added, got [%d]: %v", len(addressesAdded), addressesAdded)
+		}
+		checkAddressAndWeight(t, addressesAdded[0], pod2, defaultWeight)
+	})
+
+	t.Run("Withdraws advertised endpoints when their identity is revoked", func(t *testing.T) {
+		denyList := identity.NewDenyList()
+		mockGetServer, translator := makeEndpointTranslatorWithDenyList(t, denyList)
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForPods(t, pod1, pod2))
+		addressesAdded := (<-mockGetServer.updatesReceived).GetAdd().Addrs
+		if len(addressesAdded) != 2 {
+			t.Fatalf("Expecting [2] addresses to be added, got [%d]: %v", len(addressesAdded), addressesAdded)
+		}
+
+		denyList.Update(denyPod1)
+		addressesRemoved := (<-mockGetServer.updatesReceived).GetRemove().Addrs
+		if len(addressesRemoved) != 1 {
+			t.Fatalf("Expecting [1] address to be removed, got [%d]: %v", len(addressesRemoved), addressesRemoved)
+		}
+		checkAddress(t, addressesRemoved[0], pod1)
+
+		// Updating the deny-list without changes for this stream sends nothing.
+		denyList.Update(denyPod1)
+
+		denyList.Update(nil)
+		addressesAdded = (<-mockGetServer.updatesReceived).GetAdd().Addrs
+		if len(addressesAdded) != 1 {
+			t.Fatalf("Expecting [1] address to be added, got [%d]: %v", len(addressesAdded), addressesAdded)
+		}
+		checkAddressAndWeight(t, addressesAdded[0], pod1, defaultWeight)
+
+		if len(mockGetServer.updatesReceived) != 0 {
+			t.Fatalf("Expecting no further updates, got [%d]", len(mockGetServer.updatesReceived))
+		}
+	})
+
+	t.Run("Does not re-advertise endpoints removed while revoked", func(t *testing.T) {
+		denyList := identity.DenyListFromConfigMap(denyPod1)
+		mockGetServer, translator := makeEndpointTranslatorWithDenyList(t, denyList)
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForPods(t, pod1, pod2))
+		<-mockGetServer.updatesReceived // Add
+		translator.Remove(mkAddressSetForPods(t, pod1))
+		<-mockGetServer.updatesReceived // Remove
+
+		denyList.Update(nil)
+		translator.Remove(mkAddressSetForPods(t, pod2))
+		if update := <-mockGetServer.updatesReceived; update.GetRemove() == nil {
+			t.Fatalf("Expecting a remove update, got %v", update)
+		}
+	})
+}
+
 func TestEndpointTranslatorExperimentalZoneWeights(t *testing.T) {
 	zoneA := "west-1a"
 	zoneB := "west-1b"
@@ -44,6 +44,7 @@ type federatedService struct {
 
 	localDiscovery  string
 	remoteDiscovery []remoteDiscoveryID
+	policy          federatedPolicy
 	subscribers     []federatedServiceSubscriber
 
 	metadataAPI    *k8s.MetadataAPI
@@ -66,6 +67,7 @@ type federatedServiceSubscriber struct {
 	remoteTranslators map[remoteDiscoveryID]*endpointTranslator
 
 	stream    *synchronizedGetStream
+	federated *federatedStream
 	endStream chan struct{}
 }
 
@@ -212,6 +214,7 @@ func (fsw *federatedServiceWatcher) newFederatedService(service *corev1.Service)
 
 		localDiscovery:  service.Annotations[labels.LocalDiscoveryAnnotation],
 		remoteDiscovery: remoteDiscoveryIDs(service, fsw.log),
+		policy:          federatedPolicyFromService(service, fsw.log),
 		subscribers:     []federatedServiceSubscriber{},
 
 		metadataAPI:    fsw.metadataAPI,
@@ -226,6 +229,11 @@ func (fs *federatedService) update(service *corev1.Service) {
 	fs.Lock()
 	defer fs.Unlock()
 
+	fs.policy = federatedPolicyFromService(service, fs.log)
+	for _, subscriber := range fs.subscribers {
+		subscriber.federated.setPolicy(fs.policy)
+	}
+
 	newRemoteDiscovery := remoteDiscoveryIDs(service, fs.log)
 	for _, id := range newRemoteDiscovery {
 		if !slices.Contains(fs.remoteDiscovery, id) {
@@ -272,12 +280,7 @@ func (fs *federatedService) delete() {
 			EnableEndpointFiltering: false,
 		}
 		for id, translator := range subscriber.remoteTranslators {
-			remoteWatcher, _, found := fs.clusterStore.Get(id.cluster)
-			if !found {
-				fs.log.Errorf("Failed to get remote cluster %s", id.cluster)
-				continue
-			}
-			remoteWatcher.Unsubscribe(id.service, subscriber.port, remoteFilterKey, translator, false)
+			fs.clusterStore.Unsubscribe(id.cluster, id.service, subscriber.port, remoteFilterKey, translator, false)
 			translator.Stop()
 		}
 		localFilterKey := watcher.FilterKey{
@@ -308,6 +311,7 @@ func (fs *federatedService) subscribe(
 
 	subscriber := federatedServiceSubscriber{
 		stream:            syncStream,
+		federated:         newFederatedStream(syncStream, fs.policy, fs.log),
 		endStream:         endStream,
 		remoteTranslators: make(map[remoteDiscoveryID]*endpointTranslator, 0),
 		localTranslators:  make(map[string]*endpointTranslator, 0),
@@ -352,7 +356,7 @@ func (fs *federatedService) remoteDiscoverySubscribe(
 	subscriber *federatedServiceSubscriber,
 	id remoteDiscoveryID,
 ) {
-	remoteWatcher, remoteConfig, found := fs.clusterStore.Get(id.cluster)
+	_, remoteConfig, found := fs.clusterStore.Get(id.cluster)
 	if !found {
 		fs.log.Errorf("Failed to get remote cluster %s", id.cluster)
 		return
@@ -365,11 +369,12 @@ func (fs *federatedService) remoteDiscoverySubscribe(
 		fs.config.EnableH2Upgrade,
 		fs.config.ExtEndpointZoneWeights,
 		fs.config.MeshedHttp2ClientParams,
+		fs.config.IdentityDenyList,
 		fmt.Sprintf("%s.%s.svc.%s:%d", id.service, fs.namespace, remoteConfig.ClusterDomain, subscriber.port),
 		subscriber.nodeName,
 		fs.config.DefaultOpaquePorts,
 		fs.metadataAPI,
-		subscriber.stream,
+		subscriber.federated.member(id.cluster, id.service.Name),
 		subscriber.endStream,
 		fs.log,
 		fs.config.StreamQueueCapacity,
@@ -388,7 +393,7 @@ func (fs *federatedService) remoteDiscoverySubscribe(
 		NodeName:                subscriber.nodeName,
 		EnableEndpointFiltering: false, // Endpoint filtering is disabled for remote discovery.
 	}
-	err = remoteWatcher.Subscribe(watcher.ServiceID{Namespace: id.service.Namespace, Name: id.service.Name}, subscriber.port, filterKey, translator)
+	err = fs.clusterStore.Subscribe(id.cluster, watcher.ServiceID{Namespace: id.service.Namespace, Name: id.service.Name}, subscriber.port, filterKey, translator)
 	if err != nil {
 		fs.log.Errorf("Failed to subscribe to remote discovery service %q in cluster %s: %s", id.service.Name, id.cluster, err)
 	}
@@ -398,12 +403,6 @@ func (fs *federatedService) remoteDiscoveryUnsubscribe(
 	subscriber *federatedServiceSubscriber,
 	id remoteDiscoveryID,
 ) {
-	remoteWatcher, _, found := fs.clusterStore.Get(id.cluster)
-	if !found {
-		fs.log.Errorf("Failed to get remote cluster %s", id.cluster)
-		return
-	}
-
 	translator := subscriber.remoteTranslators[id]
 	fs.log.Debugf("Unsubscribing from remote discovery service %s in cluster %s", id.service, id.cluster)
 	filterKey := watcher.FilterKey{
@@ -411,7 +410,7 @@ func (fs *federatedService) remoteDiscoveryUnsubscribe(
 		NodeName:                subscriber.nodeName,
 		EnableEndpointFiltering: false, // Endpoint filtering is disabled for remote discovery.
 	}
-	remoteWatcher.Unsubscribe(id.service, subscriber.port, filterKey, translator, true)
+	fs.clusterStore.Unsubscribe(id.cluster, id.service, subscriber.port, filterKey, translator, true)
 	translator.DrainAndStop()
 	delete(subscriber.remoteTranslators, id)
 }
@@ -427,11 +426,12 @@ func (fs *federatedService) localDiscoverySubscribe(
 		fs.config.EnableH2Upgrade,
 		fs.config.ExtEndpointZoneWeights,
 		fs.config.MeshedHttp2ClientParams,
+		fs.config.IdentityDenyList,
 		localDiscovery,
 		subscriber.nodeName,
 		fs.config.DefaultOpaquePorts,
 		fs.metadataAPI,
-		subscriber.stream,
+		subscriber.federated.member(localCluster, localDiscovery),
 		subscriber.endStream,
 		fs.log,
 		fs.config.StreamQueueCapacity,
@@ -0,0 +1,306 @@
+package destination
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2-proxy-api/go/net"
+	"github.com/linkerd/linkerd2/pkg/addr"
+	labels "github.com/linkerd/linkerd2/pkg/k8s"
+	logging "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// localCluster is the name of the local cluster in the federated
+	// preference and weights annotations.
+	localCluster = "local"
+
+	maxFederatedWeight = 100
+)
+
+type (
+	// federatedPolicy holds the cluster preferences and weights of a
+	// federated service.
+	federatedPolicy struct {
+		preference []string
+		weights    map[string]uint32
+	}
+
+	// federatedStream sits between the endpoint translators of a federated
+	// service subscriber and its stream. It scales the weight of each address
+	// by the weight of its cluster and only forwards the addresses of the most
+	// preferred clusters which have ready endpoints.
+	federatedStream struct {
+		stream     pb.Destination_GetServer
+		policy     federatedPolicy
+		members    map[string]*federatedMemberStream
+		activeTier int
+		log        *logging.Entry
+
+		sync.Mutex
+	}
+
+	// federatedMemberStream is the stream handed to the endpoint translator
+	// of a single member of a federated service. It keeps track of the
+	// member's current addresses so that they can be sent or withdrawn when
+	// the preferred clusters change.
+	federatedMemberStream struct {
+		// Only Send is supported; the other methods of the embedded stream
+		// are never called by endpoint translators.
+		pb.Destination_GetServer
+
+		parent  *federatedStream
+		cluster string
+		labels  map[string]string
+		addrs   map[string]*pb.WeightedAddr
+	}
+)
+
+func newFederatedStream(stream pb.Destination_GetServer, policy federatedPolicy, log *logging.Entry) *federatedStream {
+	fs := &federatedStream{
+		stream:  stream,
+		policy:  policy,
+		members: make(map[string]*federatedMemberStream),
+		log:     log,
+	}
+	fs.activeTier = fs.bestTier()
+	return fs
+}
+
+// member returns the stream for the given member of the federated service,
+// creating it if necessary.
+func (fs *federatedStream) member(cluster, service string) *federatedMemberStream {
+	fs.Lock()
+	defer fs.Unlock()
+
+	key := service + "@" + cluster
+	if m, ok := fs.members[key]; ok {
+		return m
+	}
+	m := &federatedMemberStream{
+		Destination_GetServer: fs.stream,
+		parent:                fs,
+		cluster:               cluster,
+		addrs:                 make(map[string]*pb.WeightedAddr),
+	}
+	fs.members[key] = m
+	return m
+}
+
+func (m *federatedMemberStream) Send(update *pb.Update) error {
+	return m.parent.send(m, update)
+}
+
+// setPolicy applies a new policy, withdrawing the addresses of the clusters
+// which are no longer preferred and re-sending the addresses of the others
+// with their new weights.
+func (fs *federatedStream) setPolicy(policy federatedPolicy) {
+	fs.Lock()
+	defer fs.Unlock()
+
+	if policy.equal(fs.policy) {
+		return
+	}
+
+	oldPolicy, oldTier := fs.policy, fs.activeTier
+	fs.policy = policy
+	fs.activeTier = fs.bestTier()
+	for _, m := range fs.members {
+		wasActive := oldPolicy.tier(m.cluster) == oldTier
+		isActive := fs.policy.tier(m.cluster) == fs.activeTier
+		var err error
+		if isActive {
+			err = fs.sendAdd(m, m.addrs)
+		} else if wasActive {
+			err = fs.sendRemove(m.addrs)
+		}
+		if err != nil {
+			fs.log.Debugf("Failed to send address update: %s", err)
+		}
+	}
+}
+
+func (fs *federatedStream) send(m *federatedMemberStream, update *pb.Update) error {
+	fs.Lock()
+	defer fs.Unlock()
+
+	prevTier := fs.activeTier
+	active := fs.policy.tier(m.cluster) == prevTier
+
+	switch u := update.GetUpdate().(type) {
+	case *pb.Update_Add:
+		m.labels = u.Add.GetMetricLabels()
+		added := make(map[string]*pb.WeightedAddr, len(u.Add.GetAddrs()))
+		for _, wa := range u.Add.GetAddrs() {
+			key := addr.ProxyAddressToString(wa.GetAddr())
+			m.addrs[key] = wa
+			added[key] = wa
+		}
+		if active {
+			if err := fs.sendAdd(m, added); err != nil {
+				return err
+			}
+		}
+	case *pb.Update_Remove:
+		removed := make(map[string]*pb.WeightedAddr, len(u.Remove.GetAddrs()))
+		for _, a := range u.Remove.GetAddrs() {
+			key := addr.ProxyAddressToString(a)
+			if wa, ok := m.addrs[key]; ok {
+				removed[key] = wa
+				delete(m.addrs, key)
+			}
+		}
+		if active {
+			if err := fs.sendRemove(removed); err != nil {
+				return err
+			}
+		}
+	default:
+		return fs.stream.Send(update)
+	}
+
+	fs.activeTier = fs.bestTier()
+	if fs.activeTier == prevTier {
+		return nil
+	}
+	fs.log.Debugf("Preferred tier of federated service changed from %d to %d", prevTier, fs.activeTier)
+	for _, other := range fs.members {
+		switch fs.policy.tier(other.cluster) {
+		case prevTier:
+			if err := fs.sendRemove(other.addrs); err != nil {
+				return err
+			}
+		case fs.activeTier:
+			if err := fs.sendAdd(other, other.addrs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bestTier returns the most preferred tier which has addresses. When no
+// member has addresses, the least preferred tier is returned so that the
+// first addresses of any member become active.
+func (fs *federatedStream) bestTier() int {
+	best := len(fs.policy.preference)
+	for _, m := range fs.members {
+		if len(m.addrs) == 0 {
+			continue
+		}
+		if tier := fs.policy.tier(m.cluster); tier < best {
+			best = tier
+		}
+	}
+	return best
+}
+
+func (fs *federatedStream) sendAdd(m *federatedMemberStream, addrs map[string]*pb.WeightedAddr) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+	weight := fs.policy.weight(m.cluster)
+	weighted := make([]*pb.WeightedAddr, 0, len(addrs))
+	for _, wa := range addrs {
+		wa = proto.Clone(wa).(*pb.WeightedAddr)
+		wa.Weight *= weight
+		if wa.MetricLabels == nil {
+			wa.MetricLabels = map[string]string{}
+		}
+		wa.MetricLabels[labels.FederatedClusterLabel] = m.cluster
+		weighted = append(weighted, wa)
+	}
+	slices.SortFunc(weighted, func(a, b *pb.WeightedAddr) int {
+		return strings.Compare(addr.ProxyAddressToString(a.GetAddr()), addr.ProxyAddressToString(b.GetAddr()))
+	})
+	return fs.stream.Send(&pb.Update{Update: &pb.Update_Add{
+		Add: &pb.WeightedAddrSet{
+			Addrs:        weighted,
+			MetricLabels: m.labels,
+		},
+	}})
+}
+
+func (fs *federatedStream) sendRemove(addrs map[string]*pb.WeightedAddr) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+	removed := make([]*net.TcpAddress, 0, len(addrs))
+	for _, wa := range addrs {
+		removed = append(removed, wa.GetAddr())
+	}
+	slices.SortFunc(removed, func(a, b *net.TcpAddress) int {
+		return strings.Compare(addr.ProxyAddressToString(a), addr.ProxyAddressToString(b))
+	})
+	return fs.stream.Send(&pb.Update{Update: &pb.Update_Remove{
+		Remove: &pb.AddrSet{Addrs: removed},
+	}})
+}
+
+// tier returns the preference tier of a cluster; lower is more preferred.
+// Clusters which are not listed share the least preferred tier.
+func (p federatedPolicy) tier(cluster string) int {
+	if i := slices.Index(p.preference, cluster); i >= 0 {
+		return i
+	}
+	return len(p.preference)
+}
+
+func (p federatedPolicy) weight(cluster string) uint32 {
+	if w, ok := p.weights[cluster]; ok {
+		return w
+	}
+	return 1
+}
+
+func (p federatedPolicy) equal(other federatedPolicy) bool {
+	if !slices.Equal(p.preference, other.preference) || len(p.weights) != len(other.weights) {
+		return false
+	}
+	for cluster, w := range p.weights {
+		if ow, ok := other.weights[cluster]; !ok || ow != w {
+			return false
+		}
+	}
+	return true
+}
+
+func federatedPolicyFromService(service *corev1.Service, log *logging.Entry) federatedPolicy {
+	policy := federatedPolicy{
+		preference: []string{},
+		weights:    map[string]uint32{},
+	}
+
+	for _, cluster := range strings.Split(service.Annotations[labels.FederatedPreferenceAnnotation], ",") {
+		cluster = strings.TrimSpace(cluster)
+		if cluster == "" || slices.Contains(policy.preference, cluster) {
+			continue
+		}
+		policy.preference = append(policy.preference, cluster)
+	}
+
+	for _, pair := range strings.Split(service.Annotations[labels.FederatedWeightsAnnotation], ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.Split(pair, "=")
+		if len(parts) != 2 {
+			log.Errorf("Invalid federated weight '%s'", pair)
+			continue
+		}
+		weight, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+		if err != nil || weight == 0 || weight > maxFederatedWeight {
+			log.Errorf("Invalid federated weight '%s': weight must be between 1 and %d", pair, maxFederatedWeight)
+			continue
+		}
+		policy.weights[strings.TrimSpace(parts[0])] = uint32(weight)
+	}
+
+	return policy
+}
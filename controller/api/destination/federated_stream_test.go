@@ -0,0 +1,190 @@
+package destination
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2-proxy-api/go/net"
+	"github.com/linkerd/linkerd2/pkg/addr"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	logging "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFederatedStream(t *testing.T) {
+	local := weightedAddr(t, "172.17.0.1:8080")
+	east := weightedAddr(t, "172.17.1.1:8080")
+	north := weightedAddr(t, "172.17.2.1:8080")
+
+	t.Run("forwards all clusters without preferences", func(t *testing.T) {
+		server := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+		fs := newFederatedStream(server, federatedPolicy{}, logging.WithField("test", t.Name()))
+
+		sendAdd(t, fs.member(localCluster, "bb"), local)
+		sendAdd(t, fs.member("east", "bb"), east)
+
+		assertAdd(t, <-server.updatesReceived, "172.17.0.1:8080", defaultWeight, localCluster)
+		assertAdd(t, <-server.updatesReceived, "172.17.1.1:8080", defaultWeight, "east")
+	})
+
+	t.Run("spills over to remote clusters", func(t *testing.T) {
+		server := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+		fs := newFederatedStream(server, federatedPolicy{preference: []string{localCluster}}, logging.WithField("test", t.Name()))
+
+		// Only the remote clusters have endpoints, so they are used.
+		sendAdd(t, fs.member("east", "bb"), east)
+		assertAdd(t, <-server.updatesReceived, "172.17.1.1:8080", defaultWeight, "east")
+
+		// The local cluster becomes ready and the remote endpoints are
+		// withdrawn.
+		sendAdd(t, fs.member(localCluster, "bb"), local)
+		assertRemove(t, <-server.updatesReceived, "172.17.1.1:8080")
+		assertAdd(t, <-server.updatesReceived, "172.17.0.1:8080", defaultWeight, localCluster)
+
+		// Changes to remote clusters are not forwarded while the local
+		// cluster has endpoints.
+		sendAdd(t, fs.member("north", "bb"), north)
+		assertNoUpdates(t, server)
+
+		// The local cluster loses its endpoints and the remote clusters are
+		// used again.
+		sendRemove(t, fs.member(localCluster, "bb"), local)
+		assertRemove(t, <-server.updatesReceived, "172.17.0.1:8080")
+		update := <-server.updatesReceived
+		if len(update.GetAdd().GetAddrs()) != 1 {
+			t.Fatalf("Expected an add of a single address, got %v", update)
+		}
+		update2 := <-server.updatesReceived
+		if len(update2.GetAdd().GetAddrs()) != 1 {
+			t.Fatalf("Expected an add of a single address, got %v", update2)
+		}
+	})
+
+	t.Run("applies cluster weights", func(t *testing.T) {
+		server := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+		fs := newFederatedStream(server, federatedPolicy{weights: map[string]uint32{"east": 3}}, logging.WithField("test", t.Name()))
+
+		sendAdd(t, fs.member(localCluster, "bb"), local)
+		sendAdd(t, fs.member("east", "bb"), east)
+		assertAdd(t, <-server.updatesReceived, "172.17.0.1:8080", defaultWeight, localCluster)
+		assertAdd(t, <-server.updatesReceived, "172.17.1.1:8080", 3*defaultWeight, "east")
+
+		// Changing the weights re-sends the addresses with their new weights.
+		fs.setPolicy(federatedPolicy{weights: map[string]uint32{"east": 2}})
+		for range 2 {
+			update := <-server.updatesReceived
+			if addr.ProxyAddressToString(update.GetAdd().GetAddrs()[0].GetAddr()) == "172.17.1.1:8080" {
+				assertAdd(t, update, "172.17.1.1:8080", 2*defaultWeight, "east")
+			} else {
+				assertAdd(t, update, "172.17.0.1:8080", defaultWeight, localCluster)
+			}
+		}
+	})
+
+	t.Run("withdraws addresses when preferences change", func(t *testing.T) {
+		server := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+		fs := newFederatedStream(server, federatedPolicy{}, logging.WithField("test", t.Name()))
+
+		sendAdd(t, fs.member(localCluster, "bb"), local)
+		sendAdd(t, fs.member("east", "bb"), east)
+		<-server.updatesReceived
+		<-server.updatesReceived
+
+		fs.setPolicy(federatedPolicy{preference: []string{"east"}})
+		for range 2 {
+			update := <-server.updatesReceived
+			if update.GetRemove() != nil {
+				assertRemove(t, update, "172.17.0.1:8080")
+			} else {
+				assertAdd(t, update, "172.17.1.1:8080", defaultWeight, "east")
+			}
+		}
+	})
+}
+
+func TestFederatedPolicyFromService(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				"multicluster.linkerd.io/federated-preference": "local, east,,local",
+				"multicluster.linkerd.io/federated-weights":    "local=4,east=0,north=101,west,south=2",
+			},
+		},
+	}
+	expected := federatedPolicy{
+		preference: []string{localCluster, "east"},
+		weights:    map[string]uint32{localCluster: 4, "south": 2},
+	}
+	policy := federatedPolicyFromService(service, logging.WithField("test", t.Name()))
+	if !policy.equal(expected) {
+		t.Fatalf("Expected policy %+v, got %+v", expected, policy)
+	}
+	if policy.tier("north") != 2 {
+		t.Fatalf("Expected unlisted cluster to have tier 2, got %d", policy.tier("north"))
+	}
+}
+
+func weightedAddr(t *testing.T, address string) *pb.WeightedAddr {
+	t.Helper()
+	ip, err := addr.ParseProxyIP(strings.TrimSuffix(address, ":8080"))
+	if err != nil {
+		t.Fatalf("Invalid address %s: %s", address, err)
+	}
+	return &pb.WeightedAddr{
+		Addr:         &net.TcpAddress{Ip: ip, Port: 8080},
+		Weight:       defaultWeight,
+		MetricLabels: map[string]string{},
+	}
+}
+
+func sendAdd(t *testing.T, m *federatedMemberStream, wa *pb.WeightedAddr) {
+	t.Helper()
+	err := m.Send(&pb.Update{Update: &pb.Update_Add{Add: &pb.WeightedAddrSet{Addrs: []*pb.WeightedAddr{wa}}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func sendRemove(t *testing.T, m *federatedMemberStream, wa *pb.WeightedAddr) {
+	t.Helper()
+	err := m.Send(&pb.Update{Update: &pb.Update_Remove{Remove: &pb.AddrSet{Addrs: []*net.TcpAddress{wa.GetAddr()}}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func assertAdd(t *testing.T, update *pb.Update, address string, weight uint32, cluster string) {
+	t.Helper()
+	addrs := update.GetAdd().GetAddrs()
+	if len(addrs) != 1 {
+		t.Fatalf("Expected an add of a single address, got %v", update)
+	}
+	if got := addr.ProxyAddressToString(addrs[0].GetAddr()); got != address {
+		t.Fatalf("Expected add of %s, got %s", address, got)
+	}
+	if addrs[0].GetWeight() != weight {
+		t.Fatalf("Expected weight %d for %s, got %d", weight, address, addrs[0].GetWeight())
+	}
+	if got := addrs[0].GetMetricLabels()[k8s.FederatedClusterLabel]; got != cluster {
+		t.Fatalf("Expected cluster %s for %s, got %s", cluster, address, got)
+	}
+}
+
+func assertRemove(t *testing.T, update *pb.Update, address string) {
+	t.Helper()
+	addrs := update.GetRemove().GetAddrs()
+	if len(addrs) != 1 || addr.ProxyAddressToString(addrs[0]) != address {
+		t.Fatalf("Expected remove of %s, got %v", address, update)
+	}
+}
+
+func assertNoUpdates(t *testing.T, server *mockDestinationGetServer) {
+	t.Helper()
+	select {
+	case update := <-server.updatesReceived:
+		t.Fatalf("Unexpected update: %v", update)
+	default:
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
 	sp "github.com/linkerd/linkerd2/controller/gen/apis/serviceprofile/v1alpha2"
 	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/pkg/identity"
 	labels "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/linkerd/linkerd2/pkg/prometheus"
 	"github.com/linkerd/linkerd2/pkg/util"
@@ -42,6 +43,10 @@ type (
 
 		MeshedHttp2ClientParams *pb.Http2ClientParams
 
+		// IdentityDenyList holds identities whose endpoints must not be
+		// advertised.
+		IdentityDenyList *identity.DenyList
+
 		DefaultOpaquePorts map[uint32]struct{}
 
 		StreamQueueCapacity int
@@ -204,7 +209,7 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 			log.Debugf("Remote discovery service missing remote service name %s", service)
 			return status.Errorf(codes.FailedPrecondition, "Remote discovery service missing remote service name %s", dest.GetPath())
 		}
-		remoteWatcher, remoteConfig, found := s.clusterStore.Get(cluster)
+		_, remoteConfig, found := s.clusterStore.Get(cluster)
 		if !found {
 			log.Errorf("Failed to get remote cluster %s", cluster)
 			return status.Errorf(codes.NotFound, "Remote cluster not found: %s", cluster)
@@ -216,6 +221,7 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 			s.config.EnableH2Upgrade,
 			s.config.ExtEndpointZoneWeights,
 			s.config.MeshedHttp2ClientParams,
+			s.config.IdentityDenyList,
 			fmt.Sprintf("%s.%s.svc.%s:%d", remoteSvc, service.Namespace, remoteConfig.ClusterDomain, port),
 			token.NodeName,
 			s.config.DefaultOpaquePorts,
@@ -237,7 +243,7 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 			EnableEndpointFiltering: false, // Disable endpoint filtering for remote discovery.
 		}
 
-		err = remoteWatcher.Subscribe(watcher.ServiceID{Namespace: service.Namespace, Name: remoteSvc}, port, filterKey, translator)
+		err = s.clusterStore.Subscribe(cluster, watcher.ServiceID{Namespace: service.Namespace, Name: remoteSvc}, port, filterKey, translator)
 		if err != nil {
 			var ise watcher.InvalidService
 			if errors.As(err, &ise) {
@@ -247,7 +253,7 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 			log.Errorf("Failed to subscribe to remote discovery service %q in cluster %s: %s", dest.GetPath(), cluster, err)
 			return err
 		}
-		defer remoteWatcher.Unsubscribe(watcher.ServiceID{Namespace: service.Namespace, Name: remoteSvc}, port, filterKey, translator, false)
+		defer s.clusterStore.Unsubscribe(cluster, watcher.ServiceID{Namespace: service.Namespace, Name: remoteSvc}, port, filterKey, translator, false)
 
 	} else {
 		log.Debug("Local discovery service detected")
@@ -259,6 +265,7 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 			s.config.EnableH2Upgrade,
 			s.config.ExtEndpointZoneWeights,
 			s.config.MeshedHttp2ClientParams,
+			s.config.IdentityDenyList,
 			dest.GetPath(),
 			token.NodeName,
 			s.config.DefaultOpaquePorts,
@@ -34,6 +34,7 @@ const fullyQualifiedNameOpaque = "name3.ns.svc.mycluster.local"
 const fullyQualifiedNameOpaqueService = "name4.ns.svc.mycluster.local"
 const fullyQualifiedNameSkipped = "name5.ns.svc.mycluster.local"
 const fullyQualifiedPodDNS = "pod-0.statefulset-svc.ns.svc.mycluster.local"
+const fullyQualifiedRemotePodDNS = "bar-0.bar-target.ns.svc.mycluster.local"
 const clusterIP = "172.17.12.0"
 const clusterIPv6 = "2001:db8::88"
 const clusterIPOpaque = "172.17.12.1"
@@ -51,6 +52,8 @@ const externalIP = "192.168.1.20"
 const externalIPv6 = "2001:db8::78"
 const externalWorkloadIP = "200.1.1.1"
 const externalWorkloadIPPolicy = "200.1.1.2"
+const remotePodIP = "10.42.0.5"
+const remoteGatewayIP = "192.168.1.30"
 const port uint32 = 8989
 const linkerdAdminPort uint32 = 4191
 const opaquePort uint32 = 4242
@@ -747,6 +750,24 @@ func TestGetProfiles(t *testing.T) {
 		}
 	})
 
+	t.Run("Return profile with gateway endpoint when using remote pod IP of headless mirror in direct mode", func(t *testing.T) {
+		server := makeServer(t)
+
+		stream := profileStream(t, server, remotePodIP, port, "ns:ns")
+		defer stream.Cancel()
+
+		assertRemoteEndpointProfile(t, stream.Updates())
+	})
+
+	t.Run("Return profile with gateway endpoint when using remote pod DNS of headless mirror in direct mode", func(t *testing.T) {
+		server := makeServer(t)
+
+		stream := profileStream(t, server, fullyQualifiedRemotePodDNS, port, "ns:ns")
+		defer stream.Cancel()
+
+		assertRemoteEndpointProfile(t, stream.Updates())
+	})
+
 	t.Run("Return default profile when IP does not map to service or pod", func(t *testing.T) {
 		server := makeServer(t)
 
@@ -1329,6 +1350,32 @@ func testReturnEndpointsForServer(t *testing.T, server *server, stream *bufferin
 	}
 }
 
+// assertRemoteEndpointProfile checks that the profile for the remote endpoint
+// of the bar-target headless mirror in direct mode points to the gateway.
+func assertRemoteEndpointProfile(t *testing.T, updates []*pb.DestinationProfile) {
+	t.Helper()
+
+	epAddr, err := toAddress(remoteGatewayIP, 4143)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+
+	profile := assertSingleProfile(t, updates)
+	if profile.Endpoint == nil {
+		t.Fatalf("Expected response to have endpoint field")
+	}
+	if profile.Endpoint.Addr.String() != epAddr.String() {
+		t.Fatalf("Expected endpoint address to be %s, but it was %s", epAddr, profile.Endpoint.Addr)
+	}
+	if identity := profile.Endpoint.GetTlsIdentity().GetDnsLikeIdentity().GetName(); identity != "gateway-identity" {
+		t.Fatalf("Expected TLS identity to be gateway-identity, but it was %q", identity)
+	}
+	expectedAuthority := fmt.Sprintf("bar-0.bar.ns.svc.cluster.local:%d", port)
+	if authority := profile.Endpoint.GetAuthorityOverride().GetAuthorityOverride(); authority != expectedAuthority {
+		t.Fatalf("Expected authority override to be %s, but it was %q", expectedAuthority, authority)
+	}
+}
+
 func assertSingleProfile(t *testing.T, updates []*pb.DestinationProfile) *pb.DestinationProfile {
 	t.Helper()
 	// Under normal conditions the creation of resources by the fake API will
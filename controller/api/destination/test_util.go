@@ -9,6 +9,7 @@ import (
 	"github.com/linkerd/linkerd2/controller/api/util"
 	l5dcrdclient "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned"
 	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/pkg/identity"
 	"github.com/prometheus/client_golang/prometheus"
 	logging "github.com/sirupsen/logrus"
 )
@@ -754,6 +755,58 @@ spec:
   type: LoadBalancer
   ports:
   - port: 80`,
+		`
+apiVersion: v1
+kind: Service
+metadata:
+  name: bar-target
+  namespace: ns
+  labels:
+    mirror.linkerd.io/mirrored-service: "true"
+spec:
+  clusterIP: None
+  ports:
+  - port: 8989`,
+		`
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: bar-target
+  namespace: ns
+  annotations:
+    mirror.linkerd.io/headless-direct-mirror: "true"
+    mirror.linkerd.io/remote-gateway-addresses: "192.168.1.30:4143"
+    mirror.linkerd.io/remote-gateway-identity: gateway-identity
+    mirror.linkerd.io/remote-svc-fq-name: bar.ns.svc.cluster.local
+  labels:
+    mirror.linkerd.io/mirrored-service: "true"
+subsets:
+- addresses:
+  - ip: 10.42.0.5
+    hostname: bar-0
+  ports:
+  - port: 8989`,
+		`
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: bar-target-xxxx
+  namespace: ns
+  annotations:
+    mirror.linkerd.io/headless-direct-mirror: "true"
+    mirror.linkerd.io/remote-gateway-addresses: "192.168.1.30:4143"
+    mirror.linkerd.io/remote-gateway-identity: gateway-identity
+    mirror.linkerd.io/remote-svc-fq-name: bar.ns.svc.cluster.local
+  labels:
+    mirror.linkerd.io/mirrored-service: "true"
+    kubernetes.io/service-name: bar-target
+addressType: IPv4
+endpoints:
+- addresses:
+  - 10.42.0.5
+  hostname: bar-0
+ports:
+- port: 8989`,
 	}
 
 	externalWorkloads := []string{`
@@ -1155,6 +1208,16 @@ func makeEndpointTranslator(t *testing.T) (*mockDestinationGetServer, *endpointT
 }
 
 func makeEndpointTranslatorWithOpaqueTransport(t *testing.T, forceOpaqueTransport bool) (*mockDestinationGetServer, *endpointTranslator) {
+	t.Helper()
+	return makeEndpointTranslatorWithOptions(t, forceOpaqueTransport, nil)
+}
+
+func makeEndpointTranslatorWithDenyList(t *testing.T, denyList *identity.DenyList) (*mockDestinationGetServer, *endpointTranslator) {
+	t.Helper()
+	return makeEndpointTranslatorWithOptions(t, false, denyList)
+}
+
+func makeEndpointTranslatorWithOptions(t *testing.T, forceOpaqueTransport bool, denyList *identity.DenyList) (*mockDestinationGetServer, *endpointTranslator) {
 	t.Helper()
 	node := `apiVersion: v1
 kind: Node
@@ -1186,6 +1249,7 @@ metadata:
 		true,  // enableH2Upgrade
 		false, // extEndpointZoneWeights
 		nil,   // meshedHttp2ClientParams
+		denyList,
 		"service-name.service-ns",
 		"test-123",
 		map[uint32]struct{}{},
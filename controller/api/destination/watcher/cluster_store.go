@@ -1,9 +1,11 @@
 package watcher
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"sync"
 
 	"github.com/linkerd/linkerd2/controller/k8s"
@@ -133,6 +135,41 @@ func NewClusterStoreWithDecoder(
 				cs.log.Errorf("Error adding cluster %s to store: %v", clusterName, err)
 			}
 		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSecret, ok := oldObj.(*v1.Secret)
+			if !ok {
+				cs.log.Errorf("Error processing 'Secret' object: got %#v, expected *corev1.Secret", oldObj)
+				return
+			}
+			secret, ok := newObj.(*v1.Secret)
+			if !ok {
+				cs.log.Errorf("Error processing 'Secret' object: got %#v, expected *corev1.Secret", newObj)
+				return
+			}
+
+			if secret.Type != pkgK8s.MirrorSecretType {
+				cs.log.Tracef("Skipping Update event for 'Secret' object %s/%s: invalid type %s", secret.Namespace, secret.Name, secret.Type)
+				return
+			}
+
+			clusterName, found := secret.GetLabels()[clusterNameLabel]
+			if !found {
+				cs.log.Tracef("Skipping Update event for 'Secret' object %s/%s: missing \"%s\" label", secret.Namespace, secret.Name, clusterNameLabel)
+				return
+			}
+
+			// Credentials are decoded only once when a cluster is added, so a
+			// rotated kubeconfig requires the cluster's watcher to be replaced.
+			if bytes.Equal(oldSecret.Data[pkgK8s.ConfigKeyName], secret.Data[pkgK8s.ConfigKeyName]) &&
+				reflect.DeepEqual(oldSecret.GetAnnotations(), secret.GetAnnotations()) {
+				return
+			}
+
+			cs.log.Infof("Credentials for cluster %s changed; replacing its watcher", clusterName)
+			if err := cs.updateCluster(clusterName, secret); err != nil {
+				cs.log.Errorf("Error updating cluster %s in store: %v", clusterName, err)
+			}
+		},
 		DeleteFunc: func(obj interface{}) {
 			secret, ok := obj.(*v1.Secret)
 			if !ok {
@@ -179,6 +216,33 @@ func (cs *ClusterStore) Get(clusterName string) (*EndpointsWatcher, ClusterConfi
 	return cw.watcher, cw.config, found
 }
 
+// Subscribe subscribes the listener to a service in the given cluster.
+// Subscribing through the store rather than through the watcher returned by
+// Get ensures that the subscription is carried over to the new watcher when
+// the cluster's credentials are rotated.
+func (cs *ClusterStore) Subscribe(clusterName string, id ServiceID, port Port, filterKey FilterKey, listener EndpointUpdateListener) error {
+	cs.RLock()
+	defer cs.RUnlock()
+	cw, found := cs.store[clusterName]
+	if !found {
+		return fmt.Errorf("remote cluster %s not found", clusterName)
+	}
+	return cw.watcher.Subscribe(id, port, filterKey, listener)
+}
+
+// Unsubscribe removes a listener subscribed through Subscribe from the
+// cluster's current watcher. Nothing is done if the cluster has been removed,
+// as its watcher is stopped.
+func (cs *ClusterStore) Unsubscribe(clusterName string, id ServiceID, port Port, filterKey FilterKey, listener EndpointUpdateListener, withRemove bool) {
+	cs.RLock()
+	defer cs.RUnlock()
+	cw, found := cs.store[clusterName]
+	if !found {
+		return
+	}
+	cw.watcher.Unsubscribe(id, port, filterKey, listener, withRemove)
+}
+
 // removeCluster is triggered by the cache's Secret informer when a secret is
 // removed. Given a cluster name, it removes the entry from the cache after
 // stopping the associated watcher.
@@ -189,10 +253,9 @@ func (cs *ClusterStore) removeCluster(clusterName string) {
 	if !found {
 		return
 	}
-	r.watcher.removeHandlers()
+	r.stop()
 	r.watcher.k8sAPI.UnregisterGauges()
 	r.watcher.metadataAPI.UnregisterGauges()
-	close(r.stopCh)
 	delete(cs.store, clusterName)
 	cs.log.Infof("Removed cluster %s from ClusterStore", clusterName)
 }
@@ -202,27 +265,99 @@ func (cs *ClusterStore) removeCluster(clusterName string) {
 // object, it creates an EndpointsWatcher for a remote cluster and syncs its
 // informers before returning.
 func (cs *ClusterStore) addCluster(clusterName string, secret *v1.Secret) error {
+	watcher, config, err := cs.newRemoteWatcher(clusterName, secret)
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{}, 1)
+
+	cs.Lock()
+	defer cs.Unlock()
+	cs.store[clusterName] = remoteCluster{
+		watcher,
+		config,
+		stopCh,
+	}
+
+	go watcher.k8sAPI.Sync(stopCh)
+	go watcher.metadataAPI.Sync(stopCh)
+
+	cs.log.Infof("Added cluster %s to ClusterStore", clusterName)
+
+	return nil
+}
+
+// updateCluster is triggered by the cache's Secret informer when the
+// credentials or configuration of a known cluster change. It creates a new
+// EndpointsWatcher from the updated Secret and, once its informers have
+// synced, swaps it for the current one. The subscriptions of the current
+// watcher are moved to the new one, so that the streams they belong to keep
+// receiving updates.
+func (cs *ClusterStore) updateCluster(clusterName string, secret *v1.Secret) error {
+	// The informer size gauges of both watchers share the same labels, so
+	// the current ones must be unregistered for the new ones to be
+	// registered, and can't be unregistered once they are
+	cs.RLock()
+	if current, found := cs.store[clusterName]; found {
+		current.watcher.k8sAPI.UnregisterGauges()
+		current.watcher.metadataAPI.UnregisterGauges()
+	}
+	cs.RUnlock()
+
+	watcher, config, err := cs.newRemoteWatcher(clusterName, secret)
+	if err != nil {
+		return err
+	}
+
+	// Sync before swapping so that the subscriptions moved over are sent the
+	// cluster's current state rather than an empty one
+	stopCh := make(chan struct{}, 1)
+	watcher.k8sAPI.Sync(stopCh)
+	watcher.metadataAPI.Sync(stopCh)
+
+	cs.Lock()
+	defer cs.Unlock()
+	current, found := cs.store[clusterName]
+	cs.store[clusterName] = remoteCluster{
+		watcher,
+		config,
+		stopCh,
+	}
+	if found {
+		current.watcher.moveSubscriptions(watcher)
+		current.stop()
+	}
+
+	cs.log.Infof("Updated cluster %s in ClusterStore", clusterName)
+
+	return nil
+}
+
+// newRemoteWatcher creates an EndpointsWatcher for the remote cluster
+// described by the given Secret, along with the cluster's configuration. The
+// watcher's informers are not started.
+func (cs *ClusterStore) newRemoteWatcher(clusterName string, secret *v1.Secret) (*EndpointsWatcher, ClusterConfig, error) {
 	data, found := secret.Data[pkgK8s.ConfigKeyName]
 	if !found {
-		return errors.New("missing kubeconfig file")
+		return nil, ClusterConfig{}, errors.New("missing kubeconfig file")
 	}
 
 	clusterDomain, found := secret.GetAnnotations()[clusterDomainAnnotation]
 	if !found {
-		return fmt.Errorf("missing \"%s\" annotation", clusterDomainAnnotation)
+		return nil, ClusterConfig{}, fmt.Errorf("missing \"%s\" annotation", clusterDomainAnnotation)
 	}
 
 	trustDomain, found := secret.GetAnnotations()[trustDomainAnnotation]
 	if !found {
-		return fmt.Errorf("missing \"%s\" annotation", trustDomainAnnotation)
+		return nil, ClusterConfig{}, fmt.Errorf("missing \"%s\" annotation", trustDomainAnnotation)
 	}
 
 	remoteAPI, metadataAPI, err := cs.decodeFn(data, clusterName, cs.enableEndpointSlices)
 	if err != nil {
-		return err
+		return nil, ClusterConfig{}, err
 	}
 
-	stopCh := make(chan struct{}, 1)
 	watcher, err := NewEndpointsWatcher(
 		remoteAPI,
 		metadataAPI,
@@ -234,26 +369,16 @@ func (cs *ClusterStore) addCluster(clusterName string, secret *v1.Secret) error
 		clusterName,
 	)
 	if err != nil {
-		return err
+		return nil, ClusterConfig{}, err
 	}
 
-	cs.Lock()
-	defer cs.Unlock()
-	cs.store[clusterName] = remoteCluster{
-		watcher,
-		ClusterConfig{
-			trustDomain,
-			clusterDomain,
-		},
-		stopCh,
-	}
-
-	go remoteAPI.Sync(stopCh)
-	go metadataAPI.Sync(stopCh)
-
-	cs.log.Infof("Added cluster %s to ClusterStore", clusterName)
+	return watcher, ClusterConfig{trustDomain, clusterDomain}, nil
+}
 
-	return nil
+// stop removes the watcher's informer handlers and stops its informers
+func (r remoteCluster) stop() {
+	r.watcher.removeHandlers()
+	close(r.stopCh)
 }
 
 // decodeK8sConfigFromSecret implements the decoder function type. Given a byte
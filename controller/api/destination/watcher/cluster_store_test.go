@@ -1,13 +1,17 @@
 package watcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/linkerd/linkerd2/controller/k8s"
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/linkerd/linkerd2/testutil"
 	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestClusterStoreHandlers(t *testing.T) {
@@ -157,6 +161,202 @@ func TestClusterStoreHandlers(t *testing.T) {
 	}
 }
 
+func TestClusterStoreSecretRotation(t *testing.T) {
+	k8sAPI, err := k8s.NewFakeAPI(validRemoteSecret)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	cs, err := NewClusterStoreWithDecoder(k8sAPI.Client, "linkerd", true, false, CreateMockDecoder(), prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Unexpected error when starting watcher cache: %s", err)
+	}
+
+	cs.Sync(nil)
+
+	var original *EndpointsWatcher
+	err = testutil.RetryFor(time.Second*30, func() error {
+		watcher, _, found := cs.Get("remote")
+		if !found {
+			return errors.New("cluster remote is missing from the cache")
+		}
+		original = watcher
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	secret, err := k8sAPI.Client.CoreV1().Secrets("linkerd").Get(context.Background(), "remote-cluster-credentials", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	secret.Data[pkgK8s.ConfigKeyName] = []byte("rotated credentials")
+	if _, err := k8sAPI.Client.CoreV1().Secrets("linkerd").Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	err = testutil.RetryFor(time.Second*30, func() error {
+		watcher, _, found := cs.Get("remote")
+		if !found {
+			return errors.New("cluster remote is missing from the cache")
+		}
+		if watcher == original {
+			return errors.New("watcher for cluster remote has not been replaced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	err = testutil.RetryFor(time.Second*30, func() error {
+		if !original.k8sAPI.ES().Informer().IsStopped() {
+			return errors.New("informers for the replaced watcher should be stopped")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestClusterStoreSecretRotationWithSubscription(t *testing.T) {
+	k8sAPI, err := k8s.NewFakeAPI(validRemoteSecret)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	// Each decoding of the credentials yields a cluster in which the service
+	// is backed by a different endpoint
+	decoded := 0
+	decoders := []configDecoder{
+		CreateMockDecoder(rotationService, rotationEndpointSlice("172.17.0.12")),
+		CreateMockDecoder(rotationService, rotationEndpointSlice("172.17.0.19")),
+	}
+	decoder := func(data []byte, cluster string, enableEndpointSlices bool) (*k8s.API, *k8s.MetadataAPI, error) {
+		decode := decoders[decoded]
+		decoded++
+		return decode(data, cluster, enableEndpointSlices)
+	}
+
+	cs, err := NewClusterStoreWithDecoder(k8sAPI.Client, "linkerd", true, false, decoder, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Unexpected error when starting watcher cache: %s", err)
+	}
+
+	cs.Sync(nil)
+
+	id := ServiceID{Namespace: "ns", Name: "name-1"}
+	listener := newBufferingEndpointListener()
+	err = testutil.RetryFor(time.Second*30, func() error {
+		if _, _, found := cs.Get("remote"); !found {
+			return errors.New("cluster remote is missing from the cache")
+		}
+		return cs.Subscribe("remote", id, 8989, FilterKey{}, listener)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err = testutil.RetryFor(time.Second*30, func() error {
+		listener.Lock()
+		defer listener.Unlock()
+		if len(listener.added) == 0 {
+			return errors.New("no address added before the rotation")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	listener.ExpectAdded([]string{"172.17.0.12:8989"}, t)
+
+	secret, err := k8sAPI.Client.CoreV1().Secrets("linkerd").Get(context.Background(), "remote-cluster-credentials", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	secret.Data[pkgK8s.ConfigKeyName] = []byte("rotated credentials")
+	if _, err := k8sAPI.Client.CoreV1().Secrets("linkerd").Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The subscription is moved to the new watcher, which sends the new
+	// endpoint and withdraws the one the listener got from the previous one
+	err = testutil.RetryFor(time.Second*30, func() error {
+		listener.Lock()
+		defer listener.Unlock()
+		if len(listener.removed) == 0 {
+			return errors.New("stale address not removed after the rotation")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	listener.ExpectAdded([]string{"172.17.0.12:8989", "172.17.0.19:8989"}, t)
+	listener.ExpectRemoved([]string{"172.17.0.12:8989"}, t)
+
+	// Updates from the new watcher keep reaching the listener, and
+	// unsubscribing through the store removes it from the new watcher
+	watcher, _, _ := cs.Get("remote")
+	slice, err := watcher.k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Get(context.Background(), "name-1-f5fad", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	slice.Endpoints[0].Addresses = []string{"172.17.0.20"}
+	if _, err := watcher.k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Update(context.Background(), slice, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err = testutil.RetryFor(time.Second*30, func() error {
+		listener.Lock()
+		defer listener.Unlock()
+		if len(listener.added) < 3 {
+			return errors.New("update from the new watcher not received")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	listener.ExpectAdded([]string{"172.17.0.12:8989", "172.17.0.19:8989", "172.17.0.20:8989"}, t)
+
+	cs.Unsubscribe("remote", id, 8989, FilterKey{}, listener, false)
+	if watcher.snapshot(id, 8989, FilterKey{}).Addresses != nil {
+		t.Fatal("Expected the listener to be unsubscribed from the new watcher")
+	}
+}
+
+var rotationService = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: name-1
+  namespace: ns
+spec:
+  type: ClusterIP
+  ports:
+  - port: 8989`
+
+func rotationEndpointSlice(ip string) string {
+	return fmt.Sprintf(`
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: name-1
+  name: name-1-f5fad
+  namespace: ns
+addressType: IPv4
+endpoints:
+- addresses:
+  - %s
+  conditions:
+    ready: true
+ports:
+- name: ""
+  port: 8989`, ip)
+}
+
 var validRemoteSecret = `
 apiVersion: v1
 kind: Secret
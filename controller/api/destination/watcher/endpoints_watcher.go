@@ -177,6 +177,52 @@ func (ew *EndpointsWatcher) Unsubscribe(id ServiceID, port Port, filterKey Filte
 	sp.unsubscribe(port, listener, filterKey, withRemove)
 }
 
+// moveSubscriptions subscribes every listener of ew to the same service, port
+// and filter in dst, which replaces ew e.g. after the cluster's credentials
+// are rotated. dst is expected to have synced: each listener is sent dst's
+// addresses, and then the removal of those it got from ew that dst doesn't
+// have.
+func (ew *EndpointsWatcher) moveSubscriptions(dst *EndpointsWatcher) {
+	ew.RLock()
+	defer ew.RUnlock()
+	for id, sp := range ew.publishers {
+		sp.Lock()
+		for srcPort, pp := range sp.ports {
+			for filterKey, group := range pp.filteredListeners {
+				for _, listener := range group.listeners {
+					stale := group.snapshot
+					if err := dst.Subscribe(id, srcPort, filterKey, listener); err != nil {
+						ew.log.Errorf("Failed to move subscription to [%s:%d]: %s", id, srcPort, err)
+					} else {
+						_, stale = diffAddresses(group.snapshot, dst.snapshot(id, srcPort, filterKey))
+					}
+					if len(stale.Addresses) > 0 {
+						listener.Remove(stale)
+					}
+				}
+			}
+		}
+		sp.Unlock()
+	}
+}
+
+// snapshot returns the addresses last published to the listeners subscribed
+// to the given service and port with the given filter
+func (ew *EndpointsWatcher) snapshot(id ServiceID, port Port, filterKey FilterKey) AddressSet {
+	sp, ok := ew.getServicePublisher(id)
+	if !ok {
+		return AddressSet{}
+	}
+	sp.Lock()
+	defer sp.Unlock()
+	if pp, ok := sp.ports[port]; ok {
+		if group, ok := pp.filteredListeners[filterKey]; ok {
+			return group.snapshot
+		}
+	}
+	return AddressSet{}
+}
+
 // removeHandlers will de-register any event handlers used by the
 // EndpointsWatcher's informers.
 func (ew *EndpointsWatcher) removeHandlers() {
@@ -2364,6 +2364,91 @@ subsets:
 				"172.17.0.12:9999/name1-remote-fq:8989",
 			},
 		},
+		{
+			k8sConfigs: []string{`
+apiVersion: v1
+kind: Service
+metadata:
+  name: name1-remote
+  namespace: ns
+spec:
+  clusterIP: None
+  ports:
+  - port: 8989`,
+				`
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: name1-remote
+  namespace: ns
+  annotations:
+    mirror.linkerd.io/headless-direct-mirror: "true"
+    mirror.linkerd.io/remote-gateway-addresses: "172.17.0.12:4143"
+    mirror.linkerd.io/remote-gateway-identity: "gateway-identity-1"
+    mirror.linkerd.io/remote-svc-fq-name: "name1.ns.svc.cluster.local"
+  labels:
+    mirror.linkerd.io/mirrored-service: "true"
+subsets:
+- addresses:
+  - ip: 10.42.0.5
+    hostname: name1-0
+  - ip: 10.42.0.6
+    hostname: name1-1
+  ports:
+  - port: 8989`,
+			},
+			serviceType: "headless mirrored service in direct mode",
+			id:          ServiceID{Name: "name1-remote", Namespace: "ns"},
+			hostname:    "name1-1",
+			port:        8989,
+			expectedAddresses: []string{
+				"172.17.0.12:4143/gateway-identity-1/name1-1.name1.ns.svc.cluster.local:8989",
+			},
+		},
+		{
+			k8sConfigs: []string{`
+apiVersion: v1
+kind: Service
+metadata:
+  name: name1-remote
+  namespace: ns
+spec:
+  clusterIP: None
+  ports:
+  - port: 8989`,
+				`
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: name1-remote-xxxx
+  namespace: ns
+  annotations:
+    mirror.linkerd.io/headless-direct-mirror: "true"
+    mirror.linkerd.io/remote-gateway-addresses: "172.17.0.12:4143"
+    mirror.linkerd.io/remote-gateway-identity: "gateway-identity-1"
+    mirror.linkerd.io/remote-svc-fq-name: "name1.ns.svc.cluster.local"
+  labels:
+    mirror.linkerd.io/mirrored-service: "true"
+    kubernetes.io/service-name: name1-remote
+endpoints:
+- addresses:
+  - 10.42.0.5
+  hostname: name1-0
+- addresses:
+  - 10.42.0.6
+  hostname: name1-1
+ports:
+- port: 8989`,
+			},
+			serviceType: "headless mirrored service in direct mode with endpoint slices",
+			id:          ServiceID{Name: "name1-remote", Namespace: "ns"},
+			hostname:    "name1-0",
+			port:        8989,
+			expectedAddresses: []string{
+				"172.17.0.12:4143/gateway-identity-1/name1-0.name1.ns.svc.cluster.local:8989",
+			},
+			enableEndpointSlices: true,
+		},
 	} {
 		tt := tt // pin
 		t.Run("subscribes listener to "+tt.serviceType, func(t *testing.T) {
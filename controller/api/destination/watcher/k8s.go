@@ -7,6 +7,7 @@ import (
 
 	ext "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
 	"github.com/linkerd/linkerd2/controller/k8s"
+	consts "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -20,6 +21,8 @@ const (
 	HostIPIndex = "hostIP"
 	// ExternalWorkloadIPIndex is the key for the index based on IP of externalworkloads
 	ExternalWorkloadIPIndex = "externalWorkloadIP"
+	// HeadlessDirectMirrorIPIndex is the key for the index based on IP of remote endpoints of headless mirrors in direct mode
+	HeadlessDirectMirrorIPIndex = "headlessDirectMirrorIP"
 )
 
 type (
@@ -181,6 +184,30 @@ func InitializeIndexers(k8sAPI *k8s.API) error {
 		return fmt.Errorf("could not create an indexer for externalworkloads: %w", err)
 	}
 
+	err = k8sAPI.Endpoint().Informer().AddIndexers(cache.Indexers{HeadlessDirectMirrorIPIndex: func(obj interface{}) ([]string, error) {
+		endpoints, ok := obj.(*corev1.Endpoints)
+		if !ok {
+			return nil, errors.New("object is not an endpoints")
+		}
+		if _, direct := endpoints.Annotations[consts.HeadlessDirectMirrorAnnotation]; !direct {
+			return nil, nil
+		}
+
+		addrs := []string{}
+		for _, subset := range endpoints.Subsets {
+			for _, address := range subset.Addresses {
+				for _, port := range subset.Ports {
+					addrs = append(addrs, net.JoinHostPort(address.IP, fmt.Sprintf("%d", port.Port)))
+				}
+			}
+		}
+		return addrs, nil
+	}})
+
+	if err != nil {
+		return fmt.Errorf("could not create an indexer for endpoints: %w", err)
+	}
+
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"maps"
 	"net"
+	"net/netip"
 	"strings"
 
 	"github.com/linkerd/linkerd2/controller/gen/apis/server/v1beta3"
@@ -152,6 +153,13 @@ func (pp *portPublisher) endpointSliceToAddresses(es *discovery.EndpointSlice) A
 		}
 
 		if endpoint.TargetRef == nil {
+			if _, direct := es.Annotations[consts.HeadlessDirectMirrorAnnotation]; direct {
+				if endpoint.Hostname != nil && *endpoint.Hostname != "" {
+					pp.addHeadlessDirectMirrorAddresses(addresses, es.Annotations, *endpoint.Hostname, resolvedPort, serviceID.Name, es.Namespace)
+				}
+				continue
+			}
+
 			for _, IPAddr := range endpoint.Addresses {
 				var authorityOverride string
 				if fqName, ok := es.Annotations[consts.RemoteServiceFqName]; ok {
@@ -298,6 +306,13 @@ func (pp *portPublisher) endpointsToAddresses(endpoints *corev1.Endpoints) Addre
 		for _, endpoint := range subset.Addresses {
 			hostname := endpoint.Hostname
 			if endpoint.TargetRef == nil {
+				if _, direct := endpoints.Annotations[consts.HeadlessDirectMirrorAnnotation]; direct {
+					if hostname != "" {
+						pp.addHeadlessDirectMirrorAddresses(addresses, endpoints.Annotations, hostname, resolvedPort, endpoints.Name, endpoints.Namespace)
+					}
+					continue
+				}
+
 				var authorityOverride string
 				if fqName, ok := endpoints.Annotations[consts.RemoteServiceFqName]; ok {
 					authorityOverride = fmt.Sprintf("%s:%d", fqName, pp.srcPort)
@@ -338,6 +353,50 @@ func (pp *portPublisher) endpointsToAddresses(endpoints *corev1.Endpoints) Addre
 	}
 }
 
+// addHeadlessDirectMirrorAddresses adds to addresses the gateway addresses
+// through which the remote endpoint with the given hostname, of a headless
+// mirror in direct mode, is reached.
+func (pp *portPublisher) addHeadlessDirectMirrorAddresses(addresses map[ID]Address, annotations map[string]string, hostname string, port Port, serviceName, namespace string) {
+	gateways, err := headlessDirectMirrorAddresses(annotations, hostname, port)
+	if err != nil {
+		pp.log.Errorf("Unable to create gateway addresses for %s.%s.%s: %s", hostname, serviceName, namespace, err)
+		return
+	}
+	for _, gateway := range gateways {
+		address, id := pp.newServiceRefAddress(gateway.Port, gateway.IP, &hostname, serviceName, namespace)
+		address.Identity, address.AuthorityOverride = gateway.Identity, gateway.AuthorityOverride
+		addresses[id] = address
+	}
+}
+
+// headlessDirectMirrorAddresses returns the addresses of the gateway through
+// which the remote endpoint with the given hostname and port, of a headless
+// mirror in direct mode, is reached. They carry the gateway identity and use
+// the endpoint's fully qualified name in the target cluster as the authority.
+func headlessDirectMirrorAddresses(annotations map[string]string, hostname string, port Port) ([]Address, error) {
+	authority := net.JoinHostPort(
+		fmt.Sprintf("%s.%s", hostname, annotations[consts.RemoteServiceFqName]),
+		fmt.Sprintf("%d", port),
+	)
+	var addresses []Address
+	for _, gateway := range strings.Split(annotations[consts.RemoteGatewayAddresses], ",") {
+		if gateway == "" {
+			continue
+		}
+		addrPort, err := netip.ParseAddrPort(gateway)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gateway address %q: %w", gateway, err)
+		}
+		addresses = append(addresses, Address{
+			IP:                addrPort.Addr().String(),
+			Port:              Port(addrPort.Port()),
+			Identity:          annotations[consts.RemoteGatewayIdentity],
+			AuthorityOverride: authority,
+		})
+	}
+	return addresses, nil
+}
+
 func (pp *portPublisher) newServiceRefAddress(endpointPort Port, endpointIP string, hostname *string, serviceName, serviceNamespace string) (Address, ServiceID) {
 	nameParts := []string{
 		serviceName,
@@ -44,12 +44,15 @@ type (
 	// workloadPublisher represents an address including ip:port, the backing
 	// pod or externalworkload (if any), and if the protocol is opaque. It keeps
 	// a list of listeners to be notified whenever the workload or the
-	// associated opaque protocol config changes.
+	// associated opaque protocol config changes. When ip:port is not backed by
+	// a local workload but is a remote endpoint of a headless mirror in direct
+	// mode, remote holds the gateway address through which it's reached.
 	workloadPublisher struct {
 		defaultOpaquePorts map[uint32]struct{}
 		k8sAPI             *k8s.API
 		metadataAPI        *k8s.MetadataAPI
 		addr               Address
+		remote             *Address
 		listeners          []WorkloadUpdateListener
 		metrics            metrics
 		subscriberCount    *atomic.Int32
@@ -113,6 +116,15 @@ func NewWorkloadWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *logg
 		return nil, err
 	}
 
+	_, err = k8sAPI.Endpoint().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ww.addOrDeleteEndpoints,
+		DeleteFunc: ww.addOrDeleteEndpoints,
+		UpdateFunc: ww.updateEndpoints,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return ww, nil
 }
 
@@ -317,6 +329,52 @@ func (ww *WorkloadWatcher) submitExternalWorkloadUpdate(externalWorkload *ext.Ex
 	}
 }
 
+// addOrDeleteEndpoints is an event handler so it cannot block
+func (ww *WorkloadWatcher) addOrDeleteEndpoints(obj any) {
+	endpoints, ok := obj.(*corev1.Endpoints)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			ww.log.Errorf("Couldn't get object from DeletedFinalStateUnknown %#v", obj)
+			return
+		}
+		endpoints, ok = tombstone.Obj.(*corev1.Endpoints)
+		if !ok {
+			ww.log.Errorf("DeletedFinalStateUnknown contained object that is not an Endpoints %#v", obj)
+			return
+		}
+	}
+	if isHeadlessDirectMirror(endpoints) {
+		go ww.submitHeadlessDirectMirrorUpdate()
+	}
+}
+
+// updateEndpoints is an event handler so it cannot block
+func (ww *WorkloadWatcher) updateEndpoints(oldObj any, newObj any) {
+	oldEndpoints := oldObj.(*corev1.Endpoints)
+	newEndpoints := newObj.(*corev1.Endpoints)
+	if isHeadlessDirectMirror(oldEndpoints) || isHeadlessDirectMirror(newEndpoints) {
+		go ww.submitHeadlessDirectMirrorUpdate()
+	}
+}
+
+// submitHeadlessDirectMirrorUpdate recomputes the gateway address of the
+// publishers that aren't backed by a local workload, after a change to the
+// endpoints of a headless mirror in direct mode.
+func (ww *WorkloadWatcher) submitHeadlessDirectMirrorUpdate() {
+	ww.mu.RLock()
+	defer ww.mu.RUnlock()
+
+	for ipPort, wp := range ww.publishers {
+		remote, err := ww.getHeadlessDirectMirrorAddress(ipPort.IP, ipPort.Port)
+		if err != nil {
+			ww.log.Errorf("Error getting remote endpoint for %s:%d: %s", ipPort.IP, ipPort.Port, err)
+			continue
+		}
+		wp.updateRemote(remote)
+	}
+}
+
 func (ww *WorkloadWatcher) updateServer(oldObj interface{}, newObj interface{}) {
 	oldServer := oldObj.(*v1beta3.Server)
 	newServer := newObj.(*v1beta3.Server)
@@ -399,7 +457,7 @@ func (ww *WorkloadWatcher) updateServers(servers ...*v1beta3.Server) {
 			defer wp.mu.RUnlock()
 
 			for _, listener := range wp.listeners {
-				if err := listener.Update(&wp.addr); err != nil {
+				if err := listener.Update(wp.address()); err != nil {
 					ww.log.Warnf("Error sending update to listener: %s", err)
 					continue
 				}
@@ -445,13 +503,16 @@ func (ww *WorkloadWatcher) getOrNewWorkloadPublisher(service *ServiceID, hostnam
 
 	var pod *corev1.Pod
 	var externalWorkload *ext.ExternalWorkload
+	var remote *Address
 	var err error
 	if hostname != "" {
-		pod, err = ww.getEndpointByHostname(hostname, service)
+		pod, ip, err = ww.getEndpointByHostname(hostname, service)
 		if err != nil {
 			return nil, err
 		}
-		ip = pod.Status.PodIP
+		if pod != nil {
+			ip = pod.Status.PodIP
+		}
 	} else {
 		pod, err = ww.getPodByPodIP(ip, port)
 		if err != nil {
@@ -470,6 +531,12 @@ func (ww *WorkloadWatcher) getOrNewWorkloadPublisher(service *ServiceID, hostnam
 			}
 		}
 	}
+	if pod == nil && externalWorkload == nil {
+		remote, err = ww.getHeadlessDirectMirrorAddress(ip, port)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	ipPort := IPPort{ip, port}
 	wp, ok := ww.publishers[ipPort]
@@ -482,6 +549,7 @@ func (ww *WorkloadWatcher) getOrNewWorkloadPublisher(service *ServiceID, hostnam
 				IP:   ip,
 				Port: port,
 			},
+			remote:          remote,
 			metrics:         ww.metrics,
 			subscriberCount: &ww.subscriberCount,
 			log: ww.log.WithFields(logging.Fields{
@@ -582,18 +650,64 @@ func (ww *WorkloadWatcher) getExternalWorkloadByIP(ip string, port uint32) (*ext
 	return nil, nil
 }
 
+// getHeadlessDirectMirrorAddress returns the address of the gateway through
+// which the given IP address and port is reached, if it's a remote endpoint
+// of a headless mirror in direct mode. When the gateway has several
+// addresses, the first one is used.
+func (ww *WorkloadWatcher) getHeadlessDirectMirrorAddress(ip string, port uint32) (*Address, error) {
+	addr := net.JoinHostPort(ip, fmt.Sprintf("%d", port))
+	objs, err := ww.k8sAPI.Endpoint().Informer().GetIndexer().ByIndex(HeadlessDirectMirrorIPIndex, addr)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	if len(objs) == 0 {
+		ww.log.Debugf("no remote endpoint found for %s:%d", ip, port)
+		return nil, nil
+	}
+	if len(objs) > 1 {
+		conflictingEndpoints := []string{}
+		for _, obj := range objs {
+			endpoints := obj.(*corev1.Endpoints)
+			conflictingEndpoints = append(conflictingEndpoints, fmt.Sprintf("%s:%s", endpoints.Namespace, endpoints.Name))
+		}
+		ww.log.Warnf("found conflicting %s:%d remote endpoint: %s", ip, port, strings.Join(conflictingEndpoints, ","))
+		return nil, status.Errorf(codes.FailedPrecondition, "found %d headless mirrors with a conflicting remote endpoint %s:%d", len(objs), ip, port)
+	}
+
+	endpoints := objs[0].(*corev1.Endpoints)
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.IP != ip || address.Hostname == "" {
+				continue
+			}
+			gateways, err := headlessDirectMirrorAddresses(endpoints.Annotations, address.Hostname, port)
+			if err != nil {
+				return nil, status.Error(codes.Unknown, err.Error())
+			}
+			if len(gateways) == 0 {
+				return nil, nil
+			}
+			ww.log.Debugf("found remote endpoint %s:%d in %s/%s", ip, port, endpoints.Namespace, endpoints.Name)
+			return &gateways[0], nil
+		}
+	}
+
+	return nil, nil
+}
+
 // getEndpointByHostname returns a pod that maps to the given hostname (or an
 // instanceID). The hostname is generally the prefix of the pod's DNS name;
 // since it may be arbitrary we need to look at the corresponding service's
-// Endpoints object to see whether the hostname matches a pod.
-func (ww *WorkloadWatcher) getEndpointByHostname(hostname string, svcID *ServiceID) (*corev1.Pod, error) {
+// Endpoints object to see whether the hostname matches a pod. If the endpoint
+// isn't backed by a pod, its IP is returned instead.
+func (ww *WorkloadWatcher) getEndpointByHostname(hostname string, svcID *ServiceID) (*corev1.Pod, string, error) {
 	if ww.enableEndpointSlices {
 		matchLabels := map[string]string{discovery.LabelServiceName: svcID.Name}
 		selector := labels.Set(matchLabels).AsSelector()
 
 		sliceList, err := ww.k8sAPI.ES().Lister().EndpointSlices(svcID.Namespace).List(selector)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		for _, slice := range sliceList {
 			for _, ep := range slice.Endpoints {
@@ -603,21 +717,24 @@ func (ww *WorkloadWatcher) getEndpointByHostname(hostname string, svcID *Service
 						podNamespace := ep.TargetRef.Namespace
 						pod, err := ww.k8sAPI.Pod().Lister().Pods(podNamespace).Get(podName)
 						if err != nil {
-							return nil, err
+							return nil, "", err
 						}
-						return pod, nil
+						return pod, "", nil
 					}
-					return nil, nil
+					if len(ep.Addresses) == 0 {
+						return nil, "", nil
+					}
+					return nil, ep.Addresses[0], nil
 				}
 			}
 		}
 
-		return nil, status.Errorf(codes.NotFound, "no pod found in EndpointSlices of Service %s/%s for hostname %s", svcID.Namespace, svcID.Name, hostname)
+		return nil, "", status.Errorf(codes.NotFound, "no pod found in EndpointSlices of Service %s/%s for hostname %s", svcID.Namespace, svcID.Name, hostname)
 	}
 
 	ep, err := ww.k8sAPI.Endpoint().Lister().Endpoints(svcID.Namespace).Get(svcID.Name)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	for _, subset := range ep.Subsets {
@@ -629,16 +746,16 @@ func (ww *WorkloadWatcher) getEndpointByHostname(hostname string, svcID *Service
 					podNamespace := addr.TargetRef.Namespace
 					pod, err := ww.k8sAPI.Pod().Lister().Pods(podNamespace).Get(podName)
 					if err != nil {
-						return nil, err
+						return nil, "", err
 					}
-					return pod, nil
+					return pod, "", nil
 				}
-				return nil, nil
+				return nil, addr.IP, nil
 			}
 		}
 	}
 
-	return nil, status.Errorf(codes.NotFound, "no pod found in Endpoints %s/%s for hostname %s", svcID.Namespace, svcID.Name, hostname)
+	return nil, "", status.Errorf(codes.NotFound, "no pod found in Endpoints %s/%s for hostname %s", svcID.Namespace, svcID.Name, hostname)
 }
 
 func (wp *workloadPublisher) subscribe(listener WorkloadUpdateListener) error {
@@ -647,7 +764,7 @@ func (wp *workloadPublisher) subscribe(listener WorkloadUpdateListener) error {
 
 	wp.listeners = append(wp.listeners, listener)
 
-	if err := listener.Update(&wp.addr); err != nil {
+	if err := listener.Update(wp.address()); err != nil {
 		return fmt.Errorf("failed to send initial update: %w", err)
 	}
 	wp.metrics.incUpdates()
@@ -722,7 +839,7 @@ func (wp *workloadPublisher) updatePod(pod *corev1.Pod) {
 		}
 
 		for _, l := range wp.listeners {
-			if err := l.Update(&wp.addr); err != nil {
+			if err := l.Update(wp.address()); err != nil {
 				wp.log.Warnf("Error sending update to listener: %s", err)
 				continue
 			}
@@ -740,7 +857,7 @@ func (wp *workloadPublisher) updatePod(pod *corev1.Pod) {
 		wp.addr.OwnerName = ""
 		wp.addr.OpaqueProtocol = false
 		for _, l := range wp.listeners {
-			if err := l.Update(&wp.addr); err != nil {
+			if err := l.Update(wp.address()); err != nil {
 				wp.log.Warnf("Error sending update to listener: %s", err)
 				continue
 			}
@@ -777,7 +894,32 @@ func (wp *workloadPublisher) updateExternalWorkload(externalWorkload *ext.Extern
 	}
 
 	for _, l := range wp.listeners {
-		if err := l.Update(&wp.addr); err != nil {
+		if err := l.Update(wp.address()); err != nil {
+			wp.log.Warnf("Error sending update to listener: %s", err)
+			continue
+		}
+	}
+	wp.metrics.incUpdates()
+}
+
+// updateRemote sets the gateway address through which the publisher's
+// address is reached when it's a remote endpoint of a headless mirror in
+// direct mode, notifying the listeners if it isn't backed by a local
+// workload. A nil remote means it no longer is such an endpoint.
+func (wp *workloadPublisher) updateRemote(remote *Address) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if remote == nil && wp.remote == nil {
+		return
+	}
+	wp.remote = remote
+	if wp.isLocal() {
+		return
+	}
+
+	for _, l := range wp.listeners {
+		if err := l.Update(wp.address()); err != nil {
 			wp.log.Warnf("Error sending update to listener: %s", err)
 			continue
 		}
@@ -785,6 +927,21 @@ func (wp *workloadPublisher) updateExternalWorkload(externalWorkload *ext.Extern
 	wp.metrics.incUpdates()
 }
 
+// isLocal returns whether the publisher's address is backed by a local pod
+// or externalworkload.
+func (wp *workloadPublisher) isLocal() bool {
+	return wp.addr.Pod != nil || wp.addr.ExternalWorkload != nil
+}
+
+// address returns the address to be sent to listeners. Local workloads take
+// precedence over remote endpoints of headless mirrors in direct mode.
+func (wp *workloadPublisher) address() *Address {
+	if !wp.isLocal() && wp.remote != nil {
+		return wp.remote
+	}
+	return &wp.addr
+}
+
 // GetAnnotatedOpaquePorts returns the opaque ports for the pod given its
 // annotations, or the default opaque ports if it's not annotated
 func GetAnnotatedOpaquePorts(pod *corev1.Pod, defaultPorts map[uint32]struct{}) map[uint32]struct{} {
@@ -857,6 +1014,11 @@ func isNamedInExternalWorkload(pr string, ew *ext.ExternalWorkload) (int32, bool
 	return 0, false
 }
 
+func isHeadlessDirectMirror(endpoints *corev1.Endpoints) bool {
+	_, ok := endpoints.Annotations[consts.HeadlessDirectMirrorAnnotation]
+	return ok
+}
+
 func isRunning(pod *corev1.Pod) bool {
 	return pod != nil && pod.Status.Phase == corev1.PodRunning
 }
@@ -96,9 +96,11 @@ type AuthorityEndpoints struct {
 
 // PodDetails holds the details for pod associated to an Endpoint
 type PodDetails struct {
-	Name string
-	IP   uint32
-	Port uint32
+	Name   string
+	IP     uint32
+	Port   uint32
+	Weight uint32
+	Labels map[string]string
 }
 
 // BuildAddrSet converts AuthorityEndpoints into its protobuf representation
@@ -110,7 +112,10 @@ func BuildAddrSet(endpoint AuthorityEndpoints) *destinationPb.WeightedAddrSet {
 			Port: pod.Port,
 		}
 		labels := map[string]string{"pod": pod.Name}
-		weightedAddr := &destinationPb.WeightedAddr{Addr: addr, MetricLabels: labels}
+		for k, v := range pod.Labels {
+			labels[k] = v
+		}
+		weightedAddr := &destinationPb.WeightedAddr{Addr: addr, Weight: pod.Weight, MetricLabels: labels}
 		addrs = append(addrs, weightedAddr)
 	}
 	labels := map[string]string{"namespace": endpoint.Namespace, "service": endpoint.ServiceID}
@@ -18,6 +18,7 @@ import (
 	"github.com/linkerd/linkerd2/controller/k8s"
 	"github.com/linkerd/linkerd2/pkg/admin"
 	"github.com/linkerd/linkerd2/pkg/flags"
+	"github.com/linkerd/linkerd2/pkg/identity"
 	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/linkerd/linkerd2/pkg/trace"
 	"github.com/linkerd/linkerd2/pkg/util"
@@ -187,6 +188,11 @@ func Main(args []string) {
 		forceOpaqueTransport = false
 	}
 
+	denyList := identity.NewDenyList()
+	if err := identity.WatchDenyList(ctx, k8Client, *controllerNamespace, denyList); err != nil {
+		log.Fatalf("Failed to watch the identity deny-list: %s", err)
+	}
+
 	config := destination.Config{
 		ControllerNS:            *controllerNamespace,
 		IdentityTrustDomain:     *trustDomain,
@@ -198,6 +204,7 @@ func Main(args []string) {
 		EnableIPv6:              *enableIPv6,
 		ExtEndpointZoneWeights:  *extEndpointZoneWeights,
 		MeshedHttp2ClientParams: meshedHTTP2ClientParams,
+		IdentityDenyList:        denyList,
 		StreamQueueCapacity:     *streamQueueCapacity,
 	}
 	server, err := destination.NewServer(
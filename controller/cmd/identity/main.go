@@ -2,12 +2,15 @@ package identity
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -40,9 +43,26 @@ func Main(args []string) {
 	trustDomain := cmd.String("identity-trust-domain", "", "configures the name suffix used for identities")
 	identityIssuanceLifeTime := cmd.String("identity-issuance-lifetime", "", "the amount of time for which the Identity issuer should certify identity")
 	identityClockSkewAllowance := cmd.String("identity-clock-skew-allowance", "", "the amount of time to allow for clock skew within a Linkerd cluster")
+	issuerKeyAlgorithms := cmd.String("identity-issuer-key-algorithms", "", "comma-separated list of the key algorithms allowed for the issuer certificate (ecdsa-p256, rsa-2048, rsa-4096, ed25519); all if empty")
+	shortLivedIssuerLifetime := cmd.Duration("short-lived-issuer-lifetime", 0, "when set, the identity controller mints issuers valid for this long, signed with the issuer credentials, and rotates them after two thirds of their lifetime")
+	csrKeyAlgorithms := cmd.String("identity-csr-key-algorithms", string(tls.DefaultKeyAlgorithm), "comma-separated list of the key algorithms allowed for the proxies' CSRs (ecdsa-p256, rsa-2048, rsa-4096, ed25519); all if empty")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
 	qps := cmd.Float64("kube-apiclient-qps", 100, "Maximum QPS sent to the kube-apiserver before throttling")
 	burst := cmd.Int("kube-apiclient-burst", 200, "Burst value over kube-apiclient-qps")
+	externalSignerURL := cmd.String("external-signer-url", "", "URL of an external signer holding the issuer private key; when set, only the issuer certificate is read from disk")
+	externalSignerKeyID := cmd.String("external-signer-key-id", "", "ID of the issuer private key in the external signer")
+	externalSignerTimeout := cmd.Duration("external-signer-timeout", tls.DefaultExternalSignerTimeout, "timeout of requests to the external signer")
+	tokenValidator := cmd.String("token-validator", "tokenreview", "how service account tokens are validated: tokenreview (a TokenReview per request) or jwks (locally, against the API server's cached JWKS)")
+	strictAudience := cmd.Bool("token-audience-strict", false, "reject tokens which were not issued for the Linkerd audience")
+	jwksRefresh := cmd.Duration("jwks-refresh-interval", idctl.DefaultJWKSRefreshInterval, "interval at which cached JWKS are refreshed")
+	oidcIssuer := cmd.String("external-workload-oidc-issuer", "", "URL of an OIDC issuer whose tokens authenticate ExternalWorkloads")
+	oidcAudience := cmd.String("external-workload-oidc-audience", idctl.LinkerdAudienceKey, "audience required in ExternalWorkload tokens")
+	oidcClaim := cmd.String("external-workload-oidc-identity-claim", "sub", "claim of ExternalWorkload tokens holding the workload identity")
+	oidcJWKSFile := cmd.String("external-workload-jwks-file", "", "path to a static JWKS for the ExternalWorkload token issuer; discovered from the issuer if not set")
+	oidcNamespaces := cmd.String("external-workload-oidc-namespaces", "", "comma-separated list of the namespaces ExternalWorkload tokens can be issued for; all namespaces if not set")
+	enableSPIFFE := cmd.Bool("enable-spiffe", false, "add the SPIFFE ID of the certified service account, in the identity trust domain, as a URI SAN to issued certificates")
+	spiffeBundleAddr := cmd.String("spiffe-bundle-addr", "", "address on which the trust anchors are served in the SPIFFE bundle format; disabled if empty")
+	auditLogPath := cmd.String("audit-log", "", "path of a file to which an audit record is appended for every certification request; \"-\" for stdout")
 
 	issuerPath := cmd.String("issuer",
 		"/var/run/linkerd/identity/issuer",
@@ -123,19 +143,6 @@ func Main(args []string) {
 	}
 
 	expectedName := fmt.Sprintf("identity.%s.%s", *controllerNS, *trustDomain)
-	issuerEvent := make(chan struct{})
-	issuerError := make(chan error)
-
-	//
-	// Create and start FS creds watcher
-	//
-	watcher := tls.NewFsCredsWatcher(*issuerPath, issuerEvent, issuerError)
-	go func() {
-		if err := watcher.StartWatching(ctx); err != nil {
-			//nolint:gocritic
-			log.Fatalf("Failed to start creds watcher: %s", err)
-		}
-	}()
 
 	//
 	// Create k8s API
@@ -150,10 +157,29 @@ func Main(args []string) {
 	}
 	log.Infof("Using k8s client with QPS=%.2f Burst=%d", config.QPS, config.Burst)
 
-	v, err := idctl.NewK8sTokenValidator(ctx, k8sAPI, dom)
+	var v identity.Validator
+	switch *tokenValidator {
+	case "tokenreview":
+		v, err = idctl.NewK8sTokenValidator(ctx, k8sAPI, dom, *strictAudience)
+	case "jwks":
+		v, err = idctl.NewK8sJWKSValidator(ctx, k8sAPI, dom, *jwksRefresh)
+	default:
+		err = fmt.Errorf("unknown token validator %q", *tokenValidator)
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize identity service: %s", err)
 	}
+	if *oidcIssuer != "" {
+		var namespaces []string
+		if *oidcNamespaces != "" {
+			namespaces = strings.Split(*oidcNamespaces, ",")
+		}
+		oidc, err := idctl.NewOIDCValidator(ctx, *oidcIssuer, *oidcAudience, *oidcClaim, *oidcJWKSFile, namespaces, dom, *jwksRefresh)
+		if err != nil {
+			log.Fatalf("Failed to initialize ExternalWorkload token validator: %s", err)
+		}
+		v = idctl.NewIssuerRouter(v, map[string]identity.Validator{*oidcIssuer: oidc})
+	}
 
 	// Create K8s event recorder
 	eventBroadcaster := record.NewBroadcaster()
@@ -182,14 +208,91 @@ func Main(args []string) {
 	// Create, initialize and run service
 	//
 	svc := identity.NewService(v, trustAnchors, &validity, recordEventFunc, expectedName, issuerPathCrt, issuerPathKey)
+	if *externalSignerURL != "" {
+		log.Infof("Using external signer at %s", *externalSignerURL)
+		client := &http.Client{Timeout: *externalSignerTimeout}
+		svc.UseExternalSigner(tls.NewExternalSigner(*externalSignerURL, *externalSignerKeyID, client))
+	}
+	denyList := identity.NewDenyList()
+	if err := identity.WatchDenyList(ctx, k8sAPI, *controllerNS, denyList); err != nil {
+		log.Fatalf("Failed to watch the identity deny-list: %s", err)
+	}
+	svc.UseDenyList(denyList)
+	issuerAlgs, err := tls.ParseKeyAlgorithms(*issuerKeyAlgorithms)
+	if err != nil {
+		//nolint:gocritic
+		log.Fatalf("Invalid issuer key algorithms: %s", err)
+	}
+	csrAlgs, err := tls.ParseKeyAlgorithms(*csrKeyAlgorithms)
+	if err != nil {
+		//nolint:gocritic
+		log.Fatalf("Invalid CSR key algorithms: %s", err)
+	}
+	svc.UseKeyAlgorithms(issuerAlgs, csrAlgs)
+	if *shortLivedIssuerLifetime != 0 {
+		if err := svc.UseShortLivedIssuers(*shortLivedIssuerLifetime); err != nil {
+			//nolint:gocritic
+			log.Fatalf("Invalid short-lived issuer lifetime: %s", err)
+		}
+		log.Infof("Minting short-lived issuers valid for %s", *shortLivedIssuerLifetime)
+	}
+	if *enableSPIFFE {
+		svc.UseSPIFFE(*trustDomain)
+	}
+	switch *auditLogPath {
+	case "":
+	case "-":
+		svc.UseAuditLog(os.Stdout)
+	default:
+		auditLog, err := os.OpenFile(filepath.Clean(*auditLogPath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			//nolint:gocritic
+			log.Fatalf("Failed to open audit log: %s", err)
+		}
+		defer auditLog.Close()
+		svc.UseAuditLog(auditLog)
+	}
 	if err = svc.Initialize(); err != nil {
 		//nolint:gocritic
 		log.Fatalf("Failed to initialize identity service: %s", err)
 	}
+	go svc.Run(ctx)
+
+	//
+	// Create and start FS creds watcher
+	//
+	watcher := tls.NewFsCredsWatcher().
+		WithFileSet(svc.IssuerFileSet(*issuerPath)).
+		WithFileSet(tls.FileSet{
+			Name:  "trust anchors",
+			Dir:   k8s.MountPathTrustRootsBase,
+			Paths: []string{k8s.MountPathTrustRootsPEM},
+			Load: func(contents map[string][]byte) error {
+				return svc.UpdateTrustAnchors(contents[k8s.MountPathTrustRootsPEM])
+			},
+		})
 	go func() {
-		svc.Run(issuerEvent, issuerError)
+		if err := watcher.StartWatching(ctx); err != nil {
+			//nolint:gocritic
+			log.Fatalf("Failed to start creds watcher: %s", err)
+		}
 	}()
 
+	if *spiffeBundleAddr != "" {
+		bundleServer := &http.Server{
+			Addr:              *spiffeBundleAddr,
+			Handler:           identity.NewSPIFFEBundleHandler(k8s.MountPathTrustRootsPEM),
+			ReadHeaderTimeout: 15 * time.Second,
+		}
+		go func() {
+			log.Infof("serving the SPIFFE bundle on %s", *spiffeBundleAddr)
+			if err := bundleServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("failed to serve the SPIFFE bundle: %s", err)
+			}
+		}()
+		defer bundleServer.Shutdown(ctx)
+	}
+
 	//
 	// Bind and serve
 	//
@@ -4,12 +4,19 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/linkerd/linkerd2/controller/k8s"
 	injector "github.com/linkerd/linkerd2/controller/proxy-injector"
 	"github.com/linkerd/linkerd2/controller/webhook"
 	"github.com/linkerd/linkerd2/pkg/flags"
 	"github.com/linkerd/linkerd2/pkg/inject"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 // Main executes the proxy-injector subcommand
@@ -20,12 +27,55 @@ func Main(args []string) {
 	kubeconfig := cmd.String("kubeconfig", "", "path to kubeconfig")
 	linkerdNamespace := cmd.String("linkerd-namespace", "linkerd", "control plane namespace")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	enableAutoRollout := cmd.Bool("enable-auto-rollout", false, "Restart the meshed workloads whose injection config changed because of a change to their namespace or ProxyConfig")
+	autoRolloutDryRun := cmd.Bool("auto-rollout-dry-run", false, "Only log and record events for the restarts the rollout controller would perform")
+	autoRolloutRestartsPerMinute := cmd.Float64("auto-rollout-restarts-per-minute", 5, "Maximum number of workloads restarted per minute by the rollout controller")
+	meshRequiredExemptions := cmd.String("mesh-required-exemptions", "", "Comma-separated list of namespace/kind/name patterns matching the workloads allowed to run without a proxy in namespaces labelled with linkerd.io/mesh-required=true")
 	flags.ConfigureAndParse(cmd, args)
 
+	exemptions, err := injector.ParseMeshRequiredExemptions(strings.Split(*meshRequiredExemptions, ","))
+	if err != nil {
+		log.Fatalf("invalid -mesh-required-exemptions: %s", err)
+	}
+
+	ctx := context.Background()
+
+	// ProxyConfigs are optional: if they can't be watched (e.g. the CRDs
+	// aren't installed yet) pods are injected without them. They are resolved
+	// from the informers' caches when a pod is injected, so changes apply to
+	// the pods created afterwards.
+	proxyConfigs, err := k8s.InitializeAPI(ctx, *kubeconfig, false, "local", k8s.ProxyConfig, k8s.ClusterProxyConfig)
+	if err != nil {
+		log.Warnf("not watching ProxyConfigs: %s", err)
+		proxyConfigs = nil
+	} else {
+		proxyConfigs.Sync(nil)
+	}
+
+	// Services are only used to infer the outbound ports to skip of the
+	// workloads enabling it: if they can't be watched, none are inferred.
+	services, err := k8s.InitializeAPI(ctx, *kubeconfig, false, "local", k8s.Svc)
+	if err != nil {
+		log.Warnf("not watching Services: %s", err)
+		services = nil
+	} else {
+		services.Sync(nil)
+	}
+
+	if *enableAutoRollout {
+		done := make(chan struct{})
+		defer close(done)
+		startRolloutController(ctx, *kubeconfig, *linkerdNamespace, proxyConfigs, *autoRolloutRestartsPerMinute, *autoRolloutDryRun, done)
+	}
+
 	webhook.Launch(
-		context.Background(),
+		ctx,
 		[]k8s.APIResource{k8s.NS, k8s.Deploy, k8s.RC, k8s.RS, k8s.Job, k8s.DS, k8s.SS, k8s.Pod, k8s.CJ},
-		injector.Inject(*linkerdNamespace, inject.GetOverriddenValues),
+		injector.Inject(*linkerdNamespace, inject.GetOverriddenValues, proxyConfigs, services),
+		map[string]webhook.Handler{
+			webhook.ExplainPath:      injector.Explain(*linkerdNamespace, proxyConfigs, services),
+			webhook.MeshRequiredPath: injector.MeshRequired(*linkerdNamespace, exemptions, proxyConfigs),
+		},
 		"linkerd-proxy-injector",
 		*metricsAddr,
 		*addr,
@@ -33,3 +83,40 @@ func Main(args []string) {
 		*enablePprof,
 	)
 }
+
+func startRolloutController(
+	ctx context.Context,
+	kubeconfig, linkerdNamespace string,
+	proxyConfigs *k8s.API,
+	restartsPerMinute float64,
+	dryRun bool,
+	done chan struct{},
+) {
+	hostname, ok := os.LookupEnv("HOSTNAME")
+	if !ok {
+		log.Fatal("Failed to initialize the rollout controller, \"HOSTNAME\" value not found")
+	}
+	if restartsPerMinute <= 0 {
+		log.Fatalf("-auto-rollout-restarts-per-minute must be positive, got %v", restartsPerMinute)
+	}
+
+	k8sAPI, err := k8s.InitializeAPI(ctx, kubeconfig, true, "local", k8s.NS, k8s.Deploy, k8s.SS, k8s.DS, k8s.PDB)
+	if err != nil {
+		log.Fatalf("Failed to initialize the rollout controller's Kubernetes API: %s", err)
+	}
+
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: k8sAPI.Client.CoreV1().Events(""),
+	})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "linkerd-proxy-injector"})
+
+	controller := injector.NewRolloutController(k8sAPI, proxyConfigs, recorder, hostname, linkerdNamespace, restartsPerMinute, dryRun, done)
+	k8sAPI.Sync(nil)
+	controller.Start()
+	if dryRun {
+		log.Info("started the rollout controller in dry-run mode")
+	} else {
+		log.Info("started the rollout controller")
+	}
+}
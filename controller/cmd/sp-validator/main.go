@@ -23,6 +23,7 @@ func Main(args []string) {
 		context.Background(),
 		nil,
 		validator.AdmitSP,
+		nil,
 		"linkerd-sp-validator",
 		*metricsAddr,
 		*addr,
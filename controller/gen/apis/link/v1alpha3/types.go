@@ -62,6 +62,10 @@ type LinkStatus struct {
 	MirrorServices []ServiceStatus `json:"mirrorServices,omitempty"`
 	// +optional
 	FederatedServices []ServiceStatus `json:"federatedServices,omitempty"`
+	// Conditions describe the state of the Link itself, such as the validity
+	// of the credentials used to access the target cluster.
+	// +optional
+	Conditions []LinkCondition `json:"conditions,omitempty"`
 }
 
 type ServiceStatus struct {
@@ -0,0 +1,142 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +groupName=config.linkerd.io
+
+// ProxyConfig is a named set of proxy configuration overrides which workloads
+// of its namespace refer to with the config.linkerd.io/proxy-config
+// annotation, instead of setting the matching config.linkerd.io annotations
+// one by one.
+type ProxyConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the proxy configuration overrides
+	Spec ProxyConfigSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ProxyConfigList contains a list of ProxyConfig resources.
+type ProxyConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ProxyConfig `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterProxyConfig is a cluster-wide ProxyConfig, which workloads of any
+// namespace may refer to. A ProxyConfig takes precedence over a
+// ClusterProxyConfig of the same name.
+type ClusterProxyConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the proxy configuration overrides
+	Spec ProxyConfigSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterProxyConfigList contains a list of ClusterProxyConfig resources.
+type ClusterProxyConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterProxyConfig `json:"items"`
+}
+
+// ProxyConfigSpec holds proxy configuration overrides. Unset fields leave the
+// configuration inherited from the namespace and the installation untouched.
+type ProxyConfigSpec struct {
+	// Resources overrides the proxy container's resource requirements
+	// +optional
+	Resources *ProxyResources `json:"resources,omitempty"`
+
+	// Ports overrides the proxy ports and the ports it handles
+	// +optional
+	Ports *ProxyPorts `json:"ports,omitempty"`
+
+	// LogLevel overrides the proxy log level, e.g. warn,linkerd=info
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// LogFormat overrides the proxy log format, plain or json
+	// +optional
+	LogFormat string `json:"logFormat,omitempty"`
+
+	// Timeouts overrides the proxy timeouts
+	// +optional
+	Timeouts *ProxyTimeouts `json:"timeouts,omitempty"`
+}
+
+// ProxyResources holds the resource requirements of the proxy container.
+type ProxyResources struct {
+	// +optional
+	CPU *ResourceRequirements `json:"cpu,omitempty"`
+	// +optional
+	Memory *ResourceRequirements `json:"memory,omitempty"`
+	// +optional
+	EphemeralStorage *ResourceRequirements `json:"ephemeralStorage,omitempty"`
+}
+
+// ResourceRequirements holds the request and limit of a resource, as
+// Kubernetes quantities.
+type ResourceRequirements struct {
+	// +optional
+	Request string `json:"request,omitempty"`
+	// +optional
+	Limit string `json:"limit,omitempty"`
+}
+
+// ProxyPorts holds the ports of the proxy and the ports it handles.
+type ProxyPorts struct {
+	// +optional
+	Admin *int32 `json:"admin,omitempty"`
+	// +optional
+	Control *int32 `json:"control,omitempty"`
+	// +optional
+	Inbound *int32 `json:"inbound,omitempty"`
+	// +optional
+	Outbound *int32 `json:"outbound,omitempty"`
+
+	// IgnoreInbound is a comma-separated list of inbound ports or port ranges
+	// which bypass the proxy
+	// +optional
+	IgnoreInbound string `json:"ignoreInbound,omitempty"`
+
+	// IgnoreOutbound is a comma-separated list of outbound ports or port
+	// ranges which bypass the proxy
+	// +optional
+	IgnoreOutbound string `json:"ignoreOutbound,omitempty"`
+
+	// Opaque is a comma-separated list of ports or port ranges which skip
+	// protocol detection
+	// +optional
+	Opaque string `json:"opaque,omitempty"`
+}
+
+// ProxyTimeouts holds the proxy timeouts, as durations such as 100ms or 1m.
+type ProxyTimeouts struct {
+	// +optional
+	InboundConnect string `json:"inboundConnect,omitempty"`
+	// +optional
+	OutboundConnect string `json:"outboundConnect,omitempty"`
+	// +optional
+	InboundDiscoveryCacheUnused string `json:"inboundDiscoveryCacheUnused,omitempty"`
+	// +optional
+	OutboundDiscoveryCacheUnused string `json:"outboundDiscoveryCacheUnused,omitempty"`
+	// +optional
+	ShutdownGracePeriod string `json:"shutdownGracePeriod,omitempty"`
+}
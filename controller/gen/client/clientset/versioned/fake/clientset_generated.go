@@ -32,6 +32,8 @@ import (
 	fakepolicyv1alpha1 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/policy/v1alpha1/fake"
 	policyv1beta3 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/policy/v1beta3"
 	fakepolicyv1beta3 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/policy/v1beta3/fake"
+	proxyconfigv1alpha1 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/proxyconfig/v1alpha1"
+	fakeproxyconfigv1alpha1 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/proxyconfig/v1alpha1/fake"
 	serverv1beta1 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/server/v1beta1"
 	fakeserverv1beta1 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/server/v1beta1/fake"
 	serverv1beta2 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/server/v1beta2"
@@ -149,6 +151,11 @@ func (c *Clientset) PolicyV1beta3() policyv1beta3.PolicyV1beta3Interface {
 	return &fakepolicyv1beta3.FakePolicyV1beta3{Fake: &c.Fake}
 }
 
+// ProxyconfigV1alpha1 retrieves the ProxyconfigV1alpha1Client
+func (c *Clientset) ProxyconfigV1alpha1() proxyconfigv1alpha1.ProxyconfigV1alpha1Interface {
+	return &fakeproxyconfigv1alpha1.FakeProxyconfigV1alpha1{Fake: &c.Fake}
+}
+
 // ServerV1beta1 retrieves the ServerV1beta1Client
 func (c *Clientset) ServerV1beta1() serverv1beta1.ServerV1beta1Interface {
 	return &fakeserverv1beta1.FakeServerV1beta1{Fake: &c.Fake}
@@ -0,0 +1,70 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	context "context"
+
+	proxyconfigv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	scheme "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// ClusterProxyConfigsGetter has a method to return a ClusterProxyConfigInterface.
+// A group's client should implement this interface.
+type ClusterProxyConfigsGetter interface {
+	ClusterProxyConfigs() ClusterProxyConfigInterface
+}
+
+// ClusterProxyConfigInterface has methods to work with ClusterProxyConfig resources.
+type ClusterProxyConfigInterface interface {
+	Create(ctx context.Context, clusterProxyConfig *proxyconfigv1alpha1.ClusterProxyConfig, opts v1.CreateOptions) (*proxyconfigv1alpha1.ClusterProxyConfig, error)
+	Update(ctx context.Context, clusterProxyConfig *proxyconfigv1alpha1.ClusterProxyConfig, opts v1.UpdateOptions) (*proxyconfigv1alpha1.ClusterProxyConfig, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*proxyconfigv1alpha1.ClusterProxyConfig, error)
+	List(ctx context.Context, opts v1.ListOptions) (*proxyconfigv1alpha1.ClusterProxyConfigList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *proxyconfigv1alpha1.ClusterProxyConfig, err error)
+	ClusterProxyConfigExpansion
+}
+
+// clusterProxyConfigs implements ClusterProxyConfigInterface
+type clusterProxyConfigs struct {
+	*gentype.ClientWithList[*proxyconfigv1alpha1.ClusterProxyConfig, *proxyconfigv1alpha1.ClusterProxyConfigList]
+}
+
+// newClusterProxyConfigs returns a ClusterProxyConfigs
+func newClusterProxyConfigs(c *ProxyconfigV1alpha1Client) *clusterProxyConfigs {
+	return &clusterProxyConfigs{
+		gentype.NewClientWithList[*proxyconfigv1alpha1.ClusterProxyConfig, *proxyconfigv1alpha1.ClusterProxyConfigList](
+			"clusterproxyconfigs",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			"",
+			func() *proxyconfigv1alpha1.ClusterProxyConfig { return &proxyconfigv1alpha1.ClusterProxyConfig{} },
+			func() *proxyconfigv1alpha1.ClusterProxyConfigList {
+				return &proxyconfigv1alpha1.ClusterProxyConfigList{}
+			},
+		),
+	}
+}
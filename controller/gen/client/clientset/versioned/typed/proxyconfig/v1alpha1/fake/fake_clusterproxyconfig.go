@@ -0,0 +1,48 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	proxyconfigv1alpha1 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/proxyconfig/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeClusterProxyConfigs implements ClusterProxyConfigInterface
+type fakeClusterProxyConfigs struct {
+	*gentype.FakeClientWithList[*v1alpha1.ClusterProxyConfig, *v1alpha1.ClusterProxyConfigList]
+	Fake *FakeProxyconfigV1alpha1
+}
+
+func newFakeClusterProxyConfigs(fake *FakeProxyconfigV1alpha1) proxyconfigv1alpha1.ClusterProxyConfigInterface {
+	return &fakeClusterProxyConfigs{
+		gentype.NewFakeClientWithList[*v1alpha1.ClusterProxyConfig, *v1alpha1.ClusterProxyConfigList](
+			fake.Fake,
+			"",
+			v1alpha1.SchemeGroupVersion.WithResource("clusterproxyconfigs"),
+			v1alpha1.SchemeGroupVersion.WithKind("ClusterProxyConfig"),
+			func() *v1alpha1.ClusterProxyConfig { return &v1alpha1.ClusterProxyConfig{} },
+			func() *v1alpha1.ClusterProxyConfigList { return &v1alpha1.ClusterProxyConfigList{} },
+			func(dst, src *v1alpha1.ClusterProxyConfigList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.ClusterProxyConfigList) []*v1alpha1.ClusterProxyConfig { return gentype.ToPointerSlice(list.Items) },
+			func(list *v1alpha1.ClusterProxyConfigList, items []*v1alpha1.ClusterProxyConfig) { list.Items = gentype.FromPointerSlice(items) },
+		),
+		fake,
+	}
+}
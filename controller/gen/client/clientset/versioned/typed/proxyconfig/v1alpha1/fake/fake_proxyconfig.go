@@ -0,0 +1,48 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	proxyconfigv1alpha1 "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/typed/proxyconfig/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeProxyConfigs implements ProxyConfigInterface
+type fakeProxyConfigs struct {
+	*gentype.FakeClientWithList[*v1alpha1.ProxyConfig, *v1alpha1.ProxyConfigList]
+	Fake *FakeProxyconfigV1alpha1
+}
+
+func newFakeProxyConfigs(fake *FakeProxyconfigV1alpha1, namespace string) proxyconfigv1alpha1.ProxyConfigInterface {
+	return &fakeProxyConfigs{
+		gentype.NewFakeClientWithList[*v1alpha1.ProxyConfig, *v1alpha1.ProxyConfigList](
+			fake.Fake,
+			namespace,
+			v1alpha1.SchemeGroupVersion.WithResource("proxyconfigs"),
+			v1alpha1.SchemeGroupVersion.WithKind("ProxyConfig"),
+			func() *v1alpha1.ProxyConfig { return &v1alpha1.ProxyConfig{} },
+			func() *v1alpha1.ProxyConfigList { return &v1alpha1.ProxyConfigList{} },
+			func(dst, src *v1alpha1.ProxyConfigList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.ProxyConfigList) []*v1alpha1.ProxyConfig { return gentype.ToPointerSlice(list.Items) },
+			func(list *v1alpha1.ProxyConfigList, items []*v1alpha1.ProxyConfig) { list.Items = gentype.FromPointerSlice(items) },
+		),
+		fake,
+	}
+}
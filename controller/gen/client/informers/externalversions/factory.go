@@ -28,6 +28,7 @@ import (
 	internalinterfaces "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/internalinterfaces"
 	link "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/link"
 	policy "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/policy"
+	proxyconfig "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/proxyconfig"
 	server "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/server"
 	serverauthorization "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/serverauthorization"
 	serviceprofile "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/serviceprofile"
@@ -263,6 +264,7 @@ type SharedInformerFactory interface {
 	Externalworkload() externalworkload.Interface
 	Link() link.Interface
 	Policy() policy.Interface
+	Proxyconfig() proxyconfig.Interface
 	Server() server.Interface
 	Serverauthorization() serverauthorization.Interface
 	Linkerd() serviceprofile.Interface
@@ -280,6 +282,10 @@ func (f *sharedInformerFactory) Policy() policy.Interface {
 	return policy.New(f, f.namespace, f.tweakListOptions)
 }
 
+func (f *sharedInformerFactory) Proxyconfig() proxyconfig.Interface {
+	return proxyconfig.New(f, f.namespace, f.tweakListOptions)
+}
+
 func (f *sharedInformerFactory) Server() server.Interface {
 	return server.New(f, f.namespace, f.tweakListOptions)
 }
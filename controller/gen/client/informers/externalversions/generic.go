@@ -27,6 +27,7 @@ import (
 	v1alpha3 "github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha3"
 	policyv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/policy/v1alpha1"
 	v1beta3 "github.com/linkerd/linkerd2/controller/gen/apis/policy/v1beta3"
+	proxyconfigv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
 	serverv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/server/v1beta1"
 	v1beta2 "github.com/linkerd/linkerd2/controller/gen/apis/server/v1beta2"
 	serverv1beta3 "github.com/linkerd/linkerd2/controller/gen/apis/server/v1beta3"
@@ -96,6 +97,12 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	case v1beta3.SchemeGroupVersion.WithResource("httproutes"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Policy().V1beta3().HTTPRoutes().Informer()}, nil
 
+		// Group=proxyconfig, Version=v1alpha1
+	case proxyconfigv1alpha1.SchemeGroupVersion.WithResource("clusterproxyconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Proxyconfig().V1alpha1().ClusterProxyConfigs().Informer()}, nil
+	case proxyconfigv1alpha1.SchemeGroupVersion.WithResource("proxyconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Proxyconfig().V1alpha1().ProxyConfigs().Informer()}, nil
+
 		// Group=server, Version=v1beta1
 	case serverv1beta1.SchemeGroupVersion.WithResource("servers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Server().V1beta1().Servers().Informer()}, nil
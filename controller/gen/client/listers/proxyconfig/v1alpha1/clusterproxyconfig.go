@@ -0,0 +1,48 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	proxyconfigv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ClusterProxyConfigLister helps list ClusterProxyConfigs.
+// All objects returned here must be treated as read-only.
+type ClusterProxyConfigLister interface {
+	// List lists all ClusterProxyConfigs in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*proxyconfigv1alpha1.ClusterProxyConfig, err error)
+	// Get retrieves the ClusterProxyConfig from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*proxyconfigv1alpha1.ClusterProxyConfig, error)
+	ClusterProxyConfigListerExpansion
+}
+
+// clusterProxyConfigLister implements the ClusterProxyConfigLister interface.
+type clusterProxyConfigLister struct {
+	listers.ResourceIndexer[*proxyconfigv1alpha1.ClusterProxyConfig]
+}
+
+// NewClusterProxyConfigLister returns a new ClusterProxyConfigLister.
+func NewClusterProxyConfigLister(indexer cache.Indexer) ClusterProxyConfigLister {
+	return &clusterProxyConfigLister{listers.New[*proxyconfigv1alpha1.ClusterProxyConfig](indexer, proxyconfigv1alpha1.Resource("clusterproxyconfig"))}
+}
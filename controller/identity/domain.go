@@ -2,6 +2,7 @@ package identity
 
 import (
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/util/validation"
 )
@@ -34,3 +35,11 @@ func (d *TrustDomain) Identity(typ, nm, ns string) (string, error) {
 	id := fmt.Sprintf("%s.%s.%s.identity.%s.%s", nm, ns, typ, d.controlNS, d.domain)
 	return id, nil
 }
+
+// Contains returns whether id is a DNS-form identity within the domain.
+func (d *TrustDomain) Contains(id string) bool {
+	if errs := validation.IsDNS1123Subdomain(id); len(errs) > 0 {
+		return false
+	}
+	return strings.HasSuffix(id, fmt.Sprintf(".identity.%s.%s", d.controlNS, d.domain))
+}
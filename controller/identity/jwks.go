@@ -0,0 +1,240 @@
+package identity
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultJWKSRefreshInterval is the default interval at which cached JSON
+	// Web Key Sets are refreshed.
+	DefaultJWKSRefreshInterval = 5 * time.Minute
+
+	// minJWKSRefreshInterval bounds how often a key set is refetched when a
+	// token is signed by an unknown key, so that tokens with bogus key IDs
+	// can't be used to hammer the key set's source.
+	minJWKSRefreshInterval = 10 * time.Second
+
+	// maxJWKSRetryInterval bounds the backoff between attempts to fetch a key
+	// set whose source keeps failing.
+	maxJWKSRetryInterval = DefaultJWKSRefreshInterval
+)
+
+type (
+	// keySet caches the public keys of a JSON Web Key Set, refetching them
+	// periodically and whenever a token references an unknown key. Concurrent
+	// fetches are coalesced, and failed fetches are retried with backoff.
+	keySet struct {
+		fetch   func(context.Context) ([]byte, error)
+		refresh time.Duration
+		group   singleflight.Group
+
+		mu          sync.Mutex
+		keys        map[string]crypto.PublicKey
+		fetchedAt   time.Time
+		attemptedAt time.Time
+		failures    int
+	}
+
+	jsonWebKeySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	jsonWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+)
+
+func newKeySet(fetch func(context.Context) ([]byte, error), refresh time.Duration) *keySet {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefreshInterval
+	}
+	return &keySet{fetch: fetch, refresh: refresh}
+}
+
+// key returns the public key with the given ID, fetching the key set if it
+// is stale or doesn't contain the key. The cached key is returned while a
+// failing source is being backed off from.
+func (ks *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	key, ok := ks.keys[kid]
+	fresh := time.Since(ks.fetchedAt) < ks.refresh
+	retry := time.Since(ks.attemptedAt) >= ks.retryInterval()
+	ks.mu.Unlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+	if retry {
+		if err := ks.update(ctx); err != nil {
+			if ok {
+				log.Warnf("failed to refresh JWKS, using cached keys: %s", err)
+				return key, nil
+			}
+			return nil, err
+		}
+		ks.mu.Lock()
+		key, ok = ks.keys[kid]
+		ks.mu.Unlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// retryInterval returns how long to wait after the last fetch attempt before
+// fetching the key set again, doubling with each consecutive failure. It must
+// be called with ks.mu held.
+func (ks *keySet) retryInterval() time.Duration {
+	interval := minJWKSRefreshInterval
+	for i := 0; i < ks.failures && interval < maxJWKSRetryInterval; i++ {
+		interval *= 2
+	}
+	return min(interval, maxJWKSRetryInterval)
+}
+
+// update fetches the key set, outside of ks.mu so that cached keys can still
+// be read in the meantime, and swaps in its keys. Concurrent calls share a
+// single fetch.
+func (ks *keySet) update(ctx context.Context) error {
+	_, err, _ := ks.group.Do("", func() (any, error) {
+		keys, err := ks.load(ctx)
+
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		ks.attemptedAt = time.Now()
+		if err != nil {
+			ks.failures++
+			return nil, err
+		}
+		ks.keys = keys
+		ks.fetchedAt = ks.attemptedAt
+		ks.failures = 0
+		log.Debugf("loaded %d keys from JWKS", len(keys))
+		return nil, nil
+	})
+	return err
+}
+
+func (ks *keySet) load(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := ks.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// parseJWKS returns the signing keys of a JSON Web Key Set, indexed by key
+// ID. Keys of unsupported types are skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Debugf("skipping JWK %q: %s", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no supported signing keys")
+	}
+	return keys, nil
+}
+
+func (jwk *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeJWKInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
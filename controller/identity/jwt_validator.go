@@ -0,0 +1,250 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/linkerd/linkerd2/pkg/identity"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+const (
+	k8sJWKSPath            = "/openid/v1/jwks"
+	oidcDiscoveryPath      = "/.well-known/openid-configuration"
+	defaultJWTLeeway       = 30 * time.Second
+	defaultOIDCHTTPTimeout = 10 * time.Second
+
+	// ExternalWorkloadIdentityType is the type of the identities OIDC tokens
+	// can be issued for, i.e. <name>.<namespace>.externalworkload.identity.<...>
+	ExternalWorkloadIdentityType = "externalworkload"
+)
+
+var jwtSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+type (
+	// JWTValidator implements Validator by verifying JWTs locally against a
+	// cached JSON Web Key Set, so that no round-trip to the token issuer is
+	// needed per certification request. Tokens must be issued by the
+	// validator's issuer for its audience.
+	JWTValidator struct {
+		issuer, audience string
+		keys             *keySet
+		identity         func(jwt.MapClaims) (string, error)
+	}
+
+	// IssuerRouter implements Validator by dispatching tokens to a Validator
+	// based on their (unverified) issuer. Tokens from unknown issuers are
+	// handled by the default Validator.
+	IssuerRouter struct {
+		byIssuer map[string]identity.Validator
+		fallback identity.Validator
+	}
+
+	oidcDiscovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+)
+
+// NewK8sJWKSValidator creates a JWTValidator for Kubernetes service account
+// tokens, using the API server's service account issuer discovery endpoints.
+// Tokens must carry the Linkerd audience: unlike the K8sTokenValidator, there
+// is no fallback to tokens for the default audience.
+func NewK8sJWKSValidator(
+	ctx context.Context,
+	k8s k8s.Interface,
+	domain *TrustDomain,
+	refresh time.Duration,
+) (identity.Validator, error) {
+	rc := k8s.Discovery().RESTClient()
+	data, err := rc.Get().AbsPath(oidcDiscoveryPath).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the service account issuer: %w", err)
+	}
+	var discovery oidcDiscovery
+	if err := json.Unmarshal(data, &discovery); err != nil {
+		return nil, fmt.Errorf("invalid service account issuer discovery document: %w", err)
+	}
+
+	fetch := func(ctx context.Context) ([]byte, error) {
+		return rc.Get().AbsPath(k8sJWKSPath).DoRaw(ctx)
+	}
+	v := newJWTValidator(discovery.Issuer, LinkerdAudienceKey, newKeySet(fetch, refresh), serviceAccountIdentity(domain))
+	if err := v.keys.update(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// NewOIDCValidator creates a JWTValidator for tokens of an OIDC issuer, used
+// to authenticate workloads outside of Kubernetes, such as ExternalWorkloads.
+// The identity is read from the given claim and must be an ExternalWorkload
+// identity of the trust domain, in one of the given namespaces if any, so
+// that the issuer can't be used to obtain the identity of a service account.
+// The issuer's keys are read from jwksFile when it is set, and discovered
+// from the issuer otherwise.
+func NewOIDCValidator(
+	ctx context.Context,
+	issuer, audience, claim, jwksFile string,
+	namespaces []string,
+	domain *TrustDomain,
+	refresh time.Duration,
+) (identity.Validator, error) {
+	if issuer == "" || audience == "" {
+		return nil, errors.New("an issuer and an audience are required")
+	}
+
+	var fetch func(context.Context) ([]byte, error)
+	if jwksFile != "" {
+		fetch = func(context.Context) ([]byte, error) {
+			return os.ReadFile(filepath.Clean(jwksFile))
+		}
+	} else {
+		client := &http.Client{Timeout: defaultOIDCHTTPTimeout}
+		data, err := httpGet(ctx, client, strings.TrimSuffix(issuer, "/")+oidcDiscoveryPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OIDC issuer %s: %w", issuer, err)
+		}
+		var discovery oidcDiscovery
+		if err := json.Unmarshal(data, &discovery); err != nil {
+			return nil, fmt.Errorf("invalid OIDC discovery document: %w", err)
+		}
+		if discovery.Issuer != issuer {
+			return nil, fmt.Errorf("OIDC discovery document is for issuer %q, expected %q", discovery.Issuer, issuer)
+		}
+		fetch = func(ctx context.Context) ([]byte, error) {
+			return httpGet(ctx, client, discovery.JWKSURI)
+		}
+	}
+
+	v := newJWTValidator(issuer, audience, newKeySet(fetch, refresh), claimIdentity(claim, namespaces, domain))
+	if err := v.keys.update(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func newJWTValidator(issuer, audience string, keys *keySet, identity func(jwt.MapClaims) (string, error)) *JWTValidator {
+	return &JWTValidator{issuer, audience, keys, identity}
+}
+
+// Validate verifies the token's signature and claims and returns the
+// DNS-form linkerd ID it was issued for.
+func (v *JWTValidator) Validate(ctx context.Context, tok []byte) (string, error) {
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.key(ctx, kid)
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(string(tok), claims, keyFunc,
+		jwt.WithValidMethods(jwtSigningMethods),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(defaultJWTLeeway),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenMalformed) {
+			return "", identity.InvalidToken{Reason: err.Error()}
+		}
+		log.Debugf("token could not be authenticated: %s", err)
+		return "", identity.NotAuthenticated{}
+	}
+
+	return v.identity(claims)
+}
+
+// NewIssuerRouter creates an IssuerRouter dispatching tokens whose issuer is a
+// key of byIssuer to the matching Validator, and other tokens to fallback.
+func NewIssuerRouter(fallback identity.Validator, byIssuer map[string]identity.Validator) *IssuerRouter {
+	return &IssuerRouter{byIssuer, fallback}
+}
+
+// Validate dispatches the token to the Validator of its issuer.
+func (r *IssuerRouter) Validate(ctx context.Context, tok []byte) (string, error) {
+	claims := jwt.MapClaims{}
+	// The signature is verified by the Validator the token is dispatched to.
+	if _, _, err := jwt.NewParser().ParseUnverified(string(tok), claims); err == nil {
+		if iss, err := claims.GetIssuer(); err == nil {
+			if v, ok := r.byIssuer[iss]; ok {
+				return v.Validate(ctx, tok)
+			}
+		}
+	}
+	return r.fallback.Validate(ctx, tok)
+}
+
+// serviceAccountIdentity returns the identity of a Kubernetes service account
+// token, whose subject has the form system:serviceaccount:NS:SA.
+func serviceAccountIdentity(domain *TrustDomain) func(jwt.MapClaims) (string, error) {
+	return func(claims jwt.MapClaims) (string, error) {
+		sub, err := claims.GetSubject()
+		if err != nil {
+			return "", identity.InvalidToken{Reason: err.Error()}
+		}
+		uns := strings.Split(sub, ":")
+		if len(uns) != 4 || uns[0] != "system" || uns[1] != "serviceaccount" {
+			msg := fmt.Sprintf("Subject must be in form system:serviceaccount:NS:SA: %s", sub)
+			return "", identity.InvalidToken{Reason: msg}
+		}
+		for _, l := range uns[1:] {
+			if errs := validation.IsDNS1123Label(l); len(errs) > 0 {
+				return "", identity.InvalidToken{Reason: fmt.Sprintf("Not a label: %s", l)}
+			}
+		}
+		return domain.Identity(uns[1], uns[3], uns[2])
+	}
+}
+
+// claimIdentity returns the identity held by the given claim of a token,
+// which must be a DNS-form linkerd ID of the trust domain of the form
+// <name>.<namespace>.externalworkload.identity.<...>. Service account
+// identities are rejected, as are namespaces not in the given list, when not
+// empty.
+func claimIdentity(claim string, namespaces []string, domain *TrustDomain) func(jwt.MapClaims) (string, error) {
+	return func(claims jwt.MapClaims) (string, error) {
+		id, _ := claims[claim].(string)
+		if id == "" {
+			return "", identity.InvalidToken{Reason: fmt.Sprintf("missing %s claim", claim)}
+		}
+		if !domain.Contains(id) {
+			return "", identity.InvalidToken{Reason: fmt.Sprintf("identity %s is not in the trust domain", id)}
+		}
+		labels := strings.SplitN(id, ".", 4)
+		if len(labels) != 4 || !strings.HasPrefix(labels[3], "identity.") || labels[2] != ExternalWorkloadIdentityType {
+			return "", identity.InvalidToken{Reason: fmt.Sprintf("identity %s is not an ExternalWorkload identity", id)}
+		}
+		if len(namespaces) > 0 && !slices.Contains(namespaces, labels[1]) {
+			return "", identity.InvalidToken{Reason: fmt.Sprintf("identity %s is not in an allowed namespace", id)}
+		}
+		return id, nil
+	}
+}
+
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", url, rsp.Status)
+	}
+	return io.ReadAll(io.LimitReader(rsp.Body, 1<<20))
+}
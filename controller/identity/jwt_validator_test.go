@@ -0,0 +1,318 @@
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/linkerd/linkerd2/pkg/identity"
+)
+
+type fakeIssuer struct {
+	keys    map[string]*ecdsa.PrivateKey
+	fetches int
+}
+
+func newFakeIssuer(t *testing.T, kids ...string) *fakeIssuer {
+	t.Helper()
+	issuer := &fakeIssuer{keys: map[string]*ecdsa.PrivateKey{}}
+	for _, kid := range kids {
+		issuer.addKey(t, kid)
+	}
+	return issuer
+}
+
+func (f *fakeIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	f.keys[kid] = key
+}
+
+func (f *fakeIssuer) jwks(context.Context) ([]byte, error) {
+	f.fetches++
+	set := jsonWebKeySet{}
+	for kid, key := range f.keys {
+		set.Keys = append(set.Keys, jsonWebKey{
+			Kty: "EC",
+			Kid: kid,
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	return json.Marshal(set)
+}
+
+func (f *fakeIssuer) sign(t *testing.T, kid string, claims jwt.MapClaims) []byte {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	tok.Header["kid"] = kid
+	signed, err := tok.SignedString(f.keys[kid])
+	if err != nil {
+		t.Fatalf("failed to sign token: %s", err)
+	}
+	return []byte(signed)
+}
+
+func saClaims(iss, aud, sub string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": iss,
+		"aud": []string{aud},
+		"sub": sub,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestJWTValidator(t *testing.T) {
+	domain, err := NewTrustDomain("linkerd", "cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := "https://kubernetes.default.svc.cluster.local"
+	issuer := newFakeIssuer(t, "key-1")
+	v := newJWTValidator(iss, LinkerdAudienceKey, newKeySet(issuer.jwks, time.Hour), serviceAccountIdentity(domain))
+
+	expiredClaims := saClaims(iss, LinkerdAudienceKey, "system:serviceaccount:emojivoto:web")
+	expiredClaims["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	testCases := []struct {
+		name        string
+		token       []byte
+		expectedID  string
+		expectedErr string
+	}{
+		{
+			name:       "valid service account token",
+			token:      issuer.sign(t, "key-1", saClaims(iss, LinkerdAudienceKey, "system:serviceaccount:emojivoto:web")),
+			expectedID: "web.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+		},
+		{
+			name:        "token for another audience",
+			token:       issuer.sign(t, "key-1", saClaims(iss, "https://kubernetes.default.svc", "system:serviceaccount:emojivoto:web")),
+			expectedErr: "identity.NotAuthenticated",
+		},
+		{
+			name:        "token of another issuer",
+			token:       issuer.sign(t, "key-1", saClaims("https://other", LinkerdAudienceKey, "system:serviceaccount:emojivoto:web")),
+			expectedErr: "identity.NotAuthenticated",
+		},
+		{
+			name:        "expired token",
+			token:       issuer.sign(t, "key-1", expiredClaims),
+			expectedErr: "identity.NotAuthenticated",
+		},
+		{
+			name:        "malformed token",
+			token:       []byte("not-a-jwt"),
+			expectedErr: "identity.InvalidToken",
+		},
+		{
+			name:        "token for a user",
+			token:       issuer.sign(t, "key-1", saClaims(iss, LinkerdAudienceKey, "alice")),
+			expectedErr: "identity.InvalidToken",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := v.Validate(context.Background(), tc.token)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if id != tc.expectedID {
+					t.Fatalf("expected identity %s, got %s", tc.expectedID, id)
+				}
+				return
+			}
+			if errType := fmt.Sprintf("%T", err); errType != tc.expectedErr {
+				t.Fatalf("expected a %s error, got %s: %v", tc.expectedErr, errType, err)
+			}
+		})
+	}
+
+	if issuer.fetches != 1 {
+		t.Fatalf("expected the JWKS to be fetched once, got %d", issuer.fetches)
+	}
+
+	// A token signed by a new key triggers a refresh of the cached keys.
+	issuer.addKey(t, "key-2")
+	v.keys.attemptedAt = time.Now().Add(-minJWKSRefreshInterval)
+	tok := issuer.sign(t, "key-2", saClaims(iss, LinkerdAudienceKey, "system:serviceaccount:emojivoto:web"))
+	if _, err := v.Validate(context.Background(), tok); err != nil {
+		t.Fatalf("unexpected error validating a token signed by a rotated key: %s", err)
+	}
+	if issuer.fetches != 2 {
+		t.Fatalf("expected the JWKS to be refetched, got %d fetches", issuer.fetches)
+	}
+}
+
+func TestKeySetBackoff(t *testing.T) {
+	issuer := newFakeIssuer(t, "key-1")
+	var failing bool
+	fetches := 0
+	ks := newKeySet(func(ctx context.Context) ([]byte, error) {
+		fetches++
+		if failing {
+			return nil, errors.New("unavailable")
+		}
+		return issuer.jwks(ctx)
+	}, time.Hour)
+
+	if _, err := ks.key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Once the keys are stale and the source fails, the cached key keeps
+	// being used and the source isn't refetched until the backoff elapses.
+	failing = true
+	ks.fetchedAt = time.Now().Add(-2 * time.Hour)
+	ks.attemptedAt = ks.fetchedAt
+	for range 3 {
+		if _, err := ks.key(context.Background(), "key-1"); err != nil {
+			t.Fatalf("expected the cached key to be used, got error: %s", err)
+		}
+	}
+	if fetches != 2 {
+		t.Fatalf("expected 2 fetches, got %d", fetches)
+	}
+	if _, err := ks.key(context.Background(), "key-2"); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+	if fetches != 2 {
+		t.Fatalf("expected an unknown key not to be fetched while backing off, got %d fetches", fetches)
+	}
+	if interval := ks.retryInterval(); interval != 2*minJWKSRefreshInterval {
+		t.Fatalf("expected the retry interval to be %s, got %s", 2*minJWKSRefreshInterval, interval)
+	}
+
+	// A successful fetch resets the backoff.
+	failing = false
+	ks.attemptedAt = time.Now().Add(-ks.retryInterval())
+	if _, err := ks.key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fetches != 3 {
+		t.Fatalf("expected 3 fetches, got %d", fetches)
+	}
+	if interval := ks.retryInterval(); interval != minJWKSRefreshInterval {
+		t.Fatalf("expected the retry interval to be reset to %s, got %s", minJWKSRefreshInterval, interval)
+	}
+}
+
+func TestKeySetCoalescesFetches(t *testing.T) {
+	issuer := newFakeIssuer(t, "key-1")
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	ks := newKeySet(func(ctx context.Context) ([]byte, error) {
+		fetches.Add(1)
+		<-release
+		return issuer.jwks(ctx)
+	}, time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ks.key(context.Background(), "key-1")
+			errs <- err
+		}()
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected concurrent lookups to share a single fetch, got %d fetches", n)
+	}
+}
+
+func TestIssuerRouter(t *testing.T) {
+	domain, err := NewTrustDomain("linkerd", "cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oidcIss := "https://oidc.example.com"
+	issuer := newFakeIssuer(t, "vm")
+	oidc := newJWTValidator(oidcIss, LinkerdAudienceKey, newKeySet(issuer.jwks, time.Hour), claimIdentity("sub", nil, domain))
+	fallback := &staticValidator{"fallback"}
+	router := NewIssuerRouter(fallback, map[string]identity.Validator{oidcIss: oidc})
+
+	id := "vm-1.mixed-env.externalworkload.identity.linkerd.cluster.local"
+	got, err := router.Validate(context.Background(), issuer.sign(t, "vm", saClaims(oidcIss, LinkerdAudienceKey, id)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != id {
+		t.Fatalf("expected identity %s, got %s", id, got)
+	}
+
+	_, err = router.Validate(context.Background(), issuer.sign(t, "vm", saClaims(oidcIss, LinkerdAudienceKey, "vm-1.example.com")))
+	var ite identity.InvalidToken
+	if !errors.As(err, &ite) {
+		t.Fatalf("expected an identity outside of the trust domain to be rejected, got %v", err)
+	}
+
+	got, err = router.Validate(context.Background(), []byte("opaque-token"))
+	if err != nil || got != "fallback" {
+		t.Fatalf("expected other tokens to be handled by the fallback validator, got %s, %v", got, err)
+	}
+}
+
+func TestClaimIdentity(t *testing.T) {
+	domain, err := NewTrustDomain("linkerd", "cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityOf := claimIdentity("sub", []string{"mixed-env"}, domain)
+
+	for _, tc := range []struct {
+		id    string
+		valid bool
+	}{
+		{"vm-1.mixed-env.externalworkload.identity.linkerd.cluster.local", true},
+		{"vm-1.other.externalworkload.identity.linkerd.cluster.local", false},
+		{"linkerd-identity.linkerd.serviceaccount.identity.linkerd.cluster.local", false},
+		{"default.mixed-env.serviceaccount.identity.linkerd.cluster.local", false},
+		{"vm-1.identity.linkerd.cluster.local", false},
+		{"vm-1.example.com", false},
+	} {
+		id, err := identityOf(jwt.MapClaims{"sub": tc.id})
+		if tc.valid {
+			if err != nil || id != tc.id {
+				t.Errorf("expected %s to be accepted, got %s, %v", tc.id, id, err)
+			}
+			continue
+		}
+		var ite identity.InvalidToken
+		if !errors.As(err, &ite) {
+			t.Errorf("expected %s to be rejected, got %v", tc.id, err)
+		}
+	}
+}
+
+type staticValidator struct{ id string }
+
+func (v *staticValidator) Validate(context.Context, []byte) (string, error) {
+	return v.id, nil
+}
@@ -24,8 +24,9 @@ const (
 
 // K8sTokenValidator implements Validator for Kubernetes bearer tokens.
 type K8sTokenValidator struct {
-	authn  kauthn.AuthenticationV1Interface
-	domain *TrustDomain
+	authn          kauthn.AuthenticationV1Interface
+	domain         *TrustDomain
+	strictAudience bool
 }
 
 // NewK8sTokenValidator takes a kubernetes client and trust domain to create a
@@ -34,17 +35,21 @@ type K8sTokenValidator struct {
 // The kubernetes client is used immediately to validate that the client has
 // sufficient privileges to perform token reviews. An error is returned if this
 // access check fails.
+//
+// When strictAudience is set, tokens must carry the Linkerd audience; otherwise
+// tokens for the default audience are accepted as well.
 func NewK8sTokenValidator(
 	ctx context.Context,
 	k8s k8s.Interface,
 	domain *TrustDomain,
+	strictAudience bool,
 ) (identity.Validator, error) {
 	if err := checkAccess(ctx, k8s.AuthorizationV1()); err != nil {
 		return nil, err
 	}
 
 	authn := k8s.AuthenticationV1()
-	return &K8sTokenValidator{authn, domain}, nil
+	return &K8sTokenValidator{authn, domain, strictAudience}, nil
 }
 
 // Validate accepts kubernetes bearer tokens and returns a DNS-form linkerd ID.
@@ -56,7 +61,7 @@ func (k *K8sTokenValidator) Validate(ctx context.Context, tok []byte) (string, e
 	}
 
 	if rvw.Status.Error != "" {
-		if strings.Contains(rvw.Status.Error, "token audiences") {
+		if !k.strictAudience && strings.Contains(rvw.Status.Error, "token audiences") {
 			// Fallback to the default service account token validation if the error is realted to audiences
 			log.Debugf("TokenReview with audiences Failed. Falling back to the default")
 			tr = kauthnApi.TokenReview{Spec: kauthnApi.TokenReviewSpec{Token: string(tok), Audiences: []string{}}}
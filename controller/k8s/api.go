@@ -13,6 +13,7 @@ import (
 	l5dcrdinformer "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions"
 	ewinformers "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/externalworkload/v1beta1"
 	linkinformers "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/link/v1alpha3"
+	pcinformers "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/proxyconfig/v1alpha1"
 	srvinformers "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/server/v1beta3"
 	spinformers "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/serviceprofile/v1alpha2"
 	"github.com/linkerd/linkerd2/pkg/k8s"
@@ -34,6 +35,7 @@ import (
 	batchv1informers "k8s.io/client-go/informers/batch/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	discoveryinformers "k8s.io/client-go/informers/discovery/v1"
+	policyv1informers "k8s.io/client-go/informers/policy/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -48,6 +50,7 @@ type API struct {
 
 	cj       batchv1informers.CronJobInformer
 	cm       coreinformers.ConfigMapInformer
+	cpc      pcinformers.ClusterProxyConfigInformer
 	deploy   appv1informers.DeploymentInformer
 	ds       appv1informers.DaemonSetInformer
 	endpoint coreinformers.EndpointsInformer
@@ -57,6 +60,8 @@ type API struct {
 	link     linkinformers.LinkInformer
 	mwc      arinformers.MutatingWebhookConfigurationInformer
 	ns       coreinformers.NamespaceInformer
+	pc       pcinformers.ProxyConfigInformer
+	pdb      policyv1informers.PodDisruptionBudgetInformer
 	pod      coreinformers.PodInformer
 	rc       coreinformers.ReplicationControllerInformer
 	rs       appv1informers.ReplicaSetInformer
@@ -138,6 +143,11 @@ func initAPI(ctx context.Context, k8sClient *k8s.KubernetesAPI, dynamicClient dy
 			if err != nil {
 				return nil, err
 			}
+		case res == ProxyConfig || res == ClusterProxyConfig:
+			err := k8s.ProxyConfigsAccess(ctx, k8sClient)
+			if err != nil {
+				return nil, err
+			}
 		default:
 			continue
 		}
@@ -266,6 +276,13 @@ func newAPI(
 			api.cm = sharedInformers.Core().V1().ConfigMaps()
 			api.syncChecks = append(api.syncChecks, api.cm.Informer().HasSynced)
 			api.promGauges.addInformerSize(k8s.ConfigMap, informerLabels, api.cm.Informer())
+		case ClusterProxyConfig:
+			if l5dCrdSharedInformers == nil {
+				panic("Linkerd CRD shared informer not configured")
+			}
+			api.cpc = l5dCrdSharedInformers.Proxyconfig().V1alpha1().ClusterProxyConfigs()
+			api.syncChecks = append(api.syncChecks, api.cpc.Informer().HasSynced)
+			api.promGauges.addInformerSize(k8s.ClusterProxyConfig, informerLabels, api.cpc.Informer())
 		case Deploy:
 			api.deploy = sharedInformers.Apps().V1().Deployments()
 			api.syncChecks = append(api.syncChecks, api.deploy.Informer().HasSynced)
@@ -308,10 +325,21 @@ func newAPI(
 			api.ns = sharedInformers.Core().V1().Namespaces()
 			api.syncChecks = append(api.syncChecks, api.ns.Informer().HasSynced)
 			api.promGauges.addInformerSize(k8s.Namespace, informerLabels, api.ns.Informer())
+		case PDB:
+			api.pdb = sharedInformers.Policy().V1().PodDisruptionBudgets()
+			api.syncChecks = append(api.syncChecks, api.pdb.Informer().HasSynced)
+			api.promGauges.addInformerSize(k8s.PodDisruptionBudget, informerLabels, api.pdb.Informer())
 		case Pod:
 			api.pod = sharedInformers.Core().V1().Pods()
 			api.syncChecks = append(api.syncChecks, api.pod.Informer().HasSynced)
 			api.promGauges.addInformerSize(k8s.Pod, informerLabels, api.pod.Informer())
+		case ProxyConfig:
+			if l5dCrdSharedInformers == nil {
+				panic("Linkerd CRD shared informer not configured")
+			}
+			api.pc = l5dCrdSharedInformers.Proxyconfig().V1alpha1().ProxyConfigs()
+			api.syncChecks = append(api.syncChecks, api.pc.Informer().HasSynced)
+			api.promGauges.addInformerSize(k8s.ProxyConfig, informerLabels, api.pc.Informer())
 		case RC:
 			api.rc = sharedInformers.Core().V1().ReplicationControllers()
 			api.syncChecks = append(api.syncChecks, api.rc.Informer().HasSynced)
@@ -463,6 +491,24 @@ func (api *API) ExtWorkload() ewinformers.ExternalWorkloadInformer {
 	return api.ew
 }
 
+// ProxyConfig provides access to a shared informer and lister for
+// ProxyConfigs.
+func (api *API) ProxyConfig() pcinformers.ProxyConfigInformer {
+	if api.pc == nil {
+		panic("ProxyConfig informer not configured")
+	}
+	return api.pc
+}
+
+// ClusterProxyConfig provides access to a shared informer and lister for
+// ClusterProxyConfigs.
+func (api *API) ClusterProxyConfig() pcinformers.ClusterProxyConfigInformer {
+	if api.cpc == nil {
+		panic("ClusterProxyConfig informer not configured")
+	}
+	return api.cpc
+}
+
 // CM provides access to a shared informer and lister for ConfigMaps.
 func (api *API) CM() coreinformers.ConfigMapInformer {
 	if api.cm == nil {
@@ -532,6 +578,15 @@ func (api *API) Secret() coreinformers.SecretInformer {
 	return api.secret
 }
 
+// PDB provides access to a shared informer and lister for
+// PodDisruptionBudgets.
+func (api *API) PDB() policyv1informers.PodDisruptionBudgetInformer {
+	if api.pdb == nil {
+		panic("PDB informer not configured")
+	}
+	return api.pdb
+}
+
 // CJ provides access to a shared informer and lister for CronJobs.
 func (api *API) CJ() batchv1informers.CronJobInformer {
 	if api.cj == nil {
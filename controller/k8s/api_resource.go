@@ -3,6 +3,7 @@ package k8s
 import (
 	"strings"
 
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
 	serverv1beta3 "github.com/linkerd/linkerd2/controller/gen/apis/server/v1beta3"
 	sazv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/serverauthorization/v1beta1"
 	spv1alpha2 "github.com/linkerd/linkerd2/controller/gen/apis/serviceprofile/v1alpha2"
@@ -14,6 +15,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -45,6 +47,9 @@ const (
 	Secret
 	Srv
 	Saz
+	ProxyConfig
+	ClusterProxyConfig
+	PDB
 )
 
 // GVK returns the GroupVersionKind corresponding for the provided APIResource
@@ -54,6 +59,8 @@ func (res APIResource) GVK() (schema.GroupVersionKind, error) {
 		return batchv1.SchemeGroupVersion.WithKind("CronJob"), nil
 	case CM:
 		return v1.SchemeGroupVersion.WithKind("ConfigMap"), nil
+	case ClusterProxyConfig:
+		return pcv1alpha1.SchemeGroupVersion.WithKind(k8s.ClusterProxyConfigKind), nil
 	case Deploy:
 		return appsv1.SchemeGroupVersion.WithKind("Deployment"), nil
 	case DS:
@@ -70,8 +77,12 @@ func (res APIResource) GVK() (schema.GroupVersionKind, error) {
 		return v1.SchemeGroupVersion.WithKind("Node"), nil
 	case NS:
 		return v1.SchemeGroupVersion.WithKind("Namespace"), nil
+	case PDB:
+		return policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget"), nil
 	case Pod:
 		return v1.SchemeGroupVersion.WithKind("Pod"), nil
+	case ProxyConfig:
+		return pcv1alpha1.SchemeGroupVersion.WithKind(k8s.ProxyConfigKind), nil
 	case RC:
 		return v1.SchemeGroupVersion.WithKind("ReplicationController"), nil
 	case RS:
@@ -98,6 +109,8 @@ func GetAPIResource(kind string) (APIResource, error) {
 	switch strings.ToLower(kind) {
 	case k8s.CronJob:
 		return CJ, nil
+	case k8s.ClusterProxyConfig:
+		return ClusterProxyConfig, nil
 	case k8s.ConfigMap:
 		return CM, nil
 	case k8s.Deployment:
@@ -118,6 +131,10 @@ func GetAPIResource(kind string) (APIResource, error) {
 		return Node, nil
 	case k8s.Pod:
 		return Pod, nil
+	case k8s.PodDisruptionBudget:
+		return PDB, nil
+	case k8s.ProxyConfig:
+		return ProxyConfig, nil
 	case k8s.ReplicationController:
 		return RC, nil
 	case k8s.ReplicaSet:
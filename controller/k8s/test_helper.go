@@ -71,6 +71,9 @@ func NewFakeClusterScopedAPI(clientSet kubernetes.Interface, l5dClientSet l5dcrd
 		Srv,
 		Secret,
 		ExtWorkload,
+		ProxyConfig,
+		ClusterProxyConfig,
+		PDB,
 	)
 }
 
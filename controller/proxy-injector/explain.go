@@ -0,0 +1,63 @@
+package injector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/controller/webhook"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	log "github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Explain returns the function handling dry-run admission requests: it
+// admits the pod untouched, without recording events nor metrics, and
+// describes in the response's warnings whether it would be injected and the
+// value and source of every proxy setting
+func Explain(linkerdNamespace string, proxyConfigs, services *k8s.API) webhook.Handler {
+	return func(
+		ctx context.Context,
+		api *k8s.MetadataAPI,
+		request *admissionv1beta1.AdmissionRequest,
+		_ record.EventRecorder,
+	) (*admissionv1beta1.AdmissionResponse, error) {
+		resourceConfig, report, err := parseRequest(ctx, api, request, linkerdNamespace, proxyConfigs, services)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("explaining %s", report.ResName())
+
+		explanation, err := resourceConfig.Explain(report, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &admissionv1beta1.AdmissionResponse{
+			UID:      request.UID,
+			Allowed:  true,
+			Warnings: explanationWarnings(explanation),
+		}, nil
+	}
+}
+
+// explanationWarnings renders an explanation as admission warnings, one per
+// line
+func explanationWarnings(explanation *inject.Explanation) []string {
+	var warnings []string
+	if explanation.Injectable {
+		warnings = append(warnings, fmt.Sprintf("%s/%s would be injected", explanation.Kind, explanation.Name))
+	} else {
+		for _, reason := range explanation.SkipReasons {
+			warnings = append(warnings, fmt.Sprintf("%s/%s would be skipped: %s", explanation.Kind, explanation.Name, reason))
+		}
+	}
+	if explanation.ProxyConfig != "" {
+		warnings = append(warnings, fmt.Sprintf("using ProxyConfig %s", explanation.ProxyConfig))
+	}
+	warnings = append(warnings, explanation.Warnings...)
+	for _, setting := range explanation.Settings {
+		warnings = append(warnings, fmt.Sprintf("%s=%s (%s)", setting.Annotation, setting.Value, setting.Source))
+	}
+	return warnings
+}
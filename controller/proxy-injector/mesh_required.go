@@ -0,0 +1,156 @@
+package injector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/controller/webhook"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+	log "github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// MeshRequiredExemption matches the pods allowed to run without a proxy in
+// namespaces requiring pods to be meshed. Kind and Name refer to the pod's
+// top-level owner (e.g. a deployment), or to the pod itself when it has no
+// owner. Every field is a pattern as understood by path.Match.
+type MeshRequiredExemption struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// ParseMeshRequiredExemptions parses exemptions of the form
+// "namespace/kind/name", e.g. "payments/job/*"
+func ParseMeshRequiredExemptions(exemptions []string) ([]MeshRequiredExemption, error) {
+	parsed := []MeshRequiredExemption{}
+	for _, exemption := range exemptions {
+		exemption = strings.TrimSpace(exemption)
+		if exemption == "" {
+			continue
+		}
+		parts := strings.Split(exemption, "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid exemption %q: expected namespace/kind/name", exemption)
+		}
+		for _, part := range parts {
+			if _, err := path.Match(part, ""); err != nil {
+				return nil, fmt.Errorf("invalid exemption %q: %w", exemption, err)
+			}
+		}
+		parsed = append(parsed, MeshRequiredExemption{
+			Namespace: parts[0],
+			Kind:      strings.ToLower(parts[1]),
+			Name:      parts[2],
+		})
+	}
+	return parsed, nil
+}
+
+// Matches returns whether the exemption applies to the workload of the given
+// namespace, kind and name
+func (e MeshRequiredExemption) Matches(namespace, kind, name string) bool {
+	for _, m := range []struct{ pattern, value string }{
+		{e.Namespace, namespace},
+		{e.Kind, strings.ToLower(kind)},
+		{e.Name, name},
+	} {
+		if ok, _ := path.Match(m.pattern, m.value); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (e MeshRequiredExemption) String() string {
+	return fmt.Sprintf("%s/%s/%s", e.Namespace, e.Kind, e.Name)
+}
+
+// MeshRequired returns the function handling the validating admission
+// requests of pods created in namespaces labelled with
+// linkerd.io/mesh-required=true. As validating webhooks are called after the
+// mutating ones, those pods are expected to have been injected already: the
+// ones without a proxy are denied, unless they match one of the exemptions.
+func MeshRequired(linkerdNamespace string, exemptions []MeshRequiredExemption, proxyConfigs *k8s.API) webhook.Handler {
+	return func(
+		ctx context.Context,
+		api *k8s.MetadataAPI,
+		request *admissionv1beta1.AdmissionRequest,
+		_ record.EventRecorder,
+	) (*admissionv1beta1.AdmissionResponse, error) {
+		admitted := &admissionv1beta1.AdmissionResponse{
+			UID:     request.UID,
+			Allowed: true,
+		}
+		if strings.ToLower(request.Kind.Kind) != pkgK8s.Pod {
+			return admitted, nil
+		}
+
+		ns, err := api.Get(k8s.NS, request.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if ns.GetLabels()[pkgK8s.MeshRequiredLabel] != "true" {
+			return admitted, nil
+		}
+
+		resourceConfig, report, err := parseRequest(ctx, api, request, linkerdNamespace, proxyConfigs, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		reason := meshRequiredDenialReason(resourceConfig, report, request.Namespace, exemptions)
+		if reason == "" {
+			return admitted, nil
+		}
+		log.Infof("denied %s: %s", report.ResName(), reason)
+		return &admissionv1beta1.AdmissionResponse{
+			UID:     request.UID,
+			Allowed: false,
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonForbidden,
+				Code:    http.StatusForbidden,
+				Message: reason,
+			},
+		}, nil
+	}
+}
+
+// meshRequiredDenialReason returns the message explaining why the pod can't
+// be admitted in a namespace requiring pods to be meshed, or an empty string
+// if it has a proxy or is exempted
+func meshRequiredDenialReason(conf *inject.ResourceConfig, report *inject.Report, namespace string, exemptions []MeshRequiredExemption) string {
+	if conf.HasProxyContainer() {
+		return ""
+	}
+
+	kind, name := report.Kind, report.Name
+	subject := fmt.Sprintf("%s/%s", kind, name)
+	if owner := conf.GetOwnerRef(); owner != nil && !strings.EqualFold(owner.Kind, pkgK8s.Pod) {
+		kind, name = strings.ToLower(owner.Kind), owner.Name
+		subject = fmt.Sprintf("the pod of %s/%s", kind, name)
+	}
+	for _, exemption := range exemptions {
+		if exemption.Matches(namespace, kind, name) {
+			log.Infof("admitting %s without a proxy, as it's exempted by %s", subject, exemption)
+			return ""
+		}
+	}
+
+	cause := "the proxy injector didn't inject it"
+	if reasons := report.SkipReasons(); len(reasons) > 0 {
+		cause = strings.Join(reasons, ", ")
+	}
+	return fmt.Sprintf(
+		"namespace %s requires pods to be meshed (%s=true), but the proxy wasn't injected into %s: %s. Remove what prevents its injection, or exempt it by adding \"%s/%s/%s\" to proxyInjector.meshRequired.exemptions",
+		namespace, pkgK8s.MeshRequiredLabel, subject, cause, namespace, kind, name,
+	)
+}
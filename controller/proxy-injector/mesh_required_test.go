@@ -0,0 +1,125 @@
+package injector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseMeshRequiredExemptions(t *testing.T) {
+	exemptions, err := ParseMeshRequiredExemptions([]string{"payments/Job/*", "", " monitoring/daemonset/node-exporter"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []MeshRequiredExemption{
+		{Namespace: "payments", Kind: "job", Name: "*"},
+		{Namespace: "monitoring", Kind: "daemonset", Name: "node-exporter"},
+	}
+	if diff := deep.Equal(exemptions, expected); diff != nil {
+		t.Errorf("%+v", diff)
+	}
+
+	for _, invalid := range []string{"payments/job", "payments/job/[", "a/b/c/d"} {
+		if _, err := ParseMeshRequiredExemptions([]string{invalid}); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestMeshRequiredDenialReason(t *testing.T) {
+	values.IdentityTrustAnchorsPEM = "IdentityTrustAnchorsPEM"
+	exemptions := []MeshRequiredExemption{{Namespace: "payments", Kind: "job", Name: "*"}}
+
+	for _, tc := range []struct {
+		name        string
+		ownerKind   string
+		annotations map[string]string
+		containers  []string
+		expected    string
+	}{
+		{
+			name:       "meshed pod",
+			ownerKind:  pkgK8s.Deployment,
+			containers: []string{pkgK8s.ProxyContainerName, "app"},
+		},
+		{
+			name:        "pod opted out of injection",
+			ownerKind:   pkgK8s.Deployment,
+			annotations: map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectDisabled},
+			containers:  []string{"app"},
+			expected:    `namespace payments requires pods to be meshed (linkerd.io/mesh-required=true), but the proxy wasn't injected into the pod of deployment/web: pod has the annotation "linkerd.io/inject:disabled". Remove what prevents its injection, or exempt it by adding "payments/deployment/web" to proxyInjector.meshRequired.exemptions`,
+		},
+		{
+			name:        "exempted pod",
+			ownerKind:   pkgK8s.Job,
+			annotations: map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectDisabled},
+			containers:  []string{"app"},
+		},
+		{
+			name:       "injectable pod without a proxy",
+			ownerKind:  pkgK8s.Pod,
+			containers: []string{"app"},
+			expected:   `namespace payments requires pods to be meshed (linkerd.io/mesh-required=true), but the proxy wasn't injected into pod/web: the proxy injector didn't inject it. Remove what prevents its injection, or exempt it by adding "payments/pod/web" to proxyInjector.meshRequired.exemptions`,
+		},
+	} {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "payments", Annotations: tc.annotations},
+			}
+			for _, name := range tc.containers {
+				pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: name})
+			}
+			conf := inject.NewResourceConfig(values, inject.OriginWebhook, "linkerd").
+				WithOwnerRetriever(func(*corev1.Pod) (string, string, error) { return tc.ownerKind, "web", nil }).
+				WithNsAnnotations(map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled}).
+				WithKind("Pod")
+			report, err := conf.FromObject(pod)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if reason := meshRequiredDenialReason(conf, report, "payments", exemptions); reason != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, reason)
+			}
+		})
+	}
+}
+
+func TestMeshRequired(t *testing.T) {
+	api, err := k8s.NewFakeMetadataAPI([]string{`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: emojivoto`,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	api.Sync(nil)
+	handler := MeshRequired("linkerd", nil, nil)
+
+	for _, kind := range []string{"Pod", "Service"} {
+		request := &admissionv1beta1.AdmissionRequest{
+			UID:       "uid",
+			Namespace: "emojivoto",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: kind},
+		}
+		response, err := handler(context.Background(), api, request, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !response.Allowed {
+			t.Errorf("Expected the %s to be admitted in a namespace not requiring pods to be meshed", strings.ToLower(kind))
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/linkerd/linkerd2/pkg/inject"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -47,6 +48,15 @@ func admissionResponseLabels(owner, namespace, skip, reason, annotationAt string
 	return configLabels
 }
 
+func incAdmissionResponses(labels prometheus.Labels) {
+	counter, err := proxyInjectionAdmissionResponses.GetMetricWith(labels)
+	if err != nil {
+		log.Errorf("failed to get proxy_inject_admission_responses metric: %q", err)
+		return
+	}
+	counter.Inc()
+}
+
 func configToPrometheusLabels(conf *inject.ResourceConfig) prometheus.Labels {
 	labels := conf.GetOverriddenConfiguration()
 	promLabels := map[string]string{}
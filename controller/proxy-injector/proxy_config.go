@@ -0,0 +1,36 @@
+package injector
+
+import (
+	"fmt"
+
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// proxyConfigRetriever resolves ProxyConfigs from the given API's caches,
+// looking first for a ProxyConfig in the workload's namespace and then for a
+// ClusterProxyConfig of the same name
+func proxyConfigRetriever(api *k8s.API, ns string) inject.ProxyConfigRetrieverFunc {
+	return func(name string) (*pcv1alpha1.ProxyConfigSpec, error) {
+		if api == nil {
+			return nil, fmt.Errorf("ProxyConfigs are not being watched")
+		}
+		pc, err := api.ProxyConfig().Lister().ProxyConfigs(ns).Get(name)
+		if err == nil {
+			return &pc.Spec, nil
+		}
+		if !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		cpc, err := api.ClusterProxyConfig().Lister().Get(name)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil, fmt.Errorf("no ProxyConfig nor ClusterProxyConfig named %s found for namespace %s", name, ns)
+			}
+			return nil, err
+		}
+		return &cpc.Spec, nil
+	}
+}
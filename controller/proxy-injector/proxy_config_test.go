@@ -0,0 +1,61 @@
+package injector
+
+import (
+	"testing"
+
+	"github.com/linkerd/linkerd2/controller/k8s"
+)
+
+func TestProxyConfigRetriever(t *testing.T) {
+	api, err := k8s.NewFakeAPI(`
+apiVersion: config.linkerd.io/v1alpha1
+kind: ProxyConfig
+metadata:
+  name: small
+  namespace: emojivoto
+spec:
+  logLevel: debug`, `
+apiVersion: config.linkerd.io/v1alpha1
+kind: ClusterProxyConfig
+metadata:
+  name: small
+spec:
+  logLevel: info`, `
+apiVersion: config.linkerd.io/v1alpha1
+kind: ClusterProxyConfig
+metadata:
+  name: large
+spec:
+  logLevel: warn`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	api.Sync(nil)
+
+	for _, tc := range []struct {
+		ns, name, expectedLogLevel string
+	}{
+		{"emojivoto", "small", "debug"},
+		{"emojivoto", "large", "warn"},
+		{"books", "small", "info"},
+		{"books", "missing", ""},
+	} {
+		spec, err := proxyConfigRetriever(api, tc.ns)(tc.name)
+		if tc.expectedLogLevel == "" {
+			if err == nil {
+				t.Errorf("Expected an error resolving %s in %s", tc.name, tc.ns)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error resolving %s in %s: %s", tc.name, tc.ns, err)
+		}
+		if spec.LogLevel != tc.expectedLogLevel {
+			t.Errorf("Expected log level %q for %s in %s, got %q", tc.expectedLogLevel, tc.name, tc.ns, spec.LogLevel)
+		}
+	}
+
+	if _, err := proxyConfigRetriever(nil, "emojivoto")("small"); err == nil {
+		t.Error("Expected an error when ProxyConfigs aren't watched")
+	}
+}
@@ -0,0 +1,610 @@
+package injector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/pkg/inject"
+	pkgk8s "github.com/linkerd/linkerd2/pkg/k8s"
+	logging "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// Name of the lease resource the rollout controller will use
+	rolloutLeaseName = "linkerd-proxy-injector-rollout"
+
+	rolloutLeaseDuration      = 30 * time.Second
+	rolloutLeaseRenewDeadline = 10 * time.Second
+	rolloutLeaseRetryPeriod   = 2 * time.Second
+
+	// Delays between the retries of a restart, e.g. while it's blocked by a
+	// PodDisruptionBudget
+	rolloutBaseRetryDelay = 5 * time.Second
+	rolloutMaxRetryDelay  = 10 * time.Minute
+
+	// Max retries for a workload to be restarted
+	rolloutMaxRetries = 15
+
+	// restartedAtAnnotation is the pod template annotation set by
+	// `kubectl rollout restart`
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+	eventReasonRestarted        = "InjectionConfigRestarted"
+	eventReasonRestartDryRun    = "InjectionConfigRestartDryRun"
+	eventReasonRestartBlocked   = "InjectionConfigRestartBlocked"
+	eventReasonRestartFailed    = "InjectionConfigRestartFailed"
+	rolloutWorkloadKeySeparator = "/"
+)
+
+// errDisruptionNotAllowed is returned when a PodDisruptionBudget prevents a
+// workload from being restarted
+var errDisruptionNotAllowed = errors.New("disruption not allowed by PodDisruptionBudget")
+
+// RolloutController restarts the meshed workloads whose effective injection
+// config changed because of a change to their namespace's annotations or to
+// the ProxyConfig they refer to, so that their pods get re-injected. Restarts
+// are rate-limited and postponed while a PodDisruptionBudget covering the
+// workload's pods doesn't allow any disruption.
+type RolloutController struct {
+	k8sAPI       *k8s.API
+	proxyConfigs *k8s.API
+	recorder     record.EventRecorder
+	dryRun       bool
+	log          *logging.Entry
+	stop         chan struct{}
+	lec          leaderelection.LeaderElectionConfig
+
+	// restarts paces the restarts, while the queue's rate limiter only
+	// delays the retries of failed or postponed ones
+	restarts *rate.Limiter
+
+	// queue holds the workloads to restart. It is shut down, along with its
+	// worker, when the leadership is lost, and replaced by an empty one.
+	queue workqueue.TypedRateLimitingInterface[string]
+
+	// reasons holds why each queued workload must be restarted
+	reasons map[string]string
+
+	// handlers holds the informers' callbacks, registered while leading
+	handlers []rolloutHandler
+
+	sync.Mutex
+}
+
+type rolloutHandler struct {
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
+}
+
+// NewRolloutController creates a new controller restarting up to
+// restartsPerMinute workloads per minute. k8sAPI must watch namespaces,
+// deployments, statefulsets, daemonsets and PodDisruptionBudgets; proxyConfigs, which watches
+// ProxyConfigs and ClusterProxyConfigs, may be nil. In dry-run mode, the
+// restarts are only logged and recorded as events. The controller must be
+// started with its `Start()` method.
+func NewRolloutController(
+	k8sAPI *k8s.API,
+	proxyConfigs *k8s.API,
+	recorder record.EventRecorder,
+	hostname, controllerNs string,
+	restartsPerMinute float64,
+	dryRun bool,
+	stopCh chan struct{},
+) *RolloutController {
+	rc := &RolloutController{
+		k8sAPI:       k8sAPI,
+		proxyConfigs: proxyConfigs,
+		recorder:     recorder,
+		queue:        newRolloutQueue(),
+		dryRun:       dryRun,
+		stop:         stopCh,
+		reasons:      map[string]string{},
+		log: logging.WithFields(logging.Fields{
+			"component": "rollout-controller",
+		}),
+		restarts: rate.NewLimiter(rate.Limit(restartsPerMinute/60), 1),
+	}
+
+	rc.lec = leaderelection.LeaderElectionConfig{
+		ReleaseOnCancel: true,
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      rolloutLeaseName,
+				Namespace: controllerNs,
+			},
+			Client: k8sAPI.Client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: hostname,
+			},
+		},
+		LeaseDuration: rolloutLeaseDuration,
+		RenewDeadline: rolloutLeaseRenewDeadline,
+		RetryPeriod:   rolloutLeaseRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				rc.Lock()
+				queue := rc.queue
+				rc.Unlock()
+				if err := rc.addHandlers(); err != nil {
+					panic(fmt.Sprintf("failed to register event handlers: %v", err))
+				}
+				// The context is canceled when the leadership is lost.
+				go rc.processQueue(ctx, queue)
+			},
+			OnStoppedLeading: func() {
+				if err := rc.removeHandlers(); err != nil {
+					panic(fmt.Sprintf("failed to de-register event handlers: %v", err))
+				}
+				// The restarts queued while leading are left to the next
+				// leader, which detects config changes on its own.
+				rc.Lock()
+				rc.queue.ShutDown()
+				rc.queue = newRolloutQueue()
+				rc.reasons = map[string]string{}
+				rc.Unlock()
+				rc.log.Infof("%s released lease", hostname)
+			},
+			OnNewLeader: func(identity string) {
+				if identity == hostname {
+					rc.log.Infof("%s acquired lease", hostname)
+				}
+			},
+		},
+	}
+
+	return rc
+}
+
+func newRolloutQueue() workqueue.TypedRateLimitingInterface[string] {
+	rateLimiter := workqueue.NewTypedItemExponentialFailureRateLimiter[string](rolloutBaseRetryDelay, rolloutMaxRetryDelay)
+	return workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[string]{
+		Name: "rollout_controller_workqueue",
+	})
+}
+
+// Start runs the leader elector in the background. Only the leader watches for
+// changes and runs the processing loop restarting workloads.
+//
+// Warning: Do not call Start() more than once
+func (rc *RolloutController) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			leaderelection.RunOrDie(ctx, rc.lec)
+			select {
+			case <-ctx.Done():
+				rc.log.Trace("leader election client received shutdown signal")
+				return
+			default:
+			}
+		}
+	}()
+
+	go func() {
+		<-rc.stop
+		rc.Lock()
+		rc.queue.ShutDown()
+		rc.Unlock()
+		cancel()
+		rc.log.Info("received shutdown signal")
+	}()
+}
+
+// addHandlers registers the callbacks detecting injection config changes.
+// Objects listed when registering them are ignored, as only subsequent
+// changes trigger restarts.
+func (rc *RolloutController) addHandlers() error {
+	rc.Lock()
+	defer rc.Unlock()
+
+	add := func(informer cache.SharedIndexInformer, handler cache.ResourceEventHandler) error {
+		registration, err := informer.AddEventHandler(handler)
+		if err != nil {
+			return err
+		}
+		rc.handlers = append(rc.handlers, rolloutHandler{informer, registration})
+		return nil
+	}
+
+	if err := add(rc.k8sAPI.NS().Informer(), cache.ResourceEventHandlerFuncs{
+		UpdateFunc: rc.onNamespaceUpdate,
+	}); err != nil {
+		return err
+	}
+
+	if rc.proxyConfigs == nil {
+		return nil
+	}
+	handler := cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if !isInInitialList {
+				rc.onProxyConfigUpdate(nil, obj)
+			}
+		},
+		UpdateFunc: rc.onProxyConfigUpdate,
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			rc.onProxyConfigUpdate(obj, nil)
+		},
+	}
+	if err := add(rc.proxyConfigs.ProxyConfig().Informer(), handler); err != nil {
+		return err
+	}
+	return add(rc.proxyConfigs.ClusterProxyConfig().Informer(), handler)
+}
+
+func (rc *RolloutController) removeHandlers() error {
+	rc.Lock()
+	defer rc.Unlock()
+	for _, h := range rc.handlers {
+		if err := h.informer.RemoveEventHandler(h.registration); err != nil {
+			return err
+		}
+	}
+	rc.handlers = nil
+	return nil
+}
+
+func (rc *RolloutController) onNamespaceUpdate(oldObj, newObj interface{}) {
+	oldNs, ok := oldObj.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+	newNs, ok := newObj.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+	if newNs.Labels[pkgk8s.AdmissionWebhookLabel] == pkgk8s.Disabled {
+		return
+	}
+	before := inject.EffectiveInjectionAnnotations(nil, oldNs.Annotations, nil)
+	after := inject.EffectiveInjectionAnnotations(nil, newNs.Annotations, nil)
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	reason := fmt.Sprintf("the injection config of namespace %s changed", newNs.Name)
+	rc.enqueueChanged(newNs.Name, reason, func(template *corev1.PodTemplateSpec) (map[string]string, map[string]string) {
+		return rc.effectiveAnnotations(template, oldNs.Annotations, newNs.Name, nil),
+			rc.effectiveAnnotations(template, newNs.Annotations, newNs.Name, nil)
+	})
+}
+
+// onProxyConfigUpdate handles the creation, update and deletion of
+// ProxyConfigs and ClusterProxyConfigs, oldObj and newObj being nil
+// respectively when they're created and deleted
+func (rc *RolloutController) onProxyConfigUpdate(oldObj, newObj interface{}) {
+	var namespace, name, kind string
+	var oldSpec, newSpec *pcv1alpha1.ProxyConfigSpec
+	for i, obj := range []interface{}{oldObj, newObj} {
+		var spec *pcv1alpha1.ProxyConfigSpec
+		switch pc := obj.(type) {
+		case *pcv1alpha1.ProxyConfig:
+			namespace, name, kind, spec = pc.Namespace, pc.Name, pkgk8s.ProxyConfigKind, &pc.Spec
+		case *pcv1alpha1.ClusterProxyConfig:
+			namespace, name, kind, spec = "", pc.Name, pkgk8s.ClusterProxyConfigKind, &pc.Spec
+		case nil:
+		default:
+			return
+		}
+		if i == 0 {
+			oldSpec = spec
+		} else {
+			newSpec = spec
+		}
+	}
+	if name == "" || reflect.DeepEqual(oldSpec, newSpec) {
+		return
+	}
+
+	var namespaces []string
+	if namespace != "" {
+		namespaces = []string{namespace}
+	} else {
+		nsList, err := rc.k8sAPI.NS().Lister().List(labels.Everything())
+		if err != nil {
+			rc.log.Errorf("failed to list namespaces: %s", err)
+			return
+		}
+		for _, ns := range nsList {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+
+	reason := fmt.Sprintf("%s %s changed", kind, name)
+	for _, ns := range namespaces {
+		nsObj, err := rc.k8sAPI.NS().Lister().Get(ns)
+		if err != nil {
+			continue
+		}
+		if nsObj.Labels[pkgk8s.AdmissionWebhookLabel] == pkgk8s.Disabled {
+			continue
+		}
+		before, after := oldSpec, newSpec
+		if namespace == "" {
+			// ClusterProxyConfigs are shadowed by ProxyConfigs of the same
+			// name in the workload's namespace
+			if _, err := rc.proxyConfigs.ProxyConfig().Lister().ProxyConfigs(ns).Get(name); err == nil {
+				continue
+			}
+		} else {
+			// The ProxyConfig shadows, or stops shadowing, the
+			// ClusterProxyConfig of the same name
+			if cpc, err := rc.proxyConfigs.ClusterProxyConfig().Lister().Get(name); err == nil {
+				if before == nil {
+					before = &cpc.Spec
+				}
+				if after == nil {
+					after = &cpc.Spec
+				}
+			}
+		}
+
+		nsAnn := nsObj.Annotations
+		rc.enqueueChanged(ns, reason, func(template *corev1.PodTemplateSpec) (map[string]string, map[string]string) {
+			if proxyConfigName(template, nsAnn) != name {
+				return nil, nil
+			}
+			return inject.EffectiveInjectionAnnotations(template.Annotations, nsAnn, before),
+				inject.EffectiveInjectionAnnotations(template.Annotations, nsAnn, after)
+		})
+	}
+}
+
+// effectiveAnnotations returns the effective injection annotations of pods
+// created from the template, resolving the ProxyConfig they refer to unless
+// it's given
+func (rc *RolloutController) effectiveAnnotations(
+	template *corev1.PodTemplateSpec,
+	nsAnn map[string]string,
+	namespace string,
+	spec *pcv1alpha1.ProxyConfigSpec,
+) map[string]string {
+	if spec == nil {
+		if name := proxyConfigName(template, nsAnn); name != "" {
+			resolved, err := proxyConfigRetriever(rc.proxyConfigs, namespace)(name)
+			if err == nil {
+				spec = resolved
+			}
+		}
+	}
+	return inject.EffectiveInjectionAnnotations(template.Annotations, nsAnn, spec)
+}
+
+// proxyConfigName returns the name of the ProxyConfig pods created from the
+// template refer to
+func proxyConfigName(template *corev1.PodTemplateSpec, nsAnn map[string]string) string {
+	if name, ok := template.Annotations[pkgk8s.ProxyConfigAnnotation]; ok {
+		return name
+	}
+	return nsAnn[pkgk8s.ProxyConfigAnnotation]
+}
+
+// enqueueChanged queues for a restart the workloads of the namespace whose
+// injection annotations, as computed before and after the change by diff,
+// differ while enabling injection either before or after the change
+func (rc *RolloutController) enqueueChanged(
+	namespace, reason string,
+	diff func(*corev1.PodTemplateSpec) (map[string]string, map[string]string),
+) {
+	for kind, template := range rc.workloadTemplates(namespace) {
+		if _, ok := template.Labels[pkgk8s.ControllerComponentLabel]; ok {
+			continue
+		}
+		before, after := diff(template)
+		if reflect.DeepEqual(before, after) || (!inject.InjectionEnabled(before) && !inject.InjectionEnabled(after)) {
+			continue
+		}
+		rc.enqueue(kind, reason)
+	}
+}
+
+// workloadTemplates returns the pod templates of the namespace's workloads
+// which can be restarted, indexed by their key
+func (rc *RolloutController) workloadTemplates(namespace string) map[string]*corev1.PodTemplateSpec {
+	templates := map[string]*corev1.PodTemplateSpec{}
+	deploys, err := rc.k8sAPI.Deploy().Lister().Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		rc.log.Errorf("failed to list deployments in namespace %s: %s", namespace, err)
+	}
+	for _, d := range deploys {
+		templates[workloadKey(pkgk8s.Deployment, namespace, d.Name)] = &d.Spec.Template
+	}
+	statefulsets, err := rc.k8sAPI.SS().Lister().StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		rc.log.Errorf("failed to list statefulsets in namespace %s: %s", namespace, err)
+	}
+	for _, s := range statefulsets {
+		templates[workloadKey(pkgk8s.StatefulSet, namespace, s.Name)] = &s.Spec.Template
+	}
+	daemonsets, err := rc.k8sAPI.DS().Lister().DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		rc.log.Errorf("failed to list daemonsets in namespace %s: %s", namespace, err)
+	}
+	for _, d := range daemonsets {
+		templates[workloadKey(pkgk8s.DaemonSet, namespace, d.Name)] = &d.Spec.Template
+	}
+	return templates
+}
+
+func (rc *RolloutController) enqueue(key, reason string) {
+	rc.Lock()
+	rc.reasons[key] = reason
+	queue := rc.queue
+	rc.Unlock()
+	rc.log.Debugf("queuing %s for a restart: %s", key, reason)
+	queue.Add(key)
+}
+
+func workloadKey(kind, namespace, name string) string {
+	return strings.Join([]string{kind, namespace, name}, rolloutWorkloadKeySeparator)
+}
+
+// processQueue pops workloads off the queue and restarts them until the queue
+// is shut down or the context, canceled when the leadership is lost, is done
+func (rc *RolloutController) processQueue(ctx context.Context, queue workqueue.TypedRateLimitingInterface[string]) {
+	for {
+		key, quit := queue.Get()
+		if quit {
+			rc.log.Trace("queue received shutdown signal")
+			return
+		}
+		if ctx.Err() != nil {
+			queue.Done(key)
+			rc.log.Trace("no longer leading, not restarting workloads")
+			return
+		}
+
+		err := rc.restart(ctx, key)
+		rc.handleError(queue, err, key)
+		queue.Done(key)
+	}
+}
+
+// handleError retries failed restarts according to the queue's rate limiting
+// algorithm, until the retry budget is exhausted
+func (rc *RolloutController) handleError(queue workqueue.TypedRateLimitingInterface[string], err error, key string) {
+	if err == nil || queue.NumRequeues(key) >= rolloutMaxRetries {
+		if err != nil {
+			rc.log.Errorf("dropping %s out of the queue: %s", key, err)
+		}
+		queue.Forget(key)
+		rc.Lock()
+		delete(rc.reasons, key)
+		rc.Unlock()
+		return
+	}
+
+	if errors.Is(err, errDisruptionNotAllowed) {
+		rc.log.Infof("postponing the restart of %s: %s", key, err)
+	} else {
+		rc.log.Errorf("failed to restart %s, retrying: %s", key, err)
+	}
+	queue.AddRateLimited(key)
+}
+
+// restart restarts the workload as `kubectl rollout restart` would, unless a
+// PodDisruptionBudget prevents it or the controller is in dry-run mode
+func (rc *RolloutController) restart(ctx context.Context, key string) error {
+	parts := strings.Split(key, rolloutWorkloadKeySeparator)
+	if len(parts) != 3 {
+		return nil
+	}
+	kind, namespace, name := parts[0], parts[1], parts[2]
+
+	rc.Lock()
+	reason := rc.reasons[key]
+	rc.Unlock()
+
+	obj, template, err := rc.getWorkload(kind, namespace, name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := rc.checkDisruptionBudgets(namespace, template); err != nil {
+		if errors.Is(err, errDisruptionNotAllowed) {
+			rc.recorder.Eventf(obj, corev1.EventTypeNormal, eventReasonRestartBlocked, "Restart postponed after %s: %s", reason, err)
+		}
+		return err
+	}
+
+	if err := rc.restarts.Wait(ctx); err != nil {
+		return err
+	}
+
+	if rc.dryRun {
+		rc.log.Infof("would restart %s/%s %s: %s", namespace, kind, name, reason)
+		rc.recorder.Eventf(obj, corev1.EventTypeNormal, eventReasonRestartDryRun, "Would restart the %s (dry run): %s", kind, reason)
+		return nil
+	}
+
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`, restartedAtAnnotation, time.Now().Format(time.RFC3339))
+	apps := rc.k8sAPI.Client.AppsV1()
+	switch kind {
+	case pkgk8s.Deployment:
+		_, err = apps.Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	case pkgk8s.StatefulSet:
+		_, err = apps.StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	case pkgk8s.DaemonSet:
+		_, err = apps.DaemonSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	}
+	if err != nil {
+		rc.recorder.Eventf(obj, corev1.EventTypeWarning, eventReasonRestartFailed, "Failed to restart the %s: %s", kind, err)
+		return err
+	}
+
+	rc.log.Infof("restarted %s/%s %s: %s", namespace, kind, name, reason)
+	rc.recorder.Eventf(obj, corev1.EventTypeNormal, eventReasonRestarted, "Restarted the %s: %s", kind, reason)
+	return nil
+}
+
+func (rc *RolloutController) getWorkload(kind, namespace, name string) (runtime.Object, *corev1.PodTemplateSpec, error) {
+	switch kind {
+	case pkgk8s.Deployment:
+		d, err := rc.k8sAPI.Deploy().Lister().Deployments(namespace).Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return d, &d.Spec.Template, nil
+	case pkgk8s.StatefulSet:
+		s, err := rc.k8sAPI.SS().Lister().StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, &s.Spec.Template, nil
+	case pkgk8s.DaemonSet:
+		d, err := rc.k8sAPI.DS().Lister().DaemonSets(namespace).Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return d, &d.Spec.Template, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported workload kind %s", kind)
+}
+
+// checkDisruptionBudgets returns errDisruptionNotAllowed if a
+// PodDisruptionBudget selecting the template's pods doesn't currently allow
+// any disruption. This is only a pre-check against the informer's cache:
+// rollout restarts go through the workload controllers, which don't honor
+// PodDisruptionBudgets, so a budget may still be exceeded if it changes
+// after the check or while the rollout progresses.
+func (rc *RolloutController) checkDisruptionBudgets(namespace string, template *corev1.PodTemplateSpec) error {
+	pdbs, err := rc.k8sAPI.PDB().Lister().PodDisruptionBudgets(namespace).List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(template.Labels)) {
+			continue
+		}
+		if pdb.Status.DisruptionsAllowed < 1 {
+			return fmt.Errorf("%w %s", errDisruptionNotAllowed, pdb.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package injector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	"github.com/linkerd/linkerd2/controller/k8s"
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+var rolloutTestResources = []string{`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: emojivoto`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: emojivoto
+spec:
+  template:
+    metadata:
+      labels:
+        app: web`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: voting
+  namespace: emojivoto
+spec:
+  template:
+    metadata:
+      labels:
+        app: voting
+      annotations:
+        linkerd.io/inject: disabled`, `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: emoji
+  namespace: emojivoto
+spec:
+  template:
+    metadata:
+      labels:
+        app: emoji
+      annotations:
+        config.linkerd.io/proxy-config: small`, `
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: emoji
+  namespace: emojivoto
+spec:
+  selector:
+    matchLabels:
+      app: emoji
+status:
+  disruptionsAllowed: 0`, `
+apiVersion: config.linkerd.io/v1alpha1
+kind: ProxyConfig
+metadata:
+  name: small
+  namespace: emojivoto
+spec:
+  logLevel: debug`,
+}
+
+func newTestRolloutController(t *testing.T, dryRun bool) (*RolloutController, *record.FakeRecorder) {
+	t.Helper()
+	api, err := k8s.NewFakeAPI(rolloutTestResources...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	api.Sync(nil)
+	recorder := record.NewFakeRecorder(10)
+	return NewRolloutController(api, api, recorder, "test", "linkerd", 60, dryRun, make(chan struct{})), recorder
+}
+
+func namespace(ann map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "emojivoto", Annotations: ann}}
+}
+
+func TestRolloutControllerNamespaceUpdate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		old, new map[string]string
+		expected []string
+	}{
+		{
+			name:     "enabling injection",
+			old:      nil,
+			new:      map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled},
+			expected: []string{"deployment/emojivoto/web", "statefulset/emojivoto/emoji"},
+		},
+		{
+			name: "changing a config annotation overridden by the ProxyConfig",
+			old: map[string]string{
+				pkgK8s.ProxyInjectAnnotation:   pkgK8s.ProxyInjectEnabled,
+				pkgK8s.ProxyLogLevelAnnotation: "info",
+			},
+			new: map[string]string{
+				pkgK8s.ProxyInjectAnnotation:   pkgK8s.ProxyInjectEnabled,
+				pkgK8s.ProxyLogLevelAnnotation: "warn",
+			},
+			expected: []string{"deployment/emojivoto/web"},
+		},
+		{
+			name:     "changing a config annotation of an unmeshed namespace",
+			old:      map[string]string{pkgK8s.ProxyLogLevelAnnotation: "info"},
+			new:      map[string]string{pkgK8s.ProxyLogLevelAnnotation: "warn"},
+			expected: []string{},
+		},
+		{
+			name:     "changing an unrelated annotation",
+			old:      map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled},
+			new:      map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled, "example.com/owner": "team"},
+			expected: []string{},
+		},
+	} {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			rc, _ := newTestRolloutController(t, false)
+			rc.onNamespaceUpdate(namespace(tc.old), namespace(tc.new))
+			assertQueued(t, rc, tc.expected)
+		})
+	}
+}
+
+func TestRolloutControllerProxyConfigUpdate(t *testing.T) {
+	rc, _ := newTestRolloutController(t, false)
+	old := &pcv1alpha1.ProxyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "small", Namespace: "emojivoto"},
+		Spec:       pcv1alpha1.ProxyConfigSpec{LogLevel: "debug"},
+	}
+	updated := old.DeepCopy()
+	updated.Spec.LogLevel = "trace"
+
+	// The statefulset isn't meshed, as injection isn't enabled for it
+	rc.onProxyConfigUpdate(old, updated)
+	assertQueued(t, rc, []string{})
+
+	api := rc.k8sAPI
+	ns, err := api.Client.CoreV1().Namespaces().Get(context.Background(), "emojivoto", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.Annotations = map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled}
+	if err := api.NS().Informer().GetStore().Update(ns); err != nil {
+		t.Fatal(err)
+	}
+	rc.onProxyConfigUpdate(old, updated)
+	assertQueued(t, rc, []string{"statefulset/emojivoto/emoji"})
+	if reason := rc.reasons["statefulset/emojivoto/emoji"]; reason != "ProxyConfig small changed" {
+		t.Errorf("Unexpected reason %q", reason)
+	}
+}
+
+func TestRolloutControllerRestart(t *testing.T) {
+	t.Run("restarts the workload", func(t *testing.T) {
+		rc, recorder := newTestRolloutController(t, false)
+		rc.reasons["deployment/emojivoto/web"] = "the injection config of namespace emojivoto changed"
+		if err := rc.restart(context.Background(), "deployment/emojivoto/web"); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		deploy, err := rc.k8sAPI.Client.AppsV1().Deployments("emojivoto").Get(context.Background(), "web", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := deploy.Spec.Template.Annotations[restartedAtAnnotation]; !ok {
+			t.Errorf("Expected the %s annotation to be set, got %v", restartedAtAnnotation, deploy.Spec.Template.Annotations)
+		}
+		assertEvent(t, recorder, eventReasonRestarted)
+	})
+
+	t.Run("doesn't restart in dry-run mode", func(t *testing.T) {
+		rc, recorder := newTestRolloutController(t, true)
+		if err := rc.restart(context.Background(), "deployment/emojivoto/web"); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		deploy, err := rc.k8sAPI.Client.AppsV1().Deployments("emojivoto").Get(context.Background(), "web", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := deploy.Spec.Template.Annotations[restartedAtAnnotation]; ok {
+			t.Error("Expected the deployment not to be restarted in dry-run mode")
+		}
+		assertEvent(t, recorder, eventReasonRestartDryRun)
+	})
+
+	t.Run("is postponed by PodDisruptionBudgets", func(t *testing.T) {
+		rc, recorder := newTestRolloutController(t, false)
+		err := rc.restart(context.Background(), "statefulset/emojivoto/emoji")
+		if err == nil || !strings.Contains(err.Error(), errDisruptionNotAllowed.Error()) {
+			t.Fatalf("Expected a disruption error, got %v", err)
+		}
+		assertEvent(t, recorder, eventReasonRestartBlocked)
+	})
+
+	t.Run("ignores deleted workloads", func(t *testing.T) {
+		rc, _ := newTestRolloutController(t, false)
+		if err := rc.restart(context.Background(), "daemonset/emojivoto/missing"); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	})
+}
+
+func TestRolloutControllerLeadershipLoss(t *testing.T) {
+	rc, _ := newTestRolloutController(t, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	rc.lec.Callbacks.OnStartedLeading(ctx)
+	queue := rc.queue
+
+	cancel()
+	rc.lec.Callbacks.OnStoppedLeading()
+	if !queue.ShuttingDown() {
+		t.Error("Expected the queue to be shut down when the leadership is lost")
+	}
+	assertQueued(t, rc, nil)
+
+	// A worker whose leadership was lost doesn't restart the workloads left
+	// in its queue.
+	queue = newRolloutQueue()
+	queue.Add("deployment/emojivoto/web")
+	rc.processQueue(ctx, queue)
+	deploy, err := rc.k8sAPI.Client.AppsV1().Deployments("emojivoto").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := deploy.Spec.Template.Annotations[restartedAtAnnotation]; ok {
+		t.Error("Expected the deployment not to be restarted after the leadership is lost")
+	}
+}
+
+func assertQueued(t *testing.T, rc *RolloutController, expected []string) {
+	t.Helper()
+	if len(rc.reasons) != len(expected) || rc.queue.Len() != len(expected) {
+		t.Fatalf("Expected %v to be queued, got %v (queue length %d)", expected, rc.reasons, rc.queue.Len())
+	}
+	for _, key := range expected {
+		if _, ok := rc.reasons[key]; !ok {
+			t.Errorf("Expected %s to be queued, got %v", key, rc.reasons)
+		}
+	}
+}
+
+func assertEvent(t *testing.T, recorder *record.FakeRecorder, reason string) {
+	t.Helper()
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, reason) {
+			t.Errorf("Expected a %s event, got %q", reason, event)
+		}
+	default:
+		t.Errorf("Expected a %s event", reason)
+	}
+}
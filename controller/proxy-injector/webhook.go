@@ -21,14 +21,22 @@ import (
 )
 
 const (
-	eventTypeSkipped  = "InjectionSkipped"
-	eventTypeInjected = "Injected"
+	eventTypeSkipped            = "InjectionSkipped"
+	eventTypeInjected           = "Injected"
+	eventTypeProxyConfigMissing = "ProxyConfigNotFound"
+	eventTypeFailed             = "InjectionFailed"
+	eventTypePortsInferred      = "OpaquePortsInferred"
+	eventTypeSkipPortsInferred  = "SkipOutboundPortsInferred"
 )
 
 // Inject returns the function that produces an AdmissionResponse containing
 // the patch, if any, to apply to the pod (proxy sidecar and eventually the
-// init container to set it up)
-func Inject(linkerdNamespace string, overrider inject.ValueOverrider) webhook.Handler {
+// init container to set it up). ProxyConfigs referred to by workloads or
+// namespaces are resolved from proxyConfigs' caches; when it's nil, or a
+// ProxyConfig can't be resolved, pods are injected without it. The outbound
+// ports to skip are inferred from the services in services' cache; none are
+// when it's nil.
+func Inject(linkerdNamespace string, overrider inject.ValueOverrider, proxyConfigs, services *k8s.API) webhook.Handler {
 	return func(
 		ctx context.Context,
 		api *k8s.MetadataAPI,
@@ -40,28 +48,7 @@ func Inject(linkerdNamespace string, overrider inject.ValueOverrider) webhook.Ha
 		// Build the resource config based off the request metadata and kind of
 		// object. This is later used to build the injection report and generated
 		// patch.
-		valuesConfig, err := config.Values(pkgK8s.MountPathValuesConfig)
-		if err != nil {
-			return nil, err
-		}
-
-		caPEM, err := os.ReadFile(pkgK8s.MountPathTrustRootsPEM)
-		if err != nil {
-			return nil, err
-		}
-		valuesConfig.IdentityTrustAnchorsPEM = string(caPEM)
-
-		ns, err := api.Get(k8s.NS, request.Namespace)
-		if err != nil {
-			return nil, err
-		}
-		resourceConfig := inject.NewResourceConfig(valuesConfig, inject.OriginWebhook, linkerdNamespace).
-			WithOwnerRetriever(ownerRetriever(ctx, api, request.Namespace)).
-			WithNsAnnotations(ns.GetAnnotations()).
-			WithKind(request.Kind.Kind)
-
-		// Build the injection report.
-		report, err := resourceConfig.ParseMetaAndYAML(request.Object.Raw)
+		resourceConfig, report, err := parseRequest(ctx, api, request, linkerdNamespace, proxyConfigs, services)
 		if err != nil {
 			return nil, err
 		}
@@ -103,42 +90,39 @@ func Inject(linkerdNamespace string, overrider inject.ValueOverrider) webhook.Ha
 		if injectable {
 			resourceConfig.AppendPodAnnotation(pkgK8s.CreatedByAnnotation, fmt.Sprintf("linkerd/proxy-injector %s", version.Version))
 
-			// If namespace has annotations that do not exist on pod then copy them
-			// over to pod's template.
-			inject.AppendNamespaceAnnotations(resourceConfig.GetOverrideAnnotations(), resourceConfig.GetNsAnnotations(), resourceConfig.GetWorkloadAnnotations())
-
-			// If the pod did not inherit the opaque ports annotation from the
-			// namespace, then add the default value from the config values. This
-			// ensures that the generated patch always sets the opaque ports
-			// annotation.
-			if !resourceConfig.HasWorkloadAnnotation(pkgK8s.ProxyOpaquePortsAnnotation) {
-				defaultPorts := strings.Split(resourceConfig.GetValues().Proxy.OpaquePorts, ",")
-				filteredPorts := resourceConfig.FilterPodOpaquePorts(defaultPorts)
-				// Only add the annotation if there are ports that the pod exposes
-				// that are in the default opaque ports list.
-				if len(filteredPorts) != 0 {
-					ports := strings.Join(filteredPorts, ",")
-					resourceConfig.AppendPodAnnotation(pkgK8s.ProxyOpaquePortsAnnotation, ports)
+			// Add the config annotations inherited from the ProxyConfig and the
+			// namespace, and the default opaque ports.
+			if err := resourceConfig.AppendInjectorAnnotations(); err != nil {
+				log.Warnf("injecting %s without its ProxyConfig: %s", report.ResName(), err)
+				if parent != nil {
+					recorder.Eventf(parent, v1.EventTypeWarning, eventTypeProxyConfigMissing, "Linkerd sidecar proxy injected without its ProxyConfig: %s", err)
 				}
 			}
 
 			patchJSON, err := resourceConfig.GetPodPatch(true, overrider)
 			if err != nil {
+				if parent != nil {
+					recorder.Eventf(parent, v1.EventTypeWarning, eventTypeFailed, "Linkerd sidecar proxy injection failed: %s", err)
+				}
+				incAdmissionResponses(admissionResponseLabels(ownerKind, request.Namespace, "true", inject.InjectionError, report.InjectAnnotationAt, configLabels))
 				return nil, err
 			}
 
 			if parent != nil {
 				recorder.Event(parent, v1.EventTypeNormal, eventTypeInjected, "Linkerd sidecar proxy injected")
 			}
+			// The inferred ports only apply if the pod's opaque ports weren't
+			// set by its ProxyConfig
+			if resourceConfig.GetOverrideAnnotations()[pkgK8s.ProxyOpaquePortsAnnotation] == report.InferredOpaquePortsValue {
+				recordInferredPorts(report, parent, recorder)
+			}
+			if resourceConfig.GetOverrideAnnotations()[pkgK8s.ProxyIgnoreOutboundPortsAnnotation] == report.InferredSkipOutboundPortsValue {
+				recordInferredSkipPorts(report, parent, recorder)
+			}
 			log.Infof("injection patch generated for: %s", report.ResName())
 			log.Debugf("injection patch: %s", patchJSON)
 
-			counter, err := proxyInjectionAdmissionResponses.GetMetricWith(admissionResponseLabels(ownerKind, request.Namespace, "false", "", report.InjectAnnotationAt, configLabels))
-			if err != nil {
-				log.Errorf("failed to get proxy_inject_admission_responses metric: %q", err)
-			} else {
-				counter.Inc()
-			}
+			incAdmissionResponses(admissionResponseLabels(ownerKind, request.Namespace, "false", "", report.InjectAnnotationAt, configLabels))
 
 			patchType := admissionv1beta1.PatchTypeJSONPatch
 			return &admissionv1beta1.AdmissionResponse{
@@ -151,11 +135,7 @@ func Inject(linkerdNamespace string, overrider inject.ValueOverrider) webhook.Ha
 
 		// Resource could not be injected with the sidecar, format the reason
 		// for injection being skipped to emit an event
-		readableReasons := make([]string, 0, len(reasons))
-		for _, reason := range reasons {
-			readableReasons = append(readableReasons, inject.Reasons[reason])
-		}
-		readableMsg := strings.Join(readableReasons, ", ")
+		readableMsg := strings.Join(report.SkipReasons(), ", ")
 
 		if parent != nil {
 			recorder.Eventf(parent, v1.EventTypeNormal, eventTypeSkipped, "Linkerd sidecar proxy injection skipped: %s", readableMsg)
@@ -173,12 +153,14 @@ func Inject(linkerdNamespace string, overrider inject.ValueOverrider) webhook.Ha
 		if len(patchJSON) != 0 {
 			log.Infof("annotation patch generated for: %s", report.ResName())
 			log.Debugf("annotation patch: %s", patchJSON)
+			recordInferredPorts(report, parent, recorder)
 
-			counter, err := proxyInjectionAdmissionResponses.GetMetricWith(admissionResponseLabels(ownerKind, request.Namespace, "false", "", report.InjectAnnotationAt, configLabels))
-			if err != nil {
-				log.Errorf("failed to get proxy_inject_admission_responses metric: %q", err)
+			// The pod is only annotated: its proxy injection was skipped. Other
+			// resources (e.g. services) are recorded as admitted with a patch
+			if resourceConfig.IsPod() {
+				incAdmissionResponses(admissionResponseLabels(ownerKind, request.Namespace, "true", strings.Join(reasons, ","), report.InjectAnnotationAt, configLabels))
 			} else {
-				counter.Inc()
+				incAdmissionResponses(admissionResponseLabels(ownerKind, request.Namespace, "false", "", report.InjectAnnotationAt, configLabels))
 			}
 
 			patchType := admissionv1beta1.PatchTypeJSONPatch
@@ -196,12 +178,7 @@ func Inject(linkerdNamespace string, overrider inject.ValueOverrider) webhook.Ha
 		if resourceConfig.IsPod() {
 			log.Infof("skipped %s: %s", report.ResName(), readableMsg)
 
-			counter, err := proxyInjectionAdmissionResponses.GetMetricWith(admissionResponseLabels(ownerKind, request.Namespace, "true", strings.Join(reasons, ","), report.InjectAnnotationAt, configLabels))
-			if err != nil {
-				log.Errorf("failed to get proxy_inject_admission_responses metric: %q", err)
-			} else {
-				counter.Inc()
-			}
+			incAdmissionResponses(admissionResponseLabels(ownerKind, request.Namespace, "true", strings.Join(reasons, ","), report.InjectAnnotationAt, configLabels))
 
 			return &admissionv1beta1.AdmissionResponse{
 				UID:     request.UID,
@@ -216,9 +193,94 @@ func Inject(linkerdNamespace string, overrider inject.ValueOverrider) webhook.Ha
 	}
 }
 
+// recordInferredPorts logs the ports inferred as opaque, if any, along with
+// the annotation pinning them, and records it in an event of the parent
+func recordInferredPorts(report *inject.Report, parent *metav1.PartialObjectMetadata, recorder record.EventRecorder) {
+	if len(report.InferredOpaquePorts) == 0 {
+		return
+	}
+	ports := make([]string, len(report.InferredOpaquePorts))
+	for i, p := range report.InferredOpaquePorts {
+		ports[i] = p.String()
+	}
+	msg := fmt.Sprintf("Linkerd inferred the opaque ports %s; pin them with the annotation \"%s: %s\"",
+		strings.Join(ports, ", "), pkgK8s.ProxyOpaquePortsAnnotation, report.InferredOpaquePortsValue)
+	log.Infof("%s: %s", report.ResName(), msg)
+	if parent != nil {
+		recorder.Event(parent, v1.EventTypeNormal, eventTypePortsInferred, msg)
+	}
+}
+
+// recordInferredSkipPorts logs the outbound ports inferred to skip the proxy,
+// if any, along with the annotation pinning them, and records it in an event
+// of the parent
+func recordInferredSkipPorts(report *inject.Report, parent *metav1.PartialObjectMetadata, recorder record.EventRecorder) {
+	if len(report.InferredSkipOutboundPorts) == 0 {
+		return
+	}
+	ports := make([]string, len(report.InferredSkipOutboundPorts))
+	for i, p := range report.InferredSkipOutboundPorts {
+		ports[i] = p.String()
+	}
+	msg := fmt.Sprintf("Linkerd inferred the outbound ports to skip %s; pin them with the annotation \"%s: %s\"",
+		strings.Join(ports, ", "), pkgK8s.ProxyIgnoreOutboundPortsAnnotation, report.InferredSkipOutboundPortsValue)
+	log.Infof("%s: %s", report.ResName(), msg)
+	if parent != nil {
+		recorder.Event(parent, v1.EventTypeNormal, eventTypeSkipPortsInferred, msg)
+	}
+}
+
+// parseRequest builds the resource config and injection report of the
+// admission request's object
+func parseRequest(
+	ctx context.Context,
+	api *k8s.MetadataAPI,
+	request *admissionv1beta1.AdmissionRequest,
+	linkerdNamespace string,
+	proxyConfigs *k8s.API,
+	services *k8s.API,
+) (*inject.ResourceConfig, *inject.Report, error) {
+	valuesConfig, err := config.Values(pkgK8s.MountPathValuesConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	caPEM, err := os.ReadFile(pkgK8s.MountPathTrustRootsPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	valuesConfig.IdentityTrustAnchorsPEM = string(caPEM)
+
+	ns, err := api.Get(k8s.NS, request.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	resourceConfig := inject.NewResourceConfig(valuesConfig, inject.OriginWebhook, linkerdNamespace).
+		WithOwnerRetriever(ownerRetriever(ctx, api, request.Namespace)).
+		WithProxyConfigRetriever(proxyConfigRetriever(proxyConfigs, request.Namespace)).
+		WithServiceRetriever(serviceRetriever(services, request.Namespace)).
+		WithNsAnnotations(ns.GetAnnotations()).
+		WithKind(request.Kind.Kind)
+
+	report, err := resourceConfig.ParseMetaAndYAML(request.Object.Raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resourceConfig, report, nil
+}
+
 func ownerRetriever(ctx context.Context, api *k8s.MetadataAPI, ns string) inject.OwnerRetrieverFunc {
 	return func(p *v1.Pod) (string, string, error) {
 		p.SetNamespace(ns)
 		return api.GetOwnerKindAndName(ctx, p, true)
 	}
 }
+
+func serviceRetriever(api *k8s.API, ns string) inject.ServiceRetrieverFunc {
+	if api == nil {
+		return nil
+	}
+	return func() ([]*v1.Service, error) {
+		return api.Svc().Lister().Services(ns).List(labels.Everything())
+	}
+}
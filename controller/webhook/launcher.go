@@ -13,11 +13,13 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// Launch sets up and starts the webhook and metrics servers
+// Launch sets up and starts the webhook and metrics servers. handler is served
+// on "/" and each of the optional extraHandlers on its path.
 func Launch(
 	ctx context.Context,
 	apiresources []k8s.APIResource,
 	handler Handler,
+	extraHandlers map[string]Handler,
 	component,
 	metricsAddr string,
 	addr string,
@@ -56,7 +58,7 @@ func Launch(
 		log.Fatalf("failed to initialize Kubernetes API: %s", err)
 	}
 
-	s, err := NewServer(ctx, k8sAPI, metadataAPI, addr, pkgk8s.MountPathTLSBase, handler, component)
+	s, err := NewServer(ctx, k8sAPI, metadataAPI, addr, pkgk8s.MountPathTLSBase, handler, extraHandlers, component)
 	if err != nil {
 		//nolint:gocritic
 		log.Fatalf("failed to initialize the webhook server: %s", err)
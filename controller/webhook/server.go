@@ -33,6 +33,18 @@ type Handler func(
 	record.EventRecorder,
 ) (*admissionv1beta1.AdmissionResponse, error)
 
+const (
+	// ExplainPath is the path of the proxy injector's dry-run admission
+	// endpoint. Admission requests sent there are handled by an explainer,
+	// which must not have any side effect.
+	ExplainPath = "/explain"
+
+	// MeshRequiredPath is the path of the proxy injector's validating
+	// admission endpoint, rejecting unmeshed pods in namespaces requiring
+	// them to be meshed
+	MeshRequiredPath = "/mesh-required"
+)
+
 // Server describes the https server implementing the webhook
 type Server struct {
 	*http.Server
@@ -42,25 +54,17 @@ type Server struct {
 	recorder    record.EventRecorder
 }
 
-// NewServer returns a new instance of Server
+// NewServer returns a new instance of Server, serving handler on "/" and
+// each of the optional extraHandlers on its path.
 func NewServer(
 	ctx context.Context,
 	api *pkgk8s.KubernetesAPI,
 	metadataAPI *k8s.MetadataAPI,
 	addr, certPath string,
 	handler Handler,
+	extraHandlers map[string]Handler,
 	component string,
 ) (*Server, error) {
-	updateEvent := make(chan struct{})
-	errEvent := make(chan error)
-	watcher := pkgTls.NewFsCredsWatcher(certPath, updateEvent, errEvent).
-		WithFilePaths(pkgk8s.MountPathTLSCrtPEM, pkgk8s.MountPathTLSKeyPEM)
-	go func() {
-		if err := watcher.StartWatching(ctx); err != nil {
-			log.Fatalf("Failed to start creds watcher: %s", err)
-		}
-	}()
-
 	server := &http.Server{
 		Addr:              addr,
 		ReadHeaderTimeout: 15 * time.Second,
@@ -77,17 +81,17 @@ func NewServer(
 	})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: component})
 
-	s := getConfiguredServer(server, metadataAPI, handler, recorder)
-	if err := watcher.UpdateCert(s.certValue); err != nil {
+	s := getConfiguredServer(server, metadataAPI, handler, extraHandlers, recorder)
+	watcher := pkgTls.NewFsCredsWatcher().
+		WithFileSet(pkgTls.NewKeyPairFileSet(component+" certificate", certPath, pkgk8s.MountPathTLSCrtPEM, pkgk8s.MountPathTLSKeyPEM, s.certValue))
+	if err := watcher.LoadFileSets(); err != nil {
 		log.Fatalf("Failed to initialized certificate: %s", err)
 	}
-
-	log := log.WithFields(log.Fields{
-		"component": "proxy-injector",
-		"addr":      addr,
-	})
-
-	go watcher.ProcessEvents(log, s.certValue, updateEvent, errEvent)
+	go func() {
+		if err := watcher.StartWatching(ctx); err != nil {
+			log.Fatalf("Failed to start creds watcher: %s", err)
+		}
+	}()
 
 	return s, nil
 }
@@ -96,11 +100,19 @@ func getConfiguredServer(
 	httpServer *http.Server,
 	metadataAPI *k8s.MetadataAPI,
 	handler Handler,
+	extraHandlers map[string]Handler,
 	recorder record.EventRecorder,
 ) *Server {
 	var emptyCert atomic.Value
 	s := &Server{httpServer, metadataAPI, handler, &emptyCert, recorder}
-	s.Handler = http.HandlerFunc(s.serve)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serve)
+	for path, h := range extraHandlers {
+		mux.HandleFunc(path, func(res http.ResponseWriter, req *http.Request) {
+			s.serveWith(h, res, req)
+		})
+	}
+	s.Handler = mux
 	httpServer.TLSConfig.GetCertificate = s.getCertificate
 	return s
 }
@@ -122,6 +134,10 @@ func (s *Server) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error
 }
 
 func (s *Server) serve(res http.ResponseWriter, req *http.Request) {
+	s.serveWith(s.handler, res, req)
+}
+
+func (s *Server) serveWith(handler Handler, res http.ResponseWriter, req *http.Request) {
 	var (
 		data []byte
 		err  error
@@ -139,7 +155,7 @@ func (s *Server) serve(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	response, err := s.processReq(req.Context(), data)
+	response, err := s.processReq(req.Context(), handler, data)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusBadRequest)
 		return
@@ -157,7 +173,7 @@ func (s *Server) serve(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (s *Server) processReq(ctx context.Context, data []byte) (*admissionv1beta1.AdmissionReview, error) {
+func (s *Server) processReq(ctx context.Context, handler Handler, data []byte) (*admissionv1beta1.AdmissionReview, error) {
 	admissionReview, err := decode(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode admission review request: %w", err)
@@ -168,7 +184,7 @@ func (s *Server) processReq(ctx context.Context, data []byte) (*admissionv1beta1
 	log.Infof("received admission review request %q", admissionReview.Request.UID)
 	log.Debugf("admission request: %+v", admissionReview.Request)
 
-	admissionResponse, err := s.handler(ctx, s.metadataAPI, admissionReview.Request, s.recorder)
+	admissionResponse, err := handler(ctx, s.metadataAPI, admissionReview.Request, s.recorder)
 	if err != nil {
 		log.Error("failed to run webhook handler. Reason: ", err)
 		admissionReview.Response = &admissionv1beta1.AdmissionResponse{
@@ -28,7 +28,7 @@ func TestServe(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
-		testServer := getConfiguredServer(mockHTTPServer, k8sAPI, nil, nil)
+		testServer := getConfiguredServer(mockHTTPServer, k8sAPI, nil, nil, nil)
 		in := bytes.NewReader(nil)
 		request := httptest.NewRequest(http.MethodGet, "/", in)
 
@@ -46,7 +46,7 @@ func TestServe(t *testing.T) {
 }
 
 func TestShutdown(t *testing.T) {
-	testServer := getConfiguredServer(mockHTTPServer, nil, nil, nil)
+	testServer := getConfiguredServer(mockHTTPServer, nil, nil, nil, nil)
 
 	go func() {
 		if err := testServer.ListenAndServe(); err != nil {
@@ -403,6 +403,15 @@ func (hc *healthChecker) checkRemoteClusterConnectivity(ctx context.Context) err
 			errors = append(errors, fmt.Errorf("* secret: [%s/%s] cluster: [%s]: unable to parse api config: %w", secret.Namespace, secret.Name, link.Spec.TargetClusterName, err))
 			continue
 		}
+		expiry, err := servicemirror.CredentialsExpiry(config)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("* secret: [%s/%s] cluster: [%s]: unable to determine credentials expiry: %w", secret.Namespace, secret.Name, link.Spec.TargetClusterName, err))
+			continue
+		}
+		if !expiry.IsZero() && time.Now().After(expiry) {
+			errors = append(errors, fmt.Errorf("* secret: [%s/%s] cluster: [%s]: credentials expired at %s; run 'linkerd multicluster link-gen --rotate' to refresh them", secret.Namespace, secret.Name, link.Spec.TargetClusterName, expiry.UTC().Format(time.RFC3339)))
+			continue
+		}
 		remoteAPI, err := k8s.NewAPIForConfig(clientConfig, "", []string{}, healthcheck.RequestTimeout, 0, 0)
 		if err != nil {
 			errors = append(errors, fmt.Errorf("* secret: [%s/%s] cluster: [%s]: could not instantiate api for target cluster: %w", secret.Namespace, secret.Name, link.Spec.TargetClusterName, err))
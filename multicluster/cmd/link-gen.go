@@ -37,6 +37,7 @@ type (
 		excludedAnnotations      []string
 		excludedLabels           []string
 		enableGateway            bool
+		rotate                   bool
 		output                   string
 	}
 )
@@ -51,10 +52,17 @@ func newGenCommand() *cobra.Command {
 
 Note that the Link resource applies only in one direction. In order for two
 clusters to mirror each other, a Link resource will have to be generated for
-each cluster and applied to the other.`,
+each cluster and applied to the other.
+
+With --rotate, only the credentials secrets are output. Applying them to a
+cluster that is already linked refreshes the credentials used by the service
+mirror controller and the destination controller without re-linking.`,
 		Args: cobra.NoArgs,
 		Example: `  # To link the west cluster to east
   linkerd --context=east multicluster link-gen --cluster-name east | kubectl --context=west apply -f -
+
+  # To refresh the credentials of an existing link from west to east
+  linkerd --context=east multicluster link-gen --cluster-name east --rotate | kubectl --context=west apply -f -
   `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 
@@ -97,15 +105,24 @@ each cluster and applied to the other.`,
 				return err
 			}
 
+			separator := []byte("---\n")
+			if opts.output == "json" {
+				separator = []byte("\n")
+			}
+
+			if opts.rotate {
+				stdout.Write(creds)
+				stdout.Write(separator)
+				stdout.Write(destinationCreds)
+				stdout.Write(separator)
+				return nil
+			}
+
 			link, err := getLink(cmd.Context(), k, configMap.ClusterDomain, opts)
 			if err != nil {
 				return err
 			}
 
-			separator := []byte("---\n")
-			if opts.output == "json" {
-				separator = []byte("\n")
-			}
 			stdout.Write(creds)
 			stdout.Write(separator)
 			stdout.Write(destinationCreds)
@@ -131,6 +148,7 @@ each cluster and applied to the other.`,
 	cmd.Flags().StringSliceVar(&opts.excludedAnnotations, "excluded-annotations", opts.excludedAnnotations, "Annotations to exclude when mirroring services")
 	cmd.Flags().StringSliceVar(&opts.excludedLabels, "excluded-labels", opts.excludedLabels, "Labels to exclude when mirroring services")
 	cmd.Flags().BoolVar(&opts.enableGateway, "gateway", opts.enableGateway, "If false, allows a link to be created against a cluster that does not have a gateway service")
+	cmd.Flags().BoolVar(&opts.rotate, "rotate", opts.rotate, "Only output the credentials secrets, to refresh the credentials of an existing link")
 	cmd.Flags().StringVarP(&opts.output, "output", "o", "yaml", "Output format. One of: json|yaml")
 
 	pkgcmd.ConfigureNamespaceFlagCompletion(
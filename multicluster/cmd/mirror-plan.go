@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/linkerd/linkerd2/cli/table"
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha3"
+	servicemirror "github.com/linkerd/linkerd2/multicluster/service-mirror"
+	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
+	"github.com/linkerd/linkerd2/pkg/healthcheck"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	sm "github.com/linkerd/linkerd2/pkg/servicemirror"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+type mirrorPlanOptions struct {
+	namespace                string
+	selector                 string
+	remoteDiscoverySelector  string
+	federatedServiceSelector string
+	enableHeadlessServices   bool
+	enableNamespaceCreation  bool
+	output                   string
+}
+
+func newMirrorPlanCommand() *cobra.Command {
+	opts := &mirrorPlanOptions{
+		namespace: defaultMulticlusterNamespace,
+		output:    healthcheck.TableOutput,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "mirror-plan LINK",
+		Short: "Show the changes the service mirror would apply for a Link, without applying them",
+		Long: `Show the changes the service mirror would apply for a Link, without applying them.
+
+This command connects to the target cluster using the Link's credentials,
+evaluates the Link's selectors against the services in the target cluster and
+prints the mirror and federated Services and Endpoints that would be created,
+updated or deleted in the local cluster. Nothing is written to either cluster.
+
+The Link's selectors can be overridden with flags to preview the effect of
+changing them before updating the Link.`,
+		Args: cobra.ExactArgs(1),
+		Example: `  # Show the pending changes for the Link to the east cluster
+  linkerd multicluster mirror-plan east
+
+  # Preview the effect of a new selector before applying it to the Link
+  linkerd multicluster mirror-plan east --selector 'app in (web, api)'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.output != healthcheck.TableOutput && opts.output != healthcheck.JSONOutput {
+				return fmt.Errorf("output format %s not supported", opts.output)
+			}
+
+			k, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+
+			link, err := k.L5dCrdClient.LinkV1alpha3().Links(opts.namespace).Get(cmd.Context(), args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			if cmd.Flags().Changed("selector") {
+				if link.Spec.Selector, err = metav1.ParseToLabelSelector(opts.selector); err != nil {
+					return err
+				}
+			}
+			if cmd.Flags().Changed("remote-discovery-selector") {
+				if link.Spec.RemoteDiscoverySelector, err = metav1.ParseToLabelSelector(opts.remoteDiscoverySelector); err != nil {
+					return err
+				}
+			}
+			if cmd.Flags().Changed("federated-service-selector") {
+				if link.Spec.FederatedServiceSelector, err = metav1.ParseToLabelSelector(opts.federatedServiceSelector); err != nil {
+					return err
+				}
+			}
+
+			input, err := getMirrorPlanInput(cmd.Context(), k, link)
+			if err != nil {
+				return err
+			}
+			input.EnableHeadlessServices = opts.enableHeadlessServices
+			input.EnableNamespaceCreation = opts.enableNamespaceCreation
+
+			return renderMirrorPlan(servicemirror.Plan(input), opts.output, stdout)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.namespace, "namespace", opts.namespace, "The namespace of the Link")
+	cmd.Flags().StringVarP(&opts.selector, "selector", "l", "", "Override the Link's selector (label query) for services to mirror")
+	cmd.Flags().StringVar(&opts.remoteDiscoverySelector, "remote-discovery-selector", "", "Override the Link's selector (label query) for services to mirror in remote discovery mode")
+	cmd.Flags().StringVar(&opts.federatedServiceSelector, "federated-service-selector", "", "Override the Link's selector (label query) for federated service members")
+	cmd.Flags().BoolVar(&opts.enableHeadlessServices, "enable-headless-services", opts.enableHeadlessServices, "Assume the service mirror has headless service mirroring enabled")
+	cmd.Flags().BoolVar(&opts.enableNamespaceCreation, "enable-namespace-creation", opts.enableNamespaceCreation, "Assume the service mirror has namespace creation enabled")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", opts.output, "Output format. One of: table|json")
+
+	pkgcmd.ConfigureNamespaceFlagCompletion(
+		cmd, []string{"namespace"},
+		kubeconfigPath, impersonate, impersonateGroup, kubeContext)
+	return cmd
+}
+
+// getMirrorPlanInput reads the state of the local cluster and of the Link's
+// target cluster, using the Link's credentials for the latter.
+func getMirrorPlanInput(ctx context.Context, k *k8s.KubernetesAPI, link *v1alpha3.Link) (servicemirror.PlanInput, error) {
+	secret, err := k.CoreV1().Secrets(link.Namespace).Get(ctx, link.Spec.ClusterCredentialsSecret, metav1.GetOptions{})
+	if err != nil {
+		return servicemirror.PlanInput{}, fmt.Errorf("failed to load credentials secret %s: %w", link.Spec.ClusterCredentialsSecret, err)
+	}
+	creds, err := sm.ParseRemoteClusterSecret(secret)
+	if err != nil {
+		return servicemirror.PlanInput{}, err
+	}
+	clientConfig, err := clientcmd.RESTConfigFromKubeConfig(creds)
+	if err != nil {
+		return servicemirror.PlanInput{}, fmt.Errorf("unable to parse kube config: %w", err)
+	}
+	remoteAPI, err := k8s.NewAPIForConfig(clientConfig, "", []string{}, healthcheck.RequestTimeout, 0, 0)
+	if err != nil {
+		return servicemirror.PlanInput{}, fmt.Errorf("could not instantiate api for target cluster %s: %w", link.Spec.TargetClusterName, err)
+	}
+
+	remoteServices, err := remoteAPI.CoreV1().Services(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return servicemirror.PlanInput{}, fmt.Errorf("failed to list services in target cluster %s: %w", link.Spec.TargetClusterName, err)
+	}
+
+	mirrored := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=true", k8s.MirroredResourceLabel)}
+	localServices, err := k.CoreV1().Services(corev1.NamespaceAll).List(ctx, mirrored)
+	if err != nil {
+		return servicemirror.PlanInput{}, err
+	}
+	localEndpoints, err := k.CoreV1().Endpoints(corev1.NamespaceAll).List(ctx, mirrored)
+	if err != nil {
+		return servicemirror.PlanInput{}, err
+	}
+	namespaces, err := k.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return servicemirror.PlanInput{}, err
+	}
+	localNamespaces := []string{}
+	for _, ns := range namespaces.Items {
+		localNamespaces = append(localNamespaces, ns.Name)
+	}
+
+	return servicemirror.PlanInput{
+		Link:            link,
+		RemoteServices:  remoteServices.Items,
+		LocalServices:   localServices.Items,
+		LocalEndpoints:  localEndpoints.Items,
+		LocalNamespaces: localNamespaces,
+	}, nil
+}
+
+func renderMirrorPlan(changes []servicemirror.PlannedChange, output string, w io.Writer) error {
+	if output == healthcheck.JSONOutput {
+		out, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", out)
+		return nil
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes")
+		return nil
+	}
+
+	columns := []table.Column{
+		table.NewColumn("ACTION").WithLeftAlign(),
+		table.NewColumn("KIND").WithLeftAlign(),
+		table.NewColumn("NAMESPACE").WithLeftAlign(),
+		table.NewColumn("NAME").WithLeftAlign(),
+		table.NewColumn("REMOTE").WithLeftAlign(),
+		table.NewColumn("REASON").WithLeftAlign(),
+	}
+	rows := []table.Row{}
+	for _, change := range changes {
+		rows = append(rows, table.Row{
+			string(change.Action),
+			change.Kind,
+			change.Namespace,
+			change.Name,
+			change.Remote,
+			change.Reason,
+		})
+	}
+	t := table.NewTable(columns, rows)
+	t.Render(w)
+	return nil
+}
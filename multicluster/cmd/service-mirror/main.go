@@ -18,8 +18,10 @@ import (
 	"github.com/linkerd/linkerd2/pkg/k8s"
 	sm "github.com/linkerd/linkerd2/pkg/servicemirror"
 	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -38,8 +40,9 @@ const (
 )
 
 var (
-	clusterWatcher *servicemirror.RemoteClusterServiceWatcher
-	probeWorker    *servicemirror.ProbeWorker
+	clusterWatcher     *servicemirror.RemoteClusterServiceWatcher
+	probeWorker        *servicemirror.ProbeWorker
+	credentialsWatcher *servicemirror.CredentialsWatcher
 )
 
 // Main executes the service-mirror controller
@@ -52,6 +55,7 @@ func Main(args []string) {
 	namespace := cmd.String("namespace", "", "namespace containing Link and credentials Secret")
 	repairPeriod := cmd.Duration("endpoint-refresh-period", 1*time.Minute, "frequency to refresh endpoint resolution")
 	enableHeadlessSvc := cmd.Bool("enable-headless-services", false, "toggle support for headless service mirroring")
+	enableHeadlessDirectMirroring := cmd.Bool("enable-headless-direct-mirroring", false, "mirror the endpoints of headless services as they are, to be reached through the gateway, instead of creating a service per endpoint")
 	enableNamespaceCreation := cmd.Bool("enable-namespace-creation", false, "toggle support for namespace creation")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
 	localMirror := cmd.Bool("local-mirror", false, "watch the local cluster for federated service members")
@@ -59,6 +63,9 @@ func Main(args []string) {
 	exludedAnnotations := cmd.String("excluded-annotations", "", "Annotations to exclude when mirroring services")
 	excludedLabels := cmd.String("excluded-labels", "", "Labels to exclude when mirroring services")
 	probeSvc := cmd.String("probe-service", "", "Name of the target cluster probe service")
+	credentialsCheckPeriod := cmd.Duration("credentials-check-period", 1*time.Minute, "frequency to verify the target cluster credentials and check for their rotation")
+	localAPIWriteQPS := cmd.Float64("local-api-write-qps", 0, "maximum rate of writes to the local Kubernetes API server, in requests per second; writes to leases and events are not limited; 0 disables the limit")
+	localAPIWriteBurst := cmd.Int("local-api-write-burst", 10, "maximum burst of writes to the local Kubernetes API server")
 
 	flags.ConfigureAndParse(cmd, args)
 	linkName := cmd.Arg(0)
@@ -92,9 +99,17 @@ func Main(args []string) {
 	// controllerK8sAPI is used by the cluster watcher to manage
 	// mirror resources such as services, namespaces, and endpoints.
 
-	controllerK8sAPI, err := controllerK8s.InitializeAPI(
+	config, err := k8s.GetConfig(*kubeConfigPath, "")
+	if err != nil {
+		log.Fatalf("error configuring Kubernetes API client: %s", err)
+	}
+	controllerConfig := rest.CopyConfig(config)
+	if *localAPIWriteQPS > 0 {
+		controllerConfig.Wrap(servicemirror.NewWriteRateLimiter(float32(*localAPIWriteQPS), *localAPIWriteBurst))
+	}
+	controllerK8sAPI, err := controllerK8s.InitializeAPIForConfig(
 		rootCtx,
-		*kubeConfigPath,
+		controllerConfig,
 		false,
 		"local",
 		controllerK8s.NS,
@@ -104,10 +119,6 @@ func Main(args []string) {
 	if err != nil {
 		log.Fatalf("Failed to initialize K8s API: %s", err)
 	}
-	config, err := k8s.GetConfig(*kubeConfigPath, "")
-	if err != nil {
-		log.Fatalf("error configuring Kubernetes API client: %s", err)
-	}
 	l5dClient, err := controllerK8s.NewL5DCRDClient(config)
 	if err != nil {
 		log.Fatalf("Failed to initialize K8s API: %s", err)
@@ -153,7 +164,7 @@ func Main(args []string) {
 					ExcludedLabels:           excludedLabelList,
 				},
 			}
-			err = startLocalClusterWatcher(ctx, *namespace, controllerK8sAPI, linksAPI, *requeueLimit, *repairPeriod, *enableHeadlessSvc, *enableNamespaceCreation, link)
+			err = startLocalClusterWatcher(ctx, *namespace, controllerK8sAPI, linksAPI, *requeueLimit, *repairPeriod, *enableHeadlessSvc, *enableHeadlessDirectMirroring, *enableNamespaceCreation, link)
 			if err != nil {
 				log.Fatalf("Failed to start local cluster watcher: %s", err)
 			}
@@ -180,8 +191,9 @@ func Main(args []string) {
 				log.Fatalf("Failed to add event handler to Link informer: %s", err)
 			}
 
-			// Each time the link resource is updated, reload the config and restart the
-			// cluster watcher.
+			// Each time the link resource is updated, or its credentials
+			// are rotated, reload the config and restart the cluster watcher.
+			var currentLink *v1alpha3.Link
 			for {
 				select {
 				// ctx.Done() is a one-shot channel that will be closed once
@@ -193,14 +205,26 @@ func Main(args []string) {
 					// Before terminating the loop, stop the workers and set
 					// them to nil to release memory.
 					cleanupWorkers()
+				case <-credentialsRotated():
+					log.Infof("Credentials for link %s rotated; restarting cluster watcher", linkName)
+					results <- currentLink
 				case link := <-results:
+					currentLink = link
 					if link != nil {
 						log.Infof("Got updated link %s: %+v", linkName, link)
 						creds, err := loadCredentials(ctx, link, *namespace, controllerK8sAPI.Client)
 						if err != nil {
+							// the credentials are unusable; report it (only the
+							// first time for repeated failures) and requeue the
+							// link to give it another try
 							log.Errorf("Failed to load remote cluster credentials: %s", err)
+							servicemirror.ReportCredentialsError(ctx, linksAPI, link, servicemirror.ReasonInvalidCredentials, err)
+							cleanupWorkers()
+							time.Sleep(linkWatchRestartAfter)
+							results <- link
+							continue
 						}
-						err = restartClusterWatcher(ctx, link, *namespace, *probeSvc, creds, controllerK8sAPI, linksAPI, *requeueLimit, *repairPeriod, metrics, *enableHeadlessSvc, *enableNamespaceCreation)
+						err = restartClusterWatcher(ctx, link, *namespace, *probeSvc, creds, controllerK8sAPI, linksAPI, *requeueLimit, *repairPeriod, *credentialsCheckPeriod, metrics, *enableHeadlessSvc, *enableHeadlessDirectMirroring, *enableNamespaceCreation)
 						if err != nil {
 							// failed to restart cluster watcher; give a bit of slack
 							// and requeue the link to give it another try
@@ -290,9 +314,9 @@ election:
 }
 
 // cleanupWorkers is a utility function that checks whether the worker pointers
-// (clusterWatcher and probeWorker) are instantiated, and if they are, stops
-// their execution and sets the pointers to a nil value so that memory may be
-// garbage collected.
+// (clusterWatcher, probeWorker and credentialsWatcher) are instantiated, and if
+// they are, stops their execution and sets the pointers to a nil value so that
+// memory may be garbage collected.
 func cleanupWorkers() {
 	if clusterWatcher != nil {
 		// release, but do not clean-up services created
@@ -305,6 +329,21 @@ func cleanupWorkers() {
 		probeWorker.Stop()
 		probeWorker = nil
 	}
+
+	if credentialsWatcher != nil {
+		credentialsWatcher.Stop()
+		credentialsWatcher = nil
+	}
+}
+
+// credentialsRotated returns the channel on which the current credentials
+// watcher signals a rotation of the credentials secret. If there is no
+// credentials watcher, a nil channel is returned, which blocks forever.
+func credentialsRotated() <-chan struct{} {
+	if credentialsWatcher == nil {
+		return nil
+	}
+	return credentialsWatcher.Rotated
 }
 
 func loadCredentials(ctx context.Context, link *v1alpha3.Link, namespace string, k8sAPI kubernetes.Interface) ([]byte, error) {
@@ -313,7 +352,14 @@ func loadCredentials(ctx context.Context, link *v1alpha3.Link, namespace string,
 	if err != nil {
 		return nil, fmt.Errorf("failed to load credentials secret %s: %w", link.Spec.ClusterCredentialsSecret, err)
 	}
-	return sm.ParseRemoteClusterSecret(secret)
+	creds, err := sm.ParseRemoteClusterSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := clientcmd.RESTConfigFromKubeConfig(creds); err != nil {
+		return nil, fmt.Errorf("unable to parse kube config: %w", err)
+	}
+	return creds, nil
 }
 
 func restartClusterWatcher(
@@ -326,8 +372,10 @@ func restartClusterWatcher(
 	linksAPI *controllerK8s.API,
 	requeueLimit int,
 	repairPeriod time.Duration,
+	credentialsCheckPeriod time.Duration,
 	metrics servicemirror.ProbeMetricVecs,
 	enableHeadlessSvc bool,
+	enableHeadlessDirectMirroring bool,
 	enableNamespaceCreation bool,
 ) error {
 
@@ -368,9 +416,13 @@ func restartClusterWatcher(
 		repairPeriod,
 		ch,
 		enableHeadlessSvc,
+		enableHeadlessDirectMirroring,
 		enableNamespaceCreation,
 	)
 	if err != nil {
+		if kerrors.IsUnauthorized(err) || kerrors.IsForbidden(err) {
+			servicemirror.ReportCredentialsError(ctx, linksAPI, link, servicemirror.ReasonInvalidCredentials, err)
+		}
 		return fmt.Errorf("unable to create cluster watcher: %w", err)
 	}
 	clusterWatcher = cw
@@ -379,6 +431,17 @@ func restartClusterWatcher(
 		return fmt.Errorf("failed to start cluster watcher: %w", err)
 	}
 
+	credentialsWatcher = servicemirror.NewCredentialsWatcher(
+		link,
+		namespace,
+		creds,
+		controllerK8sAPI.Client,
+		remoteAPI.Client,
+		linksAPI,
+		credentialsCheckPeriod,
+	)
+	credentialsWatcher.Start(ctx)
+
 	return nil
 }
 
@@ -390,6 +453,7 @@ func startLocalClusterWatcher(
 	requeueLimit int,
 	repairPeriod time.Duration,
 	enableHeadlessSvc bool,
+	enableHeadlessDirectMirroring bool,
 	enableNamespaceCreation bool,
 	link v1alpha3.Link,
 ) error {
@@ -405,6 +469,7 @@ func startLocalClusterWatcher(
 		repairPeriod,
 		make(chan bool),
 		enableHeadlessSvc,
+		enableHeadlessDirectMirroring,
 		enableNamespaceCreation,
 	)
 	if err != nil {
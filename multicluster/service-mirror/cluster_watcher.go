@@ -64,6 +64,7 @@ type (
 		gatewayAlive             atomic.Bool
 		liveness                 chan bool
 		headlessServicesEnabled  bool
+		headlessDirectMirroring  bool
 		namespaceCreationEnabled bool
 
 		informerHandlers
@@ -199,6 +200,7 @@ func NewRemoteClusterServiceWatcher(
 	repairPeriod time.Duration,
 	liveness chan bool,
 	enableHeadlessSvc bool,
+	enableHeadlessDirectMirroring bool,
 	enableNamespaceCreation bool,
 ) (*RemoteClusterServiceWatcher, error) {
 	_, err := remoteAPI.Client.Discovery().ServerVersion()
@@ -230,11 +232,12 @@ func NewRemoteClusterServiceWatcher(
 		log: logging.WithFields(logging.Fields{
 			"cluster": link.Spec.TargetClusterName,
 		}),
-		eventsQueue:              workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[any]()),
+		eventsQueue:              newEventsQueue(link.Spec.TargetClusterName),
 		requeueLimit:             requeueLimit,
 		repairPeriod:             repairPeriod,
 		liveness:                 liveness,
 		headlessServicesEnabled:  enableHeadlessSvc,
+		headlessDirectMirroring:  enableHeadlessDirectMirroring,
 		namespaceCreationEnabled: enableNamespaceCreation,
 	}
 
@@ -1302,6 +1305,13 @@ func (rcsw *RemoteClusterServiceWatcher) processNextEvent(ctx context.Context) (
 		rcsw.log.Infof("Received: Stop")
 	}
 
+	start := time.Now()
+	defer func() {
+		if event != nil {
+			eventProcessingLatencyHistogram.WithLabelValues(rcsw.link.Spec.TargetClusterName, eventTypeName(event)).Observe(time.Since(start).Seconds())
+		}
+	}()
+
 	var err error
 	switch ev := event.(type) {
 	case *OnAddCalled:
@@ -1514,6 +1524,11 @@ func (rcsw *RemoteClusterServiceWatcher) Stop(cleanupState bool) {
 	rcsw.eventsQueue.ShutDown()
 	rcsw.eventBroadcaster.Shutdown()
 
+	metricLabels := prometheus.Labels{gatewayClusterName: rcsw.link.Spec.TargetClusterName}
+	eventsQueueDepthGauge.DeletePartialMatch(metricLabels)
+	eventsQueueLatencyHistogram.DeletePartialMatch(metricLabels)
+	eventProcessingLatencyHistogram.DeletePartialMatch(metricLabels)
+
 	if rcsw.svcHandler != nil {
 		if err := rcsw.remoteAPIClient.Svc().Informer().RemoveEventHandler(rcsw.svcHandler); err != nil {
 			rcsw.log.Warnf("error removing service informer handler: %s", err)
@@ -1595,8 +1610,14 @@ func (rcsw *RemoteClusterServiceWatcher) repairEndpoints(ctx context.Context) er
 
 		// Mirrors for headless services are also headless, and their
 		// Endpoints point to auxiliary services instead of pointing to
-		// the gateway, so they're skipped.
+		// the gateway, so they're skipped. With direct mirroring, their
+		// Endpoints do point to the gateway and are rebuilt from the
+		// exported Endpoints instead.
 		if svc.Spec.ClusterIP == corev1.ClusterIPNone {
+			if rcsw.headlessDirectMirroring {
+				rcsw.repairHeadlessDirectEndpoints(ctx, &svc)
+				continue
+			}
 			rcsw.log.Debugf("Skipped repairing endpoints for headless mirror %s/%s", svc.Namespace, svc.Name)
 			continue
 		}
@@ -1664,6 +1685,22 @@ func (rcsw *RemoteClusterServiceWatcher) repairEndpoints(ctx context.Context) er
 	return nil
 }
 
+// repairHeadlessDirectEndpoints rebuilds the Endpoints of a headless mirror
+// in direct mode, so that they point to the current gateway addresses.
+func (rcsw *RemoteClusterServiceWatcher) repairHeadlessDirectEndpoints(ctx context.Context, svc *corev1.Service) {
+	if _, ok := svc.Labels[consts.RemoteDiscoveryLabel]; ok {
+		return
+	}
+	exportedEndpoints, err := rcsw.remoteAPIClient.Endpoint().Lister().Endpoints(svc.Namespace).Get(rcsw.targetResourceName(svc.Name))
+	if err != nil {
+		rcsw.log.Errorf("Failed to get exported endpoints for headless mirror %s/%s: %s", svc.Namespace, svc.Name, err)
+		return
+	}
+	if err := rcsw.createOrUpdateHeadlessEndpoints(ctx, exportedEndpoints); err != nil {
+		rcsw.log.Errorf("Failed to repair endpoints for headless mirror %s/%s: %s", svc.Namespace, svc.Name, err)
+	}
+}
+
 // createOrUpdateGatewayEndpoints will create or update the gateway mirror
 // endpoints for a remote cluster. These endpoints are required for the probe
 // worker responsible for probing gateway liveness, so these endpoints are
@@ -3,6 +3,9 @@ package servicemirror
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
 	consts "github.com/linkerd/linkerd2/pkg/k8s"
 	logging "github.com/sirupsen/logrus"
@@ -32,7 +35,9 @@ import (
 // When creating endpoints for a headless mirror, we also create an endpoint
 // mirror (clusterIP) service for each of the endpoints' named addresses. If the
 // headless mirror exists and has an endpoints object, we simply update by
-// either creating or deleting endpoint mirror services.
+// either creating or deleting endpoint mirror services. When direct mirroring
+// is enabled, no endpoint mirror services are created; see
+// createOrUpdateHeadlessDirectEndpoints.
 func (rcsw *RemoteClusterServiceWatcher) createOrUpdateHeadlessEndpoints(ctx context.Context, exportedEndpoints *corev1.Endpoints) error {
 	exportedService, err := rcsw.remoteAPIClient.Svc().Lister().Services(exportedEndpoints.Namespace).Get(exportedEndpoints.Name)
 	if err != nil {
@@ -66,6 +71,10 @@ func (rcsw *RemoteClusterServiceWatcher) createOrUpdateHeadlessEndpoints(ctx con
 		}
 	}
 
+	if rcsw.headlessDirectMirroring && mirrorService.Spec.ClusterIP == corev1.ClusterIPNone {
+		return rcsw.createOrUpdateHeadlessDirectEndpoints(ctx, exportedService, exportedEndpoints)
+	}
+
 	headlessMirrorEpName := rcsw.mirrorServiceName(exportedEndpoints.Name)
 	headlessMirrorEndpoints, err := rcsw.localAPIClient.Endpoint().Lister().Endpoints(exportedEndpoints.Namespace).Get(headlessMirrorEpName)
 	if err != nil {
@@ -137,12 +146,130 @@ func (rcsw *RemoteClusterServiceWatcher) createOrUpdateHeadlessEndpoints(ctx con
 	}
 
 	headlessMirrorName := rcsw.mirrorServiceName(exportedService.Name)
+	if err := rcsw.deleteEndpointMirrorServices(ctx, exportedService.Namespace, headlessMirrorName, endpointMirrors); err != nil {
+		return err
+	}
+
+	// Update endpoints. The headless mirror may have previously been mirrored
+	// in direct mode, in which case it is no longer.
+	delete(mirrorEndpoints.Annotations, consts.HeadlessDirectMirrorAnnotation)
+	delete(mirrorEndpoints.Annotations, consts.RemoteGatewayAddresses)
+	mirrorEndpoints.Subsets = newSubsets
+	err = rcsw.updateMirrorEndpoints(ctx, mirrorEndpoints)
+	if err != nil {
+		return RetryableError{[]error{err}}
+	}
+
+	return nil
+}
+
+// createOrUpdateHeadlessDirectEndpoints reconciles the endpoints object of a
+// headless mirror when direct mirroring is enabled. Rather than creating an
+// endpoint mirror service for each named address, the named addresses of the
+// exported endpoints are mirrored as they are, so that
+// <hostname>.<headless mirror> resolves to the remote endpoint. The endpoints
+// object is annotated with the addresses and identity of the gateway, which
+// the destination controller uses to send traffic for those remote endpoints
+// through the gateway, using each hostname's fully qualified name in the
+// target cluster as the authority. This keeps the number of objects in the
+// source cluster constant regardless of the number of replicas of the
+// exported service.
+//
+// Endpoint mirror services left over from a previous mirroring of the same
+// service without direct mirroring are deleted.
+func (rcsw *RemoteClusterServiceWatcher) createOrUpdateHeadlessDirectEndpoints(ctx context.Context, exportedService *corev1.Service, exportedEndpoints *corev1.Endpoints) error {
+	gatewayAddresses, err := rcsw.resolveGatewayAddress()
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseUint(rcsw.link.Spec.GatewayPort, 10, 16); err != nil {
+		return fmt.Errorf("invalid gateway port %q: %w", rcsw.link.Spec.GatewayPort, err)
+	}
+	gateways := make([]string, len(gatewayAddresses))
+	for i, gateway := range gatewayAddresses {
+		gateways[i] = net.JoinHostPort(gateway.IP, rcsw.link.Spec.GatewayPort)
+	}
+
+	subsets := []corev1.EndpointSubset{}
+	for _, subset := range exportedEndpoints.Subsets {
+		var addresses []corev1.EndpointAddress
+		for _, address := range subset.Addresses {
+			if address.Hostname == "" {
+				continue
+			}
+			addresses = append(addresses, corev1.EndpointAddress{
+				Hostname: address.Hostname,
+				IP:       address.IP,
+			})
+		}
+		if len(addresses) == 0 {
+			continue
+		}
+		subsets = append(subsets, corev1.EndpointSubset{
+			Addresses: addresses,
+			Ports:     subset.DeepCopy().Ports,
+		})
+	}
+
+	annotations := map[string]string{
+		consts.RemoteServiceFqName:            fmt.Sprintf("%s.%s.svc.%s", exportedService.Name, exportedService.Namespace, rcsw.link.Spec.TargetClusterDomain),
+		consts.HeadlessDirectMirrorAnnotation: "true",
+		consts.RemoteGatewayAddresses:         strings.Join(gateways, ","),
+	}
+	if rcsw.link.Spec.GatewayIdentity != "" {
+		annotations[consts.RemoteGatewayIdentity] = rcsw.link.Spec.GatewayIdentity
+	}
+
+	headlessMirrorName := rcsw.mirrorServiceName(exportedService.Name)
+	if err := rcsw.deleteEndpointMirrorServices(ctx, exportedService.Namespace, headlessMirrorName, nil); err != nil {
+		return err
+	}
+
+	mirrorEndpoints, err := rcsw.localAPIClient.Endpoint().Lister().Endpoints(exportedService.Namespace).Get(headlessMirrorName)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		rcsw.log.Infof("Creating a new direct headless mirror endpoints object for headless mirror %s/%s", exportedService.Namespace, headlessMirrorName)
+		err = rcsw.createMirrorEndpoints(ctx, &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        headlessMirrorName,
+				Namespace:   exportedService.Namespace,
+				Labels:      rcsw.getMirrorEndpointLabels(exportedService),
+				Annotations: annotations,
+			},
+			Subsets: subsets,
+		})
+		if err != nil {
+			return RetryableError{[]error{err}}
+		}
+		return nil
+	}
+
+	mirrorEndpoints = mirrorEndpoints.DeepCopy()
+	if mirrorEndpoints.Annotations == nil {
+		mirrorEndpoints.Annotations = make(map[string]string)
+	}
+	for k, v := range annotations {
+		mirrorEndpoints.Annotations[k] = v
+	}
+	mirrorEndpoints.Subsets = subsets
+	if err := rcsw.updateMirrorEndpoints(ctx, mirrorEndpoints); err != nil {
+		return RetryableError{[]error{err}}
+	}
+	return nil
+}
+
+// deleteEndpointMirrorServices deletes the endpoint mirror services belonging
+// to a headless mirror, except for the ones whose name is in keep.
+func (rcsw *RemoteClusterServiceWatcher) deleteEndpointMirrorServices(ctx context.Context, namespace, headlessMirrorName string, keep map[string]struct{}) error {
 	matchLabels := map[string]string{
 		consts.MirroredHeadlessSvcNameLabel: headlessMirrorName,
 	}
 
 	// Fetch all Endpoint Mirror services that belong to the same Headless Mirror
-	endpointMirrorServices, err := rcsw.localAPIClient.Svc().Lister().Services(exportedService.Namespace).List(labels.Set(matchLabels).AsSelector())
+	endpointMirrorServices, err := rcsw.localAPIClient.Svc().Lister().Services(namespace).List(labels.Set(matchLabels).AsSelector())
 	if err != nil {
 		return err
 	}
@@ -151,7 +278,7 @@ func (rcsw *RemoteClusterServiceWatcher) createOrUpdateHeadlessEndpoints(ctx con
 	for _, service := range endpointMirrorServices {
 		// If the service's name does not show up in the up-to-date map of
 		// Endpoint Mirror names, then we should delete it.
-		if _, found := endpointMirrors[service.Name]; found {
+		if _, found := keep[service.Name]; found {
 			continue
 		}
 		err := rcsw.localAPIClient.Client.CoreV1().Services(service.Namespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
@@ -165,14 +292,6 @@ func (rcsw *RemoteClusterServiceWatcher) createOrUpdateHeadlessEndpoints(ctx con
 	if len(errors) > 0 {
 		return RetryableError{errors}
 	}
-
-	// Update endpoints
-	mirrorEndpoints.Subsets = newSubsets
-	err = rcsw.updateMirrorEndpoints(ctx, mirrorEndpoints)
-	if err != nil {
-		return RetryableError{[]error{err}}
-	}
-
 	return nil
 }
 
@@ -966,6 +966,56 @@ func TestRemoteEndpointsUpdatedMirroring(t *testing.T) {
 	}
 }
 
+func TestHeadlessDirectMirroring(t *testing.T) {
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[any]())
+	localAPI, err := updateHeadlessServiceToDirectMirroring.runEnvironment(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The endpoint mirror service created without direct mirroring is
+	// deleted and no new ones are created.
+	services, err := localAPI.Client.CoreV1().Services("ns2").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services.Items) != 1 || services.Items[0].Name != "service-one-remote" {
+		t.Fatalf("Was expecting only the headless mirror service but found %v", services.Items)
+	}
+
+	expected := headlessMirrorEndpoints("service-one-remote", "ns2", map[string]string{"lk": "lv"}, "gateway-identity", nil)
+	expected.Annotations[consts.HeadlessDirectMirrorAnnotation] = "true"
+	expected.Annotations[consts.RemoteGatewayAddresses] = "192.0.2.129:889"
+	expected.Subsets = []corev1.EndpointSubset{
+		{
+			Addresses: []corev1.EndpointAddress{
+				{
+					Hostname: "pod-0",
+					IP:       "192.0.0.1",
+				},
+				{
+					Hostname: "pod-1",
+					IP:       "192.0.0.1",
+				},
+			},
+			Ports: []corev1.EndpointPort{
+				{
+					Name:     "port1",
+					Port:     555,
+					Protocol: "TCP",
+				},
+			},
+		},
+	}
+	actual, err := localAPI.Client.CoreV1().Endpoints("ns2").Get(context.Background(), "service-one-remote", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := diffEndpoints(expected, actual); err != nil {
+		t.Fatalf("endpoint %s/%s: %v", expected.Namespace, expected.Name, err)
+	}
+}
+
 func TestHeadlessEndpointMirrorCleanupRespectsNamespaces(t *testing.T) {
 	onUpdateEndpointsCalledEnv := *multiNamespaceHeadlessMirrorEndpointsWithSameServiceName
 	onUpdateEndpointsCalledEnv.events = []interface{}{
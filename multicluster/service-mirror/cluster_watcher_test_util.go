@@ -36,10 +36,11 @@ var (
 )
 
 type testEnvironment struct {
-	events          []interface{}
-	remoteResources []string
-	localResources  []string
-	link            v1alpha3.Link
+	events                  []interface{}
+	remoteResources         []string
+	localResources          []string
+	link                    v1alpha3.Link
+	headlessDirectMirroring bool
 }
 
 func (te *testEnvironment) runEnvironment(watcherQueue workqueue.TypedRateLimitingInterface[any]) (*k8s.API, error) {
@@ -66,6 +67,7 @@ func (te *testEnvironment) runEnvironment(watcherQueue workqueue.TypedRateLimiti
 		eventsQueue:             watcherQueue,
 		requeueLimit:            0,
 		headlessServicesEnabled: true,
+		headlessDirectMirroring: te.headlessDirectMirroring,
 	}
 	watcher.setGatewayAlive(true)
 
@@ -510,6 +512,74 @@ var createExportedHeadlessService = &testEnvironment{
 	},
 }
 
+var updateHeadlessServiceToDirectMirroring = &testEnvironment{
+	events: []interface{}{
+		&OnUpdateEndpointsCalled{
+			ep: remoteHeadlessEndpointsUpdate("service-one", "ns2", "113", "192.0.0.1", []corev1.EndpointPort{
+				{
+					Name:     "port1",
+					Protocol: "TCP",
+					Port:     555,
+				},
+			}),
+		},
+	},
+	remoteResources: []string{
+		asYaml(gateway("existing-gateway", "existing-namespace", "222", "192.0.2.129", "gateway", 889, "gateway-identity", 123456, "/probe1", "120s")),
+		asYaml(remoteHeadlessService("service-one", "ns2", "111", map[string]string{"lk": "lv"},
+			[]corev1.ServicePort{
+				{
+					Name:     "port1",
+					Protocol: "TCP",
+					Port:     555,
+				},
+			})),
+	},
+	localResources: []string{
+		asYaml(namespace("ns2")),
+		asYaml(headlessMirrorService("service-one-remote", "ns2", "111", map[string]string{"lk": "lv"},
+			[]corev1.ServicePort{
+				{
+					Name:     "port1",
+					Protocol: "TCP",
+					Port:     555,
+				},
+			})),
+		asYaml(endpointMirrorService("pod-0", "service-one-remote", "ns2", "112", map[string]string{"lk": "lv"},
+			[]corev1.ServicePort{
+				{
+					Name:     "port1",
+					Protocol: "TCP",
+					Port:     555,
+				},
+			})),
+		asYaml(headlessMirrorEndpoints("service-one-remote", "ns2", map[string]string{"lk": "lv"}, "gateway-identity", []corev1.EndpointPort{
+			{
+				Name:     "port1",
+				Protocol: "TCP",
+				Port:     555,
+			},
+		})),
+	},
+	link: v1alpha3.Link{
+		Spec: v1alpha3.LinkSpec{
+			TargetClusterName:   clusterName,
+			TargetClusterDomain: clusterDomain,
+			GatewayIdentity:     "gateway-identity",
+			GatewayAddress:      "192.0.2.129",
+			GatewayPort:         "889",
+			ProbeSpec: v1alpha3.ProbeSpec{
+				Port:   "123456",
+				Path:   "/probe1",
+				Period: "120",
+			},
+			Selector:                defaultSelector,
+			RemoteDiscoverySelector: defaultRemoteDiscoverySelector,
+		},
+	},
+	headlessDirectMirroring: true,
+}
+
 var deleteMirrorService = &testEnvironment{
 	events: []interface{}{
 		&RemoteServiceUnexported{
@@ -0,0 +1,266 @@
+package servicemirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha3"
+	"github.com/linkerd/linkerd2/controller/k8s"
+	sm "github.com/linkerd/linkerd2/pkg/servicemirror"
+	"github.com/prometheus/client_golang/prometheus"
+	logging "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CredentialsValidCondition is the type of the Link condition reporting
+	// whether the credentials used to access the target cluster are valid.
+	CredentialsValidCondition = "CredentialsValid"
+
+	reasonValid = "Valid"
+	// ReasonInvalidCredentials is used when the credentials secret can't be
+	// loaded or parsed.
+	ReasonInvalidCredentials = "InvalidCredentials"
+	reasonExpired            = "Expired"
+	reasonUnauthorized       = "Unauthorized"
+	reasonForbidden          = "Forbidden"
+)
+
+// CredentialsWatcher periodically verifies that the credentials used to
+// access a target cluster are still accepted by its API server, reporting the
+// result as a Link condition and as metrics. It also detects when the
+// credentials secret is rotated, in which case it signals through the Rotated
+// channel so that the cluster watcher can be restarted with the new
+// credentials.
+type CredentialsWatcher struct {
+	link         *v1alpha3.Link
+	namespace    string
+	creds        []byte
+	expiry       time.Time
+	localClient  kubernetes.Interface
+	remoteClient kubernetes.Interface
+	linksAPI     *k8s.API
+	period       time.Duration
+	last         *v1alpha3.LinkCondition
+	Rotated      chan struct{}
+	stopCh       chan struct{}
+	log          *logging.Entry
+}
+
+// NewCredentialsWatcher creates a new CredentialsWatcher for the given Link
+// and the credentials currently in use to access its target cluster.
+func NewCredentialsWatcher(
+	link *v1alpha3.Link,
+	namespace string,
+	creds []byte,
+	localClient kubernetes.Interface,
+	remoteClient kubernetes.Interface,
+	linksAPI *k8s.API,
+	period time.Duration,
+) *CredentialsWatcher {
+	log := logging.WithFields(logging.Fields{
+		"cluster": link.Spec.TargetClusterName,
+	})
+
+	expiry, err := sm.CredentialsExpiry(creds)
+	if err != nil {
+		log.Warnf("Unable to determine credentials expiry: %s", err)
+	}
+	expirySeconds := float64(0)
+	if !expiry.IsZero() {
+		expirySeconds = float64(expiry.Unix())
+	}
+	credentialsExpiryGauge.With(prometheus.Labels{gatewayClusterName: link.Spec.TargetClusterName}).Set(expirySeconds)
+
+	return &CredentialsWatcher{
+		link:         link,
+		namespace:    namespace,
+		creds:        creds,
+		expiry:       expiry,
+		localClient:  localClient,
+		remoteClient: remoteClient,
+		linksAPI:     linksAPI,
+		period:       period,
+		Rotated:      make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		log:          log,
+	}
+}
+
+// Start this credentials watcher
+func (cw *CredentialsWatcher) Start(ctx context.Context) {
+	cw.log.Info("Starting credentials watcher")
+	go cw.run(ctx)
+}
+
+// Stop this credentials watcher
+func (cw *CredentialsWatcher) Stop() {
+	cw.log.Info("Stopping credentials watcher")
+	credentialsExpiryGauge.Delete(prometheus.Labels{gatewayClusterName: cw.link.Spec.TargetClusterName})
+	close(cw.stopCh)
+}
+
+func (cw *CredentialsWatcher) run(ctx context.Context) {
+	ticker := NewTicker(cw.period, cw.period/10)
+	defer ticker.Stop()
+
+	cw.check(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			if rotated := cw.check(ctx); rotated {
+				return
+			}
+		case <-cw.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check verifies the credentials and updates the Link condition accordingly.
+// It returns true if the credentials secret has been rotated, in which case
+// the caller is expected to restart with the new credentials.
+func (cw *CredentialsWatcher) check(ctx context.Context) bool {
+	secret, err := cw.localClient.CoreV1().Secrets(cw.namespace).Get(ctx, cw.link.Spec.ClusterCredentialsSecret, metav1.GetOptions{})
+	if err != nil {
+		cw.log.Warnf("Failed to get credentials secret %s: %s", cw.link.Spec.ClusterCredentialsSecret, err)
+	} else if creds, err := sm.ParseRemoteClusterSecret(secret); err == nil && !bytes.Equal(creds, cw.creds) {
+		cw.log.Infof("Credentials secret %s has been rotated", cw.link.Spec.ClusterCredentialsSecret)
+		select {
+		case cw.Rotated <- struct{}{}:
+		default:
+		}
+		return true
+	}
+
+	condition, ok := cw.evaluate(ctx)
+	if !ok {
+		return false
+	}
+	if condition.Status == metav1.ConditionFalse {
+		credentialsErrorCounter.With(prometheus.Labels{
+			gatewayClusterName: cw.link.Spec.TargetClusterName,
+			reasonLabelName:    condition.Reason,
+		}).Inc()
+	}
+	if cw.last != nil && cw.last.Status == condition.Status && cw.last.Reason == condition.Reason && cw.last.Message == condition.Message {
+		return false
+	}
+	if cw.last != nil && cw.last.Status == condition.Status {
+		condition.LastTransitionTime = cw.last.LastTransitionTime
+	}
+	cw.last = &condition
+	PatchLinkCondition(ctx, cw.linksAPI, cw.link, condition)
+	return false
+}
+
+// evaluate probes the target cluster's API server and builds the resulting
+// condition. The second return value is false when the probe failed for
+// reasons unrelated to the credentials (e.g. the API server is unreachable),
+// in which case the condition is left untouched.
+func (cw *CredentialsWatcher) evaluate(ctx context.Context) (v1alpha3.LinkCondition, bool) {
+	if !cw.expiry.IsZero() && time.Now().After(cw.expiry) {
+		return CredentialsCondition(false, reasonExpired, fmt.Sprintf("Credentials expired at %s", cw.expiry.UTC().Format(time.RFC3339))), true
+	}
+
+	_, err := cw.remoteClient.Discovery().ServerVersion()
+	switch {
+	case err == nil:
+		message := "Credentials do not expire"
+		if !cw.expiry.IsZero() {
+			message = fmt.Sprintf("Credentials expire at %s", cw.expiry.UTC().Format(time.RFC3339))
+		}
+		return CredentialsCondition(true, reasonValid, message), true
+	case kerrors.IsUnauthorized(err):
+		return CredentialsCondition(false, reasonUnauthorized, fmt.Sprintf("Target cluster rejected credentials: %s", err)), true
+	case kerrors.IsForbidden(err):
+		return CredentialsCondition(false, reasonForbidden, fmt.Sprintf("Target cluster rejected credentials: %s", err)), true
+	default:
+		cw.log.Debugf("Unable to reach target cluster API server: %s", err)
+		return v1alpha3.LinkCondition{}, false
+	}
+}
+
+// ReportCredentialsError records a failure to load or use the credentials of
+// the given Link, both as a metric and as a Link condition. Callers retrying
+// with the same credentials may report the same failure repeatedly, so it's
+// only recorded when the Link doesn't already carry it.
+func ReportCredentialsError(ctx context.Context, linksAPI *k8s.API, link *v1alpha3.Link, reason string, err error) {
+	if !PatchLinkCondition(ctx, linksAPI, link, CredentialsCondition(false, reason, err.Error())) {
+		return
+	}
+	credentialsErrorCounter.With(prometheus.Labels{
+		gatewayClusterName: link.Spec.TargetClusterName,
+		reasonLabelName:    reason,
+	}).Inc()
+}
+
+// CredentialsCondition builds a CredentialsValid Link condition.
+func CredentialsCondition(valid bool, reason, message string) v1alpha3.LinkCondition {
+	status := metav1.ConditionTrue
+	if !valid {
+		status = metav1.ConditionFalse
+	}
+	return v1alpha3.LinkCondition{
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+		Reason:             reason,
+		Status:             status,
+		Type:               CredentialsValidCondition,
+	}
+}
+
+// PatchLinkCondition sets the given condition in the Link's status, replacing
+// any existing condition of the same type. It returns false, leaving the Link
+// untouched, if the condition is already set with the same status, reason
+// and message, or if the Link couldn't be patched.
+func PatchLinkCondition(ctx context.Context, linksAPI *k8s.API, link *v1alpha3.Link, condition v1alpha3.LinkCondition) bool {
+	current, err := linksAPI.L5dClient.LinkV1alpha3().Links(link.GetNamespace()).Get(ctx, link.Name, metav1.GetOptions{})
+	if err != nil {
+		logging.Errorf("Failed to get link %s/%s: %s", link.Namespace, link.Name, err)
+		return false
+	}
+
+	conditions := []v1alpha3.LinkCondition{}
+	for _, c := range current.Status.Conditions {
+		if c.Type != condition.Type {
+			conditions = append(conditions, c)
+			continue
+		}
+		if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return false
+		}
+	}
+	conditions = append(conditions, condition)
+
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"conditions": conditions,
+		},
+	})
+	if err != nil {
+		logging.Errorf("Failed to marshal link conditions: %s", err)
+		return false
+	}
+	_, err = linksAPI.L5dClient.LinkV1alpha3().Links(link.GetNamespace()).Patch(
+		ctx,
+		link.Name,
+		types.MergePatchType,
+		patch,
+		metav1.PatchOptions{},
+		"status",
+	)
+	if err != nil {
+		logging.Errorf("Failed to patch link status %s/%s: %s", link.Namespace, link.Name, err)
+		return false
+	}
+	return true
+}
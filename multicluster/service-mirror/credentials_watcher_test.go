@@ -0,0 +1,141 @@
+package servicemirror
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha3"
+	"github.com/linkerd/linkerd2/controller/k8s"
+	consts "github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const credentialsSecret = `
+apiVersion: v1
+kind: Secret
+type: mirror.linkerd.io/remote-kubeconfig
+metadata:
+  name: cluster-credentials-remote
+  namespace: linkerd-multicluster
+data:
+  kubeconfig: b3JpZ2luYWw=
+`
+
+func TestCredentialsWatcher(t *testing.T) {
+	ctx := context.Background()
+
+	localAPI, err := k8s.NewFakeAPIWithL5dClient(credentialsSecret)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	remoteAPI, err := k8s.NewFakeAPI()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	link := &v1alpha3.Link{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "remote",
+			Namespace: "linkerd-multicluster",
+		},
+		Spec: v1alpha3.LinkSpec{
+			TargetClusterName:        "remote",
+			ClusterCredentialsSecret: "cluster-credentials-remote",
+		},
+	}
+	_, err = localAPI.L5dClient.LinkV1alpha3().Links(link.Namespace).Create(ctx, link, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cw := NewCredentialsWatcher(link, link.Namespace, []byte("original"), localAPI.Client, remoteAPI.Client, localAPI, time.Minute)
+
+	t.Run("reports valid credentials", func(t *testing.T) {
+		if rotated := cw.check(ctx); rotated {
+			t.Fatal("Credentials should not be reported as rotated")
+		}
+
+		current, err := localAPI.L5dClient.LinkV1alpha3().Links(link.Namespace).Get(ctx, link.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(current.Status.Conditions) != 1 {
+			t.Fatalf("Expected 1 condition, got %d", len(current.Status.Conditions))
+		}
+		condition := current.Status.Conditions[0]
+		if condition.Type != CredentialsValidCondition || condition.Status != metav1.ConditionTrue || condition.Reason != reasonValid {
+			t.Fatalf("Unexpected condition: %+v", condition)
+		}
+	})
+
+	t.Run("reports expired credentials", func(t *testing.T) {
+		cw.expiry = time.Now().Add(-time.Hour)
+		defer func() { cw.expiry = time.Time{} }()
+
+		cw.check(ctx)
+
+		current, err := localAPI.L5dClient.LinkV1alpha3().Links(link.Namespace).Get(ctx, link.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(current.Status.Conditions) != 1 {
+			t.Fatalf("Expected 1 condition, got %d", len(current.Status.Conditions))
+		}
+		condition := current.Status.Conditions[0]
+		if condition.Status != metav1.ConditionFalse || condition.Reason != reasonExpired {
+			t.Fatalf("Unexpected condition: %+v", condition)
+		}
+	})
+
+	t.Run("reports repeated credential errors once", func(t *testing.T) {
+		counter := credentialsErrorCounter.With(prometheus.Labels{
+			gatewayClusterName: link.Spec.TargetClusterName,
+			reasonLabelName:    ReasonInvalidCredentials,
+		})
+		before := testutil.ToFloat64(counter)
+
+		for range 3 {
+			ReportCredentialsError(ctx, localAPI, link, ReasonInvalidCredentials, errors.New("unable to parse kube config"))
+		}
+
+		if reported := testutil.ToFloat64(counter) - before; reported != 1 {
+			t.Fatalf("Expected the error to be reported once, got %v", reported)
+		}
+		current, err := localAPI.L5dClient.LinkV1alpha3().Links(link.Namespace).Get(ctx, link.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(current.Status.Conditions) != 1 {
+			t.Fatalf("Expected 1 condition, got %d", len(current.Status.Conditions))
+		}
+		condition := current.Status.Conditions[0]
+		if condition.Status != metav1.ConditionFalse || condition.Reason != ReasonInvalidCredentials {
+			t.Fatalf("Unexpected condition: %+v", condition)
+		}
+	})
+
+	t.Run("detects rotated credentials", func(t *testing.T) {
+		secret, err := localAPI.Client.CoreV1().Secrets(link.Namespace).Get(ctx, link.Spec.ClusterCredentialsSecret, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		secret.Data[consts.ConfigKeyName] = []byte("rotated")
+		_, err = localAPI.Client.CoreV1().Secrets(link.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if rotated := cw.check(ctx); !rotated {
+			t.Fatal("Credentials should be reported as rotated")
+		}
+		select {
+		case <-cw.Rotated:
+		default:
+			t.Fatal("Expected a rotation signal")
+		}
+	})
+}
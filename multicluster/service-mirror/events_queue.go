@@ -0,0 +1,172 @@
+package servicemirror
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+)
+
+type eventPriority int
+
+const (
+	// highPriority is the priority of the events generated by changes to
+	// resources in either cluster.
+	highPriority eventPriority = iota
+	// lowPriority is the priority of periodic housekeeping events, such as
+	// endpoint repairs and orphaned services GC, which reconcile every
+	// mirrored resource and can take a long time on large clusters.
+	lowPriority
+
+	numPriorities
+)
+
+// priorityQueue is a workqueue.Queue which hands out high priority events
+// before low priority ones, so that a full repair doesn't delay the
+// processing of changes to exported services. Events of the same priority are
+// handed out in FIFO order. It is only accessed by the workqueue while holding
+// its lock, so it does no locking of its own.
+type priorityQueue struct {
+	queues     [numPriorities][]any
+	enqueuedAt map[any]time.Time
+
+	depth   *prometheus.GaugeVec
+	latency prometheus.ObserverVec
+}
+
+func newPriorityQueue(clusterName string) *priorityQueue {
+	labels := prometheus.Labels{gatewayClusterName: clusterName}
+	return &priorityQueue{
+		enqueuedAt: make(map[any]time.Time),
+		depth:      eventsQueueDepthGauge.MustCurryWith(labels),
+		latency:    eventsQueueLatencyHistogram.MustCurryWith(labels),
+	}
+}
+
+// newEventsQueue returns a rate limiting workqueue whose events are handed
+// out by priority.
+func newEventsQueue(clusterName string) workqueue.TypedRateLimitingInterface[any] {
+	return workqueue.NewTypedRateLimitingQueueWithConfig(
+		workqueue.DefaultTypedControllerRateLimiter[any](),
+		workqueue.TypedRateLimitingQueueConfig[any]{
+			DelayingQueue: workqueue.NewTypedDelayingQueueWithConfig(workqueue.TypedDelayingQueueConfig[any]{
+				Queue: workqueue.NewTypedWithConfig(workqueue.TypedQueueConfig[any]{
+					Queue: newPriorityQueue(clusterName),
+				}),
+			}),
+		},
+	)
+}
+
+// Touch is called when an event which is already queued is added again. The
+// event keeps its position in the queue.
+func (pq *priorityQueue) Touch(any) {}
+
+func (pq *priorityQueue) Push(event any) {
+	priority := priorityOf(event)
+	pq.queues[priority] = append(pq.queues[priority], event)
+	pq.enqueuedAt[event] = time.Now()
+	pq.depth.WithLabelValues(eventTypeName(event)).Inc()
+}
+
+func (pq *priorityQueue) Len() int {
+	n := 0
+	for _, q := range pq.queues {
+		n += len(q)
+	}
+	return n
+}
+
+func (pq *priorityQueue) Pop() any {
+	for priority, q := range pq.queues {
+		if len(q) == 0 {
+			continue
+		}
+		event := q[0]
+		q[0] = nil
+		pq.queues[priority] = q[1:]
+
+		eventType := eventTypeName(event)
+		pq.depth.WithLabelValues(eventType).Dec()
+		if enqueuedAt, ok := pq.enqueuedAt[event]; ok {
+			pq.latency.WithLabelValues(eventType).Observe(time.Since(enqueuedAt).Seconds())
+			delete(pq.enqueuedAt, event)
+		}
+		return event
+	}
+	return nil
+}
+
+func priorityOf(event any) eventPriority {
+	switch event.(type) {
+	case *RepairEndpoints, *OrphanedServicesGcTriggered:
+		return lowPriority
+	default:
+		return highPriority
+	}
+}
+
+// eventTypeName returns the name of the type of an event, to be used as a
+// metric label.
+func eventTypeName(event any) string {
+	if event == nil {
+		return "none"
+	}
+	t := reflect.TypeOf(event)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// writeRateLimiter is an http.RoundTripper which limits the rate of write
+// requests sent to the Kubernetes API server. Reads, including the list and
+// watch requests of informers, are not limited, and neither are writes to
+// leases and events (see isExemptFromWriteLimit).
+type writeRateLimiter struct {
+	limiter flowcontrol.RateLimiter
+	next    http.RoundTripper
+}
+
+// NewWriteRateLimiter returns a function wrapping an http.RoundTripper so
+// that at most qps write requests per second, with bursts of up to burst
+// requests, are sent through it. It is meant to be passed to
+// rest.Config.Wrap.
+func NewWriteRateLimiter(qps float32, burst int) func(http.RoundTripper) http.RoundTripper {
+	limiter := flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &writeRateLimiter{limiter, next}
+	}
+}
+
+func (w *writeRateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if isExemptFromWriteLimit(req.URL.Path) {
+			break
+		}
+		if err := w.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return w.next.RoundTrip(req)
+}
+
+// isExemptFromWriteLimit returns whether the request path targets leases or
+// events. The client used to mirror resources is also used for leader
+// election and event recording, and those writes mustn't queue behind a burst
+// of mirror writes: a lease renewal delayed past the renew deadline would make
+// the service mirror lose its leadership.
+func isExemptFromWriteLimit(path string) bool {
+	if strings.HasPrefix(path, "/apis/coordination.k8s.io/") || strings.HasPrefix(path, "/apis/events.k8s.io/") {
+		return true
+	}
+	// Core events: /api/v1/namespaces/<namespace>/events[/<name>]
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	return len(parts) >= 5 && parts[0] == "api" && parts[2] == "namespaces" && parts[4] == "events"
+}
@@ -0,0 +1,96 @@
+package servicemirror
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestEventsQueuePriority(t *testing.T) {
+	queue := newEventsQueue("priority-test")
+	defer queue.ShutDown()
+
+	repair := &RepairEndpoints{}
+	gc := &OrphanedServicesGcTriggered{}
+	added := &OnAddCalled{svc: &corev1.Service{}}
+	deleted := &OnDeleteCalled{svc: &corev1.Service{}}
+
+	queue.Add(repair)
+	queue.Add(gc)
+	queue.Add(added)
+	queue.Add(deleted)
+
+	depth := testutil.ToFloat64(eventsQueueDepthGauge.WithLabelValues("priority-test", "RepairEndpoints"))
+	if depth != 1 {
+		t.Fatalf("Expected a queue depth of 1 for RepairEndpoints, got %f", depth)
+	}
+
+	for _, expected := range []any{added, deleted, repair, gc} {
+		event, shutdown := queue.Get()
+		if shutdown {
+			t.Fatal("Queue should not be shut down")
+		}
+		if event != expected {
+			t.Fatalf("Expected %s, got %s", eventTypeName(expected), eventTypeName(event))
+		}
+		queue.Done(event)
+	}
+
+	depth = testutil.ToFloat64(eventsQueueDepthGauge.WithLabelValues("priority-test", "RepairEndpoints"))
+	if depth != 0 {
+		t.Fatalf("Expected a queue depth of 0 for RepairEndpoints, got %f", depth)
+	}
+}
+
+func TestWriteRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewWriteRateLimiter(0.1, 1)(http.DefaultTransport)}
+	do := func(method string, path ...string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, method, server.URL+strings.Join(path, ""), nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		rsp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		rsp.Body.Close()
+		return nil
+	}
+
+	if err := do(http.MethodPost); err != nil {
+		t.Fatalf("First write should not be limited: %s", err)
+	}
+	for range 3 {
+		if err := do(http.MethodGet); err != nil {
+			t.Fatalf("Reads should not be limited: %s", err)
+		}
+	}
+	if err := do(http.MethodPut); err == nil {
+		t.Fatal("Second write should be limited")
+	}
+	for _, path := range []string{
+		"/apis/coordination.k8s.io/v1/namespaces/linkerd-multicluster/leases/service-mirror-write-target",
+		"/api/v1/namespaces/linkerd-multicluster/events",
+		"/apis/events.k8s.io/v1/namespaces/linkerd-multicluster/events/mirror.1",
+	} {
+		if err := do(http.MethodPut, path); err != nil {
+			t.Fatalf("Writes to %s should not be limited: %s", path, err)
+		}
+	}
+	if err := do(http.MethodPut, "/api/v1/namespaces/linkerd-multicluster/services/web-target"); err == nil {
+		t.Fatal("Writes to services should be limited")
+	}
+}
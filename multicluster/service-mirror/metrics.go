@@ -10,6 +10,7 @@ const (
 	gatewayClusterName   = "target_cluster_name"
 	eventTypeLabelName   = "event_type"
 	probeSuccessfulLabel = "probe_successful"
+	reasonLabelName      = "reason"
 )
 
 // ProbeMetricVecs stores metrics about about gateways collected by probe
@@ -35,7 +36,14 @@ type ProbeMetrics struct {
 	unregister     func()
 }
 
-var endpointRepairCounter *prometheus.CounterVec
+var (
+	endpointRepairCounter           *prometheus.CounterVec
+	credentialsExpiryGauge          *prometheus.GaugeVec
+	credentialsErrorCounter         *prometheus.CounterVec
+	eventsQueueDepthGauge           *prometheus.GaugeVec
+	eventsQueueLatencyHistogram     *prometheus.HistogramVec
+	eventProcessingLatencyHistogram *prometheus.HistogramVec
+)
 
 func init() {
 	endpointRepairCounter = promauto.NewCounterVec(
@@ -45,6 +53,48 @@ func init() {
 		},
 		[]string{gatewayClusterName},
 	)
+
+	credentialsExpiryGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "service_mirror_credentials_expiry_timestamp_seconds",
+			Help: "Unix time at which the credentials used to access the target cluster expire; 0 if they don't expire",
+		},
+		[]string{gatewayClusterName},
+	)
+
+	credentialsErrorCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "service_mirror_credentials_errors_total",
+			Help: "Increments when the credentials used to access the target cluster are found to be invalid",
+		},
+		[]string{gatewayClusterName, reasonLabelName},
+	)
+
+	eventsQueueDepthGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "service_mirror_events_queue_depth",
+			Help: "The number of events waiting to be processed by the service mirror controller",
+		},
+		[]string{gatewayClusterName, eventTypeLabelName},
+	)
+
+	eventsQueueLatencyHistogram = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "service_mirror_events_queue_latency_seconds",
+			Help:    "A histogram of the time events wait in the queue before being processed by the service mirror controller",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{gatewayClusterName, eventTypeLabelName},
+	)
+
+	eventProcessingLatencyHistogram = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "service_mirror_events_processing_latency_seconds",
+			Help:    "A histogram of the time taken by the service mirror controller to process events",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{gatewayClusterName, eventTypeLabelName},
+	)
 }
 
 // NewProbeMetricVecs creates a new ProbeMetricVecs.
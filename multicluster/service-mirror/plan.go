@@ -0,0 +1,261 @@
+package servicemirror
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha3"
+	consts "github.com/linkerd/linkerd2/pkg/k8s"
+	logging "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PlanAction is the action the service mirror controller would take on a
+// local resource.
+type PlanAction string
+
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpdate PlanAction = "update"
+	PlanDelete PlanAction = "delete"
+	PlanSkip   PlanAction = "skip"
+)
+
+type (
+	// PlannedChange is a change the service mirror controller would apply to
+	// a resource in the local cluster.
+	PlannedChange struct {
+		Action    PlanAction `json:"action"`
+		Kind      string     `json:"kind"`
+		Namespace string     `json:"namespace"`
+		Name      string     `json:"name"`
+		Remote    string     `json:"remote,omitempty"`
+		Reason    string     `json:"reason"`
+	}
+
+	// PlanInput holds the state of both clusters a plan is computed from.
+	PlanInput struct {
+		Link            *v1alpha3.Link
+		RemoteServices  []corev1.Service
+		LocalServices   []corev1.Service
+		LocalEndpoints  []corev1.Endpoints
+		LocalNamespaces []string
+
+		EnableHeadlessServices  bool
+		EnableNamespaceCreation bool
+	}
+)
+
+// Plan computes the changes the service mirror controller would apply to the
+// local cluster when reconciling the given remote services against the
+// Link's Selector, RemoteDiscoverySelector and FederatedServiceSelector. It
+// only inspects the given state and never writes to either cluster.
+func Plan(in PlanInput) []PlannedChange {
+	rcsw := &RemoteClusterServiceWatcher{
+		link:                     in.Link,
+		headlessServicesEnabled:  in.EnableHeadlessServices,
+		namespaceCreationEnabled: in.EnableNamespaceCreation,
+		log: logging.WithFields(logging.Fields{
+			"cluster": in.Link.Spec.TargetClusterName,
+		}),
+	}
+
+	key := func(namespace, name string) string { return namespace + "/" + name }
+	localServices := map[string]*corev1.Service{}
+	for i := range in.LocalServices {
+		svc := &in.LocalServices[i]
+		localServices[key(svc.Namespace, svc.Name)] = svc
+	}
+	localEndpoints := map[string]struct{}{}
+	for _, ep := range in.LocalEndpoints {
+		localEndpoints[key(ep.Namespace, ep.Name)] = struct{}{}
+	}
+	localNamespaces := map[string]struct{}{}
+	for _, ns := range in.LocalNamespaces {
+		localNamespaces[ns] = struct{}{}
+	}
+	remoteServices := map[string]*corev1.Service{}
+	for i := range in.RemoteServices {
+		svc := &in.RemoteServices[i]
+		remoteServices[key(svc.Namespace, svc.Name)] = svc
+	}
+
+	changes := []PlannedChange{}
+	add := func(action PlanAction, kind, namespace, name, remote, reason string) {
+		changes = append(changes, PlannedChange{
+			Action:    action,
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			Remote:    remote,
+			Reason:    reason,
+		})
+	}
+
+	for _, remote := range in.RemoteServices {
+		remoteRef := key(remote.Namespace, remote.Name)
+		mirrorName := rcsw.mirrorServiceName(remote.Name)
+		local, mirrored := localServices[key(remote.Namespace, mirrorName)]
+		_, hasEndpoints := localEndpoints[key(remote.Namespace, mirrorName)]
+
+		if rcsw.isExported(remote.Labels) || rcsw.isRemoteDiscovery(remote.Labels) {
+			remoteDiscovery := rcsw.isRemoteDiscovery(remote.Labels)
+			mode := "exported"
+			if remoteDiscovery {
+				mode = "remote discovery"
+			}
+
+			// Headless services are mirrored as headless services from their
+			// endpoints when headless support is enabled, and as regular
+			// ClusterIP services otherwise.
+			if remote.Spec.ClusterIP == corev1.ClusterIPNone && !remoteDiscovery && rcsw.headlessServicesEnabled {
+				mode = "exported headless"
+			}
+
+			_, nsExists := localNamespaces[remote.Namespace]
+			switch {
+			case !mirrored && !nsExists && !rcsw.namespaceCreationEnabled:
+				add(PlanSkip, "Service", remote.Namespace, mirrorName, remoteRef, "namespace does not exist")
+			case !mirrored:
+				if !nsExists {
+					add(PlanCreate, "Namespace", "", remote.Namespace, remoteRef, "namespace creation is enabled")
+				}
+				add(PlanCreate, "Service", remote.Namespace, mirrorName, remoteRef, fmt.Sprintf("matches %s selector", mode))
+				if !remoteDiscovery {
+					add(PlanCreate, "Endpoints", remote.Namespace, mirrorName, remoteRef, "gateway endpoints for mirror service")
+				}
+			case local.Annotations[consts.RemoteResourceVersionAnnotation] != remote.ResourceVersion:
+				add(PlanUpdate, "Service", remote.Namespace, mirrorName, remoteRef, "remote service changed")
+				switch {
+				case remoteDiscovery && hasEndpoints:
+					add(PlanDelete, "Endpoints", remote.Namespace, mirrorName, remoteRef, "service switched to remote discovery")
+				case !remoteDiscovery && !hasEndpoints:
+					add(PlanCreate, "Endpoints", remote.Namespace, mirrorName, remoteRef, "service switched to gateway mode")
+				case !remoteDiscovery:
+					add(PlanUpdate, "Endpoints", remote.Namespace, mirrorName, remoteRef, "remote service changed")
+				}
+			}
+		} else if mirrored && isMirrorOf(local, rcsw.link.Spec.TargetClusterName) {
+			add(PlanDelete, "Service", remote.Namespace, mirrorName, remoteRef, "no longer matches selector")
+			if hasEndpoints {
+				add(PlanDelete, "Endpoints", remote.Namespace, mirrorName, remoteRef, "no longer matches selector")
+			}
+		}
+
+		federatedName := rcsw.federatedServiceName(remote.Name)
+		federated, federatedExists := localServices[key(remote.Namespace, federatedName)]
+		member := fmt.Sprintf("%s@%s", remote.Name, rcsw.link.Spec.TargetClusterName)
+		if rcsw.isFederatedServiceMember(remote.Labels) {
+			switch {
+			case remote.Spec.ClusterIP == corev1.ClusterIPNone:
+				add(PlanSkip, "Service", remote.Namespace, federatedName, remoteRef, "headless service cannot join federated service")
+			case !federatedExists:
+				add(PlanCreate, "Service", remote.Namespace, federatedName, remoteRef, "matches federated service selector")
+			case !remoteDiscoveryContains(federated.Annotations[consts.RemoteDiscoveryAnnotation], member):
+				add(PlanUpdate, "Service", remote.Namespace, federatedName, remoteRef, "joins federated service")
+			}
+		} else if federatedExists {
+			if change, ok := planFederatedLeave(federated, member, remoteRef, "no longer matches federated service selector"); ok {
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	// Local resources whose remote service no longer exists are cleaned up
+	// by the orphaned services GC.
+	for _, local := range in.LocalServices {
+		if isMirrorOf(&local, rcsw.link.Spec.TargetClusterName) {
+			mirroredName := local.Name
+			if headlessName, ok := local.Labels[consts.MirroredHeadlessSvcNameLabel]; ok {
+				mirroredName = headlessName
+			}
+			remoteName := rcsw.originalResourceName(mirroredName)
+			if _, ok := remoteServices[key(local.Namespace, remoteName)]; ok {
+				continue
+			}
+			remoteRef := key(local.Namespace, remoteName)
+			add(PlanDelete, "Service", local.Namespace, local.Name, remoteRef, "remote service does not exist")
+			if _, ok := localEndpoints[key(local.Namespace, local.Name)]; ok {
+				add(PlanDelete, "Endpoints", local.Namespace, local.Name, remoteRef, "remote service does not exist")
+			}
+			continue
+		}
+
+		if _, ok := local.Labels[consts.MirroredResourceLabel]; !ok {
+			continue
+		}
+		for _, member := range splitMembers(local.Annotations[consts.RemoteDiscoveryAnnotation]) {
+			name, cluster, ok := parseMember(member)
+			if !ok || cluster != rcsw.link.Spec.TargetClusterName {
+				continue
+			}
+			if _, ok := remoteServices[key(local.Namespace, name)]; ok {
+				continue
+			}
+			local := local
+			if change, ok := planFederatedLeave(&local, member, key(local.Namespace, name), "remote service does not exist"); ok {
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Kind > changes[j].Kind
+	})
+	return changes
+}
+
+// planFederatedLeave returns the change resulting from a remote member
+// leaving a federated service: the service is deleted when it was the last
+// member, and updated otherwise.
+func planFederatedLeave(federated *corev1.Service, member, remoteRef, reason string) (PlannedChange, bool) {
+	members := splitMembers(federated.Annotations[consts.RemoteDiscoveryAnnotation])
+	if !remoteDiscoveryContains(federated.Annotations[consts.RemoteDiscoveryAnnotation], member) {
+		return PlannedChange{}, false
+	}
+	action := PlanUpdate
+	if len(members) == 1 && federated.Annotations[consts.LocalDiscoveryAnnotation] == "" {
+		action = PlanDelete
+	}
+	return PlannedChange{
+		Action:    action,
+		Kind:      "Service",
+		Namespace: federated.Namespace,
+		Name:      federated.Name,
+		Remote:    remoteRef,
+		Reason:    reason,
+	}, true
+}
+
+func isMirrorOf(svc *corev1.Service, clusterName string) bool {
+	_, mirrored := svc.Labels[consts.MirroredResourceLabel]
+	return mirrored && svc.Labels[consts.RemoteClusterNameLabel] == clusterName
+}
+
+func splitMembers(members string) []string {
+	if members == "" {
+		return nil
+	}
+	out := []string{}
+	for _, m := range strings.Split(members, ",") {
+		if m != "" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func parseMember(member string) (string, string, bool) {
+	parts := strings.Split(member, "@")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,162 @@
+package servicemirror
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha3"
+	consts "github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPlan(t *testing.T) {
+	federatedSelector, _ := metav1.ParseToLabelSelector(consts.DefaultFederatedServiceSelector + "=member")
+	link := &v1alpha3.Link{
+		Spec: v1alpha3.LinkSpec{
+			TargetClusterName:        clusterName,
+			TargetClusterDomain:      clusterDomain,
+			Selector:                 defaultSelector,
+			RemoteDiscoverySelector:  defaultRemoteDiscoverySelector,
+			FederatedServiceSelector: federatedSelector,
+		},
+	}
+	exported := map[string]string{consts.DefaultExportedServiceSelector: "true"}
+	remoteDiscovery := map[string]string{consts.DefaultExportedServiceSelector: "remote-discovery"}
+	member := map[string]string{consts.DefaultFederatedServiceSelector: "member"}
+
+	for _, tt := range []struct {
+		name     string
+		input    PlanInput
+		expected []PlannedChange
+	}{
+		{
+			name: "no changes",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "1", exported, nil)},
+				LocalServices:   []corev1.Service{*mirrorService("svc-remote", "ns1", "1", nil, nil)},
+				LocalEndpoints:  []corev1.Endpoints{{ObjectMeta: metav1.ObjectMeta{Name: "svc-remote", Namespace: "ns1"}}},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{},
+		},
+		{
+			name: "exported service without mirror",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "1", exported, nil)},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanCreate, Kind: "Service", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "matches exported selector"},
+				{Action: PlanCreate, Kind: "Endpoints", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "gateway endpoints for mirror service"},
+			},
+		},
+		{
+			name: "remote discovery service without mirror",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "1", remoteDiscovery, nil)},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanCreate, Kind: "Service", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "matches remote discovery selector"},
+			},
+		},
+		{
+			name: "missing namespace",
+			input: PlanInput{
+				RemoteServices: []corev1.Service{*remoteService("svc", "ns1", "1", exported, nil)},
+			},
+			expected: []PlannedChange{
+				{Action: PlanSkip, Kind: "Service", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "namespace does not exist"},
+			},
+		},
+		{
+			name: "missing namespace with namespace creation",
+			input: PlanInput{
+				RemoteServices:          []corev1.Service{*remoteService("svc", "ns1", "1", remoteDiscovery, nil)},
+				EnableNamespaceCreation: true,
+			},
+			expected: []PlannedChange{
+				{Action: PlanCreate, Kind: "Namespace", Namespace: "", Name: "ns1", Remote: "ns1/svc", Reason: "namespace creation is enabled"},
+				{Action: PlanCreate, Kind: "Service", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "matches remote discovery selector"},
+			},
+		},
+		{
+			name: "remote service changed",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "2", exported, nil)},
+				LocalServices:   []corev1.Service{*mirrorService("svc-remote", "ns1", "1", nil, nil)},
+				LocalEndpoints:  []corev1.Endpoints{{ObjectMeta: metav1.ObjectMeta{Name: "svc-remote", Namespace: "ns1"}}},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanUpdate, Kind: "Service", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "remote service changed"},
+				{Action: PlanUpdate, Kind: "Endpoints", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "remote service changed"},
+			},
+		},
+		{
+			name: "service no longer exported",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "1", nil, nil)},
+				LocalServices:   []corev1.Service{*mirrorService("svc-remote", "ns1", "1", nil, nil)},
+				LocalEndpoints:  []corev1.Endpoints{{ObjectMeta: metav1.ObjectMeta{Name: "svc-remote", Namespace: "ns1"}}},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanDelete, Kind: "Service", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "no longer matches selector"},
+				{Action: PlanDelete, Kind: "Endpoints", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "no longer matches selector"},
+			},
+		},
+		{
+			name: "orphaned mirror",
+			input: PlanInput{
+				LocalServices:   []corev1.Service{*mirrorService("svc-remote", "ns1", "1", nil, nil)},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanDelete, Kind: "Service", Namespace: "ns1", Name: "svc-remote", Remote: "ns1/svc", Reason: "remote service does not exist"},
+			},
+		},
+		{
+			name: "federated member without federated service",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "1", member, nil)},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanCreate, Kind: "Service", Namespace: "ns1", Name: "svc-federated", Remote: "ns1/svc", Reason: "matches federated service selector"},
+			},
+		},
+		{
+			name: "federated member joining federated service",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "1", member, nil)},
+				LocalServices:   []corev1.Service{*federatedService("svc", "ns1", nil, "svc", "")},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanUpdate, Kind: "Service", Namespace: "ns1", Name: "svc-federated", Remote: "ns1/svc", Reason: "joins federated service"},
+			},
+		},
+		{
+			name: "last federated member leaving",
+			input: PlanInput{
+				RemoteServices:  []corev1.Service{*remoteService("svc", "ns1", "1", nil, nil)},
+				LocalServices:   []corev1.Service{*federatedService("svc", "ns1", nil, "", "svc@remote")},
+				LocalNamespaces: []string{"ns1"},
+			},
+			expected: []PlannedChange{
+				{Action: PlanDelete, Kind: "Service", Namespace: "ns1", Name: "svc-federated", Remote: "ns1/svc", Reason: "no longer matches federated service selector"},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.Link = link
+			changes := Plan(tt.input)
+			if diff := deep.Equal(changes, tt.expected); diff != nil {
+				t.Errorf("Unexpected plan: %v", diff)
+			}
+		})
+	}
+}
@@ -148,6 +148,7 @@ type (
 		OutboundTransportMode                string           `json:"outboundTransportMode"`
 		AccessLog                            string           `json:"accessLog"`
 		ShutdownGracePeriod                  string           `json:"shutdownGracePeriod"`
+		IdentityKeyAlgorithm                 string           `json:"identityKeyAlgorithm"`
 		NativeSidecar                        bool             `json:"nativeSidecar"`
 		StartupProbe                         *StartupProbe    `json:"startupProbe"`
 		ReadinessProbe                       *Probe           `json:"readinessProbe"`
@@ -341,6 +342,8 @@ type (
 		ExternalCA                    bool              `json:"externalCA"`
 		ServiceAccountTokenProjection bool              `json:"serviceAccountTokenProjection"`
 		Issuer                        *Issuer           `json:"issuer"`
+		CSRKeyAlgorithms              []string          `json:"csrKeyAlgorithms"`
+		SPIFFE                        *SPIFFE           `json:"spiffe"`
 		KubeAPI                       *KubeAPI          `json:"kubeAPI"`
 		PodAnnotations                map[string]string `json:"podAnnotations"`
 
@@ -350,10 +353,28 @@ type (
 
 	// Issuer has the Helm variables of the identity issuer
 	Issuer struct {
-		Scheme             string     `json:"scheme"`
-		ClockSkewAllowance string     `json:"clockSkewAllowance"`
-		IssuanceLifetime   string     `json:"issuanceLifetime"`
-		TLS                *IssuerTLS `json:"tls"`
+		Scheme                   string          `json:"scheme"`
+		ClockSkewAllowance       string          `json:"clockSkewAllowance"`
+		IssuanceLifetime         string          `json:"issuanceLifetime"`
+		KeyAlgorithm             string          `json:"keyAlgorithm"`
+		ShortLivedIssuerLifetime string          `json:"shortLivedIssuerLifetime"`
+		TLS                      *IssuerTLS      `json:"tls"`
+		ExternalSigner           *ExternalSigner `json:"externalSigner"`
+	}
+
+	// SPIFFE has the Helm variables of the identity controller's SPIFFE
+	// support
+	SPIFFE struct {
+		Enabled    bool `json:"enabled"`
+		BundlePort uint `json:"bundlePort"`
+	}
+
+	// ExternalSigner configures an external signer holding the issuer's
+	// private key
+	ExternalSigner struct {
+		URL     string `json:"url"`
+		KeyID   string `json:"keyID"`
+		Timeout string `json:"timeout"`
 	}
 
 	// KubeAPI contains the kube-apiserver client config
@@ -368,6 +389,23 @@ type (
 		PodAnnotations  map[string]string `json:"podAnnotations"`
 		AdditionalEnv   []corev1.EnvVar   `json:"additionalEnv"`
 		ExperimentalEnv []corev1.EnvVar   `json:"experimentalEnv"`
+		AutoRollout     *AutoRollout      `json:"autoRollout"`
+		MeshRequired    *MeshRequired     `json:"meshRequired"`
+	}
+
+	// AutoRollout configures the proxy injector's rollout controller
+	AutoRollout struct {
+		Enabled           bool    `json:"enabled"`
+		DryRun            bool    `json:"dryRun"`
+		RestartsPerMinute float64 `json:"restartsPerMinute"`
+	}
+
+	// MeshRequired configures the proxy injector's validating webhook denying
+	// unmeshed pods in namespaces requiring pods to be meshed
+	MeshRequired struct {
+		Enabled       bool     `json:"enabled"`
+		FailurePolicy string   `json:"failurePolicy"`
+		Exemptions    []string `json:"exemptions"`
 	}
 
 	// Webhook Helm variables for a webhook
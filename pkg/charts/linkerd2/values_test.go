@@ -259,9 +259,15 @@ func TestNewValues(t *testing.T) {
 			Issuer: &Issuer{
 				ClockSkewAllowance: "20s",
 				IssuanceLifetime:   "24h0m0s",
+				KeyAlgorithm:       "ecdsa-p256",
 				TLS:                &IssuerTLS{},
 				Scheme:             "linkerd.io/tls",
+				ExternalSigner: &ExternalSigner{
+					Timeout: "10s",
+				},
 			},
+			CSRKeyAlgorithms: []string{"ecdsa-p256"},
+			SPIFFE:           &SPIFFE{BundlePort: 8081},
 			KubeAPI: &KubeAPI{
 				ClientQPS:   100,
 				ClientBurst: 200,
@@ -281,6 +287,8 @@ func TestNewValues(t *testing.T) {
 		ProxyInjector: &ProxyInjector{
 			Webhook:        Webhook{TLS: &TLS{}, NamespaceSelector: namespaceSelectorInjector},
 			PodAnnotations: map[string]string{},
+			AutoRollout:    &AutoRollout{RestartsPerMinute: 5},
+			MeshRequired:   &MeshRequired{FailurePolicy: "Fail", Exemptions: []string{}},
 		},
 		ProfileValidator: &Webhook{TLS: &TLS{}, NamespaceSelector: namespaceSelectorSimple},
 		PolicyValidator:  &Webhook{TLS: &TLS{}, NamespaceSelector: namespaceSelectorSimple},
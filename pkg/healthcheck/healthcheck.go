@@ -1533,7 +1533,7 @@ func (hc *HealthChecker) CheckProxyHealth(ctx context.Context, controlPlaneNames
 	}
 
 	// Check proxy certificates
-	return checkPodsProxiesCertificate(ctx, *hc.kubeAPI, namespace, controlPlaneNamespace)
+	return CheckPodsProxiesCertificate(ctx, *hc.kubeAPI, namespace, controlPlaneNamespace)
 }
 
 // CheckCertAndAnchorsExpiringSoon checks if the given cert and anchors expire soon, and returns an
@@ -2360,10 +2360,13 @@ func GetMeshedPodsIdentityData(ctx context.Context, api kubernetes.Interface, da
 }
 
 func (hc *HealthChecker) checkDataPlaneProxiesCertificate(ctx context.Context) error {
-	return checkPodsProxiesCertificate(ctx, *hc.kubeAPI, hc.DataPlaneNamespace, hc.ControlPlaneNamespace)
+	return CheckPodsProxiesCertificate(ctx, *hc.kubeAPI, hc.DataPlaneNamespace, hc.ControlPlaneNamespace)
 }
 
-func checkPodsProxiesCertificate(ctx context.Context, kubeAPI k8s.KubernetesAPI, targetNamespace, controlPlaneNamespace string) error {
+// CheckPodsProxiesCertificate checks that the proxies of all the meshed pods
+// in targetNamespace (or in all namespaces if empty) have been started with
+// the current trust bundle of the control plane.
+func CheckPodsProxiesCertificate(ctx context.Context, kubeAPI k8s.KubernetesAPI, targetNamespace, controlPlaneNamespace string) error {
 	meshedPods, err := GetMeshedPodsIdentityData(ctx, kubeAPI, targetNamespace)
 	if err != nil {
 		return err
@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/peer"
+)
+
+// Outcomes of a certification request, as recorded in AuditRecords.
+const (
+	AuditOutcomeIssued = "issued"
+	AuditOutcomeDenied = "denied"
+	AuditOutcomeError  = "error"
+)
+
+// Reasons for which a certification request may be denied or fail, as
+// recorded in AuditRecords.
+const (
+	AuditReasonInvalidRequest   = "invalid_request"
+	AuditReasonInvalidCSR       = "invalid_csr"
+	AuditReasonInvalidToken     = "invalid_token"
+	AuditReasonNotAuthenticated = "not_authenticated"
+	AuditReasonIdentityMismatch = "identity_mismatch"
+	AuditReasonRevoked          = "revoked"
+	AuditReasonIssuerNotReady   = "issuer_not_ready"
+	AuditReasonIssuerInvalid    = "issuer_invalid"
+	AuditReasonValidationFailed = "validation_failed"
+	AuditReasonIssuanceFailed   = "issuance_failed"
+)
+
+type (
+	// AuditRecord describes the handling of a single certification request.
+	AuditRecord struct {
+		Time           time.Time     `json:"time"`
+		Outcome        string        `json:"outcome"`
+		Reason         string        `json:"reason,omitempty"`
+		Identity       string        `json:"identity"`
+		ServiceAccount string        `json:"serviceAccount,omitempty"`
+		Namespace      string        `json:"namespace,omitempty"`
+		Serial         string        `json:"serial,omitempty"`
+		NotAfter       *time.Time    `json:"notAfter,omitempty"`
+		SHA256         string        `json:"sha256,omitempty"`
+		Requester      string        `json:"requester,omitempty"`
+		Duration       time.Duration `json:"duration"`
+	}
+
+	// auditLog writes AuditRecords as JSON lines.
+	auditLog struct {
+		sync.Mutex
+		enc *json.Encoder
+	}
+)
+
+// UseAuditLog configures the service to write an AuditRecord for every
+// certification request to w, as a JSON object per line. It must be called
+// before the service is registered.
+func (svc *Service) UseAuditLog(w io.Writer) {
+	svc.auditLog = &auditLog{enc: json.NewEncoder(w)}
+}
+
+func newAuditRecord(ctx context.Context, identity string) *AuditRecord {
+	record := &AuditRecord{Time: time.Now(), Identity: identity}
+	if client, ok := peer.FromContext(ctx); ok && client.Addr != nil {
+		record.Requester = client.Addr.String()
+	}
+	return record
+}
+
+// authenticated records the service account and namespace of the identity
+// the requester's token was validated for. They're left empty until then, as
+// the requested identity is chosen by the requester.
+func (r *AuditRecord) authenticated(identity string) {
+	// Identities have the form <sa>.<ns>.serviceaccount.identity.<...>
+	if segments := strings.Split(identity, "."); len(segments) > 1 {
+		r.ServiceAccount = segments[0]
+		r.Namespace = segments[1]
+	}
+}
+
+func (r *AuditRecord) deny(reason string) {
+	r.Outcome = AuditOutcomeDenied
+	r.Reason = reason
+}
+
+func (r *AuditRecord) fail(reason string) {
+	r.Outcome = AuditOutcomeError
+	r.Reason = reason
+}
+
+func (svc *Service) audit(record *AuditRecord) {
+	record.Duration = time.Since(record.Time)
+	observeCertify(record)
+
+	if svc.auditLog == nil {
+		return
+	}
+	svc.auditLog.Lock()
+	defer svc.auditLog.Unlock()
+	if err := svc.auditLog.enc.Encode(record); err != nil {
+		log.Errorf("failed to write audit record for %s: %s", record.Identity, err)
+	}
+}
@@ -0,0 +1,253 @@
+package identity
+
+import (
+	"context"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DenyListIdentitiesKey is the key of the deny-list ConfigMap holding the
+	// revoked identities, one per line.
+	DenyListIdentitiesKey = "identities"
+
+	// DenyListSerialsKey is the key of the deny-list ConfigMap holding the
+	// serial numbers of revoked certificates, one per line, in hexadecimal
+	// form (optionally colon-separated, as printed by openssl).
+	DenyListSerialsKey = "serials"
+
+	// issuedKeysSweepInterval is how often the keys of expired certificates
+	// are forgotten.
+	issuedKeysSweepInterval = time.Hour
+)
+
+// DenyList holds the identities and certificate serial numbers which have
+// been revoked. The identity controller refuses to certify revoked identities
+// and the keys of revoked certificates, and the destination controller stops
+// advertising endpoints with revoked identities, withdrawing them from
+// existing streams when the deny-list changes. The policy controller reads
+// the revoked identities as well, removing them from the identities
+// authorization policies match by name. A nil DenyList denies nothing.
+type DenyList struct {
+	sync.RWMutex
+	identities map[string]struct{}
+	serials    map[string]struct{}
+
+	listenersMu  sync.Mutex
+	listeners    map[uint64]func()
+	nextListener uint64
+}
+
+// NewDenyList returns an empty DenyList.
+func NewDenyList() *DenyList {
+	return &DenyList{
+		identities: map[string]struct{}{},
+		serials:    map[string]struct{}{},
+		listeners:  map[uint64]func(){},
+	}
+}
+
+// DenyListFromConfigMap returns a DenyList with the contents of the given
+// deny-list ConfigMap.
+func DenyListFromConfigMap(cm *corev1.ConfigMap) *DenyList {
+	dl := NewDenyList()
+	dl.Update(cm)
+	return dl
+}
+
+// Update replaces the contents of the DenyList with those of the given
+// deny-list ConfigMap. A nil ConfigMap clears the DenyList.
+func (dl *DenyList) Update(cm *corev1.ConfigMap) {
+	identities := map[string]struct{}{}
+	serials := map[string]struct{}{}
+	if cm != nil {
+		for _, id := range strings.Fields(cm.Data[DenyListIdentitiesKey]) {
+			identities[id] = struct{}{}
+		}
+		for _, serial := range strings.Fields(cm.Data[DenyListSerialsKey]) {
+			if s, ok := normalizeSerial(serial); ok {
+				serials[s] = struct{}{}
+			} else {
+				log.Warnf("ignoring invalid serial number %q in the identity deny-list", serial)
+			}
+		}
+	}
+
+	dl.Lock()
+	dl.identities = identities
+	dl.serials = serials
+	dl.Unlock()
+	log.Infof("identity deny-list updated: %d identities, %d serials", len(identities), len(serials))
+
+	dl.listenersMu.Lock()
+	defer dl.listenersMu.Unlock()
+	for _, listener := range dl.listeners {
+		listener()
+	}
+}
+
+// Subscribe registers a listener called after every Update. Listeners are
+// called synchronously and must not block. The returned function removes the
+// listener; once it returns, the listener is guaranteed not to be called
+// again.
+func (dl *DenyList) Subscribe(listener func()) func() {
+	if dl == nil {
+		return func() {}
+	}
+	dl.listenersMu.Lock()
+	defer dl.listenersMu.Unlock()
+	id := dl.nextListener
+	dl.nextListener++
+	dl.listeners[id] = listener
+	return func() {
+		dl.listenersMu.Lock()
+		defer dl.listenersMu.Unlock()
+		delete(dl.listeners, id)
+	}
+}
+
+// DeniesIdentity returns whether the given identity has been revoked.
+func (dl *DenyList) DeniesIdentity(id string) bool {
+	if dl == nil {
+		return false
+	}
+	dl.RLock()
+	defer dl.RUnlock()
+	_, ok := dl.identities[id]
+	return ok
+}
+
+// DeniesSerial returns whether the certificate with the given serial number
+// has been revoked.
+func (dl *DenyList) DeniesSerial(serial *big.Int) bool {
+	if dl == nil || serial == nil {
+		return false
+	}
+	dl.RLock()
+	defer dl.RUnlock()
+	_, ok := dl.serials[serial.Text(16)]
+	return ok
+}
+
+// WatchDenyList keeps the given DenyList up to date with the deny-list
+// ConfigMap of the given namespace until the context is canceled.
+func WatchDenyList(ctx context.Context, client kubernetes.Interface, namespace string, dl *DenyList) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", k8s.IdentityDenyListConfigMapName).String()
+		}),
+	)
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			dl.Update(obj.(*corev1.ConfigMap))
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			dl.Update(obj.(*corev1.ConfigMap))
+		},
+		DeleteFunc: func(interface{}) {
+			dl.Update(nil)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	return nil
+}
+
+// issuedKeys records the serial numbers of the certificates issued for each
+// public key. Proxies keep their key across renewals, so a CSR for the key of
+// a revoked certificate is a renewal of that certificate. Only the
+// certificates issued by this replica since it started are known.
+type issuedKeys struct {
+	sync.Mutex
+	byKey     map[[sha256.Size]byte][]issuedCert
+	nextSweep time.Time
+}
+
+type issuedCert struct {
+	serial   *big.Int
+	notAfter time.Time
+}
+
+func newIssuedKeys() *issuedKeys {
+	return &issuedKeys{byKey: map[[sha256.Size]byte][]issuedCert{}}
+}
+
+// add records a certificate issued for the given DER-encoded public key. The
+// certificates which have expired are forgotten, unless they have been
+// revoked.
+func (ik *issuedKeys) add(publicKey []byte, serial *big.Int, notAfter time.Time, dl *DenyList) {
+	if ik == nil {
+		return
+	}
+	ik.Lock()
+	defer ik.Unlock()
+
+	now := time.Now()
+	if now.After(ik.nextSweep) {
+		for key, certs := range ik.byKey {
+			if certs = liveCerts(certs, now, dl); len(certs) == 0 {
+				delete(ik.byKey, key)
+			} else {
+				ik.byKey[key] = certs
+			}
+		}
+		ik.nextSweep = now.Add(issuedKeysSweepInterval)
+	}
+
+	key := sha256.Sum256(publicKey)
+	ik.byKey[key] = append(ik.byKey[key], issuedCert{serial, notAfter})
+}
+
+// revokedSerial returns the serial number of a revoked certificate issued
+// for the given DER-encoded public key, if any.
+func (ik *issuedKeys) revokedSerial(publicKey []byte, dl *DenyList) (*big.Int, bool) {
+	if ik == nil {
+		return nil, false
+	}
+	ik.Lock()
+	defer ik.Unlock()
+
+	for _, cert := range ik.byKey[sha256.Sum256(publicKey)] {
+		if dl.DeniesSerial(cert.serial) {
+			return cert.serial, true
+		}
+	}
+	return nil, false
+}
+
+func liveCerts(certs []issuedCert, now time.Time, dl *DenyList) []issuedCert {
+	live := certs[:0]
+	for _, cert := range certs {
+		if cert.notAfter.After(now) || dl.DeniesSerial(cert.serial) {
+			live = append(live, cert)
+		}
+	}
+	return live
+}
+
+func normalizeSerial(serial string) (string, bool) {
+	s := strings.ReplaceAll(strings.ToLower(serial), ":", "")
+	s = strings.TrimPrefix(s, "0x")
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return "", false
+	}
+	return n.Text(16), true
+}
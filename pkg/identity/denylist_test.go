@@ -0,0 +1,154 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/identity"
+	"github.com/linkerd/linkerd2/pkg/tls"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDenyList(t *testing.T) {
+	dl := DenyListFromConfigMap(&corev1.ConfigMap{
+		Data: map[string]string{
+			DenyListIdentitiesKey: "foo.ns.serviceaccount.identity.linkerd.cluster.local\n\nbar.ns.serviceaccount.identity.linkerd.cluster.local\n",
+			DenyListSerialsKey:    "0A:1B:2c\n0xff\nnot-a-serial\n",
+		},
+	})
+
+	for _, id := range []string{
+		"foo.ns.serviceaccount.identity.linkerd.cluster.local",
+		"bar.ns.serviceaccount.identity.linkerd.cluster.local",
+	} {
+		if !dl.DeniesIdentity(id) {
+			t.Errorf("expected %s to be denied", id)
+		}
+	}
+	if dl.DeniesIdentity("baz.ns.serviceaccount.identity.linkerd.cluster.local") {
+		t.Error("expected baz to be allowed")
+	}
+
+	for _, serial := range []int64{0x0a1b2c, 0xff} {
+		if !dl.DeniesSerial(big.NewInt(serial)) {
+			t.Errorf("expected serial %x to be denied", serial)
+		}
+	}
+	if dl.DeniesSerial(big.NewInt(0x0a1b2d)) {
+		t.Error("expected serial 0a1b2d to be allowed")
+	}
+
+	updates := 0
+	unsubscribe := dl.Subscribe(func() { updates++ })
+	dl.Update(nil)
+	if dl.DeniesIdentity("foo.ns.serviceaccount.identity.linkerd.cluster.local") {
+		t.Error("expected the deny-list to be cleared")
+	}
+	if updates != 1 {
+		t.Errorf("expected the listener to be called once, got %d", updates)
+	}
+	unsubscribe()
+	dl.Update(nil)
+	if updates != 1 {
+		t.Errorf("expected the listener not to be called after unsubscribing, got %d calls", updates)
+	}
+
+	var nilList *DenyList
+	if nilList.DeniesIdentity("foo") || nilList.DeniesSerial(big.NewInt(1)) {
+		t.Error("expected a nil deny-list to deny nothing")
+	}
+	nilList.Subscribe(func() {})()
+}
+
+func TestCertifyDeniedIdentity(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("identity.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+	key, err := tls.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %s", err)
+	}
+	req := &pb.CertifyRequest{
+		Identity:                  id,
+		Token:                     []byte("token"),
+		CertificateSigningRequest: csr,
+	}
+
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &tls.Validity{Lifetime: time.Hour}, recordEvent, "", "", "")
+	svc.updateIssuer(tls.NewCA(root.Cred, tls.Validity{Lifetime: time.Hour}), root.Cred.Certificate.NotAfter)
+	dl := NewDenyList()
+	svc.UseDenyList(dl)
+
+	if _, err := svc.Certify(context.Background(), req); err != nil {
+		t.Fatalf("Failed to certify: %s", err)
+	}
+
+	dl.Update(&corev1.ConfigMap{Data: map[string]string{DenyListIdentitiesKey: id}})
+	_, err = svc.Certify(context.Background(), req)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Expected a PermissionDenied error for a revoked identity, got %v", err)
+	}
+}
+
+func TestCertifyDeniedSerial(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("identity.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+	newRequest := func() *pb.CertifyRequest {
+		key, err := tls.GenerateKey()
+		if err != nil {
+			t.Fatalf("Failed to generate key: %s", err)
+		}
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+		if err != nil {
+			t.Fatalf("Failed to create CSR: %s", err)
+		}
+		return &pb.CertifyRequest{
+			Identity:                  id,
+			Token:                     []byte("token"),
+			CertificateSigningRequest: csr,
+		}
+	}
+
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &tls.Validity{Lifetime: time.Hour}, recordEvent, "", "", "")
+	svc.updateIssuer(tls.NewCA(root.Cred, tls.Validity{Lifetime: time.Hour}), root.Cred.Certificate.NotAfter)
+	dl := NewDenyList()
+	svc.UseDenyList(dl)
+
+	req := newRequest()
+	rsp, err := svc.Certify(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to certify: %s", err)
+	}
+	crt, err := x509.ParseCertificate(rsp.GetLeafCertificate())
+	if err != nil {
+		t.Fatalf("Failed to parse the issued certificate: %s", err)
+	}
+
+	dl.Update(&corev1.ConfigMap{Data: map[string]string{DenyListSerialsKey: crt.SerialNumber.Text(16)}})
+	_, err = svc.Certify(context.Background(), req)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Expected a PermissionDenied error for the key of a revoked certificate, got %v", err)
+	}
+
+	if _, err := svc.Certify(context.Background(), newRequest()); err != nil {
+		t.Fatalf("Expected a new key to be certified, got %s", err)
+	}
+}
@@ -2,6 +2,7 @@ package identity
 
 import (
 	"context"
+	"time"
 
 	pb "github.com/linkerd/linkerd2-proxy-api/go/identity"
 	"github.com/linkerd/linkerd2/pkg/tls"
@@ -19,7 +20,7 @@ func FuzzServiceCertify(data []byte) int {
 	}
 
 	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "")
-	svc.updateIssuer(&fakeIssuer{tls.Crt{}, nil})
+	svc.updateIssuer(&fakeIssuer{tls.Crt{}, nil}, time.Time{})
 
 	_, _ = svc.Certify(context.Background(), req)
 	return 1
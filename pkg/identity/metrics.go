@@ -0,0 +1,48 @@
+package identity
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	labelNamespace = "namespace"
+	labelOutcome   = "outcome"
+	labelReason    = "reason"
+
+	// unknownNamespace labels the requests whose token wasn't validated
+	unknownNamespace = "unknown"
+)
+
+var (
+	certifyRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "identity_certify_requests_total",
+		Help: "A counter for the number of certification requests, by namespace of the authenticated identity and outcome.",
+	}, []string{labelNamespace, labelOutcome})
+
+	certifyDenials = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "identity_certify_denials_total",
+		Help: "A counter for the number of denied certification requests, by namespace of the authenticated identity and reason.",
+	}, []string{labelNamespace, labelReason})
+
+	certifyLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "identity_certify_duration_seconds",
+		Help:    "A histogram of the time taken to process certification requests, by namespace of the authenticated identity.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{labelNamespace})
+)
+
+func observeCertify(record *AuditRecord) {
+	// The namespace is only known once the requester's token has been
+	// validated; the requested identity can't be used as it would let any
+	// client create arbitrary label values
+	namespace := record.Namespace
+	if namespace == "" {
+		namespace = unknownNamespace
+	}
+	certifyRequests.WithLabelValues(namespace, record.Outcome).Inc()
+	if record.Outcome == AuditOutcomeDenied {
+		certifyDenials.WithLabelValues(namespace, record.Reason).Inc()
+	}
+	certifyLatency.WithLabelValues(namespace).Observe(record.Duration.Seconds())
+}
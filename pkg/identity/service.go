@@ -7,6 +7,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +40,8 @@ const (
 	eventTypeUpdated        = "IssuerUpdated"
 	eventTypeFailed         = "IssuerValidationFailed"
 	eventTypeIssuedLeafCert = "IssuedLeafCertificate"
+
+	eventTypeTrustAnchorsUpdated = "TrustAnchorsUpdated"
 )
 
 type (
@@ -52,6 +57,35 @@ type (
 
 		expectedName, issuerPathCrt, issuerPathKey string
 		issuerCertTTL                              time.Time
+
+		// externalSigner, when set, signs certificates instead of the issuer
+		// private key, which is then not read from disk.
+		externalSigner *tls.ExternalSigner
+
+		// auditLog, when set, receives an AuditRecord for every certification
+		// request.
+		auditLog *auditLog
+
+		// denyList, when set, holds the identities and the serial numbers of
+		// the certificates which must not be certified, the latter being
+		// matched through the keys recorded in issuedKeys.
+		denyList   *DenyList
+		issuedKeys *issuedKeys
+
+		// issuerUpdates is signaled whenever the issuer is replaced.
+		issuerUpdates chan struct{}
+
+		// issuerKeyAlgorithms and csrKeyAlgorithms, when set, restrict the
+		// algorithms of the issuer key and of the keys of certified CSRs.
+		issuerKeyAlgorithms, csrKeyAlgorithms []tls.KeyAlgorithm
+
+		// shortLivedIssuerLifetime, when set, is the lifetime of the issuers
+		// minted with the credentials read from disk.
+		shortLivedIssuerLifetime time.Duration
+
+		// spiffeTrustDomain, when set, is the SPIFFE trust domain of the SPIFFE
+		// IDs added to issued certificates.
+		spiffeTrustDomain string
 	}
 
 	// Validator implementors accept a bearer token, validates it, and returns a
@@ -79,69 +113,240 @@ type (
 
 // Initialize loads the issuer certs from disk so it can start service CSRs to proxies
 func (svc *Service) Initialize() error {
-	credentials, err := svc.loadCredentials()
+	svc.issuerMutex.Lock()
+	defer svc.issuerMutex.Unlock()
+
+	credentials, notAfter, err := svc.loadCredentials(svc.trustAnchors)
 	if err != nil {
 		return err
 	}
-	svc.updateIssuer(credentials)
+	svc.setIssuer(credentials, notAfter)
+	return nil
+}
+
+// UpdateTrustAnchors replaces the trust anchors with the given PEM-encoded
+// bundle. When the current issuer can't be verified with the new trust
+// anchors, it is reloaded from disk since it may have been rotated along with
+// them; the trust anchors are left untouched if that fails too.
+func (svc *Service) UpdateTrustAnchors(pem []byte) error {
+	trustAnchors, err := tls.DecodePEMCertPool(string(pem))
+	if err != nil {
+		return fmt.Errorf("failed to decode trust anchors: %w", err)
+	}
+
+	// The issuer is verified and swapped under the same lock so that an
+	// issuer rotated concurrently by Run is never overwritten.
+	svc.issuerMutex.Lock()
+	if err := svc.verifyIssuer(trustAnchors); err != nil {
+		reloaded, notAfter, err := svc.loadCredentials(trustAnchors)
+		if err != nil {
+			svc.issuerMutex.Unlock()
+			return fmt.Errorf("failed to verify the issuer with the new trust anchors: %w", err)
+		}
+		svc.setIssuer(reloaded, notAfter)
+	}
+	svc.trustAnchors = trustAnchors
+	svc.issuerMutex.Unlock()
+
+	message := "Updated trust anchors"
+	log.Info(message)
+	svc.recordEvent(nil, v1.EventTypeNormal, eventTypeTrustAnchorsUpdated, message)
 	return nil
 }
 
-func (svc *Service) updateIssuer(newIssuer tls.Issuer) {
+func (svc *Service) updateIssuer(newIssuer tls.Issuer, notAfter time.Time) {
 	svc.issuerMutex.Lock()
+	svc.setIssuer(newIssuer, notAfter)
+	svc.issuerMutex.Unlock()
+}
+
+// setIssuer replaces the issuer and the expiry of its certificate. It must be
+// called with the issuer mutex held.
+func (svc *Service) setIssuer(newIssuer tls.Issuer, notAfter time.Time) {
 	svc.issuer = &newIssuer
+	svc.issuerCertTTL = notAfter
 	log.Debug("Issuer has been updated")
-	svc.issuerMutex.Unlock()
+
+	// Let Run reschedule the rotation of the new issuer.
+	select {
+	case svc.issuerUpdates <- struct{}{}:
+	default:
+	}
+}
+
+// issuerNotAfter returns the expiry of the issuer certificate, which is zero
+// until an issuer is loaded.
+func (svc *Service) issuerNotAfter() time.Time {
+	svc.issuerMutex.RLock()
+	defer svc.issuerMutex.RUnlock()
+	return svc.issuerCertTTL
 }
 
 func (svc *Service) getIssuerCertTTL() float64 {
-	if svc.issuerCertTTL.IsZero() {
+	notAfter := svc.issuerNotAfter()
+	if notAfter.IsZero() {
 		log.Warn("Issuer certificate not ready: cannot get TTL")
 		return float64(0)
 	}
-	return time.Until(svc.issuerCertTTL).Seconds()
+	return time.Until(notAfter).Seconds()
 }
 
-// Run reads from the issuer and error channels and reloads the issuer certs when necessary
-func (svc *Service) Run(issuerEvent <-chan struct{}, issuerError <-chan error) {
+// Run rotates the short-lived issuers, if the service mints them, until the
+// context is canceled.
+func (svc *Service) Run(ctx context.Context) {
+	rotation := time.NewTimer(0)
+	defer rotation.Stop()
+	svc.scheduleRotation(rotation)
 	for {
 		select {
-		case <-issuerEvent:
+		case <-rotation.C:
 			if err := svc.Initialize(); err != nil {
+				message := fmt.Sprintf("Failed to rotate the short-lived issuer: %s", err)
+				log.Error(message)
+				svc.recordEvent(nil, v1.EventTypeWarning, eventTypeFailed, message)
+			}
+			svc.scheduleRotation(rotation)
+		case <-svc.issuerUpdates:
+			svc.scheduleRotation(rotation)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// IssuerFileSet returns the set of the issuer credential files, found in the
+// given directory, which reloads the issuer when they are updated. It must be
+// called after UseExternalSigner.
+func (svc *Service) IssuerFileSet(dir string) tls.FileSet {
+	return tls.FileSet{
+		Name:  "identity issuer",
+		Dir:   dir,
+		Paths: svc.issuerPaths(),
+		Load: func(contents map[string][]byte) error {
+			if err := svc.loadIssuer(contents); err != nil {
 				message := fmt.Sprintf("Skipping issuer update as certs could not be read from disk: %s", err)
 				log.Warn(message)
 				svc.recordEvent(nil, v1.EventTypeWarning, eventTypeSkipped, message)
-			} else {
-				message := "Updated identity issuer"
-				log.Info(message)
-				svc.recordEvent(nil, v1.EventTypeNormal, eventTypeUpdated, message)
+				return err
 			}
-		case err := <-issuerError:
-			log.Warnf("Received error from fs watcher: %s", err)
+			message := "Updated identity issuer"
+			log.Info(message)
+			svc.recordEvent(nil, v1.EventTypeNormal, eventTypeUpdated, message)
+			return nil
+		},
+	}
+}
+
+// loadIssuer replaces the issuer with one using the given contents of the
+// issuer credential files.
+func (svc *Service) loadIssuer(contents map[string][]byte) error {
+	creds, err := svc.decodeCredentials(contents)
+	if err != nil {
+		return err
+	}
+
+	svc.issuerMutex.Lock()
+	defer svc.issuerMutex.Unlock()
+	issuer, notAfter, err := svc.newIssuer(creds, svc.trustAnchors)
+	if err != nil {
+		return err
+	}
+	svc.setIssuer(issuer, notAfter)
+	return nil
+}
+
+// UseExternalSigner configures the service to have certificates signed by an
+// external signer holding the issuer private key. It must be called before
+// Initialize.
+func (svc *Service) UseExternalSigner(signer *tls.ExternalSigner) {
+	svc.externalSigner = signer
+}
+
+// UseDenyList configures the service to refuse to certify the identities of
+// the given DenyList, and the keys of the certificates it revokes by serial
+// number.
+func (svc *Service) UseDenyList(dl *DenyList) {
+	svc.denyList = dl
+	svc.issuedKeys = newIssuedKeys()
+}
+
+// UseKeyAlgorithms restricts the algorithms of the issuer key and of the keys
+// of certified CSRs. Empty lists allow all the supported key algorithms. It
+// must be called before Initialize.
+func (svc *Service) UseKeyAlgorithms(issuer, csr []tls.KeyAlgorithm) {
+	svc.issuerKeyAlgorithms = issuer
+	svc.csrKeyAlgorithms = csr
+}
+
+// issuerPaths returns the paths of the issuer credential files, which don't
+// include the private key when it is held by an external signer.
+func (svc *Service) issuerPaths() []string {
+	if svc.externalSigner != nil {
+		return []string{svc.issuerPathCrt}
+	}
+	return []string{svc.issuerPathCrt, svc.issuerPathKey}
+}
+
+func (svc *Service) readCredentials() (*tls.Cred, error) {
+	contents := map[string][]byte{}
+	for _, path := range svc.issuerPaths() {
+		b, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, err
 		}
+		contents[path] = b
 	}
+	return svc.decodeCredentials(contents)
 }
 
-func (svc *Service) loadCredentials() (tls.Issuer, error) {
-	creds, err := tls.ReadPEMCreds(
-		svc.issuerPathKey,
-		svc.issuerPathCrt,
-	)
+// decodeCredentials decodes the contents of the issuer credential files,
+// keyed by path.
+func (svc *Service) decodeCredentials(contents map[string][]byte) (*tls.Cred, error) {
+	if svc.externalSigner == nil {
+		return tls.ValidateAndCreateCreds(string(contents[svc.issuerPathCrt]), string(contents[svc.issuerPathKey]))
+	}
+
+	crt, err := tls.DecodePEMCrt(string(contents[svc.issuerPathCrt]))
+	if err != nil {
+		return nil, err
+	}
+	creds := tls.NewExternalCred(*crt, svc.externalSigner)
+	return &creds, nil
+}
 
+// loadCredentials reads the issuer credentials from disk and returns the
+// issuer along with the expiry of its certificate.
+func (svc *Service) loadCredentials(trustAnchors *x509.CertPool) (tls.Issuer, time.Time, error) {
+	creds, err := svc.readCredentials()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CA from disk: %w", err)
+		return nil, time.Time{}, fmt.Errorf("failed to read CA from disk: %w", err)
 	}
+	return svc.newIssuer(creds, trustAnchors)
+}
 
+// newIssuer returns the issuer using the given credentials, once they are
+// verified with the trust anchors, along with the expiry of its certificate.
+func (svc *Service) newIssuer(creds *tls.Cred, trustAnchors *x509.CertPool) (tls.Issuer, time.Time, error) {
 	// Don't verify with dns name as this is not a leaf certificate
-	if err := creds.Crt.Verify(svc.trustAnchors, "", time.Time{}); err != nil {
-		return nil, fmt.Errorf("failed to verify issuer credentials for '%s' with trust anchors: %w", svc.expectedName, err)
+	if err := creds.Crt.Verify(trustAnchors, "", time.Time{}); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to verify issuer credentials for '%s' with trust anchors: %w", svc.expectedName, err)
 	}
 
 	if !creds.Certificate.IsCA {
-		return nil, fmt.Errorf("failed to verify issuer certificate: it must be an intermediate-CA, but it is not")
+		return nil, time.Time{}, fmt.Errorf("failed to verify issuer certificate: it must be an intermediate-CA, but it is not")
 	}
 
-	svc.issuerCertTTL = creds.Certificate.NotAfter
+	if err := tls.CheckKeyAlgorithm(creds.Certificate.PublicKey, svc.issuerKeyAlgorithms); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to verify issuer certificate: %w", err)
+	}
+
+	if svc.shortLivedIssuerLifetime != 0 {
+		issuer, err := svc.mintIssuer(creds)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return issuer, issuer.Cred.Certificate.NotAfter, nil
+	}
 
 	log.Debugf("Loaded issuer cert: %s", creds.EncodeCertificatePEM())
 	now := time.Now().Unix()
@@ -150,7 +355,9 @@ func (svc *Service) loadCredentials() (tls.Issuer, error) {
 		"process_clock_time": now,
 		"ttl_seconds":        creds.Certificate.NotAfter.Unix() - now,
 	}).Info("Issuer cert loaded")
-	return tls.NewCA(*creds, *svc.validity), nil
+	ca := tls.NewCA(*creds, *svc.validity)
+	ca.KeyAlgorithms = svc.csrKeyAlgorithms
+	return ca, creds.Certificate.NotAfter, nil
 }
 
 func (svc *Service) registerCertExpirationMetrics() {
@@ -184,6 +391,15 @@ func NewService(validator Validator, trustAnchors *x509.CertPool, validity *tls.
 		issuerPathCrt,
 		issuerPathKey,
 		time.Time{},
+		nil,
+		nil,
+		nil,
+		nil,
+		make(chan struct{}, 1),
+		nil,
+		nil,
+		0,
+		"",
 	}
 	svc.registerCertExpirationMetrics()
 	return svc
@@ -198,11 +414,20 @@ func Register(g *grpc.Server, s *Service) {
 // ensureIssuerStillValid should check that the CA is still good time wise
 // and verifies just fine with the provided trust anchors
 func (svc *Service) ensureIssuerStillValid() error {
+	return svc.verifyIssuer(svc.trustAnchors)
+}
+
+// verifyIssuer verifies the current issuer, if any, with the given trust
+// anchors. It must be called with the issuer mutex held.
+func (svc *Service) verifyIssuer(trustAnchors *x509.CertPool) error {
+	if svc.issuer == nil {
+		return nil
+	}
 	issuer := *svc.issuer
 	switch is := issuer.(type) {
 	case *tls.CA:
 		// Don't verify with dns name as this is not a leaf certificate
-		return is.Cred.Verify(svc.trustAnchors, "", time.Time{})
+		return is.Cred.Verify(trustAnchors, "", time.Time{})
 	default:
 		return fmt.Errorf("unsupported issuer type. Expected *tls.CA, got %v", is)
 	}
@@ -210,17 +435,22 @@ func (svc *Service) ensureIssuerStillValid() error {
 
 // Certify validates identity and signs certificates.
 func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.CertifyResponse, error) {
+	record := newAuditRecord(ctx, req.GetIdentity())
+	defer svc.audit(record)
+
 	svc.issuerMutex.RLock()
 	defer svc.issuerMutex.RUnlock()
 
 	if svc.issuer == nil {
 		log.Warn("Certificate issuer is not ready")
+		record.fail(AuditReasonIssuerNotReady)
 		return nil, status.Error(codes.Unavailable, "cert issuer not ready yet")
 	}
 
 	// Extract the relevant info from the request.
 	reqIdentity, tok, csr, err := checkRequest(req)
 	if err != nil {
+		record.deny(AuditReasonInvalidRequest)
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
@@ -228,11 +458,17 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 		log.Errorf("could not process CSR because of CA cert validation failure: %s - CSR Identity : %s", err, reqIdentity)
 		message := fmt.Sprintf("%s - CSR Identity : %s", err.Error(), reqIdentity)
 		svc.recordEvent(nil, v1.EventTypeWarning, eventTypeFailed, message)
+		record.fail(AuditReasonIssuerInvalid)
 		return nil, err
 	}
 
-	if err = checkCSR(csr, reqIdentity); err != nil {
+	var spiffeID *url.URL
+	if svc.spiffeTrustDomain != "" {
+		spiffeID = SPIFFEID(svc.spiffeTrustDomain, reqIdentity)
+	}
+	if err = checkCSR(csr, reqIdentity, spiffeID, svc.csrKeyAlgorithms); err != nil {
 		log.Debugf("requester sent invalid CSR: %s", err)
+		record.deny(AuditReasonInvalidCSR)
 		return nil, status.Error(codes.FailedPrecondition, err.Error())
 	}
 
@@ -243,31 +479,54 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 		var nae NotAuthenticated
 		if errors.As(err, &nae) {
 			log.Infof("authentication failed for %s: %s", reqIdentity, nae)
+			record.deny(AuditReasonNotAuthenticated)
 			return nil, status.Error(codes.FailedPrecondition, nae.Error())
 		}
 		var ite InvalidToken
 		if errors.As(err, &ite) {
 			log.Infof("invalid token provided for %s: %s", reqIdentity, ite)
+			record.deny(AuditReasonInvalidToken)
 			return nil, status.Error(codes.InvalidArgument, ite.Error())
 		}
 
 		msg := fmt.Sprintf("error validating token for %s: %s", reqIdentity, err)
 		log.Error(msg)
+		record.fail(AuditReasonValidationFailed)
 		return nil, status.Error(codes.Internal, msg)
 	}
+	record.authenticated(tokIdentity)
 
 	// Ensure the requested identity matches the token's identity.
 	if reqIdentity != tokIdentity {
 		msg := fmt.Sprintf("requested identity did not match provided token: requested=%s; found=%s",
 			reqIdentity, tokIdentity)
 		log.Info(msg)
+		record.deny(AuditReasonIdentityMismatch)
 		return nil, status.Error(codes.FailedPrecondition, msg)
 	}
 
+	if svc.denyList.DeniesIdentity(tokIdentity) {
+		msg := fmt.Sprintf("identity %s has been revoked", tokIdentity)
+		log.Warn(msg)
+		record.deny(AuditReasonRevoked)
+		return nil, status.Error(codes.PermissionDenied, msg)
+	}
+	if serial, ok := svc.issuedKeys.revokedSerial(csr.RawSubjectPublicKeyInfo, svc.denyList); ok {
+		msg := fmt.Sprintf("the key of %s belongs to revoked certificate %s", tokIdentity, serial.Text(16))
+		log.Warn(msg)
+		record.deny(AuditReasonRevoked)
+		return nil, status.Error(codes.PermissionDenied, msg)
+	}
+
+	if spiffeID != nil {
+		csr.URIs = []*url.URL{spiffeID}
+	}
+
 	// Create a certificate
 	issuer := *svc.issuer
 	crt, err := issuer.IssueEndEntityCrt(csr)
 	if err != nil {
+		record.fail(AuditReasonIssuanceFailed)
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	crts := crt.ExtractRaw()
@@ -276,6 +535,7 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 		log.Fatal("the issuer provided a certificate without key material")
 	}
 	validUntil := timestamppb.New(crt.Certificate.NotAfter)
+	svc.issuedKeys.add(csr.RawSubjectPublicKeyInfo, crt.Certificate.SerialNumber, crt.Certificate.NotAfter, svc.denyList)
 
 	hasher := sha256.New()
 	hasher.Write(crts[0])
@@ -289,7 +549,19 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 		},
 	}
 	svc.recordEvent(&sa, v1.EventTypeNormal, eventTypeIssuedLeafCert, msg)
-	log.Info(msg)
+
+	record.Outcome = AuditOutcomeIssued
+	record.Serial = crt.Certificate.SerialNumber.String()
+	record.NotAfter = &crt.Certificate.NotAfter
+	record.SHA256 = hash
+	log.WithFields(log.Fields{
+		"identity":        tokIdentity,
+		"service_account": record.ServiceAccount,
+		"namespace":       record.Namespace,
+		"serial":          record.Serial,
+		"not_after":       crt.Certificate.NotAfter.Unix(),
+		"requester":       record.Requester,
+	}).Info(msg)
 
 	// Bundle issuer crt with certificate so the trust path to the root can be verified.
 	rsp := &pb.CertifyResponse{
@@ -325,7 +597,7 @@ func checkRequest(req *pb.CertifyRequest) (string, []byte, *x509.CertificateRequ
 	return reqIdentity, tok, csr, nil
 }
 
-func checkCSR(csr *x509.CertificateRequest, identity string) error {
+func checkCSR(csr *x509.CertificateRequest, identity string, spiffeID *url.URL, keyAlgorithms []tls.KeyAlgorithm) error {
 	if len(csr.DNSNames) != 1 {
 		return errors.New("CSR must have exactly one DNSName")
 	}
@@ -345,8 +617,12 @@ func checkCSR(csr *x509.CertificateRequest, identity string) error {
 	if len(csr.IPAddresses) > 0 {
 		return errors.New("cannot validate IP addresses")
 	}
-	if len(csr.URIs) > 0 {
-		return errors.New("cannot validate URIs")
+	if err := checkSPIFFEID(csr.URIs, spiffeID); err != nil {
+		return err
+	}
+
+	if err := tls.CheckKeyAlgorithm(csr.PublicKey, keyAlgorithms); err != nil {
+		return fmt.Errorf("invalid CSR public key: %w", err)
 	}
 
 	return nil
@@ -1,11 +1,28 @@
 package identity
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	pb "github.com/linkerd/linkerd2-proxy-api/go/identity"
 	"github.com/linkerd/linkerd2/pkg/tls"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestServiceNotReady(t *testing.T) {
@@ -32,7 +49,7 @@ func TestServiceNotReady(t *testing.T) {
 
 func TestInvalidRequestArguments(t *testing.T) {
 	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "")
-	svc.updateIssuer(&fakeIssuer{tls.Crt{}, nil})
+	svc.updateIssuer(&fakeIssuer{tls.Crt{}, nil}, time.Time{})
 	fakeData := "fake-data"
 	invalidCsr := func() *pb.CertifyRequest {
 		return &pb.CertifyRequest{
@@ -78,3 +95,367 @@ func TestInvalidRequestArguments(t *testing.T) {
 	}
 
 }
+
+func TestExternalSigner(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("root")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	issuerKey, err := tls.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate issuer key: %s", err)
+	}
+	issuer, err := root.GenerateCA("identity.linkerd.cluster.local", -1)
+	if err != nil {
+		t.Fatalf("Failed to create issuer: %s", err)
+	}
+	// Replace the issuer's key by one only known to the external signer.
+	issuerTemplate := *issuer.Cred.Crt.Certificate
+	issuerTemplate.PublicKey = &issuerKey.PublicKey
+	issuerCrt, err := root.Cred.SignCrt(&issuerTemplate)
+	if err != nil {
+		t.Fatalf("Failed to sign issuer certificate: %s", err)
+	}
+
+	dir := t.TempDir()
+	issuerPathCrt := filepath.Join(dir, "crt.pem")
+	if err := os.WriteFile(issuerPathCrt, []byte(issuerCrt.EncodePEM()), 0600); err != nil {
+		t.Fatalf("Failed to write issuer certificate: %s", err)
+	}
+
+	signer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req tls.ExternalSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sig, err := ecdsa.SignASN1(rand.Reader, issuerKey, req.Digest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(tls.ExternalSignResponse{Signature: sig}); err != nil {
+			t.Errorf("Failed to encode response: %s", err)
+		}
+	}))
+	defer signer.Close()
+
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+	validity := tls.Validity{Lifetime: time.Hour}
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &validity, recordEvent, "identity.linkerd.cluster.local", issuerPathCrt, filepath.Join(dir, "missing-key.pem"))
+	svc.UseExternalSigner(tls.NewExternalSigner(signer.URL, "issuer", nil))
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize service: %s", err)
+	}
+
+	key, err := tls.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %s", err)
+	}
+
+	rsp, err := svc.Certify(context.Background(), &pb.CertifyRequest{
+		Identity:                  id,
+		Token:                     []byte("token"),
+		CertificateSigningRequest: csr,
+	})
+	if err != nil {
+		t.Fatalf("Failed to certify: %s", err)
+	}
+
+	leaf, err := x509.ParseCertificate(rsp.GetLeafCertificate())
+	if err != nil {
+		t.Fatalf("Failed to parse leaf certificate: %s", err)
+	}
+	crt := tls.Crt{Certificate: leaf, TrustChain: issuerCrt.TrustChain}
+	crt.TrustChain = append(crt.TrustChain, issuerCrt.Certificate)
+	if err := crt.Verify(root.Cred.Crt.CertPool(), id, time.Time{}); err != nil {
+		t.Fatalf("Failed to verify leaf certificate: %s", err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("identity.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	id := "foo.audit-ns.serviceaccount.identity.linkerd.cluster.local"
+	key, err := tls.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %s", err)
+	}
+	req := &pb.CertifyRequest{
+		Identity:                  id,
+		Token:                     []byte("token"),
+		CertificateSigningRequest: csr,
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4567}})
+
+	var buf bytes.Buffer
+	validator := &fakeValidator{id, nil}
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(validator, root.Cred.Crt.CertPool(), &tls.Validity{Lifetime: time.Hour}, recordEvent, "", "", "")
+	svc.UseAuditLog(&buf)
+	svc.updateIssuer(tls.NewCA(root.Cred, tls.Validity{Lifetime: time.Hour}), root.Cred.Certificate.NotAfter)
+
+	issued := testutil.ToFloat64(certifyRequests.WithLabelValues("audit-ns", AuditOutcomeIssued))
+	denied := testutil.ToFloat64(certifyDenials.WithLabelValues(unknownNamespace, AuditReasonNotAuthenticated))
+
+	if _, err := svc.Certify(ctx, req); err != nil {
+		t.Fatalf("Failed to certify: %s", err)
+	}
+	validator.err = NotAuthenticated{}
+	if _, err := svc.Certify(ctx, req); err == nil {
+		t.Fatal("Expected the request to be denied")
+	}
+
+	dec := json.NewDecoder(&buf)
+	var records []AuditRecord
+	for dec.More() {
+		var record AuditRecord
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("Failed to decode audit record: %s", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 audit records, got %d", len(records))
+	}
+
+	rec := records[0]
+	if rec.Outcome != AuditOutcomeIssued || rec.Identity != id || rec.ServiceAccount != "foo" ||
+		rec.Namespace != "audit-ns" || rec.Requester != "10.0.0.1:4567" || rec.Serial == "" || rec.NotAfter == nil {
+		t.Fatalf("Unexpected audit record for issuance: %+v", rec)
+	}
+	rec = records[1]
+	if rec.Outcome != AuditOutcomeDenied || rec.Reason != AuditReasonNotAuthenticated || rec.Serial != "" || rec.Namespace != "" {
+		t.Fatalf("Unexpected audit record for denial: %+v", rec)
+	}
+
+	if v := testutil.ToFloat64(certifyRequests.WithLabelValues("audit-ns", AuditOutcomeIssued)); v != issued+1 {
+		t.Fatalf("Expected %v issued requests, got %v", issued+1, v)
+	}
+	if v := testutil.ToFloat64(certifyDenials.WithLabelValues(unknownNamespace, AuditReasonNotAuthenticated)); v != denied+1 {
+		t.Fatalf("Expected %v denied requests, got %v", denied+1, v)
+	}
+}
+
+func TestCertifyKeyAlgorithms(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("identity.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &tls.Validity{Lifetime: time.Hour}, recordEvent, "", "", "")
+	svc.UseKeyAlgorithms(nil, []tls.KeyAlgorithm{tls.KeyAlgorithmECDSAP256, tls.KeyAlgorithmEd25519})
+	svc.updateIssuer(tls.NewCA(root.Cred, tls.Validity{Lifetime: time.Hour}), root.Cred.Certificate.NotAfter)
+
+	for _, tc := range []struct {
+		alg      tls.KeyAlgorithm
+		expected codes.Code
+	}{
+		{tls.KeyAlgorithmECDSAP256, codes.OK},
+		{tls.KeyAlgorithmEd25519, codes.OK},
+		{tls.KeyAlgorithmRSA2048, codes.FailedPrecondition},
+	} {
+		key, err := tls.GenerateKeyWithAlgorithm(tc.alg)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %s", err)
+		}
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+		if err != nil {
+			t.Fatalf("Failed to create CSR: %s", err)
+		}
+		_, err = svc.Certify(context.Background(), &pb.CertifyRequest{
+			Identity:                  id,
+			Token:                     []byte("token"),
+			CertificateSigningRequest: csr,
+		})
+		if status.Code(err) != tc.expected {
+			t.Errorf("Expected code %s for a %s CSR, got %v", tc.expected, tc.alg, err)
+		}
+	}
+}
+
+func TestUpdateTrustAnchors(t *testing.T) {
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+	expectedName := "identity.linkerd.cluster.local"
+	dir := t.TempDir()
+	issuerPathCrt, issuerPathKey := filepath.Join(dir, "crt.pem"), filepath.Join(dir, "key.pem")
+	newRoot := func(t *testing.T) *tls.CA {
+		t.Helper()
+		root, err := tls.GenerateRootCAWithDefaults("root")
+		if err != nil {
+			t.Fatalf("Failed to create root CA: %s", err)
+		}
+		return root
+	}
+	writeIssuer := func(t *testing.T, root *tls.CA) *tls.CA {
+		t.Helper()
+		issuer, err := root.GenerateCA(expectedName, -1)
+		if err != nil {
+			t.Fatalf("Failed to create issuer: %s", err)
+		}
+		if err := os.WriteFile(issuerPathCrt, []byte(issuer.Cred.EncodePEM()), 0600); err != nil {
+			t.Fatalf("Failed to write issuer certificate: %s", err)
+		}
+		if err := os.WriteFile(issuerPathKey, []byte(issuer.Cred.EncodePrivateKeyPEM()), 0600); err != nil {
+			t.Fatalf("Failed to write issuer key: %s", err)
+		}
+		return issuer
+	}
+	certify := func(t *testing.T, svc *Service, root *tls.CA) {
+		t.Helper()
+		key, err := tls.GenerateKey()
+		if err != nil {
+			t.Fatalf("Failed to generate key: %s", err)
+		}
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+		if err != nil {
+			t.Fatalf("Failed to create CSR: %s", err)
+		}
+		rsp, err := svc.Certify(context.Background(), &pb.CertifyRequest{
+			Identity:                  id,
+			Token:                     []byte("token"),
+			CertificateSigningRequest: csr,
+		})
+		if err != nil {
+			t.Fatalf("Failed to certify: %s", err)
+		}
+		leaf, err := x509.ParseCertificate(rsp.GetLeafCertificate())
+		if err != nil {
+			t.Fatalf("Failed to parse leaf certificate: %s", err)
+		}
+		crt := tls.Crt{Certificate: leaf}
+		for _, der := range rsp.GetIntermediateCertificates() {
+			c, err := x509.ParseCertificate(der)
+			if err != nil {
+				t.Fatalf("Failed to parse intermediate certificate: %s", err)
+			}
+			crt.TrustChain = append(crt.TrustChain, c)
+		}
+		if err := crt.Verify(root.Cred.Crt.CertPool(), id, time.Time{}); err != nil {
+			t.Fatalf("Failed to verify leaf certificate: %s", err)
+		}
+	}
+
+	oldRoot, newerRoot := newRoot(t), newRoot(t)
+	writeIssuer(t, oldRoot)
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{id, nil}, oldRoot.Cred.Crt.CertPool(), &tls.Validity{Lifetime: time.Hour}, recordEvent, expectedName, issuerPathCrt, issuerPathKey)
+	if err := svc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize service: %s", err)
+	}
+
+	if err := svc.UpdateTrustAnchors([]byte("not a bundle")); err == nil {
+		t.Error("Expected an error for invalid trust anchors")
+	}
+
+	if err := svc.UpdateTrustAnchors([]byte(newerRoot.Cred.EncodeCertificatePEM())); err == nil {
+		t.Error("Expected an error for trust anchors which can't verify the issuer")
+	}
+	certify(t, svc, oldRoot)
+
+	bundle := oldRoot.Cred.EncodeCertificatePEM() + newerRoot.Cred.EncodeCertificatePEM()
+	if err := svc.UpdateTrustAnchors([]byte(bundle)); err != nil {
+		t.Fatalf("Failed to add a trust anchor: %s", err)
+	}
+	certify(t, svc, oldRoot)
+
+	issuer := writeIssuer(t, newerRoot)
+	if err := svc.UpdateTrustAnchors([]byte(newerRoot.Cred.EncodeCertificatePEM())); err != nil {
+		t.Fatalf("Failed to rotate the trust anchors along with the issuer: %s", err)
+	}
+	certify(t, svc, newerRoot)
+	if notAfter := svc.issuerNotAfter(); !notAfter.Equal(issuer.Cred.Certificate.NotAfter) {
+		t.Errorf("Expected the issuer expiry to be %s, got %s", issuer.Cred.Certificate.NotAfter, notAfter)
+	}
+
+	// Reloading the issuer from disk concurrently with trust anchor
+	// updates must leave an issuer verified by the current trust anchors.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if err := svc.Initialize(); err != nil {
+				t.Errorf("Failed to reload the issuer: %s", err)
+			}
+			svc.getIssuerCertTTL()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if err := svc.UpdateTrustAnchors([]byte(newerRoot.Cred.EncodeCertificatePEM())); err != nil {
+				t.Errorf("Failed to update the trust anchors: %s", err)
+			}
+		}
+	}()
+	wg.Wait()
+	certify(t, svc, newerRoot)
+}
+
+func TestIssuerFileSet(t *testing.T) {
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+	expectedName := "identity.linkerd.cluster.local"
+	dir := t.TempDir()
+	issuerPathCrt, issuerPathKey := filepath.Join(dir, "crt.pem"), filepath.Join(dir, "key.pem")
+	root, err := tls.GenerateRootCAWithDefaults("root")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	newIssuer := func(t *testing.T) *tls.CA {
+		t.Helper()
+		issuer, err := root.GenerateCA(expectedName, -1)
+		if err != nil {
+			t.Fatalf("Failed to create issuer: %s", err)
+		}
+		return issuer
+	}
+
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &tls.Validity{Lifetime: time.Hour}, recordEvent, expectedName, issuerPathCrt, issuerPathKey)
+	current := func() *x509.Certificate {
+		svc.issuerMutex.RLock()
+		defer svc.issuerMutex.RUnlock()
+		return (*svc.issuer).(*tls.CA).Cred.Certificate
+	}
+	first := newIssuer(t)
+	svc.updateIssuer(first, first.Cred.Certificate.NotAfter)
+
+	set := svc.IssuerFileSet(dir)
+	if len(set.Paths) != 2 {
+		t.Fatalf("Expected the issuer certificate and key to be watched, got %v", set.Paths)
+	}
+
+	second := newIssuer(t)
+	if err := set.Load(map[string][]byte{
+		issuerPathCrt: []byte(second.Cred.EncodePEM()),
+		issuerPathKey: []byte(first.Cred.EncodePrivateKeyPEM()),
+	}); err == nil {
+		t.Error("Expected an error for a certificate not matching the key")
+	}
+	if !current().Equal(first.Cred.Certificate) {
+		t.Error("Expected the issuer to be left untouched by an invalid update")
+	}
+
+	if err := set.Load(map[string][]byte{
+		issuerPathCrt: []byte(second.Cred.EncodePEM()),
+		issuerPathKey: []byte(second.Cred.EncodePrivateKeyPEM()),
+	}); err != nil {
+		t.Fatalf("Failed to load the issuer: %s", err)
+	}
+	if !current().Equal(second.Cred.Certificate) {
+		t.Error("Expected the issuer to be replaced")
+	}
+}
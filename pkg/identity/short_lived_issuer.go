@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/tls"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MinShortLivedIssuerLifetime is the shortest lifetime allowed for the
+	// issuers minted by the identity service.
+	MinShortLivedIssuerLifetime = 10 * time.Minute
+
+	eventTypeIssuerRotated = "IssuerRotated"
+
+	// shortLivedIssuerRetryInterval is the interval at which the rotation of a
+	// short-lived issuer is retried after a failure.
+	shortLivedIssuerRetryInterval = 30 * time.Second
+
+	// shortLivedIssuerNameSuffix is the time layout of the suffix of the names
+	// of short-lived issuers.
+	shortLivedIssuerNameSuffix = "20060102T150405Z"
+)
+
+// UseShortLivedIssuers configures the service to mint its own issuers, valid
+// for the given lifetime, with the credentials read from disk, which must
+// then belong to a CA allowed to sign intermediate CAs. Issuers are rotated
+// after two thirds of their lifetime. It must be called before Initialize.
+func (svc *Service) UseShortLivedIssuers(lifetime time.Duration) error {
+	if lifetime < MinShortLivedIssuerLifetime {
+		return fmt.Errorf("short-lived issuers must be valid for at least %s", MinShortLivedIssuerLifetime)
+	}
+	svc.shortLivedIssuerLifetime = lifetime
+	return nil
+}
+
+// mintIssuer generates a new short-lived issuer signed with the given
+// credentials.
+func (svc *Service) mintIssuer(signer *tls.Cred) (*tls.CA, error) {
+	crt := signer.Certificate
+	if crt.BasicConstraintsValid && crt.MaxPathLen == 0 && crt.MaxPathLenZero {
+		return nil, errors.New("failed to mint a short-lived issuer: the signing CA is not allowed to sign intermediate CAs")
+	}
+
+	parent, err := tls.NewCAWithRandomSerials(*signer, tls.Validity{
+		Lifetime:           svc.shortLivedIssuerLifetime,
+		ClockSkewAllowance: svc.validity.ClockSkewAllowance,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The minted issuers are named after the credentials read from disk,
+	// suffixed with their time of minting so that they can be told apart.
+	name := fmt.Sprintf("%s-%s", svc.expectedName, time.Now().UTC().Format(shortLivedIssuerNameSuffix))
+	minted, err := parent.GenerateCA(name, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint a short-lived issuer: %w", err)
+	}
+
+	issuer, err := tls.NewCAWithRandomSerials(minted.Cred, *svc.validity)
+	if err != nil {
+		return nil, err
+	}
+	issuer.KeyAlgorithms = svc.csrKeyAlgorithms
+
+	notAfter := issuer.Cred.Certificate.NotAfter
+	message := fmt.Sprintf("Minted issuer %x valid until %s", issuer.Cred.Certificate.SerialNumber, notAfter.Format(time.RFC3339))
+	log.WithFields(log.Fields{
+		"serial":        issuer.Cred.Certificate.SerialNumber.Text(16),
+		"invalid_after": notAfter.Unix(),
+	}).Info(message)
+	log.Debugf("Minted issuer chain: %s", issuer.Cred.EncodePEM())
+	svc.recordEvent(nil, v1.EventTypeNormal, eventTypeIssuerRotated, message)
+	return issuer, nil
+}
+
+// rotateIn returns the time left until the current short-lived issuer must be
+// rotated, and false if the service doesn't mint its issuers.
+func (svc *Service) rotateIn() (time.Duration, bool) {
+	notAfter := svc.issuerNotAfter()
+	if svc.shortLivedIssuerLifetime == 0 || notAfter.IsZero() {
+		return 0, false
+	}
+	rotateIn := time.Until(notAfter.Add(-svc.shortLivedIssuerLifetime / 3))
+	if rotateIn <= 0 {
+		// The last rotation failed.
+		rotateIn = shortLivedIssuerRetryInterval
+	}
+	return rotateIn, true
+}
+
+// scheduleRotation resets the given timer to fire when the current short-lived
+// issuer must be rotated, or stops it if the service doesn't mint its issuers.
+func (svc *Service) scheduleRotation(rotation *time.Timer) {
+	if rotateIn, ok := svc.rotateIn(); ok {
+		rotation.Reset(rotateIn)
+	} else {
+		rotation.Stop()
+	}
+}
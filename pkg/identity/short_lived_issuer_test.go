@@ -0,0 +1,132 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/identity"
+	"github.com/linkerd/linkerd2/pkg/tls"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestShortLivedIssuers(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("root")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	writeCreds := func(t *testing.T, cred *tls.Cred) (string, string) {
+		t.Helper()
+		dir := t.TempDir()
+		crtPath, keyPath := filepath.Join(dir, "crt.pem"), filepath.Join(dir, "key.pem")
+		if err := os.WriteFile(crtPath, []byte(cred.EncodePEM()), 0600); err != nil {
+			t.Fatalf("Failed to write certificate: %s", err)
+		}
+		if err := os.WriteFile(keyPath, []byte(cred.EncodePrivateKeyPEM()), 0600); err != nil {
+			t.Fatalf("Failed to write key: %s", err)
+		}
+		return crtPath, keyPath
+	}
+	recordEvent := func(runtime.Object, string, string, string) {}
+	validity := tls.Validity{Lifetime: 24 * time.Hour}
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+
+	t.Run("mints and rotates issuers", func(t *testing.T) {
+		crtPath, keyPath := writeCreds(t, &root.Cred)
+		svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &validity, recordEvent, "identity.linkerd.cluster.local", crtPath, keyPath)
+		if err := svc.UseShortLivedIssuers(time.Hour); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if err := svc.Initialize(); err != nil {
+			t.Fatalf("Failed to initialize service: %s", err)
+		}
+		if _, ok := svc.rotateIn(); !ok {
+			t.Fatal("Expected the short-lived issuer to be scheduled for rotation")
+		}
+
+		certify := func() []*x509.Certificate {
+			key, err := tls.GenerateKey()
+			if err != nil {
+				t.Fatalf("Failed to generate key: %s", err)
+			}
+			csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}}, key)
+			if err != nil {
+				t.Fatalf("Failed to create CSR: %s", err)
+			}
+			rsp, err := svc.Certify(context.Background(), &pb.CertifyRequest{
+				Identity:                  id,
+				Token:                     []byte("token"),
+				CertificateSigningRequest: csr,
+			})
+			if err != nil {
+				t.Fatalf("Failed to certify: %s", err)
+			}
+			var chain []*x509.Certificate
+			for _, der := range append([][]byte{rsp.GetLeafCertificate()}, rsp.GetIntermediateCertificates()...) {
+				c, err := x509.ParseCertificate(der)
+				if err != nil {
+					t.Fatalf("Failed to parse certificate: %s", err)
+				}
+				chain = append(chain, c)
+			}
+			return chain
+		}
+
+		chain := certify()
+		if len(chain) != 3 {
+			t.Fatalf("Expected the leaf, the short-lived issuer and the root, got %d certificates", len(chain))
+		}
+		leaf, issuer := chain[0], chain[1]
+		if issuer.Equal(root.Cred.Certificate) || !issuer.IsCA {
+			t.Fatal("Expected the leaf certificate to be issued by a minted issuer")
+		}
+		if name := issuer.Subject.CommonName; name == "identity.linkerd.cluster.local" || !strings.HasPrefix(name, "identity.linkerd.cluster.local-") {
+			t.Errorf("Expected the minted issuer to be named after the expected name with a suffix, got %s", name)
+		}
+		if issuer.NotAfter.After(time.Now().Add(time.Hour + time.Minute)) {
+			t.Errorf("Expected the minted issuer to be short-lived, it expires at %s", issuer.NotAfter)
+		}
+		if leaf.NotAfter.After(issuer.NotAfter) {
+			t.Errorf("Expected the leaf certificate not to outlive its issuer")
+		}
+		crt := tls.Crt{Certificate: leaf, TrustChain: []*x509.Certificate{chain[2], issuer}}
+		if err := crt.Verify(root.Cred.Crt.CertPool(), id, time.Time{}); err != nil {
+			t.Fatalf("Failed to verify leaf certificate: %s", err)
+		}
+
+		if err := svc.Initialize(); err != nil {
+			t.Fatalf("Failed to rotate the issuer: %s", err)
+		}
+		rotated := certify()[1]
+		if rotated.Equal(issuer) || rotated.SerialNumber.Cmp(issuer.SerialNumber) == 0 {
+			t.Error("Expected the rotated issuer to be a new certificate with a new serial number")
+		}
+	})
+
+	t.Run("requires a signing CA allowed to sign intermediates", func(t *testing.T) {
+		issuer, err := root.GenerateCA("identity.linkerd.cluster.local", 0)
+		if err != nil {
+			t.Fatalf("Failed to create issuer: %s", err)
+		}
+		crtPath, keyPath := writeCreds(t, &issuer.Cred)
+		svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &validity, recordEvent, "identity.linkerd.cluster.local", crtPath, keyPath)
+		if err := svc.UseShortLivedIssuers(time.Hour); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if err := svc.Initialize(); err == nil {
+			t.Fatal("Expected minting an issuer with a path length constrained CA to fail")
+		}
+	})
+
+	t.Run("rejects too short lifetimes", func(t *testing.T) {
+		svc := NewService(&fakeValidator{id, nil}, nil, &validity, recordEvent, "", "", "")
+		if err := svc.UseShortLivedIssuers(time.Minute); err == nil {
+			t.Fatal("Expected an error for a lifetime shorter than the minimum")
+		}
+	})
+}
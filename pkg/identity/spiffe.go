@@ -0,0 +1,170 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/tls"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	spiffeScheme = "spiffe"
+
+	// DefaultSPIFFEBundleRefreshHint is the refresh hint advertised in SPIFFE
+	// bundles.
+	DefaultSPIFFEBundleRefreshHint = 5 * time.Minute
+)
+
+type (
+	// SPIFFEBundle is a trust bundle in the SPIFFE bundle format, i.e. a JWK
+	// set whose keys hold the trust anchors for X.509-SVIDs.
+	SPIFFEBundle struct {
+		Keys        []SPIFFEBundleKey `json:"keys"`
+		Sequence    int64             `json:"spiffe_sequence,omitempty"`
+		RefreshHint int64             `json:"spiffe_refresh_hint,omitempty"`
+	}
+
+	// SPIFFEBundleKey is a JWK holding a trust anchor.
+	SPIFFEBundleKey struct {
+		Use string   `json:"use"`
+		Kty string   `json:"kty"`
+		Crv string   `json:"crv,omitempty"`
+		X   string   `json:"x,omitempty"`
+		Y   string   `json:"y,omitempty"`
+		N   string   `json:"n,omitempty"`
+		E   string   `json:"e,omitempty"`
+		X5c []string `json:"x5c"`
+	}
+)
+
+// UseSPIFFE configures the service to add to the issued certificates a URI
+// SAN with the SPIFFE ID of the certified service account, in the given
+// SPIFFE trust domain. CSRs may then hold that URI SAN.
+func (svc *Service) UseSPIFFE(trustDomain string) {
+	svc.spiffeTrustDomain = trustDomain
+}
+
+// SPIFFEID returns the SPIFFE ID, in the given SPIFFE trust domain, of a
+// service account's DNS-form identity, or nil if the identity doesn't belong
+// to a service account.
+func SPIFFEID(trustDomain, identity string) *url.URL {
+	parts := strings.Split(identity, ".")
+	if len(parts) < 5 || parts[2] != "serviceaccount" || parts[3] != "identity" {
+		return nil
+	}
+	return &url.URL{
+		Scheme: spiffeScheme,
+		Host:   trustDomain,
+		Path:   fmt.Sprintf("/ns/%s/sa/%s", parts[1], parts[0]),
+	}
+}
+
+// checkSPIFFEID ensures that the URI SANs of a CSR are either empty or only
+// hold the expected SPIFFE ID.
+func checkSPIFFEID(uris []*url.URL, expected *url.URL) error {
+	if len(uris) == 0 {
+		return nil
+	}
+	if expected == nil {
+		return errors.New("cannot validate URIs")
+	}
+	if len(uris) != 1 || uris[0].String() != expected.String() {
+		return fmt.Errorf("CSR URIs do not match the SPIFFE ID %s", expected)
+	}
+	return nil
+}
+
+// NewSPIFFEBundle encodes the given trust anchors as a SPIFFE bundle.
+func NewSPIFFEBundle(anchors []*x509.Certificate, sequence int64, refreshHint time.Duration) (*SPIFFEBundle, error) {
+	bundle := &SPIFFEBundle{
+		Keys:        make([]SPIFFEBundleKey, 0, len(anchors)),
+		Sequence:    sequence,
+		RefreshHint: int64(refreshHint.Seconds()),
+	}
+	for _, anchor := range anchors {
+		key := SPIFFEBundleKey{
+			Use: "x509-svid",
+			X5c: []string{base64.StdEncoding.EncodeToString(anchor.Raw)},
+		}
+		switch pub := anchor.PublicKey.(type) {
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			key.Kty = "EC"
+			key.Crv = pub.Curve.Params().Name
+			key.X = encodeJWKInt(pub.X, size)
+			key.Y = encodeJWKInt(pub.Y, size)
+		case *rsa.PublicKey:
+			key.Kty = "RSA"
+			key.N = encodeJWKInt(pub.N, 0)
+			key.E = encodeJWKInt(big.NewInt(int64(pub.E)), 0)
+		case ed25519.PublicKey:
+			key.Kty = "OKP"
+			key.Crv = "Ed25519"
+			key.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			return nil, fmt.Errorf("unsupported trust anchor key type %T", anchor.PublicKey)
+		}
+		bundle.Keys = append(bundle.Keys, key)
+	}
+	return bundle, nil
+}
+
+// NewSPIFFEBundleHandler returns an HTTP handler serving the trust anchors of
+// the given PEM file as a SPIFFE bundle. The file is read on every request so
+// that updated trust anchors are served; its modification time is used as
+// the bundle's sequence number.
+func NewSPIFFEBundleHandler(anchorsPath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		bundle, err := readSPIFFEBundle(anchorsPath)
+		if err != nil {
+			log.Errorf("failed to serve the SPIFFE bundle: %s", err)
+			http.Error(w, "trust bundle unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(bundle); err != nil {
+			log.Errorf("failed to write the SPIFFE bundle: %s", err)
+		}
+	})
+}
+
+func readSPIFFEBundle(anchorsPath string) (*SPIFFEBundle, error) {
+	path := filepath.Clean(anchorsPath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	anchors, err := tls.DecodePEMCertificates(string(pem))
+	if err != nil {
+		return nil, err
+	}
+	return NewSPIFFEBundle(anchors, info.ModTime().Unix(), DefaultSPIFFEBundleRefreshHint)
+}
+
+// encodeJWKInt encodes an integer as a base64url JWK value, left-padded with
+// zeros to the given size.
+func encodeJWKInt(n *big.Int, size int) string {
+	b := n.Bytes()
+	if len(b) < size {
+		b = append(make([]byte, size-len(b)), b...)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,172 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/identity"
+	"github.com/linkerd/linkerd2/pkg/tls"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSPIFFEID(t *testing.T) {
+	for _, tc := range []struct {
+		identity string
+		expected string
+	}{
+		{"foo.ns.serviceaccount.identity.linkerd.cluster.local", "spiffe://cluster.local/ns/ns/sa/foo"},
+		{"linkerd-identity.linkerd.serviceaccount.identity.linkerd.example.com", "spiffe://cluster.local/ns/linkerd/sa/linkerd-identity"},
+		{"foo.ns.deployment.identity.linkerd.cluster.local", ""},
+		{"foo.ns", ""},
+	} {
+		id := SPIFFEID("cluster.local", tc.identity)
+		if tc.expected == "" {
+			if id != nil {
+				t.Errorf("Expected no SPIFFE ID for %s, got %s", tc.identity, id)
+			}
+			continue
+		}
+		if id == nil || id.String() != tc.expected {
+			t.Errorf("Expected SPIFFE ID %s for %s, got %v", tc.expected, tc.identity, id)
+		}
+	}
+}
+
+func TestCertifySPIFFE(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("identity.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to create root CA: %s", err)
+	}
+	id := "foo.ns.serviceaccount.identity.linkerd.cluster.local"
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{id, nil}, root.Cred.Crt.CertPool(), &tls.Validity{Lifetime: time.Hour}, recordEvent, "", "", "")
+	svc.UseSPIFFE("cluster.local")
+	svc.updateIssuer(tls.NewCA(root.Cred, tls.Validity{Lifetime: time.Hour}), root.Cred.Certificate.NotAfter)
+
+	for _, tc := range []struct {
+		name     string
+		uris     []string
+		expected codes.Code
+	}{
+		{"without URIs", nil, codes.OK},
+		{"with the SPIFFE ID", []string{"spiffe://cluster.local/ns/ns/sa/foo"}, codes.OK},
+		{"with another SPIFFE ID", []string{"spiffe://cluster.local/ns/ns/sa/bar"}, codes.FailedPrecondition},
+		{"with extra URIs", []string{"spiffe://cluster.local/ns/ns/sa/foo", "https://example.com"}, codes.FailedPrecondition},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var uris []*url.URL
+			for _, u := range tc.uris {
+				parsed, err := url.Parse(u)
+				if err != nil {
+					t.Fatalf("Failed to parse URI: %s", err)
+				}
+				uris = append(uris, parsed)
+			}
+			key, err := tls.GenerateKey()
+			if err != nil {
+				t.Fatalf("Failed to generate key: %s", err)
+			}
+			csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{id}, URIs: uris}, key)
+			if err != nil {
+				t.Fatalf("Failed to create CSR: %s", err)
+			}
+			rsp, err := svc.Certify(context.Background(), &pb.CertifyRequest{
+				Identity:                  id,
+				Token:                     []byte("token"),
+				CertificateSigningRequest: csr,
+			})
+			if status.Code(err) != tc.expected {
+				t.Fatalf("Expected code %s, got %v", tc.expected, err)
+			}
+			if err != nil {
+				return
+			}
+			leaf, err := x509.ParseCertificate(rsp.GetLeafCertificate())
+			if err != nil {
+				t.Fatalf("Failed to parse certificate: %s", err)
+			}
+			if len(leaf.URIs) != 1 || leaf.URIs[0].String() != "spiffe://cluster.local/ns/ns/sa/foo" {
+				t.Errorf("Expected the SPIFFE ID as the only URI SAN, got %v", leaf.URIs)
+			}
+			if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != id {
+				t.Errorf("Expected the identity as the only DNS SAN, got %v", leaf.DNSNames)
+			}
+		})
+	}
+}
+
+func TestSPIFFEBundle(t *testing.T) {
+	var anchors []*x509.Certificate
+	pem := ""
+	for _, alg := range tls.KeyAlgorithms() {
+		ca, err := tls.GenerateRootCA("root-"+string(alg), alg)
+		if err != nil {
+			t.Fatalf("Failed to create %s root CA: %s", alg, err)
+		}
+		anchors = append(anchors, ca.Cred.Certificate)
+		pem += ca.Cred.Crt.EncodeCertificatePEM()
+	}
+
+	t.Run("encodes the trust anchors as JWKs", func(t *testing.T) {
+		bundle, err := NewSPIFFEBundle(anchors, 1, DefaultSPIFFEBundleRefreshHint)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		expected := []struct{ kty, crv string }{
+			{"EC", "P-256"},
+			{"RSA", ""},
+			{"RSA", ""},
+			{"OKP", "Ed25519"},
+		}
+		if len(bundle.Keys) != len(expected) {
+			t.Fatalf("Expected %d keys, got %d", len(expected), len(bundle.Keys))
+		}
+		for i, key := range bundle.Keys {
+			if key.Use != "x509-svid" || key.Kty != expected[i].kty || key.Crv != expected[i].crv {
+				t.Errorf("Unexpected key %d: %+v", i, key)
+			}
+			if len(key.X5c) != 1 {
+				t.Errorf("Expected key %d to hold its trust anchor", i)
+			}
+		}
+		if bundle.RefreshHint != int64(DefaultSPIFFEBundleRefreshHint.Seconds()) {
+			t.Errorf("Unexpected refresh hint %d", bundle.RefreshHint)
+		}
+	})
+
+	t.Run("serves the trust anchors read from disk", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca-bundle.crt")
+		if err := os.WriteFile(path, []byte(pem), 0600); err != nil {
+			t.Fatalf("Failed to write trust anchors: %s", err)
+		}
+		rec := httptest.NewRecorder()
+		NewSPIFFEBundleHandler(path).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		var bundle SPIFFEBundle
+		if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil {
+			t.Fatalf("Failed to decode bundle: %s", err)
+		}
+		if len(bundle.Keys) != len(anchors) || bundle.Sequence == 0 {
+			t.Errorf("Unexpected bundle: %+v", bundle)
+		}
+
+		rec = httptest.NewRecorder()
+		NewSPIFFEBundleHandler(filepath.Join(t.TempDir(), "missing")).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500 for missing trust anchors, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package inject
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// injectedContainers are the containers added by the proxy injector whose
+// spec is compared to detect drift
+var injectedContainers = []string{
+	k8s.InitContainerName,
+	k8s.ProxyContainerName,
+	k8s.DebugContainerName,
+}
+
+// InjectedPod returns the pod resulting from applying the proxy injector's
+// patch to podJSON, which must have been parsed into conf beforehand
+func (conf *ResourceConfig) InjectedPod(podJSON []byte, overrider ValueOverrider) (*corev1.Pod, error) {
+	patchJSON, err := conf.GetPodPatch(true, overrider)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return nil, err
+	}
+	injectedJSON, err := patch.Apply(podJSON)
+	if err != nil {
+		return nil, err
+	}
+	var pod corev1.Pod
+	if err := json.Unmarshal(injectedJSON, &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// InjectedContainersDrift compares the containers added by the proxy injector
+// to the expected and actual pod specs, returning why they differ, if they
+// do. Only the fields set by the injector are compared, so that defaults set
+// by the API server aren't reported.
+func InjectedContainersDrift(expected, actual *corev1.PodSpec) []string {
+	var reasons []string
+	for _, name := range injectedContainers {
+		e, eInit := findContainer(expected, name)
+		a, aInit := findContainer(actual, name)
+		switch {
+		case e == nil && a == nil:
+			continue
+		case e == nil:
+			reasons = append(reasons, fmt.Sprintf("%s: would no longer be injected", name))
+			continue
+		case a == nil:
+			reasons = append(reasons, fmt.Sprintf("%s: would be injected", name))
+			continue
+		}
+
+		if eInit != aInit {
+			reasons = append(reasons, fmt.Sprintf("%s: would be injected as %s", name, containerKind(eInit)))
+		}
+		for _, reason := range containerDrift(e, a) {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", name, reason))
+		}
+	}
+	return reasons
+}
+
+func containerDrift(expected, actual *corev1.Container) []string {
+	var reasons []string
+	if expected.Image != actual.Image {
+		reasons = append(reasons, fmt.Sprintf("image changed from %s to %s", actual.Image, expected.Image))
+	}
+	if expected.ImagePullPolicy != "" && expected.ImagePullPolicy != actual.ImagePullPolicy {
+		reasons = append(reasons, fmt.Sprintf("image pull policy changed from %s to %s", actual.ImagePullPolicy, expected.ImagePullPolicy))
+	}
+	if !equality.Semantic.DeepEqual(expected.Command, actual.Command) {
+		reasons = append(reasons, "command changed")
+	}
+	if !equality.Semantic.DeepEqual(expected.Args, actual.Args) {
+		reasons = append(reasons, fmt.Sprintf("args changed from %q to %q", actual.Args, expected.Args))
+	}
+	reasons = append(reasons, envDrift(expected.Env, actual.Env)...)
+	if !portsEqual(expected.Ports, actual.Ports) {
+		reasons = append(reasons, fmt.Sprintf("ports changed from %s to %s", formatPorts(actual.Ports), formatPorts(expected.Ports)))
+	}
+	if !equality.Semantic.DeepEqual(expected.Resources, actual.Resources) {
+		reasons = append(reasons, fmt.Sprintf("resources changed from %s to %s", formatResources(actual.Resources), formatResources(expected.Resources)))
+	}
+	if !equality.Semantic.DeepEqual(expected.SecurityContext, actual.SecurityContext) {
+		reasons = append(reasons, "security context changed")
+	}
+	return reasons
+}
+
+func envDrift(expected, actual []corev1.EnvVar) []string {
+	expectedVars := envByName(expected)
+	actualVars := envByName(actual)
+	names := map[string]struct{}{}
+	for name := range expectedVars {
+		names[name] = struct{}{}
+	}
+	for name := range actualVars {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var reasons []string
+	for _, name := range sorted {
+		e, inExpected := expectedVars[name]
+		a, inActual := actualVars[name]
+		switch {
+		case !inActual:
+			reasons = append(reasons, fmt.Sprintf("env %s added", name))
+		case !inExpected:
+			reasons = append(reasons, fmt.Sprintf("env %s removed", name))
+		case e.ValueFrom == nil && a.ValueFrom == nil:
+			if e.Value != a.Value {
+				reasons = append(reasons, fmt.Sprintf("env %s changed from %q to %q", name, a.Value, e.Value))
+			}
+		case !reflect.DeepEqual(normalizeEnvSource(e.ValueFrom), normalizeEnvSource(a.ValueFrom)) || e.Value != a.Value:
+			reasons = append(reasons, fmt.Sprintf("env %s changed", name))
+		}
+	}
+	return reasons
+}
+
+func envByName(env []corev1.EnvVar) map[string]corev1.EnvVar {
+	vars := make(map[string]corev1.EnvVar, len(env))
+	for _, v := range env {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+// normalizeEnvSource sets the field selector API version the API server
+// defaults to
+func normalizeEnvSource(src *corev1.EnvVarSource) *corev1.EnvVarSource {
+	if src == nil || src.FieldRef == nil || src.FieldRef.APIVersion != "" {
+		return src
+	}
+	normalized := src.DeepCopy()
+	normalized.FieldRef.APIVersion = "v1"
+	return normalized
+}
+
+func portsEqual(expected, actual []corev1.ContainerPort) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		e, a := expected[i], actual[i]
+		if e.Protocol == "" {
+			e.Protocol = corev1.ProtocolTCP
+		}
+		if a.Protocol == "" {
+			a.Protocol = corev1.ProtocolTCP
+		}
+		if e != a {
+			return false
+		}
+	}
+	return true
+}
+
+func formatPorts(ports []corev1.ContainerPort) string {
+	formatted := make([]string, 0, len(ports))
+	for _, p := range ports {
+		formatted = append(formatted, fmt.Sprintf("%s:%d", p.Name, p.ContainerPort))
+	}
+	return "[" + strings.Join(formatted, " ") + "]"
+}
+
+func formatResources(r corev1.ResourceRequirements) string {
+	format := func(list corev1.ResourceList) string {
+		names := make([]string, 0, len(list))
+		for name := range list {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		formatted := make([]string, 0, len(names))
+		for _, name := range names {
+			q := list[corev1.ResourceName(name)]
+			formatted = append(formatted, fmt.Sprintf("%s=%s", name, q.String()))
+		}
+		return strings.Join(formatted, ",")
+	}
+	return fmt.Sprintf("requests{%s} limits{%s}", format(r.Requests), format(r.Limits))
+}
+
+// findContainer returns the container of the given name and whether it's an
+// init container
+func findContainer(spec *corev1.PodSpec, name string) (*corev1.Container, bool) {
+	for i := range spec.InitContainers {
+		if spec.InitContainers[i].Name == name {
+			return &spec.InitContainers[i], true
+		}
+	}
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == name {
+			return &spec.Containers[i], false
+		}
+	}
+	return nil, false
+}
+
+func containerKind(init bool) string {
+	if init {
+		return "an init container"
+	}
+	return "a container"
+}
@@ -0,0 +1,97 @@
+package inject
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+	l5dcharts "github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func injectedTestPod(t *testing.T, values *l5dcharts.Values, annotations map[string]string) *corev1.Pod {
+	t.Helper()
+	podJSON, err := json.Marshal(&corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "emojivoto",
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: "web:v1"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := NewResourceConfig(values, OriginWebhook, "linkerd").WithKind("Pod")
+	if _, err := conf.ParseMetaAndYAML(podJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.AppendInjectorAnnotations(); err != nil {
+		t.Fatal(err)
+	}
+	pod, err := conf.InjectedPod(podJSON, GetOverriddenValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pod
+}
+
+func TestInjectedContainersDrift(t *testing.T) {
+	values, err := l5dcharts.NewValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	values.IdentityTrustAnchorsPEM = "trust-anchors"
+	inject := map[string]string{k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled}
+	actual := injectedTestPod(t, values, inject)
+
+	t.Run("no drift", func(t *testing.T) {
+		expected := injectedTestPod(t, values, inject)
+		if reasons := InjectedContainersDrift(&expected.Spec, &actual.Spec); len(reasons) != 0 {
+			t.Fatalf("Expected no drift, got %v", reasons)
+		}
+	})
+
+	t.Run("upgrade and config change", func(t *testing.T) {
+		upgraded, err := values.DeepCopy()
+		if err != nil {
+			t.Fatal(err)
+		}
+		upgraded.Proxy.Image.Version = "upgraded"
+		expected := injectedTestPod(t, upgraded, map[string]string{
+			k8s.ProxyInjectAnnotation:   k8s.ProxyInjectEnabled,
+			k8s.ProxyLogLevelAnnotation: "debug",
+		})
+
+		reasons := InjectedContainersDrift(&expected.Spec, &actual.Spec)
+		proxy, _ := findContainer(&actual.Spec, k8s.ProxyContainerName)
+		imageChange := "image changed from " + proxy.Image + " to " + values.Proxy.Image.Name + ":upgraded"
+		expectedReasons := []string{
+			"linkerd-init: " + imageChange,
+			"linkerd-proxy: " + imageChange,
+			`linkerd-proxy: env LINKERD2_PROXY_LOG changed from "warn,linkerd=info,hickory=error,[{headers}]=off,[{request}]=off" to "debug,[{headers}]=off,[{request}]=off"`,
+		}
+		if diff := deep.Equal(reasons, expectedReasons); diff != nil {
+			t.Fatalf("%+v", diff)
+		}
+	})
+
+	t.Run("removed proxy", func(t *testing.T) {
+		expected := actual.DeepCopy()
+		expected.Spec.Containers = []corev1.Container{{Name: "web", Image: "web:v1"}}
+		expected.Spec.InitContainers = nil
+		reasons := InjectedContainersDrift(&expected.Spec, &actual.Spec)
+		expectedReasons := []string{
+			"linkerd-init: would no longer be injected",
+			"linkerd-proxy: would no longer be injected",
+		}
+		if diff := deep.Equal(reasons, expectedReasons); diff != nil {
+			t.Fatalf("%+v", diff)
+		}
+	})
+}
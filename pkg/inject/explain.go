@@ -0,0 +1,182 @@
+package inject
+
+import (
+	"fmt"
+	"strconv"
+
+	l5dcharts "github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/linkerd/linkerd2/pkg/util"
+)
+
+// SettingSource describes where the value of a proxy setting comes from
+type SettingSource string
+
+const (
+	// SourceDefault is the source of settings taken from the chart values
+	SourceDefault SettingSource = "default"
+
+	// SourceNamespace is the source of settings taken from a namespace
+	// annotation
+	SourceNamespace SettingSource = "namespace annotation"
+
+	// SourceProxyConfig is the source of settings taken from the ProxyConfig
+	// referred to by the workload or its namespace
+	SourceProxyConfig SettingSource = "proxy config"
+
+	// SourceWorkload is the source of settings taken from a workload
+	// annotation
+	SourceWorkload SettingSource = "workload annotation"
+
+	// SourceCLI is the source of settings taken from a CLI flag
+	SourceCLI SettingSource = "CLI flag"
+)
+
+// Setting is the final value of a proxy setting, identified by the annotation
+// overriding it, along with the source of that value
+type Setting struct {
+	Annotation string        `json:"annotation"`
+	Value      string        `json:"value"`
+	Source     SettingSource `json:"source"`
+}
+
+// Explanation describes how a resource would be injected: whether it would
+// be injected at all and, if so, the value and source of every proxy setting
+type Explanation struct {
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Injectable  bool      `json:"injectable"`
+	SkipReasons []string  `json:"skipReasons,omitempty"`
+	ProxyConfig string    `json:"proxyConfig,omitempty"`
+	Warnings    []string  `json:"warnings,omitempty"`
+	Settings    []Setting `json:"settings,omitempty"`
+}
+
+// explainedSettings lists the settings reported in explanations, along with
+// how to read their value from the chart values once overridden
+var explainedSettings = []struct {
+	annotation string
+	value      func(*l5dcharts.Values) string
+}{
+	{k8s.ProxyImageAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Image.Name }},
+	{k8s.ProxyVersionOverrideAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Image.Version }},
+	{k8s.ProxyImagePullPolicyAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Image.PullPolicy }},
+	{k8s.ProxyAdminPortAnnotation, func(v *l5dcharts.Values) string { return formatInt32(v.Proxy.Ports.Admin) }},
+	{k8s.ProxyControlPortAnnotation, func(v *l5dcharts.Values) string { return formatInt32(v.Proxy.Ports.Control) }},
+	{k8s.ProxyInboundPortAnnotation, func(v *l5dcharts.Values) string { return formatInt32(v.Proxy.Ports.Inbound) }},
+	{k8s.ProxyOutboundPortAnnotation, func(v *l5dcharts.Values) string { return formatInt32(v.Proxy.Ports.Outbound) }},
+	{k8s.ProxyPodInboundPortsAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.PodInboundPorts }},
+	{k8s.ProxyIgnoreInboundPortsAnnotation, func(v *l5dcharts.Values) string { return v.ProxyInit.IgnoreInboundPorts }},
+	{k8s.ProxyIgnoreOutboundPortsAnnotation, func(v *l5dcharts.Values) string { return v.ProxyInit.IgnoreOutboundPorts }},
+	{k8s.ProxyOpaquePortsAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.OpaquePorts }},
+	{k8s.ProxySkipSubnetsAnnotation, func(v *l5dcharts.Values) string { return v.ProxyInit.SkipSubnets }},
+	{k8s.ProxyCPURequestAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Resources.CPU.Request }},
+	{k8s.ProxyCPULimitAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Resources.CPU.Limit }},
+	{k8s.ProxyMemoryRequestAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Resources.Memory.Request }},
+	{k8s.ProxyMemoryLimitAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Resources.Memory.Limit }},
+	{k8s.ProxyEphemeralStorageRequestAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Resources.EphemeralStorage.Request }},
+	{k8s.ProxyEphemeralStorageLimitAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.Resources.EphemeralStorage.Limit }},
+	{k8s.ProxyUIDAnnotation, func(v *l5dcharts.Values) string { return strconv.FormatInt(v.Proxy.UID, 10) }},
+	{k8s.ProxyGIDAnnotation, func(v *l5dcharts.Values) string { return strconv.FormatInt(v.Proxy.GID, 10) }},
+	{k8s.ProxyLogLevelAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.LogLevel }},
+	{k8s.ProxyLogFormatAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.LogFormat }},
+	{k8s.ProxyLogHTTPHeaders, func(v *l5dcharts.Values) string { return v.Proxy.LogHTTPHeaders }},
+	{k8s.ProxyAccessLogAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.AccessLog }},
+	{k8s.ProxyRequireIdentityOnInboundPortsAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.RequireIdentityOnInboundPorts }},
+	{k8s.ProxyDefaultInboundPolicyAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.DefaultInboundPolicy }},
+	{k8s.ProxyInboundConnectTimeout, func(v *l5dcharts.Values) string { return v.Proxy.InboundConnectTimeout }},
+	{k8s.ProxyOutboundConnectTimeout, func(v *l5dcharts.Values) string { return v.Proxy.OutboundConnectTimeout }},
+	{k8s.ProxyInboundDiscoveryCacheUnusedTimeout, func(v *l5dcharts.Values) string { return v.Proxy.InboundDiscoveryCacheUnusedTimeout }},
+	{k8s.ProxyOutboundDiscoveryCacheUnusedTimeout, func(v *l5dcharts.Values) string { return v.Proxy.OutboundDiscoveryCacheUnusedTimeout }},
+	{k8s.ProxyDisableInboundProtocolDetectTimeout, func(v *l5dcharts.Values) string {
+		return strconv.FormatBool(v.Proxy.DisableInboundProtocolDetectTimeout)
+	}},
+	{k8s.ProxyDisableOutboundProtocolDetectTimeout, func(v *l5dcharts.Values) string {
+		return strconv.FormatBool(v.Proxy.DisableOutboundProtocolDetectTimeout)
+	}},
+	{k8s.ProxyShutdownGracePeriodAnnotation, func(v *l5dcharts.Values) string { return v.Proxy.ShutdownGracePeriod }},
+	{k8s.ProxyWaitBeforeExitSecondsAnnotation, func(v *l5dcharts.Values) string {
+		return strconv.FormatUint(v.Proxy.WaitBeforeExitSeconds, 10)
+	}},
+	{k8s.ProxyAdminShutdownAnnotation, func(v *l5dcharts.Values) string { return enabledOrDisabled(v.Proxy.EnableShutdownEndpoint) }},
+	{k8s.ProxyAwait, func(v *l5dcharts.Values) string { return enabledOrDisabled(v.Proxy.Await) }},
+	{k8s.ProxyEnableNativeSidecarAnnotation, func(v *l5dcharts.Values) string { return strconv.FormatBool(v.Proxy.NativeSidecar) }},
+	{k8s.ProxyEnableExternalProfilesAnnotation, func(v *l5dcharts.Values) string {
+		return strconv.FormatBool(v.Proxy.EnableExternalProfiles)
+	}},
+	{k8s.ProxyEnableHostnameLabels, func(v *l5dcharts.Values) string { return strconv.FormatBool(v.Proxy.Metrics.HostnameLabels) }},
+	{k8s.DebugImageAnnotation, func(v *l5dcharts.Values) string { return v.DebugContainer.Image.Name }},
+	{k8s.DebugImageVersionAnnotation, func(v *l5dcharts.Values) string { return v.DebugContainer.Image.Version }},
+	{k8s.DebugImagePullPolicyAnnotation, func(v *l5dcharts.Values) string { return v.DebugContainer.Image.PullPolicy }},
+}
+
+// Explain describes how the resource would be injected, given its injection
+// report and the annotations equivalent to the CLI flags, if any. Settings
+// are resolved with the same precedence as when injecting: CLI flags, then
+// workload annotations, then the ProxyConfig, then namespace annotations and
+// finally the chart values.
+func (conf *ResourceConfig) Explain(report *Report, cliAnnotations map[string]string) (*Explanation, error) {
+	explanation := &Explanation{
+		Kind: report.Kind,
+		Name: report.Name,
+	}
+	explanation.Injectable, _ = report.Injectable()
+	explanation.SkipReasons = report.SkipReasons()
+	if !conf.HasPodTemplate() {
+		return explanation, nil
+	}
+
+	annotations := map[string]string{}
+	sources := map[string]SettingSource{}
+	overlay := func(ann map[string]string, source SettingSource) {
+		for key, value := range ann {
+			annotations[key] = value
+			sources[key] = source
+		}
+	}
+
+	overlay(conf.nsAnnotations, SourceNamespace)
+	if name, ok := conf.GetProxyConfigName(); ok && name != "" {
+		explanation.ProxyConfig = name
+		if conf.proxyConfigRetriever == nil {
+			explanation.Warnings = append(explanation.Warnings, fmt.Sprintf("ProxyConfig %s can't be resolved", name))
+		} else if spec, err := conf.proxyConfigRetriever(name); err != nil {
+			explanation.Warnings = append(explanation.Warnings, fmt.Sprintf("failed to retrieve ProxyConfig %s: %s", name, err))
+		} else {
+			overlay(ProxyConfigAnnotations(spec), SourceProxyConfig)
+		}
+	}
+	overlay(conf.pod.meta.Annotations, SourceWorkload)
+	overlay(cliAnnotations, SourceCLI)
+
+	values, err := conf.GetValues().DeepCopy()
+	if err != nil {
+		return nil, err
+	}
+	namedPorts := util.GetNamedPorts(append(conf.pod.spec.InitContainers, conf.pod.spec.Containers...))
+	ApplyAnnotationOverrides(values, annotations, conf.pod.meta.Labels, namedPorts)
+
+	for _, setting := range explainedSettings {
+		source, ok := sources[setting.annotation]
+		if !ok {
+			source = SourceDefault
+		}
+		explanation.Settings = append(explanation.Settings, Setting{
+			Annotation: setting.annotation,
+			Value:      setting.value(values),
+			Source:     source,
+		})
+	}
+	return explanation, nil
+}
+
+func formatInt32(i int32) string {
+	return strconv.FormatInt(int64(i), 10)
+}
+
+func enabledOrDisabled(b bool) string {
+	if b {
+		return k8s.Enabled
+	}
+	return k8s.Disabled
+}
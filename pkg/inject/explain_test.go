@@ -0,0 +1,136 @@
+package inject
+
+import (
+	"testing"
+
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	l5dcharts "github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExplain(t *testing.T) {
+	values, err := l5dcharts.NewValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := yaml.Marshal(&appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "emojivoto"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						k8s.ProxyInjectAnnotation:             k8s.ProxyInjectEnabled,
+						k8s.ProxyConfigAnnotation:             "small",
+						k8s.ProxyMemoryRequestAnnotation:      "64Mi",
+						k8s.ProxyLogLevelAnnotation:           "debug",
+						k8s.ProxyOutboundConnectTimeout:       "2s",
+						k8s.ProxyIgnoreInboundPortsAnnotation: "25",
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewResourceConfig(values, OriginWebhook, "linkerd").
+		WithNsAnnotations(map[string]string{
+			k8s.ProxyCPURequestAnnotation:    "10m",
+			k8s.ProxyMemoryLimitAnnotation:   "256Mi",
+			k8s.ProxyMemoryRequestAnnotation: "32Mi",
+		}).
+		WithProxyConfigRetriever(func(string) (*pcv1alpha1.ProxyConfigSpec, error) {
+			return &pcv1alpha1.ProxyConfigSpec{
+				Resources: &pcv1alpha1.ProxyResources{
+					CPU: &pcv1alpha1.ResourceRequirements{Request: "100m"},
+				},
+			}, nil
+		})
+	report, err := conf.ParseMetaAndYAML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explanation, err := conf.Explain(report, map[string]string{k8s.ProxyLogLevelAnnotation: "trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !explanation.Injectable || len(explanation.SkipReasons) != 0 {
+		t.Errorf("Expected the deployment to be injectable, got %v", explanation.SkipReasons)
+	}
+	if explanation.ProxyConfig != "small" {
+		t.Errorf("Expected ProxyConfig small, got %q", explanation.ProxyConfig)
+	}
+
+	settings := map[string]Setting{}
+	for _, setting := range explanation.Settings {
+		settings[setting.Annotation] = setting
+	}
+	for _, expected := range []Setting{
+		{k8s.ProxyCPURequestAnnotation, "100m", SourceProxyConfig},
+		{k8s.ProxyMemoryRequestAnnotation, "64Mi", SourceWorkload},
+		{k8s.ProxyMemoryLimitAnnotation, "256Mi", SourceNamespace},
+		{k8s.ProxyLogLevelAnnotation, "trace", SourceCLI},
+		{k8s.ProxyOutboundConnectTimeout, "2000ms", SourceWorkload},
+		{k8s.ProxyIgnoreInboundPortsAnnotation, "25", SourceWorkload},
+		{k8s.ProxyAdminPortAnnotation, "4191", SourceDefault},
+		{k8s.ProxyImageAnnotation, values.Proxy.Image.Name, SourceDefault},
+	} {
+		if actual := settings[expected.Annotation]; actual != expected {
+			t.Errorf("Expected %+v, got %+v", expected, actual)
+		}
+	}
+}
+
+func TestExplainSkipped(t *testing.T) {
+	values, err := l5dcharts.NewValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := yaml.Marshal(&appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{k8s.ProxyInjectAnnotation: "yes"},
+				},
+				Spec: corev1.PodSpec{HostNetwork: true},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := NewResourceConfig(values, OriginWebhook, "linkerd")
+	report, err := conf.ParseMetaAndYAML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explanation, err := conf.Explain(report, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.Injectable {
+		t.Fatal("Expected the deployment not to be injectable")
+	}
+	expected := []string{
+		Reasons[hostNetworkEnabled],
+		Reasons[invalidInjectAnnotationWorkload] + `: "yes"`,
+	}
+	if len(explanation.SkipReasons) != len(expected) {
+		t.Fatalf("Expected reasons %v, got %v", expected, explanation.SkipReasons)
+	}
+	for i, reason := range expected {
+		if explanation.SkipReasons[i] != reason {
+			t.Errorf("Expected reason %q, got %q", reason, explanation.SkipReasons[i])
+		}
+	}
+}
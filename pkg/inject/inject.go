@@ -81,12 +81,15 @@ var (
 		k8s.ProxyDisableInboundProtocolDetectTimeout,
 		k8s.ProxyEnableNativeSidecarAnnotation,
 		k8s.ProxyAdditionalEnvAnnotation,
+		k8s.ProxyConfigAnnotation,
 	}
 	// ProxyAlphaConfigAnnotations is the list of all alpha configuration
 	// (config.alpha prefix) that can be applied to a pod or namespace.
 	ProxyAlphaConfigAnnotations = []string{
 		k8s.ProxyWaitBeforeExitSecondsAnnotation,
 		k8s.ProxyEnableNativeSidecarAnnotationAlpha,
+		k8s.ProxyPortInferenceAnnotation,
+		k8s.ProxySkipPortInferenceAnnotation,
 	}
 )
 
@@ -128,9 +131,11 @@ type ResourceConfig struct {
 	// These annotations from the resources's namespace are used as a base.
 	// The resources's annotations will be applied on top of these, which
 	// allows the nsAnnotations to act as a default.
-	nsAnnotations  map[string]string
-	ownerRetriever OwnerRetrieverFunc
-	origin         Origin
+	nsAnnotations        map[string]string
+	ownerRetriever       OwnerRetrieverFunc
+	proxyConfigRetriever ProxyConfigRetrieverFunc
+	serviceRetriever     ServiceRetrieverFunc
+	origin               Origin
 
 	workload struct {
 		obj      runtime.Object
@@ -173,7 +178,8 @@ type annotationPatch struct {
 
 // AppendNamespaceAnnotations allows pods to inherit config specific annotations
 // from the namespace they belong to. If the namespace has a valid config key
-// that the pod does not, then it is appended to the pod's template
+// that neither the pod nor base (e.g. from a ProxyConfig) has, then it is
+// appended to the pod's template
 func AppendNamespaceAnnotations(base map[string]string, nsAnn map[string]string, workloadAnn map[string]string) {
 	ann := append(ProxyAnnotations, ProxyAlphaConfigAnnotations...)
 	ann = append(ann, k8s.ProxyInjectAnnotation)
@@ -182,6 +188,9 @@ func AppendNamespaceAnnotations(base map[string]string, nsAnn map[string]string,
 		if _, found := nsAnn[key]; !found {
 			continue
 		}
+		if _, found := base[key]; found {
+			continue
+		}
 		if val, ok := GetConfigOverride(key, workloadAnn, nsAnn); ok {
 			base[key] = val
 		}
@@ -683,6 +692,17 @@ func (conf *ResourceConfig) GetWorkloadAnnotations() map[string]string {
 	return conf.workload.Meta.Annotations
 }
 
+// GetWorkloadNamespace returns the namespace of the workload, if set
+func (conf *ResourceConfig) GetWorkloadNamespace() string {
+	if conf.IsPod() {
+		return conf.pod.meta.Namespace
+	}
+	if conf.workload.Meta != nil {
+		return conf.workload.Meta.Namespace
+	}
+	return ""
+}
+
 // AppendPodAnnotations appends the given annotations to the pod spec in conf
 func (conf *ResourceConfig) AppendPodAnnotations(annotations map[string]string) {
 	for annotation, value := range annotations {
@@ -876,50 +896,73 @@ func (conf *ResourceConfig) CreateOpaquePortsPatch() ([]byte, error) {
 		return conf.CreateAnnotationPatch(opaquePorts)
 	}
 
+	if !conf.IsPod() && !conf.IsService() {
+		return nil, nil
+	}
+
 	// Both the workload and the namespace do not have the annotation so a
 	// patch is created which adds the default list.
+	filteredPorts := conf.DefaultOpaquePorts()
+	if len(filteredPorts) == 0 {
+		// There are no default opaque ports to add so a patch does not need
+		// to be created.
+		return nil, nil
+	}
+	ports := strings.Join(filteredPorts, ",")
+	return conf.CreateAnnotationPatch(ports)
+}
+
+// DefaultOpaquePorts returns the ports to mark as opaque when neither the
+// workload nor its namespace have the opaque ports annotation: the default
+// opaque ports the pod or service exposes, followed by the inferred ones.
+func (conf *ResourceConfig) DefaultOpaquePorts() []string {
 	defaultPorts := strings.Split(conf.GetValues().Proxy.OpaquePorts, ",")
 	var filteredPorts []string
-	if conf.IsPod() {
+	if conf.IsService() {
+		// The workload is a service so only add the default opaque ports that
+		// are exposed as a service port, or targeted as a targetPort.
+		filteredPorts = conf.filterServiceOpaquePorts(defaultPorts)
+	} else if conf.HasPodTemplate() {
 		// The workload is a pod so only add the default opaque ports that it
 		// exposes as container ports.
 		filteredPorts = conf.FilterPodOpaquePorts(defaultPorts)
-	} else if conf.IsService() {
-		// The workload is a service so only add the default opaque ports that
-		// are exposed as a service port, or targeted as a targetPort.
-		service := conf.workload.obj.(*corev1.Service)
-		for _, p := range service.Spec.Ports {
-			port := strconv.Itoa(int(p.Port))
-			if p.TargetPort.Type == 0 && p.TargetPort.IntVal == 0 {
-				// The port's targetPort is not set, so add the port if is
-				// opaque by default. Checking that targetPort is not set
-				// avoids marking a port as opaque if it targets a port that
-				// not opaque (e.g. port=3306 and targetPort=80; 3306 should
-				// not be opaque)
-				if util.ContainsString(port, defaultPorts) {
-					filteredPorts = append(filteredPorts, port)
-				}
-			} else if util.ContainsString(strconv.Itoa(int(p.TargetPort.IntVal)), defaultPorts) {
-				// The port's targetPort is set; if it is opaque then port
-				// should also be opaque.
+	}
+	for _, p := range conf.InferOpaquePorts() {
+		filteredPorts = append(filteredPorts, strconv.Itoa(int(p.Port)))
+	}
+	return filteredPorts
+}
+
+// filterServiceOpaquePorts returns the ports of a service that are in the
+// given default opaque ports list, or that target one of them.
+func (conf *ResourceConfig) filterServiceOpaquePorts(defaultPorts []string) []string {
+	var filteredPorts []string
+	service := conf.workload.obj.(*corev1.Service)
+	for _, p := range service.Spec.Ports {
+		port := strconv.Itoa(int(p.Port))
+		if p.TargetPort.Type == 0 && p.TargetPort.IntVal == 0 {
+			// The port's targetPort is not set, so add the port if is
+			// opaque by default. Checking that targetPort is not set
+			// avoids marking a port as opaque if it targets a port that
+			// not opaque (e.g. port=3306 and targetPort=80; 3306 should
+			// not be opaque)
+			if util.ContainsString(port, defaultPorts) {
 				filteredPorts = append(filteredPorts, port)
 			}
+		} else if util.ContainsString(strconv.Itoa(int(p.TargetPort.IntVal)), defaultPorts) {
+			// The port's targetPort is set; if it is opaque then port
+			// should also be opaque.
+			filteredPorts = append(filteredPorts, port)
 		}
 	}
-	if len(filteredPorts) == 0 {
-		// There are no default opaque ports to add so a patch does not need
-		// to be created.
-		return nil, nil
-	}
-	ports := strings.Join(filteredPorts, ",")
-	return conf.CreateAnnotationPatch(ports)
+	return filteredPorts
 }
 
 // FilterPodOpaquePorts returns a list of opaque ports that a pod exposes that
 // are also in the given default opaque ports list.
 func (conf *ResourceConfig) FilterPodOpaquePorts(defaultPorts []string) []string {
 	var filteredPorts []string
-	for _, c := range append(conf.pod.spec.InitContainers, conf.pod.spec.Containers...) {
+	for _, c := range slices.Concat(conf.pod.spec.InitContainers, conf.pod.spec.Containers) {
 		for _, p := range c.Ports {
 			port := strconv.Itoa(int(p.ContainerPort))
 			if util.ContainsString(port, defaultPorts) {
@@ -1370,6 +1413,20 @@ func (conf *ResourceConfig) HasPodTemplate() bool {
 	return conf.pod.meta != nil && conf.pod.spec != nil
 }
 
+// HasProxyContainer checks if the pod (template) has the Linkerd proxy
+// container, either as a regular container or as a native sidecar
+func (conf *ResourceConfig) HasProxyContainer() bool {
+	if !conf.HasPodTemplate() {
+		return false
+	}
+	for _, c := range slices.Concat(conf.pod.spec.InitContainers, conf.pod.spec.Containers) {
+		if c.Name == k8s.ProxyContainerName {
+			return true
+		}
+	}
+	return false
+}
+
 // AnnotateNamespace annotates a namespace resource config with `annotations`.
 func (conf *ResourceConfig) AnnotateNamespace(annotations map[string]string) ([]byte, error) {
 	ns, ok := conf.workload.obj.(*corev1.Namespace)
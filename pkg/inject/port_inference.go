@@ -0,0 +1,260 @@
+package inject
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/linkerd/linkerd2/pkg/util"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// opaqueProtocols are the well-known protocols that can't be proxied with
+// protocol detection, either because the server speaks first (e.g. mysql,
+// smtp) or because they're neither HTTP nor TLS. Names are matched exactly, so
+// that e.g. a "redis-exporter" metrics port isn't taken for redis.
+//
+// Marking a port opaque keeps its traffic proxied, and thus mTLS'd, without
+// protocol detection. It doesn't bypass the proxy like the skip-inbound-ports
+// and skip-outbound-ports annotations do: whether a port must skip the proxy
+// altogether depends on where the peer runs, not only on the protocol. Hence
+// outbound ports to skip are only inferred, when enabled, from the
+// ExternalName Services the workload refers to, whose peers run outside of the
+// cluster and thus can't be meshed.
+var opaqueProtocols = map[string]struct{}{
+	"amqp":           {},
+	"amqps":          {},
+	"cassandra":      {},
+	"cql":            {},
+	"kafka":          {},
+	"ldap":           {},
+	"memcache":       {},
+	"memcached":      {},
+	"mongo":          {},
+	"mongodb":        {},
+	"mysql":          {},
+	"mysqlx":         {},
+	"nats":           {},
+	"postgres":       {},
+	"postgresql":     {},
+	"redis":          {},
+	"redis-sentinel": {},
+	"smtp":           {},
+	"submission":     {},
+	"zookeeper":      {},
+}
+
+// InferredPort is a port inferred as opaque, or as an outbound port to skip,
+// by the proxy injector
+type InferredPort struct {
+	Port int32
+	// Source describes what the port was inferred from, e.g. `port name
+	// "postgresql"`
+	Source string
+}
+
+// ServiceRetrieverFunc returns the services of the workload's namespace
+type ServiceRetrieverFunc func() ([]*corev1.Service, error)
+
+// WithServiceRetriever enriches ResourceConfig with a function that allows to
+// retrieve the services of the workload's namespace, from which the outbound
+// ports to skip are inferred
+func (conf *ResourceConfig) WithServiceRetriever(f ServiceRetrieverFunc) *ResourceConfig {
+	conf.serviceRetriever = f
+	return conf
+}
+
+func (p InferredPort) String() string {
+	return fmt.Sprintf("%d (%s)", p.Port, p.Source)
+}
+
+// PortInferenceEnabled returns whether the workload, or else its namespace,
+// has the port inference annotation set to "enabled"
+func (conf *ResourceConfig) PortInferenceEnabled() bool {
+	return conf.annotationEnabled(k8s.ProxyPortInferenceAnnotation)
+}
+
+// SkipPortInferenceEnabled returns whether the workload, or else its
+// namespace, has the skip port inference annotation set to "enabled"
+func (conf *ResourceConfig) SkipPortInferenceEnabled() bool {
+	return conf.annotationEnabled(k8s.ProxySkipPortInferenceAnnotation)
+}
+
+func (conf *ResourceConfig) annotationEnabled(annotation string) bool {
+	for _, ann := range []map[string]string{conf.pod.annotations, conf.pod.meta.Annotations} {
+		if value, ok := ann[annotation]; ok {
+			return value == k8s.Enabled
+		}
+	}
+	if conf.workload.Meta != nil {
+		if value, ok := conf.workload.Meta.Annotations[annotation]; ok {
+			return value == k8s.Enabled
+		}
+	}
+	return conf.nsAnnotations[annotation] == k8s.Enabled
+}
+
+// InferOpaquePorts returns the ports of the pod's containers, or of the
+// service, that aren't opaque by default but whose name, or appProtocol for
+// services, is a well-known opaque protocol. Nothing is inferred unless port
+// inference is enabled, nor when the workload or its namespace already set
+// the opaque ports.
+func (conf *ResourceConfig) InferOpaquePorts() []InferredPort {
+	if !conf.PortInferenceEnabled() ||
+		conf.HasWorkloadAnnotation(k8s.ProxyOpaquePortsAnnotation) {
+		return nil
+	}
+	if _, ok := conf.nsAnnotations[k8s.ProxyOpaquePortsAnnotation]; ok {
+		return nil
+	}
+
+	defaultPorts := strings.Split(conf.GetValues().Proxy.OpaquePorts, ",")
+	var inferred []InferredPort
+	add := func(port int32, source string) {
+		if util.ContainsString(strconv.Itoa(int(port)), defaultPorts) {
+			return
+		}
+		for _, p := range inferred {
+			if p.Port == port {
+				return
+			}
+		}
+		inferred = append(inferred, InferredPort{Port: port, Source: source})
+	}
+
+	if conf.IsService() {
+		service := conf.workload.obj.(*corev1.Service)
+		for _, p := range service.Spec.Ports {
+			if p.AppProtocol != nil && isOpaqueProtocol(*p.AppProtocol) {
+				add(p.Port, fmt.Sprintf("appProtocol %q", *p.AppProtocol))
+			} else if isOpaqueProtocol(p.Name) {
+				add(p.Port, fmt.Sprintf("port name %q", p.Name))
+			}
+		}
+	} else if conf.HasPodTemplate() {
+		for _, c := range slices.Concat(conf.pod.spec.InitContainers, conf.pod.spec.Containers) {
+			for _, p := range c.Ports {
+				if p.Protocol != "" && p.Protocol != corev1.ProtocolTCP {
+					continue
+				}
+				if isOpaqueProtocol(p.Name) {
+					add(p.ContainerPort, fmt.Sprintf("port name %q", p.Name))
+				}
+			}
+		}
+	}
+	return inferred
+}
+
+// InferSkipOutboundPorts returns the ports of the ExternalName services the
+// pod's containers refer to, in their environment variables, whose
+// appProtocol, or name, is a well-known opaque protocol. The peers of those
+// services run outside of the cluster, so their traffic would only be
+// proxied without mTLS. Nothing is inferred unless skip port inference is
+// enabled and the services can be retrieved, nor when the workload or its
+// namespace already set the outbound ports to skip.
+func (conf *ResourceConfig) InferSkipOutboundPorts() []InferredPort {
+	if !conf.HasPodTemplate() || conf.serviceRetriever == nil ||
+		!conf.SkipPortInferenceEnabled() ||
+		conf.HasWorkloadAnnotation(k8s.ProxyIgnoreOutboundPortsAnnotation) {
+		return nil
+	}
+	if _, ok := conf.nsAnnotations[k8s.ProxyIgnoreOutboundPortsAnnotation]; ok {
+		return nil
+	}
+
+	services, err := conf.serviceRetriever()
+	if err != nil {
+		log.Warnf("failed to retrieve the services to infer the outbound ports to skip: %s", err)
+		return nil
+	}
+	hosts := conf.referencedHosts()
+	defaultPorts := strings.Split(conf.GetValues().ProxyInit.IgnoreOutboundPorts, ",")
+	var inferred []InferredPort
+	for _, svc := range services {
+		if svc.Spec.Type != corev1.ServiceTypeExternalName || !referencesService(hosts, svc) {
+			continue
+		}
+		for _, p := range svc.Spec.Ports {
+			if p.Protocol != "" && p.Protocol != corev1.ProtocolTCP {
+				continue
+			}
+			var source string
+			if p.AppProtocol != nil && isOpaqueProtocol(*p.AppProtocol) {
+				source = fmt.Sprintf("appProtocol %q of service %q", *p.AppProtocol, svc.Name)
+			} else if isOpaqueProtocol(p.Name) {
+				source = fmt.Sprintf("port name %q of service %q", p.Name, svc.Name)
+			} else {
+				continue
+			}
+			if util.ContainsString(strconv.Itoa(int(p.Port)), defaultPorts) ||
+				slices.ContainsFunc(inferred, func(i InferredPort) bool { return i.Port == p.Port }) {
+				continue
+			}
+			inferred = append(inferred, InferredPort{Port: p.Port, Source: source})
+		}
+	}
+	return inferred
+}
+
+// SkipOutboundPortsValue returns the skip-outbound-ports annotation value
+// skipping the inferred ports along with the default ones
+func (conf *ResourceConfig) SkipOutboundPortsValue(inferred []InferredPort) string {
+	var ports []string
+	if defaultPorts := conf.GetValues().ProxyInit.IgnoreOutboundPorts; defaultPorts != "" {
+		ports = append(ports, defaultPorts)
+	}
+	for _, p := range inferred {
+		ports = append(ports, strconv.Itoa(int(p.Port)))
+	}
+	return strings.Join(ports, ",")
+}
+
+// referencedHosts returns the tokens of the pod's containers' environment
+// variable values that may be host names, e.g. "db" or
+// "db.shop.svc.cluster.local" in "postgresql://db.shop.svc.cluster.local/orders"
+func (conf *ResourceConfig) referencedHosts() map[string]struct{} {
+	hosts := map[string]struct{}{}
+	for _, c := range slices.Concat(conf.pod.spec.InitContainers, conf.pod.spec.Containers) {
+		for _, env := range c.Env {
+			tokens := strings.FieldsFunc(strings.ToLower(env.Value), func(r rune) bool {
+				return (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '.'
+			})
+			for _, token := range tokens {
+				hosts[strings.TrimSuffix(token, ".")] = struct{}{}
+			}
+		}
+	}
+	return hosts
+}
+
+// referencesService returns whether one of the hosts is the service's name,
+// qualified or not with its namespace and cluster domain
+func referencesService(hosts map[string]struct{}, svc *corev1.Service) bool {
+	if _, ok := hosts[svc.Name]; ok {
+		return true
+	}
+	qualified := fmt.Sprintf("%s.%s", svc.Name, svc.Namespace)
+	for host := range hosts {
+		if rest, ok := strings.CutPrefix(host, qualified); ok &&
+			(rest == "" || rest == ".svc" || strings.HasPrefix(rest, ".svc.")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOpaqueProtocol returns whether the port name or appProtocol is a
+// well-known opaque protocol. Domain-prefixed appProtocols (e.g.
+// "example.com/mysql") are matched on their protocol.
+func isOpaqueProtocol(name string) bool {
+	name = strings.ToLower(name)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	_, ok := opaqueProtocols[name]
+	return ok
+}
@@ -0,0 +1,228 @@
+package inject
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	l5dcharts "github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestIsOpaqueProtocol(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"mysql":             true,
+		"PostgreSQL":        true,
+		"redis-sentinel":    true,
+		"example.com/kafka": true,
+		"http":              false,
+		"kubernetes.io/h2c": false,
+		"grpc-redis":        false,
+		"redis-exporter":    false,
+		"postgres-metrics":  false,
+		"":                  false,
+	} {
+		if actual := isOpaqueProtocol(name); actual != expected {
+			t.Errorf("Expected isOpaqueProtocol(%q) to be %t", name, expected)
+		}
+	}
+}
+
+func TestInferOpaquePorts(t *testing.T) {
+	testConfig, err := l5dcharts.NewValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	pod := `apiVersion: v1
+kind: Pod
+metadata:
+  name: orders
+  namespace: shop
+spec:
+  containers:
+  - name: db
+    ports:
+    - containerPort: 15432
+      name: postgresql
+    - containerPort: 3306
+      name: mysql
+    - containerPort: 5353
+      name: redis-udp
+      protocol: UDP
+    - containerPort: 8080
+      name: http`
+	service := `apiVersion: v1
+kind: Service
+metadata:
+  name: orders
+  namespace: shop
+spec:
+  ports:
+  - name: db
+    port: 15432
+    appProtocol: postgresql
+  - name: mongodb
+    port: 27018
+  - name: http
+    port: 80`
+
+	for _, tc := range []struct {
+		name          string
+		manifest      string
+		nsAnnotations map[string]string
+		expected      []InferredPort
+		opaquePorts   string
+	}{
+		{
+			name:     "pod without port inference",
+			manifest: pod,
+		},
+		{
+			name:          "pod in a namespace enabling port inference",
+			manifest:      pod,
+			nsAnnotations: map[string]string{k8s.ProxyPortInferenceAnnotation: k8s.Enabled},
+			expected:      []InferredPort{{Port: 15432, Source: `port name "postgresql"`}},
+			opaquePorts:   "3306,15432",
+		},
+		{
+			name:     "pod in a namespace setting the opaque ports",
+			manifest: pod,
+			nsAnnotations: map[string]string{
+				k8s.ProxyPortInferenceAnnotation: k8s.Enabled,
+				k8s.ProxyOpaquePortsAnnotation:   "4444",
+			},
+		},
+		{
+			name:          "service",
+			manifest:      service,
+			nsAnnotations: map[string]string{k8s.ProxyPortInferenceAnnotation: k8s.Enabled},
+			expected: []InferredPort{
+				{Port: 15432, Source: `appProtocol "postgresql"`},
+				{Port: 27018, Source: `port name "mongodb"`},
+			},
+			opaquePorts: "15432,27018",
+		},
+	} {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			conf := NewResourceConfig(testConfig, OriginWebhook, "linkerd").
+				WithNsAnnotations(tc.nsAnnotations)
+			report, err := conf.ParseMetaAndYAML([]byte(tc.manifest))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if diff := deep.Equal(report.InferredOpaquePorts, tc.expected); diff != nil {
+				t.Errorf("%+v", diff)
+			}
+			if report.InferredOpaquePortsValue != tc.opaquePorts {
+				t.Errorf("Expected the opaque ports %q, got %q", tc.opaquePorts, report.InferredOpaquePortsValue)
+			}
+		})
+	}
+}
+
+func TestInferSkipOutboundPorts(t *testing.T) {
+	testConfig, err := l5dcharts.NewValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	pod := `apiVersion: v1
+kind: Pod
+metadata:
+  name: orders
+  namespace: shop
+  annotations:
+    config.alpha.linkerd.io/skip-port-inference: enabled
+spec:
+  containers:
+  - name: orders
+    env:
+    - name: DATABASE_URL
+      value: postgresql://orders-db.shop.svc.cluster.local:5432/orders
+    - name: SMTP_HOST
+      value: mail
+    - name: CACHE_HOST
+      value: cache`
+	services := []*corev1.Service{
+		externalNameService("orders-db", corev1.ServicePort{Name: "db", Port: 5432, AppProtocol: ptr.To("postgresql")}),
+		externalNameService("mail", corev1.ServicePort{Name: "smtp", Port: 25}, corev1.ServicePort{Name: "http", Port: 80}),
+		// Not an ExternalName service: its peers may be meshed
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "redis", Port: 6379}}},
+		},
+		// Not referred to by the pod
+		externalNameService("ledger", corev1.ServicePort{Name: "mysql", Port: 3306}),
+	}
+
+	for _, tc := range []struct {
+		name          string
+		nsAnnotations map[string]string
+		retriever     ServiceRetrieverFunc
+		expected      []InferredPort
+		skipPorts     string
+	}{
+		{
+			name:      "without services",
+			retriever: nil,
+		},
+		{
+			name:      "with services",
+			retriever: func() ([]*corev1.Service, error) { return services, nil },
+			expected: []InferredPort{
+				{Port: 5432, Source: `appProtocol "postgresql" of service "orders-db"`},
+				{Port: 25, Source: `port name "smtp" of service "mail"`},
+			},
+			skipPorts: "4567,4568,5432,25",
+		},
+		{
+			name:          "in a namespace setting the outbound ports to skip",
+			nsAnnotations: map[string]string{k8s.ProxyIgnoreOutboundPortsAnnotation: "25"},
+			retriever:     func() ([]*corev1.Service, error) { return services, nil },
+		},
+		{
+			name:      "failing to retrieve services",
+			retriever: func() ([]*corev1.Service, error) { return nil, errors.New("forbidden") },
+		},
+	} {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			conf := NewResourceConfig(testConfig, OriginWebhook, "linkerd").
+				WithNsAnnotations(tc.nsAnnotations).
+				WithServiceRetriever(tc.retriever)
+			report, err := conf.ParseMetaAndYAML([]byte(pod))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if diff := deep.Equal(report.InferredSkipOutboundPorts, tc.expected); diff != nil {
+				t.Errorf("%+v", diff)
+			}
+			if report.InferredSkipOutboundPortsValue != tc.skipPorts {
+				t.Errorf("Expected the outbound ports to skip %q, got %q", tc.skipPorts, report.InferredSkipOutboundPortsValue)
+			}
+
+			if err := conf.AppendInjectorAnnotations(); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if tc.skipPorts != "" && conf.GetOverrideAnnotations()[k8s.ProxyIgnoreOutboundPortsAnnotation] != tc.skipPorts {
+				t.Errorf("Expected the pod to be annotated with the outbound ports to skip %q, got %q", tc.skipPorts, conf.GetOverrideAnnotations()[k8s.ProxyIgnoreOutboundPortsAnnotation])
+			}
+		})
+	}
+}
+
+func externalNameService(name string, ports ...corev1.ServicePort) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: name + ".example.com",
+			Ports:        ports,
+		},
+	}
+}
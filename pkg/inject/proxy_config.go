@@ -0,0 +1,184 @@
+package inject
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+)
+
+// ProxyConfigRetrieverFunc returns the spec of the ProxyConfig of the given
+// name in the workload's namespace or, if there's none, of the
+// ClusterProxyConfig of that name
+type ProxyConfigRetrieverFunc func(name string) (*pcv1alpha1.ProxyConfigSpec, error)
+
+// WithProxyConfigRetriever enriches ResourceConfig with a function that allows
+// to resolve the ProxyConfig referred to by the workload or its namespace
+func (conf *ResourceConfig) WithProxyConfigRetriever(f ProxyConfigRetrieverFunc) *ResourceConfig {
+	conf.proxyConfigRetriever = f
+	return conf
+}
+
+// GetProxyConfigName returns the name of the ProxyConfig referred to by the
+// pod (template) or, failing that, by its namespace
+func (conf *ResourceConfig) GetProxyConfigName() (string, bool) {
+	if name, ok := conf.pod.meta.Annotations[k8s.ProxyConfigAnnotation]; ok {
+		return name, true
+	}
+	name, ok := conf.nsAnnotations[k8s.ProxyConfigAnnotation]
+	return name, ok
+}
+
+// AppendProxyConfigAnnotations resolves the ProxyConfig referred to by the
+// workload or its namespace, if any, and appends its config annotations to the
+// pod, skipping those set on the pod (template) itself. It must be called before
+// AppendNamespaceAnnotations so that the ProxyConfig takes precedence over the
+// namespace's config annotations.
+func (conf *ResourceConfig) AppendProxyConfigAnnotations() error {
+	name, ok := conf.GetProxyConfigName()
+	if !ok || name == "" {
+		return nil
+	}
+	if conf.proxyConfigRetriever == nil {
+		return fmt.Errorf("cannot resolve ProxyConfig %s", name)
+	}
+	spec, err := conf.proxyConfigRetriever(name)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve ProxyConfig %s: %w", name, err)
+	}
+
+	for key, value := range ProxyConfigAnnotations(spec) {
+		if _, found := conf.pod.meta.Annotations[key]; !found {
+			conf.pod.annotations[key] = value
+		}
+	}
+	conf.pod.annotations[k8s.ProxyConfigAnnotation] = name
+	return nil
+}
+
+// AppendInjectorAnnotations appends to the pod the annotations the proxy
+// injector adds before generating the injection patch: the config annotations
+// from the ProxyConfig the workload or its namespace refer to, which take
+// precedence over the namespace's own, then the namespace's, and finally the
+// default opaque ports and the inferred outbound ports to skip if the pod
+// didn't inherit any. Failing to resolve the
+// ProxyConfig doesn't prevent the other annotations from being appended.
+func (conf *ResourceConfig) AppendInjectorAnnotations() error {
+	err := conf.AppendProxyConfigAnnotations()
+
+	// If namespace has annotations that do not exist on pod then copy them
+	// over to pod's template.
+	AppendNamespaceAnnotations(conf.GetOverrideAnnotations(), conf.GetNsAnnotations(), conf.GetWorkloadAnnotations())
+
+	// If the pod did not inherit the opaque ports annotation from the
+	// namespace, then add the default value from the config values, along
+	// with the inferred ports. This ensures that the generated patch always
+	// sets the opaque ports annotation.
+	if !conf.HasWorkloadAnnotation(k8s.ProxyOpaquePortsAnnotation) {
+		filteredPorts := conf.DefaultOpaquePorts()
+		// Only add the annotation if there are ports that the pod exposes
+		// that are in the default opaque ports list, or inferred as opaque.
+		if len(filteredPorts) != 0 {
+			conf.AppendPodAnnotation(k8s.ProxyOpaquePortsAnnotation, strings.Join(filteredPorts, ","))
+		}
+	}
+
+	// If the pod did not inherit the outbound ports to skip either, then skip
+	// the inferred ones along with the default ones.
+	if inferred := conf.InferSkipOutboundPorts(); len(inferred) != 0 {
+		conf.AppendPodAnnotation(k8s.ProxyIgnoreOutboundPortsAnnotation, conf.SkipOutboundPortsValue(inferred))
+	}
+
+	return err
+}
+
+// EffectiveInjectionAnnotations returns the injection annotations the proxy
+// injector would apply to pods created from a template with the given
+// annotations: the template's own, then those of the given ProxyConfig, if
+// any, and finally those of the namespace
+func EffectiveInjectionAnnotations(templateAnn, nsAnn map[string]string, proxyConfig *pcv1alpha1.ProxyConfigSpec) map[string]string {
+	keys := []string{k8s.ProxyInjectAnnotation}
+	keys = append(keys, ProxyAnnotations...)
+	keys = append(keys, ProxyAlphaConfigAnnotations...)
+
+	ann := map[string]string{}
+	for _, key := range keys {
+		if value, ok := nsAnn[key]; ok {
+			ann[key] = value
+		}
+	}
+	if proxyConfig != nil {
+		for key, value := range ProxyConfigAnnotations(proxyConfig) {
+			ann[key] = value
+		}
+	}
+	for _, key := range keys {
+		if value, ok := templateAnn[key]; ok {
+			ann[key] = value
+		}
+	}
+	return ann
+}
+
+// InjectionEnabled returns whether the given injection annotations enable
+// the injection of the proxy
+func InjectionEnabled(ann map[string]string) bool {
+	switch ann[k8s.ProxyInjectAnnotation] {
+	case k8s.ProxyInjectEnabled, k8s.ProxyInjectIngress:
+		return true
+	}
+	return false
+}
+
+// ProxyConfigAnnotations returns the config annotations equivalent to the
+// given ProxyConfig spec
+func ProxyConfigAnnotations(spec *pcv1alpha1.ProxyConfigSpec) map[string]string {
+	ann := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			ann[key] = value
+		}
+	}
+	setPort := func(key string, port *int32) {
+		if port != nil {
+			ann[key] = strconv.FormatInt(int64(*port), 10)
+		}
+	}
+	setResource := func(requestKey, limitKey string, r *pcv1alpha1.ResourceRequirements) {
+		if r != nil {
+			set(requestKey, r.Request)
+			set(limitKey, r.Limit)
+		}
+	}
+
+	if r := spec.Resources; r != nil {
+		setResource(k8s.ProxyCPURequestAnnotation, k8s.ProxyCPULimitAnnotation, r.CPU)
+		setResource(k8s.ProxyMemoryRequestAnnotation, k8s.ProxyMemoryLimitAnnotation, r.Memory)
+		setResource(k8s.ProxyEphemeralStorageRequestAnnotation, k8s.ProxyEphemeralStorageLimitAnnotation, r.EphemeralStorage)
+	}
+
+	if p := spec.Ports; p != nil {
+		setPort(k8s.ProxyAdminPortAnnotation, p.Admin)
+		setPort(k8s.ProxyControlPortAnnotation, p.Control)
+		setPort(k8s.ProxyInboundPortAnnotation, p.Inbound)
+		setPort(k8s.ProxyOutboundPortAnnotation, p.Outbound)
+		set(k8s.ProxyIgnoreInboundPortsAnnotation, p.IgnoreInbound)
+		set(k8s.ProxyIgnoreOutboundPortsAnnotation, p.IgnoreOutbound)
+		set(k8s.ProxyOpaquePortsAnnotation, p.Opaque)
+	}
+
+	set(k8s.ProxyLogLevelAnnotation, spec.LogLevel)
+	set(k8s.ProxyLogFormatAnnotation, spec.LogFormat)
+
+	if t := spec.Timeouts; t != nil {
+		set(k8s.ProxyInboundConnectTimeout, t.InboundConnect)
+		set(k8s.ProxyOutboundConnectTimeout, t.OutboundConnect)
+		set(k8s.ProxyInboundDiscoveryCacheUnusedTimeout, t.InboundDiscoveryCacheUnused)
+		set(k8s.ProxyOutboundDiscoveryCacheUnusedTimeout, t.OutboundDiscoveryCacheUnused)
+		set(k8s.ProxyShutdownGracePeriodAnnotation, t.ShutdownGracePeriod)
+	}
+
+	return ann
+}
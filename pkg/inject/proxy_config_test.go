@@ -0,0 +1,223 @@
+package inject
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	pcv1alpha1 "github.com/linkerd/linkerd2/controller/gen/apis/proxyconfig/v1alpha1"
+	l5dcharts "github.com/linkerd/linkerd2/pkg/charts/linkerd2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestProxyConfigAnnotations(t *testing.T) {
+	adminPort := int32(4191)
+	spec := &pcv1alpha1.ProxyConfigSpec{
+		Resources: &pcv1alpha1.ProxyResources{
+			CPU:    &pcv1alpha1.ResourceRequirements{Request: "100m", Limit: "1"},
+			Memory: &pcv1alpha1.ResourceRequirements{Limit: "256Mi"},
+		},
+		Ports: &pcv1alpha1.ProxyPorts{
+			Admin:          &adminPort,
+			IgnoreOutbound: "3306",
+		},
+		LogLevel: "debug",
+		Timeouts: &pcv1alpha1.ProxyTimeouts{
+			OutboundConnect: "2s",
+		},
+	}
+	expected := map[string]string{
+		k8s.ProxyCPURequestAnnotation:          "100m",
+		k8s.ProxyCPULimitAnnotation:            "1",
+		k8s.ProxyMemoryLimitAnnotation:         "256Mi",
+		k8s.ProxyAdminPortAnnotation:           "4191",
+		k8s.ProxyIgnoreOutboundPortsAnnotation: "3306",
+		k8s.ProxyLogLevelAnnotation:            "debug",
+		k8s.ProxyOutboundConnectTimeout:        "2s",
+	}
+	if diff := deep.Equal(ProxyConfigAnnotations(spec), expected); diff != nil {
+		t.Errorf("%+v", diff)
+	}
+	if ann := ProxyConfigAnnotations(&pcv1alpha1.ProxyConfigSpec{}); len(ann) != 0 {
+		t.Errorf("Expected no annotations for an empty spec, got %v", ann)
+	}
+}
+
+func TestAppendProxyConfigAnnotations(t *testing.T) {
+	testConfig, err := l5dcharts.NewValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	profiles := map[string]*pcv1alpha1.ProxyConfigSpec{
+		"small": {
+			Resources: &pcv1alpha1.ProxyResources{
+				CPU:    &pcv1alpha1.ResourceRequirements{Request: "50m"},
+				Memory: &pcv1alpha1.ResourceRequirements{Request: "32Mi"},
+			},
+			LogLevel: "info",
+		},
+		"large": {
+			Resources: &pcv1alpha1.ProxyResources{
+				CPU: &pcv1alpha1.ResourceRequirements{Request: "1"},
+			},
+		},
+	}
+	retriever := func(name string) (*pcv1alpha1.ProxyConfigSpec, error) {
+		if spec, ok := profiles[name]; ok {
+			return spec, nil
+		}
+		return nil, errors.New("not found")
+	}
+
+	for _, tc := range []struct {
+		name                string
+		nsAnnotations       map[string]string
+		workloadAnnotations map[string]string
+		expectErr           bool
+		expectedCPU         string
+		expectedMemory      string
+		expectedLogLevel    string
+	}{
+		{
+			name:           "without a ProxyConfig",
+			nsAnnotations:  map[string]string{k8s.ProxyCPURequestAnnotation: "10m"},
+			expectedCPU:    "10m",
+			expectedMemory: "",
+		},
+		{
+			name:                "with a ProxyConfig referred to by the workload",
+			workloadAnnotations: map[string]string{k8s.ProxyConfigAnnotation: "small"},
+			expectedCPU:         "50m",
+			expectedMemory:      "32Mi",
+			expectedLogLevel:    "info",
+		},
+		{
+			name:             "with a ProxyConfig referred to by the namespace",
+			nsAnnotations:    map[string]string{k8s.ProxyConfigAnnotation: "small"},
+			expectedCPU:      "50m",
+			expectedMemory:   "32Mi",
+			expectedLogLevel: "info",
+		},
+		{
+			name:                "the workload's ProxyConfig takes precedence over the namespace's",
+			nsAnnotations:       map[string]string{k8s.ProxyConfigAnnotation: "small"},
+			workloadAnnotations: map[string]string{k8s.ProxyConfigAnnotation: "large"},
+			expectedCPU:         "1",
+		},
+		{
+			name: "inline workload annotations take precedence over the ProxyConfig",
+			workloadAnnotations: map[string]string{
+				k8s.ProxyConfigAnnotation:     "small",
+				k8s.ProxyCPURequestAnnotation: "200m",
+			},
+			expectedCPU:      "200m",
+			expectedMemory:   "32Mi",
+			expectedLogLevel: "info",
+		},
+		{
+			name: "the ProxyConfig takes precedence over inline namespace annotations",
+			nsAnnotations: map[string]string{
+				k8s.ProxyConfigAnnotation:      "small",
+				k8s.ProxyCPURequestAnnotation:  "10m",
+				k8s.ProxyMemoryLimitAnnotation: "64Mi",
+			},
+			expectedCPU:      "50m",
+			expectedMemory:   "32Mi",
+			expectedLogLevel: "info",
+		},
+		{
+			name:                "falls back to inline annotations when the ProxyConfig is missing",
+			nsAnnotations:       map[string]string{k8s.ProxyCPURequestAnnotation: "10m"},
+			workloadAnnotations: map[string]string{k8s.ProxyConfigAnnotation: "missing"},
+			expectErr:           true,
+			expectedCPU:         "10m",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := yaml.Marshal(&appsv1.Deployment{Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Annotations: tc.workloadAnnotations},
+					Spec:       corev1.PodSpec{},
+				},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf := NewResourceConfig(testConfig, OriginWebhook, "linkerd").
+				WithKind("Deployment").
+				WithNsAnnotations(tc.nsAnnotations).
+				WithProxyConfigRetriever(retriever)
+			if err := conf.parse(data); err != nil {
+				t.Fatal(err)
+			}
+
+			err = conf.AppendProxyConfigAnnotations()
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			AppendNamespaceAnnotations(conf.GetOverrideAnnotations(), conf.GetNsAnnotations(), conf.GetWorkloadAnnotations())
+			values, err := GetOverriddenValues(conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cpu := values.Proxy.Resources.CPU.Request; cpu != tc.expectedCPU {
+				t.Errorf("Expected CPU request %q, got %q", tc.expectedCPU, cpu)
+			}
+			if mem := values.Proxy.Resources.Memory.Request; mem != tc.expectedMemory {
+				t.Errorf("Expected memory request %q, got %q", tc.expectedMemory, mem)
+			}
+			expectedLogLevel := tc.expectedLogLevel
+			if expectedLogLevel == "" {
+				expectedLogLevel = testConfig.Proxy.LogLevel
+			}
+			if logLevel := values.Proxy.LogLevel; logLevel != expectedLogLevel {
+				t.Errorf("Expected log level %q, got %q", expectedLogLevel, logLevel)
+			}
+		})
+	}
+}
+
+func TestEffectiveInjectionAnnotations(t *testing.T) {
+	nsAnn := map[string]string{
+		k8s.ProxyInjectAnnotation:      k8s.ProxyInjectEnabled,
+		k8s.ProxyCPURequestAnnotation:  "10m",
+		k8s.ProxyLogLevelAnnotation:    "warn",
+		"example.com/unrelated":        "ignored",
+		k8s.ProxyMemoryLimitAnnotation: "64Mi",
+	}
+	templateAnn := map[string]string{
+		k8s.ProxyLogLevelAnnotation: "debug",
+		"example.com/unrelated":     "ignored",
+	}
+	spec := &pcv1alpha1.ProxyConfigSpec{
+		Resources: &pcv1alpha1.ProxyResources{
+			CPU: &pcv1alpha1.ResourceRequirements{Request: "100m"},
+		},
+		LogLevel: "info",
+	}
+
+	expected := map[string]string{
+		k8s.ProxyInjectAnnotation:      k8s.ProxyInjectEnabled,
+		k8s.ProxyCPURequestAnnotation:  "100m",
+		k8s.ProxyLogLevelAnnotation:    "debug",
+		k8s.ProxyMemoryLimitAnnotation: "64Mi",
+	}
+	ann := EffectiveInjectionAnnotations(templateAnn, nsAnn, spec)
+	if diff := deep.Equal(ann, expected); diff != nil {
+		t.Errorf("%+v", diff)
+	}
+	if !InjectionEnabled(ann) {
+		t.Error("Expected injection to be enabled")
+	}
+
+	ann = EffectiveInjectionAnnotations(map[string]string{k8s.ProxyInjectAnnotation: k8s.ProxyInjectDisabled}, nsAnn, nil)
+	if InjectionEnabled(ann) {
+		t.Error("Expected the template annotation to disable injection")
+	}
+}
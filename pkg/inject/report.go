@@ -22,6 +22,10 @@ const (
 	invalidInjectAnnotationNamespace     = "invalid_inject_annotation_at_ns"
 	disabledAutomountServiceAccountToken = "disabled_automount_service_account_token_account"
 	udpPortsEnabled                      = "udp_ports_enabled"
+
+	// InjectionError is the reason recorded by the proxy injector when the
+	// injection patch of an injectable pod couldn't be generated
+	InjectionError = "injection_error"
 )
 
 var (
@@ -36,6 +40,7 @@ var (
 		invalidInjectAnnotationNamespace:     fmt.Sprintf("invalid value for annotation \"%s\" at namespace", k8s.ProxyInjectAnnotation),
 		disabledAutomountServiceAccountToken: "automountServiceAccountToken set to \"false\", with Values.identity.serviceAccountTokenProjection set to \"false\"",
 		udpPortsEnabled:                      "UDP port(s) configured on pod spec",
+		InjectionError:                       "the injection patch couldn't be generated",
 	}
 
 	// Set of valid inject annotation values
@@ -69,6 +74,20 @@ type Report struct {
 	Annotated                    bool
 	AutomountServiceAccountToken bool
 
+	// InferredOpaquePorts are the ports inferred as opaque, when port
+	// inference is enabled, and InferredOpaquePortsValue is the resulting
+	// opaque ports annotation value, which can be set on the workload to pin
+	// them
+	InferredOpaquePorts      []InferredPort
+	InferredOpaquePortsValue string
+
+	// InferredSkipOutboundPorts are the outbound ports inferred to skip the
+	// proxy, when skip port inference is enabled, and
+	// InferredSkipOutboundPortsValue is the resulting skip-outbound-ports
+	// annotation value, which can be set on the workload to pin them
+	InferredSkipOutboundPorts      []InferredPort
+	InferredSkipOutboundPortsValue string
+
 	// Uninjected consists of two boolean flags to indicate if a proxy and
 	// proxy-init containers have been uninjected in this report
 	Uninjected struct {
@@ -124,6 +143,20 @@ func newReport(conf *ResourceConfig) *Report {
 		report.Annotatable = true
 	}
 
+	if conf.HasPodTemplate() || conf.IsService() {
+		report.InferredOpaquePorts = conf.InferOpaquePorts()
+		if len(report.InferredOpaquePorts) > 0 {
+			report.InferredOpaquePortsValue = strings.Join(conf.DefaultOpaquePorts(), ",")
+		}
+	}
+
+	if conf.HasPodTemplate() {
+		report.InferredSkipOutboundPorts = conf.InferSkipOutboundPorts()
+		if len(report.InferredSkipOutboundPorts) > 0 {
+			report.InferredSkipOutboundPortsValue = conf.SkipOutboundPortsValue(report.InferredSkipOutboundPorts)
+		}
+	}
+
 	return report
 }
 
@@ -160,6 +193,22 @@ func (r *Report) Injectable() (bool, []string) {
 	return true, nil
 }
 
+// SkipReasons returns the human readable reasons why the workload wouldn't
+// be injected, if any, including the offending value of an invalid inject
+// annotation
+func (r *Report) SkipReasons() []string {
+	_, reasons := r.Injectable()
+	readable := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		msg := Reasons[reason]
+		if reason == invalidInjectAnnotationWorkload || reason == invalidInjectAnnotationNamespace {
+			msg = fmt.Sprintf("%s: %q", msg, r.InjectAnnotationValue)
+		}
+		readable = append(readable, msg)
+	}
+	return readable
+}
+
 // IsAnnotatable returns true if the resource for a report can be annotated.
 func (r *Report) IsAnnotatable() bool {
 	return r.Annotatable
@@ -3,6 +3,7 @@ package issuercerts
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
@@ -27,6 +28,10 @@ type IssuerCertData struct {
 	IssuerCrt    string
 	IssuerKey    string
 	Expiry       *time.Time
+
+	// KeyAlgorithms restricts the algorithms allowed for the issuer key. When
+	// empty, all the supported key algorithms are allowed.
+	KeyAlgorithms []tls.KeyAlgorithm
 }
 
 // FetchIssuerData fetches the issuer data from the linkerd-identity-issuer secrets (used for linkerd.io/tls schemed secrets)
@@ -51,7 +56,7 @@ func FetchIssuerData(ctx context.Context, api kubernetes.Interface, trustAnchors
 		return nil, fmt.Errorf("could not parse issuer certificate: %w", err)
 	}
 
-	return &IssuerCertData{trustAnchors, string(crt), string(key), &cert.Certificate.NotAfter}, nil
+	return &IssuerCertData{trustAnchors, string(crt), string(key), &cert.Certificate.NotAfter, nil}, nil
 }
 
 // FetchExternalIssuerData fetches the issuer data from the linkerd-identity-issuer secrets (used for kubernetes.io/tls schemed secrets)
@@ -81,7 +86,7 @@ func FetchExternalIssuerData(ctx context.Context, api kubernetes.Interface, cont
 		return nil, fmt.Errorf("could not parse issuer certificate: %w", err)
 	}
 
-	return &IssuerCertData{string(anchors), string(crt), string(key), &cert.Certificate.NotAfter}, nil
+	return &IssuerCertData{string(anchors), string(crt), string(key), &cert.Certificate.NotAfter, nil}, nil
 }
 
 // LoadIssuerCrtAndKeyFromFiles loads the issuer certificate and key from files
@@ -111,7 +116,7 @@ func LoadIssuerDataFromFiles(keyPEMFile, crtPEMFile, trustPEMFile string) (*Issu
 		return nil, err
 	}
 
-	return &IssuerCertData{string(anchors), crt, key, nil}, nil
+	return &IssuerCertData{string(anchors), crt, key, nil, nil}, nil
 }
 
 // CheckCertValidityPeriod ensures the certificate is valid time - wise
@@ -135,52 +140,60 @@ func CheckExpiringSoon(cert *x509.Certificate) error {
 }
 
 // CheckIssuerCertAlgoRequirements ensures the certificate respects with the constraints
-// we have posed on the public key and signature algorithms. Issuer certificates can only
-// be signed by an ECDSA certificate.
+// we have posed on the public key and signature algorithms. Issuer certificates can use
+// an ECDSA P-256, an RSA 2048/4096 bit or an Ed25519 key.
 func CheckIssuerCertAlgoRequirements(cert *x509.Certificate) error {
-	if cert.PublicKeyAlgorithm == x509.ECDSA {
-		err := checkECDSACertRequirements(cert)
-		if err != nil {
+	return CheckIssuerCertKeyAlgorithm(cert, nil)
+}
+
+// CheckIssuerCertKeyAlgorithm ensures the certificate respects the constraints
+// checked by CheckIssuerCertAlgoRequirements and that its key uses one of the
+// allowed algorithms. An empty list allows all the supported key algorithms.
+func CheckIssuerCertKeyAlgorithm(cert *x509.Certificate, allowed []tls.KeyAlgorithm) error {
+	switch cert.PublicKeyAlgorithm {
+	case x509.ECDSA:
+		if err := checkECDSACertRequirements(cert); err != nil {
+			return err
+		}
+	case x509.RSA:
+		if err := checkRSACertRequirements(cert); err != nil {
+			return err
+		}
+	case x509.Ed25519:
+		if err := checkEd25519CertRequirements(cert); err != nil {
 			return err
 		}
-	} else {
-		return fmt.Errorf("issuer certificate must use ECDSA for public key algorithm, instead %s was used", cert.PublicKeyAlgorithm)
+	default:
+		return fmt.Errorf("issuer certificate must use ECDSA, RSA or Ed25519 for public key algorithm, instead %s was used", cert.PublicKeyAlgorithm)
 	}
 
-	return nil
+	return tls.CheckKeyAlgorithm(cert.PublicKey, allowed)
 }
 
 // CheckTrustAnchorAlgoRequirements ensures the certificate respects with the constraints
 // we have posed on the public key and signature algorithms. Trust anchors can be signed by
-// an ECDSA or RSA certificate.
+// an ECDSA, RSA or Ed25519 certificate.
 func CheckTrustAnchorAlgoRequirements(cert *x509.Certificate) error {
-	if cert.PublicKeyAlgorithm == x509.ECDSA {
-		err := checkECDSACertRequirements(cert)
-		if err != nil {
-			return err
-		}
-	} else if cert.PublicKeyAlgorithm == x509.RSA {
-		err := checkRSACertRequirements(cert)
-		if err != nil {
-			return err
-		}
-	} else {
-		return fmt.Errorf("trust anchor must use ECDSA or RSA for public key algorithm, instead %s was used", cert.PublicKeyAlgorithm)
+	switch cert.PublicKeyAlgorithm {
+	case x509.ECDSA:
+		return checkECDSACertRequirements(cert)
+	case x509.RSA:
+		return checkRSACertRequirements(cert)
+	case x509.Ed25519:
+		return checkEd25519CertRequirements(cert)
+	default:
+		return fmt.Errorf("trust anchor must use ECDSA, RSA or Ed25519 for public key algorithm, instead %s was used", cert.PublicKeyAlgorithm)
 	}
-
-	return nil
 }
 
 func checkECDSACertRequirements(cert *x509.Certificate) error {
-	k, ok := cert.PublicKey.(*ecdsa.PublicKey)
-	if !ok {
+	if _, ok := cert.PublicKey.(*ecdsa.PublicKey); !ok {
 		return fmt.Errorf("expected ecdsa.PublicKey but got something %v", cert.PublicKey)
 	}
-	if k.Params().BitSize != 256 {
-		return fmt.Errorf("must use P-256 curve for public key, instead P-%d was used", k.Params().BitSize)
+	if _, err := tls.PublicKeyAlgorithm(cert.PublicKey); err != nil {
+		return err
 	}
-	if cert.SignatureAlgorithm != x509.ECDSAWithSHA256 &&
-		cert.SignatureAlgorithm != x509.SHA256WithRSA {
+	if !supportedSignatureAlgorithm(cert.SignatureAlgorithm) {
 		return fmt.Errorf("must be signed by an ECDSA P-256 key, instead %s was used", cert.SignatureAlgorithm)
 	}
 
@@ -188,20 +201,42 @@ func checkECDSACertRequirements(cert *x509.Certificate) error {
 }
 
 func checkRSACertRequirements(cert *x509.Certificate) error {
-	k, ok := cert.PublicKey.(*rsa.PublicKey)
-	if !ok {
+	if _, ok := cert.PublicKey.(*rsa.PublicKey); !ok {
 		return fmt.Errorf("expected rsa.PublicKey but got something %v", cert.PublicKey)
 	}
-	if k.N.BitLen() != 2048 && k.N.BitLen() != 4096 {
-		return fmt.Errorf("RSA must use a 2048 or 4096 bit public key, instead %d bit public key was used", k.N.BitLen())
+	if _, err := tls.PublicKeyAlgorithm(cert.PublicKey); err != nil {
+		return err
 	}
-	if cert.SignatureAlgorithm != x509.SHA256WithRSA {
+	if !supportedSignatureAlgorithm(cert.SignatureAlgorithm) {
 		return fmt.Errorf("must be signed by an RSA 2048/4096 bit key, instead %s was used", cert.SignatureAlgorithm)
 	}
 
 	return nil
 }
 
+func checkEd25519CertRequirements(cert *x509.Certificate) error {
+	if _, ok := cert.PublicKey.(ed25519.PublicKey); !ok {
+		return fmt.Errorf("expected ed25519.PublicKey but got something %v", cert.PublicKey)
+	}
+	if !supportedSignatureAlgorithm(cert.SignatureAlgorithm) {
+		return fmt.Errorf("must be signed by an Ed25519 key, instead %s was used", cert.SignatureAlgorithm)
+	}
+
+	return nil
+}
+
+// supportedSignatureAlgorithm returns whether certificates may have been
+// signed with the given algorithm, i.e. by an ECDSA P-256, RSA or Ed25519
+// issuer.
+func supportedSignatureAlgorithm(alg x509.SignatureAlgorithm) bool {
+	switch alg {
+	case x509.ECDSAWithSHA256, x509.SHA256WithRSA, x509.PureEd25519:
+		return true
+	default:
+		return false
+	}
+}
+
 // VerifyAndBuildCreds builds and validates the creds out of the data in IssuerCertData
 func (ic *IssuerCertData) VerifyAndBuildCreds() (*tls.Cred, error) {
 	creds, err := tls.ValidateAndCreateCreds(ic.IssuerCrt, ic.IssuerKey)
@@ -215,7 +250,7 @@ func (ic *IssuerCertData) VerifyAndBuildCreds() (*tls.Cred, error) {
 	}
 
 	// we check the algo requirements of the issuer cert
-	if err := CheckIssuerCertAlgoRequirements(creds.Certificate); err != nil {
+	if err := CheckIssuerCertKeyAlgorithm(creds.Certificate, ic.KeyAlgorithms); err != nil {
 		return nil, err
 	}
 
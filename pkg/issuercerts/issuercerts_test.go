@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/linkerd/linkerd2/pkg/tls"
 )
 
 func rsaSelfSignedCert(t *testing.T, bits int) *x509.Certificate {
@@ -54,3 +56,30 @@ func TestCheckTrustAnchorAlgoRequirementsRSA(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckIssuerCertKeyAlgorithm(t *testing.T) {
+	root, err := tls.GenerateRootCAWithDefaults("root")
+	if err != nil {
+		t.Fatalf("failed to create root CA: %v", err)
+	}
+	for _, alg := range []tls.KeyAlgorithm{tls.KeyAlgorithmECDSAP256, tls.KeyAlgorithmRSA2048, tls.KeyAlgorithmEd25519} {
+		rootWithAlg, err := tls.GenerateRootCA("root", alg)
+		if err != nil {
+			t.Fatalf("failed to create %s root CA: %v", alg, err)
+		}
+		issuer, err := rootWithAlg.GenerateCA("issuer", -1)
+		if err != nil {
+			t.Fatalf("failed to create %s issuer: %v", alg, err)
+		}
+		if err := CheckIssuerCertAlgoRequirements(issuer.Cred.Certificate); err != nil {
+			t.Errorf("unexpected error for %s issuer: %v", alg, err)
+		}
+		if err := CheckIssuerCertKeyAlgorithm(issuer.Cred.Certificate, []tls.KeyAlgorithm{alg}); err != nil {
+			t.Errorf("unexpected error for allowed %s issuer: %v", alg, err)
+		}
+	}
+
+	if err := CheckIssuerCertKeyAlgorithm(root.Cred.Certificate, []tls.KeyAlgorithm{tls.KeyAlgorithmEd25519}); err == nil {
+		t.Error("expected an error for an ECDSA issuer when only Ed25519 is allowed")
+	}
+}
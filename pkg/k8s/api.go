@@ -491,6 +491,64 @@ func GetPodsFor(ctx context.Context, clientset kubernetes.Interface, namespace s
 	return pods, nil
 }
 
+// GetPodTemplateOwner returns the kind, name and pod template of the
+// workload which created the pod, following ReplicaSets up to their
+// Deployment. It returns a nil template for pods that weren't created from a
+// template.
+func GetPodTemplateOwner(ctx context.Context, clientset kubernetes.Interface, pod *corev1.Pod) (string, string, *corev1.PodTemplateSpec, error) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "", "", nil, nil
+	}
+
+	ns := pod.GetNamespace()
+	switch ref.Kind {
+	case "ReplicaSet":
+		rs, err := clientset.AppsV1().ReplicaSets(ns).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", nil, err
+		}
+		if rsRef := metav1.GetControllerOf(rs); rsRef != nil && rsRef.Kind == "Deployment" {
+			deploy, err := clientset.AppsV1().Deployments(ns).Get(ctx, rsRef.Name, metav1.GetOptions{})
+			if err != nil {
+				return "", "", nil, err
+			}
+			return Deployment, deploy.GetName(), &deploy.Spec.Template, nil
+		}
+		return ReplicaSet, rs.GetName(), &rs.Spec.Template, nil
+
+	case "StatefulSet":
+		ss, err := clientset.AppsV1().StatefulSets(ns).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", nil, err
+		}
+		return StatefulSet, ss.GetName(), &ss.Spec.Template, nil
+
+	case "DaemonSet":
+		ds, err := clientset.AppsV1().DaemonSets(ns).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", nil, err
+		}
+		return DaemonSet, ds.GetName(), &ds.Spec.Template, nil
+
+	case "Job":
+		job, err := clientset.BatchV1().Jobs(ns).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", nil, err
+		}
+		return Job, job.GetName(), &job.Spec.Template, nil
+
+	case "ReplicationController":
+		rc, err := clientset.CoreV1().ReplicationControllers(ns).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", nil, err
+		}
+		return ReplicationController, rc.GetName(), rc.Spec.Template, nil
+	}
+
+	return "", "", nil, nil
+}
+
 func isOwner(u types.UID, ownerRefs []metav1.OwnerReference) bool {
 	for _, or := range ownerRefs {
 		if u == or.UID {
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetPodStatus(t *testing.T) {
@@ -480,3 +481,117 @@ spec:
 		t.Fatalf("Expected 1 pod, got %d", len(pods))
 	}
 }
+
+func TestGetPodTemplateOwner(t *testing.T) {
+	configs := []string{
+		`apiVersion: v1
+kind: Pod
+metadata:
+  name: pod-1
+  namespace: ns
+  ownerReferences:
+  - apiVersion: apps/v1
+    controller: true
+    kind: ReplicaSet
+    name: rs-1
+    uid: rs-1
+`,
+		`apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: rs-1
+  namespace: ns
+  uid: rs-1
+  ownerReferences:
+  - apiVersion: apps/v1
+    controller: true
+    kind: Deployment
+    name: deploy-1
+    uid: deploy-1
+`,
+		`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deploy-1
+  namespace: ns
+  uid: deploy-1
+spec:
+  template:
+    metadata:
+      labels:
+        app: foo
+`,
+		`apiVersion: v1
+kind: Pod
+metadata:
+  name: pod-2
+  namespace: ns
+  ownerReferences:
+  - apiVersion: apps/v1
+    controller: true
+    kind: StatefulSet
+    name: ss-1
+    uid: ss-1
+`,
+		`apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: ss-1
+  namespace: ns
+  uid: ss-1
+spec:
+  template:
+    metadata:
+      labels:
+        app: bar
+`,
+		`apiVersion: v1
+kind: Pod
+metadata:
+  name: pod-3
+  namespace: ns
+`,
+	}
+
+	k8sClient, err := NewFakeAPI(configs...)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	testCases := []struct {
+		pod   string
+		kind  string
+		name  string
+		label string
+	}{
+		{"pod-1", Deployment, "deploy-1", "foo"},
+		{"pod-2", StatefulSet, "ss-1", "bar"},
+		{"pod-3", "", "", ""},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.pod, func(t *testing.T) {
+			pod, err := k8sClient.CoreV1().Pods("ns").Get(context.Background(), tc.pod, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error %s", err)
+			}
+			kind, name, template, err := GetPodTemplateOwner(context.Background(), k8sClient, pod)
+			if err != nil {
+				t.Fatalf("Unexpected error %s", err)
+			}
+			if kind != tc.kind || name != tc.name {
+				t.Fatalf("Expected owner %s/%s, got %s/%s", tc.kind, tc.name, kind, name)
+			}
+			if tc.label == "" {
+				if template != nil {
+					t.Fatalf("Expected no template, got %v", template)
+				}
+				return
+			}
+			if template == nil || template.Labels["app"] != tc.label {
+				t.Fatalf("Expected template labeled app=%s, got %v", tc.label, template)
+			}
+		})
+	}
+}
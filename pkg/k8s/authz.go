@@ -168,6 +168,30 @@ func LinksAccess(ctx context.Context, k8sClient kubernetes.Interface) error {
 	return errors.New("Links CRD not found")
 }
 
+// ProxyConfigsAccess checks whether the ProxyConfig and ClusterProxyConfig
+// CRDs are installed on the cluster and the client is authorized to access
+// them
+func ProxyConfigsAccess(ctx context.Context, k8sClient kubernetes.Interface) error {
+	groupVersion := fmt.Sprintf("%s/%s", ProxyConfigAPIGroup, ProxyConfigAPIVersion)
+	res, err := k8sClient.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return err
+	}
+	found := 0
+	for _, apiRes := range res.APIResources {
+		if apiRes.Kind == ProxyConfigKind || apiRes.Kind == ClusterProxyConfigKind {
+			found++
+		}
+	}
+	if res.GroupVersion != groupVersion || found != 2 {
+		return errors.New("ProxyConfig CRDs not found")
+	}
+	if err := ResourceAuthz(ctx, k8sClient, "", "list", ProxyConfigAPIGroup, "", ProxyConfigResource, ""); err != nil {
+		return err
+	}
+	return ResourceAuthz(ctx, k8sClient, "", "list", ProxyConfigAPIGroup, "", ClusterProxyConfigResource, "")
+}
+
 // EndpointSliceAccess verifies whether the K8s cluster has
 // access to EndpointSlice resources.
 func EndpointSliceAccess(ctx context.Context, k8sClient kubernetes.Interface) error {
@@ -107,6 +107,10 @@ func NewFakeClientSets(configs ...string) (
 			spObjs = append(spObjs, obj)
 		case ExtWorkload:
 			spObjs = append(spObjs, obj)
+		case ProxyConfig:
+			spObjs = append(spObjs, obj)
+		case ClusterProxyConfig:
+			spObjs = append(spObjs, obj)
 		default:
 			objs = append(objs, obj)
 		}
@@ -18,6 +18,7 @@ const (
 	Deployment            = "deployment"
 	Endpoints             = "endpoints"
 	EndpointSlices        = "endpointslices"
+	ClusterProxyConfig    = "clusterproxyconfig"
 	ExtWorkload           = "externalworkload"
 	Job                   = "job"
 	Link                  = "link"
@@ -26,6 +27,8 @@ const (
 	Namespace             = "namespace"
 	NetworkAuthentication = "networkauthentication"
 	Pod                   = "pod"
+	PodDisruptionBudget   = "poddisruptionbudget"
+	ProxyConfig           = "proxyconfig"
 	ReplicationController = "replicationcontroller"
 	ReplicaSet            = "replicaset"
 	Secret                = "secret"
@@ -57,9 +60,19 @@ const (
 	ExtWorkloadKind = "ExternalWorkload"
 	PodKind         = "Pod"
 
+	MeshTLSAuthenticationKind = "MeshTLSAuthentication"
+	ServiceAccountKind        = "ServiceAccount"
+
 	WorkloadAPIGroup   = "workload.linkerd.io"
 	WorkloadAPIVersion = "v1alpha1"
 
+	ProxyConfigAPIGroup        = "config.linkerd.io"
+	ProxyConfigAPIVersion      = "v1alpha1"
+	ProxyConfigKind            = "ProxyConfig"
+	ClusterProxyConfigKind     = "ClusterProxyConfig"
+	ProxyConfigResource        = "proxyconfigs"
+	ClusterProxyConfigResource = "clusterproxyconfigs"
+
 	// special case k8s job label, to not conflict with Prometheus' job label
 	l5dJob = "k8s_job"
 )
@@ -113,6 +113,14 @@ const (
 	// disable injection for a pod or namespace.
 	ProxyInjectDisabled = Disabled
 
+	// AdmissionWebhookLabel, when set to Disabled on a namespace, excludes it
+	// from Linkerd's admission webhooks
+	AdmissionWebhookLabel = ProxyConfigAnnotationsPrefix + "/admission-webhooks"
+
+	// MeshRequiredLabel, when set to "true" on a namespace, makes the proxy
+	// injector deny the admission of its pods that don't get a proxy
+	MeshRequiredLabel = Prefix + "/mesh-required"
+
 	// ProxyTrustRootSHA indicates the cert bundle configured on the injected
 	// workload.
 	ProxyTrustRootSHA = Prefix + "/trust-root-sha256"
@@ -279,6 +287,17 @@ const (
 	// configured for the Pod
 	ProxyWaitBeforeExitSecondsAnnotation = ProxyConfigAnnotationsPrefixAlpha + "/proxy-wait-before-exit-seconds"
 
+	// ProxyPortInferenceAnnotation can be set to "enabled" on a workload or
+	// namespace to have the proxy injector mark as opaque the ports whose name,
+	// or Service appProtocol, denotes a well-known opaque protocol (e.g. mysql).
+	ProxyPortInferenceAnnotation = ProxyConfigAnnotationsPrefixAlpha + "/port-inference"
+
+	// ProxySkipPortInferenceAnnotation can be set to "enabled" on a workload or
+	// namespace to have the proxy injector add to the skipped outbound ports the
+	// ports of the ExternalName Services the workload refers to whose
+	// appProtocol, or name, denotes a well-known opaque protocol.
+	ProxySkipPortInferenceAnnotation = ProxyConfigAnnotationsPrefixAlpha + "/skip-port-inference"
+
 	// ProxyEnableNativeSidecarAnnotationAlpha enables the native initContainer sidecar.
 	// Deprecated: use ProxyEnableNativeSidecarAnnotation instead.
 	ProxyEnableNativeSidecarAnnotationAlpha = ProxyConfigAnnotationsPrefixAlpha + "/proxy-enable-native-sidecar"
@@ -337,6 +356,12 @@ const (
 	// env var name rather than replacing the entire list.
 	ProxyAdditionalEnvAnnotation = ProxyConfigAnnotationsPrefix + "/proxy-additional-env"
 
+	// ProxyConfigAnnotation refers to a ProxyConfig, in the workload's
+	// namespace, or to a ClusterProxyConfig holding proxy configuration
+	// overrides. They take precedence over the namespace's config annotations
+	// but not over the workload's.
+	ProxyConfigAnnotation = ProxyConfigAnnotationsPrefix + "/proxy-config"
+
 	/*
 	 * Component Names
 	 */
@@ -371,6 +396,10 @@ const (
 	// IdentityIssuerSecretName is the name of the Secret that stores issuer credentials.
 	IdentityIssuerSecretName = "linkerd-identity-issuer"
 
+	// IdentityDenyListConfigMapName is the name of the ConfigMap listing the
+	// identities and certificate serial numbers which have been revoked.
+	IdentityDenyListConfigMapName = "linkerd-identity-deny-list"
+
 	// IdentityIssuerSchemeLinkerd is the issuer secret scheme used by linkerd
 	IdentityIssuerSchemeLinkerd = "linkerd.io/tls"
 
@@ -492,6 +521,24 @@ const (
 	// with RemoteDiscoveryAnnotation and the endpoints will be unioned.
 	LocalDiscoveryAnnotation = MulticlusterPrefix + "/local-discovery"
 
+	// FederatedPreferenceAnnotation is a comma-separated list of cluster
+	// names, in order of preference, on a federated service. Endpoints of a
+	// cluster are only used when no more preferred cluster has ready
+	// endpoints. The local cluster is named "local" and clusters which are not
+	// listed share the lowest preference.
+	FederatedPreferenceAnnotation = MulticlusterPrefix + "/federated-preference"
+
+	// FederatedWeightsAnnotation is a comma-separated list of
+	// <cluster>=<weight> pairs on a federated service. The weight of each
+	// endpoint of a cluster is multiplied by the cluster's weight, which
+	// defaults to 1.
+	FederatedWeightsAnnotation = MulticlusterPrefix + "/federated-weights"
+
+	// FederatedClusterLabel is the metric label set by the destination
+	// controller on the endpoints of a federated service, holding the cluster
+	// each endpoint belongs to.
+	FederatedClusterLabel = "federated_cluster"
+
 	// RemoteResourceVersionAnnotation is the last observed remote resource
 	// version of a mirrored resource. Useful when doing updates
 	RemoteResourceVersionAnnotation = SvcMirrorPrefix + "/remote-resource-version"
@@ -500,6 +547,18 @@ const (
 	// on the remote cluster
 	RemoteServiceFqName = SvcMirrorPrefix + "/remote-svc-fq-name"
 
+	// HeadlessDirectMirrorAnnotation is set on the Endpoints of a headless
+	// mirror whose addresses are the remote endpoints themselves, instead of
+	// an endpoint mirror Service per hostname. Traffic to each of them is sent
+	// to one of the RemoteGatewayAddresses with an authority of
+	// <hostname>.<RemoteServiceFqName>.
+	HeadlessDirectMirrorAnnotation = SvcMirrorPrefix + "/headless-direct-mirror"
+
+	// RemoteGatewayAddresses is the comma-separated list of ip:port addresses
+	// of the gateway through which the endpoints of a headless mirror in
+	// direct mode are reached
+	RemoteGatewayAddresses = SvcMirrorPrefix + "/remote-gateway-addresses"
+
 	// RemoteGatewayIdentity follows the same kind of logic as RemoteGatewayNameLabel
 	RemoteGatewayIdentity = SvcMirrorPrefix + "/remote-gateway-identity"
 
@@ -189,6 +189,82 @@ func ServersForResource(ctx context.Context, k8sAPI *KubernetesAPI, namespace st
 	return results, nil
 }
 
+// MeshTLSClientsForAuthorization returns the identities of the meshed clients
+// the given authorization requires to use mTLS: the identities and service
+// accounts of the ServerAuthorization's meshTLS clients, or of the
+// MeshTLSAuthentications required by the AuthorizationPolicy. Service
+// accounts are returned as "serviceaccount/<namespace>/<name>" and namespaces
+// as "namespace/<name>".
+func MeshTLSClientsForAuthorization(ctx context.Context, k8sAPI *KubernetesAPI, namespace string, authz Authorization) ([]string, error) {
+	clients := []string{}
+	add := func(client string) {
+		for _, c := range clients {
+			if c == client {
+				return
+			}
+		}
+		clients = append(clients, client)
+	}
+
+	if authz.ServerAuthorization != "" {
+		saz, err := k8sAPI.L5dCrdClient.ServerauthorizationV1beta1().ServerAuthorizations(namespace).Get(ctx, authz.ServerAuthorization, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		meshTLS := saz.Spec.Client.MeshTLS
+		if saz.Spec.Client.Unauthenticated || meshTLS == nil || meshTLS.UnauthenticatedTLS {
+			return clients, nil
+		}
+		for _, id := range meshTLS.Identities {
+			add(id)
+		}
+		for _, sa := range meshTLS.ServiceAccounts {
+			ns := sa.Namespace
+			if ns == "" {
+				ns = namespace
+			}
+			add(fmt.Sprintf("serviceaccount/%s/%s", ns, sa.Name))
+		}
+	}
+
+	if authz.AuthorizationPolicy != "" {
+		policy, err := k8sAPI.L5dCrdClient.PolicyV1alpha1().AuthorizationPolicies(namespace).Get(ctx, authz.AuthorizationPolicy, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range policy.Spec.RequiredAuthenticationRefs {
+			if ref.Kind != MeshTLSAuthenticationKind || ref.Group != PolicyAPIGroup {
+				continue
+			}
+			ns := namespace
+			if ref.Namespace != nil && *ref.Namespace != "" {
+				ns = string(*ref.Namespace)
+			}
+			authn, err := k8sAPI.L5dCrdClient.PolicyV1alpha1().MeshTLSAuthentications(ns).Get(ctx, string(ref.Name), metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range authn.Spec.Identities {
+				add(id)
+			}
+			for _, idRef := range authn.Spec.IdentityRefs {
+				refNs := ns
+				if idRef.Namespace != nil && *idRef.Namespace != "" {
+					refNs = string(*idRef.Namespace)
+				}
+				switch idRef.Kind {
+				case ServiceAccountKind:
+					add(fmt.Sprintf("serviceaccount/%s/%s", refNs, idRef.Name))
+				case NamespaceKind:
+					add(fmt.Sprintf("namespace/%s", idRef.Name))
+				}
+			}
+		}
+	}
+
+	return clients, nil
+}
+
 // ServerAuthorizationsForServer returns a list of ServerAuthorization names of
 // ServerAuthorizations which select the given Server.
 func ServerAuthorizationsForServer(ctx context.Context, k8sAPI *KubernetesAPI, namespace string, server string) ([]string, error) {
@@ -1,10 +1,18 @@
 package servicemirror
 
 import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	consts "github.com/linkerd/linkerd2/pkg/k8s"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 // ParseRemoteClusterSecret extracts the credentials used to access the remote cluster
@@ -17,3 +25,100 @@ func ParseRemoteClusterSecret(secret *corev1.Secret) ([]byte, error) {
 
 	return config, nil
 }
+
+// CredentialsExpiry returns the time at which the credentials of the current
+// context in the given kubeconfig expire. Bearer tokens are inspected for a
+// JWT `exp` claim and client certificates for their NotAfter field; when both
+// are present the earliest expiry is returned. A zero time is returned if the
+// credentials carry no expiry information (e.g. legacy service account
+// tokens).
+func CredentialsExpiry(kubeconfig []byte) (time.Time, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse kube config: %w", err)
+	}
+
+	context, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return time.Time{}, fmt.Errorf("could not find current context %q in kube config", config.CurrentContext)
+	}
+	authInfo, ok := config.AuthInfos[context.AuthInfo]
+	if !ok {
+		return time.Time{}, fmt.Errorf("could not find user %q in kube config", context.AuthInfo)
+	}
+
+	var expiry time.Time
+	earliest := func(t time.Time) {
+		if !t.IsZero() && (expiry.IsZero() || t.Before(expiry)) {
+			expiry = t
+		}
+	}
+
+	token := authInfo.Token
+	if token == "" && authInfo.TokenFile != "" {
+		data, err := os.ReadFile(authInfo.TokenFile)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		exp, err := tokenExpiry(token)
+		if err != nil {
+			return time.Time{}, err
+		}
+		earliest(exp)
+	}
+
+	certData := authInfo.ClientCertificateData
+	if len(certData) == 0 && authInfo.ClientCertificate != "" {
+		certData, err = os.ReadFile(authInfo.ClientCertificate)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to read client certificate: %w", err)
+		}
+	}
+	if len(certData) > 0 {
+		block, _ := pem.Decode(certData)
+		if block == nil {
+			return time.Time{}, fmt.Errorf("unable to decode client certificate PEM")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to parse client certificate: %w", err)
+		}
+		earliest(cert.NotAfter)
+	}
+
+	return expiry, nil
+}
+
+// tokenExpiry extracts the `exp` claim from a JWT bearer token without
+// verifying its signature. Tokens that aren't JWTs, or that don't carry an
+// `exp` claim, are reported as never expiring.
+func tokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to decode token payload: %w", err)
+	}
+
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse token claims: %w", err)
+	}
+	if claims.Exp == nil {
+		return time.Time{}, nil
+	}
+
+	exp, err := claims.Exp.Int64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid token exp claim: %w", err)
+	}
+	return time.Unix(exp, 0), nil
+}
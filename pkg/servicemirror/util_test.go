@@ -0,0 +1,105 @@
+package servicemirror
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/tls"
+)
+
+func TestCredentialsExpiry(t *testing.T) {
+	exp := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	ca, err := tls.GenerateRootCAWithDefaults("test-ca")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	cred, err := ca.GenerateEndEntityCred("client.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	certData := base64.StdEncoding.EncodeToString([]byte(cred.Crt.EncodeCertificatePEM()))
+
+	for _, tc := range []struct {
+		name     string
+		user     string
+		expected time.Time
+		err      bool
+	}{
+		{
+			name:     "legacy token without expiry",
+			user:     "token: not-a-jwt",
+			expected: time.Time{},
+		},
+		{
+			name:     "JWT token with exp claim",
+			user:     fmt.Sprintf("token: %s", jwt(fmt.Sprintf(`{"exp":%d}`, exp.Unix()))),
+			expected: exp,
+		},
+		{
+			name:     "JWT token without exp claim",
+			user:     fmt.Sprintf("token: %s", jwt(`{"sub":"foo"}`)),
+			expected: time.Time{},
+		},
+		{
+			name:     "client certificate",
+			user:     fmt.Sprintf("client-certificate-data: %s", certData),
+			expected: cred.Crt.Certificate.NotAfter,
+		},
+		{
+			name:     "earliest of token and client certificate",
+			user:     fmt.Sprintf("token: %s\n    client-certificate-data: %s", jwt(fmt.Sprintf(`{"exp":%d}`, exp.Unix())), certData),
+			expected: cred.Crt.Certificate.NotAfter,
+		},
+		{
+			name: "malformed JWT token",
+			user: "token: a.!!!.c",
+			err:  true,
+		},
+	} {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			expiry, err := CredentialsExpiry([]byte(kubeconfig(tc.user)))
+			if tc.err {
+				if err == nil {
+					t.Fatal("Expected error, got nothing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !expiry.Equal(tc.expected) {
+				t.Fatalf("Expected expiry %s, got %s", tc.expected, expiry)
+			}
+		})
+	}
+}
+
+func jwt(claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	return fmt.Sprintf("%s.%s.signature", header, payload)
+}
+
+func kubeconfig(user string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: https://remote.example.com
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    user: remote-sa
+current-context: remote
+users:
+- name: remote-sa
+  user:
+    %s
+`, user)
+}
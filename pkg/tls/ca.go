@@ -1,6 +1,7 @@
 package tls
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -26,6 +27,10 @@ type (
 		// validity.
 		Validity Validity
 
+		// KeyAlgorithms restricts the algorithms of the end-entity keys this CA
+		// certifies. When empty, all the supported key algorithms are allowed.
+		KeyAlgorithms []KeyAlgorithm
+
 		// nextSerialNumber is the serial number of the next certificate to issue.
 		// Serial numbers must not be reused.
 		//
@@ -101,7 +106,20 @@ func findFirstExpiration(cred *Cred) time.Time {
 
 // NewCA initializes a new CA with default settings.
 func NewCA(cred Cred, validity Validity) *CA {
-	return &CA{cred, validity, uint64(1), findFirstExpiration(&cred)}
+	return &CA{cred, validity, nil, uint64(1), findFirstExpiration(&cred)}
+}
+
+// NewCAWithRandomSerials initializes a new CA with default settings whose
+// serial numbers start at a random value, so that CAs created with the same
+// credentials, e.g. across rotations, are unlikely to reuse serial numbers.
+func NewCAWithRandomSerials(cred Cred, validity Validity) (*CA, error) {
+	start, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		return nil, err
+	}
+	ca := NewCA(cred, validity)
+	ca.nextSerialNumber = start.Uint64() + 1
+	return ca, nil
 }
 
 func init() {
@@ -112,11 +130,11 @@ func init() {
 // CreateRootCA configures a new root CA with the given settings
 func CreateRootCA(
 	name string,
-	key *ecdsa.PrivateKey,
+	key crypto.Signer,
 	validity Validity,
 ) (*CA, error) {
 	// Configure the root certificate.
-	t := createTemplate(1, &key.PublicKey, validity)
+	t := createTemplate(1, key.Public(), key.Public(), validity)
 	t.Subject = pkix.Name{CommonName: name}
 	t.IsCA = true
 	t.MaxPathLen = -1
@@ -148,8 +166,14 @@ func GenerateKey() (*ecdsa.PrivateKey, error) {
 
 // GenerateRootCAWithDefaults generates a new root CA with default settings.
 func GenerateRootCAWithDefaults(name string) (*CA, error) {
+	return GenerateRootCA(name, DefaultKeyAlgorithm)
+}
+
+// GenerateRootCA generates a new root CA with a key of the given algorithm
+// and default settings.
+func GenerateRootCA(name string, alg KeyAlgorithm) (*CA, error) {
 	// Generate a new root key.
-	key, err := GenerateKey()
+	key, err := GenerateKeyWithAlgorithm(alg)
 	if err != nil {
 		return nil, err
 	}
@@ -157,14 +181,15 @@ func GenerateRootCAWithDefaults(name string) (*CA, error) {
 	return CreateRootCA(name, key, Validity{})
 }
 
-// GenerateCA generates a new intermediate CA.
+// GenerateCA generates a new intermediate CA, with a key of the same
+// algorithm as this CA's.
 func (ca *CA) GenerateCA(name string, maxPathLen int) (*CA, error) {
-	key, err := GenerateKey()
+	key, err := ca.generateKey()
 	if err != nil {
 		return nil, err
 	}
 
-	t := ca.createTemplate(&key.PublicKey)
+	t := ca.createTemplate(key.Public())
 	t.Subject = pkix.Name{CommonName: name}
 	t.IsCA = true
 	t.MaxPathLen = maxPathLen
@@ -180,9 +205,10 @@ func (ca *CA) GenerateCA(name string, maxPathLen int) (*CA, error) {
 }
 
 // GenerateEndEntityCred creates a new certificate that is valid for the
-// given DNS name, generating a new keypair for it.
+// given DNS name, generating a new keypair for it with the same algorithm as
+// this CA's.
 func (ca *CA) GenerateEndEntityCred(dnsName string) (*Cred, error) {
-	key, err := GenerateKey()
+	key, err := ca.generateKey()
 	if err != nil {
 		return nil, err
 	}
@@ -190,7 +216,7 @@ func (ca *CA) GenerateEndEntityCred(dnsName string) (*Cred, error) {
 	csr := x509.CertificateRequest{
 		Subject:   pkix.Name{CommonName: dnsName},
 		DNSNames:  []string{dnsName},
-		PublicKey: &key.PublicKey,
+		PublicKey: key.Public(),
 	}
 	crt, err := ca.IssueEndEntityCrt(&csr)
 	if err != nil {
@@ -204,12 +230,11 @@ func (ca *CA) GenerateEndEntityCred(dnsName string) (*Cred, error) {
 // IssueEndEntityCrt creates a new certificate that is valid for the
 // given DNS name, generating a new keypair for it.
 func (ca *CA) IssueEndEntityCrt(csr *x509.CertificateRequest) (Crt, error) {
-	pubkey, ok := csr.PublicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return Crt{}, fmt.Errorf("CSR must contain an ECDSA public key: %+v", csr.PublicKey)
+	if err := CheckKeyAlgorithm(csr.PublicKey, ca.KeyAlgorithms); err != nil {
+		return Crt{}, fmt.Errorf("invalid CSR public key: %w", err)
 	}
 
-	t := ca.createTemplate(pubkey)
+	t := ca.createTemplate(csr.PublicKey)
 	t.Issuer = ca.Cred.Crt.Certificate.Subject
 	t.Subject = csr.Subject
 	t.Extensions = csr.Extensions
@@ -225,8 +250,8 @@ func (ca *CA) IssueEndEntityCrt(csr *x509.CertificateRequest) (Crt, error) {
 // createTemplate returns a certificate t for a non-CA certificate with
 // no subject name, no subjectAltNames. The t can then be modified into
 // a (root) CA t or an end-entity t by the caller.
-func (ca *CA) createTemplate(pubkey *ecdsa.PublicKey) *x509.Certificate {
-	c := createTemplate(ca.nextSerialNumber, pubkey, ca.Validity)
+func (ca *CA) createTemplate(pubkey crypto.PublicKey) *x509.Certificate {
+	c := createTemplate(ca.nextSerialNumber, pubkey, ca.Cred.Certificate.PublicKey, ca.Validity)
 	ca.nextSerialNumber++
 	// if our trust chain contains a certificate that expires
 	// sooner than the one we intend to issue, we clamp the
@@ -242,24 +267,15 @@ func (ca *CA) createTemplate(pubkey *ecdsa.PublicKey) *x509.Certificate {
 // createTemplate returns a certificate t for a non-CA certificate with
 // no subject name, no subjectAltNames. The t can then be modified into
 // a (root) CA t or an end-entity t by the caller.
+//
+// The certificate is signed with the algorithm of the signer's key pair, whose
+// public key is signer.
 func createTemplate(
 	serialNumber uint64,
-	k *ecdsa.PublicKey,
+	k crypto.PublicKey,
+	signer crypto.PublicKey,
 	v Validity,
 ) *x509.Certificate {
-	// ECDSA is used instead of RSA because ECDSA key generation is
-	// straightforward and fast whereas RSA key generation is extremely slow
-	// and error-prone.
-	//
-	// CA certificates are signed with the same algorithm as end-entity
-	// certificates because they are relatively short-lived, because using one
-	// algorithm minimizes exposure to implementation flaws, and to speed up
-	// signature verification time.
-	//
-	// SHA-256 is used because any larger digest would be truncated to 256 bits
-	// anyway since a P-256 scalar is only 256 bits long.
-	const SignatureAlgorithm = x509.ECDSAWithSHA256
-
 	if v.ValidFrom == nil {
 		now := time.Now()
 		v.ValidFrom = &now
@@ -268,7 +284,7 @@ func createTemplate(
 
 	return &x509.Certificate{
 		SerialNumber:       big.NewInt(int64(serialNumber)),
-		SignatureAlgorithm: SignatureAlgorithm,
+		SignatureAlgorithm: signatureAlgorithm(signer),
 		NotBefore:          notBefore,
 		NotAfter:           notAfter,
 		PublicKey:          k,
@@ -280,6 +296,24 @@ func createTemplate(
 	}
 }
 
+// generateKey creates a new private key with the same algorithm as this CA's.
+//
+// ECDSA P-256 is used by default, instead of RSA, because ECDSA key generation
+// is straightforward and fast whereas RSA key generation is extremely slow and
+// error-prone.
+//
+// CA certificates are signed with the same algorithm as end-entity
+// certificates because they are relatively short-lived, because using one
+// algorithm minimizes exposure to implementation flaws, and to speed up
+// signature verification time.
+func (ca *CA) generateKey() (crypto.Signer, error) {
+	alg, err := PublicKeyAlgorithm(ca.Cred.Certificate.PublicKey)
+	if err != nil {
+		alg = DefaultKeyAlgorithm
+	}
+	return GenerateKeyWithAlgorithm(alg)
+}
+
 // Window returns the time window for which a certificate should be valid.
 func (v *Validity) Window(t time.Time) (time.Time, time.Time) {
 	life := v.Lifetime
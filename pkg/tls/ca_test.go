@@ -1,6 +1,7 @@
 package tls
 
 import (
+	"crypto/x509"
 	"testing"
 	"time"
 )
@@ -65,3 +66,58 @@ func TestCaIssuesCertsWithCorrectExpiration(t *testing.T) {
 	}
 
 }
+
+func TestCaIssuesCertsWithKeyAlgorithms(t *testing.T) {
+	for _, rootAlg := range []KeyAlgorithm{KeyAlgorithmECDSAP256, KeyAlgorithmRSA2048, KeyAlgorithmEd25519} {
+		rootAlg := rootAlg
+		t.Run(string(rootAlg), func(t *testing.T) {
+			root, err := GenerateRootCA("fake-root", rootAlg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			issuer, err := root.GenerateCA("fake-issuer", -1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if alg, _ := PublicKeyAlgorithm(issuer.Cred.Certificate.PublicKey); alg != rootAlg {
+				t.Fatalf("Expected the issuer key to use %s, got %s", rootAlg, alg)
+			}
+
+			for _, alg := range []KeyAlgorithm{KeyAlgorithmECDSAP256, KeyAlgorithmRSA2048, KeyAlgorithmEd25519} {
+				key, err := GenerateKeyWithAlgorithm(alg)
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				crt, err := issuer.IssueEndEntityCrt(&x509.CertificateRequest{
+					DNSNames:  []string{"fake-name"},
+					PublicKey: key.Public(),
+				})
+				if err != nil {
+					t.Fatalf("Failed to issue a certificate for a %s key: %s", alg, err)
+				}
+				if err := crt.Verify(root.Cred.Crt.CertPool(), "fake-name", time.Time{}); err != nil {
+					t.Fatalf("Failed to verify the certificate for a %s key: %s", alg, err)
+				}
+			}
+		})
+	}
+
+	t.Run("restricted key algorithms", func(t *testing.T) {
+		root, err := GenerateRootCAWithDefaults("fake-root")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		root.KeyAlgorithms = []KeyAlgorithm{KeyAlgorithmECDSAP256}
+		key, err := GenerateKeyWithAlgorithm(KeyAlgorithmEd25519)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		_, err = root.IssueEndEntityCrt(&x509.CertificateRequest{
+			DNSNames:  []string{"fake-name"},
+			PublicKey: key.Public(),
+		})
+		if err == nil {
+			t.Fatal("Expected a CSR with a disallowed key algorithm to be rejected")
+		}
+	})
+}
@@ -2,7 +2,9 @@ package tls
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -34,10 +36,10 @@ func EncodePrivateKeyPEM(k *ecdsa.PrivateKey) ([]byte, error) {
 }
 
 // EncodePrivateKeyP8 encodes the provided key as PEM-encoded text
-func EncodePrivateKeyP8(k *ecdsa.PrivateKey) []byte {
+func EncodePrivateKeyP8(k crypto.Signer) []byte {
 	p8, err := x509.MarshalPKCS8PrivateKey(k)
 	if err != nil {
-		panic("ECDSA keys must be encodeable as PKCS8")
+		panic(fmt.Sprintf("%T keys must be encodeable as PKCS8", k))
 	}
 	return p8
 }
@@ -74,14 +76,16 @@ func DecodePEMKey(txt string) (GenericPrivateKey, error) {
 		if err != nil {
 			return nil, err
 		}
-		if ec, ok := k.(*ecdsa.PrivateKey); ok {
-			return privateKeyEC{ec}, nil
-		}
-		if rsa, ok := k.(*rsa.PrivateKey); ok {
-			return privateKeyRSA{rsa}, nil
+		switch key := k.(type) {
+		case *ecdsa.PrivateKey:
+			return privateKeyEC{key}, nil
+		case *rsa.PrivateKey:
+			return privateKeyRSA{key}, nil
+		case ed25519.PrivateKey:
+			return privateKeyEd25519{key}, nil
 		}
 		return nil, fmt.Errorf(
-			"unsupported PKCS#8 encoded private key type: '%s', linkerd2 only supports ECDSA and RSA private keys",
+			"unsupported PKCS#8 encoded private key type: '%s', linkerd2 only supports ECDSA, RSA and Ed25519 private keys",
 			reflect.TypeOf(k))
 	default:
 		return nil, fmt.Errorf("unsupported block type: '%s'", block.Type)
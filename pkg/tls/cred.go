@@ -2,7 +2,9 @@ package tls
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -25,10 +27,17 @@ type (
 		*rsa.PrivateKey
 	}
 
-	// GenericPrivateKey represents either an EC or an RSA private key
+	// PrivateKeyEd25519 wraps an Ed25519 private key
+	privateKeyEd25519 struct {
+		ed25519.PrivateKey
+	}
+
+	// GenericPrivateKey represents either an EC, an RSA or an Ed25519 private
+	// key
 	GenericPrivateKey interface {
 		matchesCertificate(*x509.Certificate) bool
 		marshal() ([]byte, error)
+		pemType() string
 	}
 
 	// Cred is a container for a certificate, trust chain, and private key.
@@ -61,13 +70,51 @@ func (k privateKeyRSA) matchesCertificate(c *x509.Certificate) bool {
 	return ok && pub.N.Cmp(k.N) == 0 && pub.E == k.E
 }
 
+func (k privateKeyEC) pemType() string {
+	return "EC PRIVATE KEY"
+}
+
 func (k privateKeyRSA) marshal() ([]byte, error) {
 	return x509.MarshalPKCS1PrivateKey(k.PrivateKey), nil
 }
 
+func (k privateKeyRSA) pemType() string {
+	return "RSA PRIVATE KEY"
+}
+
+func (k privateKeyEd25519) matchesCertificate(c *x509.Certificate) bool {
+	pub, ok := c.PublicKey.(ed25519.PublicKey)
+	return ok && pub.Equal(k.Public())
+}
+
+func (k privateKeyEd25519) marshal() ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+}
+
+func (k privateKeyEd25519) pemType() string {
+	return "PRIVATE KEY"
+}
+
+// newPrivateKey wraps a private key of one of the supported algorithms.
+func newPrivateKey(key crypto.Signer) (GenericPrivateKey, error) {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return privateKeyEC{k}, nil
+	case *rsa.PrivateKey:
+		return privateKeyRSA{k}, nil
+	case ed25519.PrivateKey:
+		return privateKeyEd25519{k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
 // validCredOrPanic creates a  Cred, panicking if the key does not match the certificate.
-func validCredOrPanic(ecKey *ecdsa.PrivateKey, crt Crt) Cred {
-	k := privateKeyEC{ecKey}
+func validCredOrPanic(key crypto.Signer, crt Crt) Cred {
+	k, err := newPrivateKey(key)
+	if err != nil {
+		panic(err.Error())
+	}
 	if !k.matchesCertificate(crt.Certificate) {
 		panic("Cert's public key does not match private key")
 	}
@@ -140,7 +187,7 @@ func (cred *Cred) EncodePrivateKeyPEM() string {
 		panic(fmt.Sprintf("Invalid private key: %s", err))
 	}
 
-	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}))
+	return string(pem.EncodeToMemory(&pem.Block{Type: cred.PrivateKey.pemType(), Bytes: b}))
 }
 
 // EncodePrivateKeyP8 encodes the provided key to the PKCS#8 binary form.
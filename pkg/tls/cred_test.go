@@ -50,6 +50,23 @@ func TestCredEncodeCertificateAndTrustChain(t *testing.T) {
 	}
 }
 
+func TestCredKeyRoundtrip(t *testing.T) {
+	for _, alg := range []KeyAlgorithm{KeyAlgorithmECDSAP256, KeyAlgorithmRSA2048, KeyAlgorithmEd25519} {
+		root, err := GenerateRootCA(t.Name(), alg)
+		if err != nil {
+			t.Fatalf("failed to create %s CA: %s", alg, err)
+		}
+
+		cred, err := ValidateAndCreateCreds(root.Cred.Crt.EncodeCertificatePEM(), root.Cred.EncodePrivateKeyPEM())
+		if err != nil {
+			t.Fatalf("failed to decode %s credentials: %s", alg, err)
+		}
+		if cred.EncodePrivateKeyPEM() != root.Cred.EncodePrivateKeyPEM() {
+			t.Errorf("decoded %s private key does not match the original", alg)
+		}
+	}
+}
+
 func TestCrtExpiry(t *testing.T) {
 	root := newRoot(t)
 	rootTrust := root.Cred.Crt.CertPool()
@@ -4,37 +4,98 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sync/atomic"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	log "github.com/sirupsen/logrus"
 )
 
-const dataDirectoryLnName = "..data"
+const (
+	dataDirectoryLnName = "..data"
+
+	// DefaultCredsWatcherDebounce is the time a FsCredsWatcher waits for the
+	// filesystem to settle before reloading credentials, so that the several
+	// events of a Kubernetes volume update result in a single reload.
+	DefaultCredsWatcherDebounce = 500 * time.Millisecond
+)
 
 // FsCredsWatcher is used to monitor tls credentials on the filesystem
 type FsCredsWatcher struct {
-	certRootPath string
-	certFilePath string
-	keyFilePath  string
-	EventChan    chan<- struct{}
-	ErrorChan    chan<- error
+	fileSets []*FileSet
+	debounce time.Duration
+}
+
+// FileSet is a named set of files, typically mounted from the same Secret or
+// ConfigMap, which a FsCredsWatcher reloads as a whole when they are updated.
+type FileSet struct {
+	// Name identifies the set in logs and metrics.
+	Name string
+
+	// Dir is the directory holding the files, which is watched for updates.
+	Dir string
+
+	// Paths are the paths of the files of the set.
+	Paths []string
+
+	// Load validates the contents of the files, keyed by path, and swaps them
+	// in. It must leave the current state untouched when they are invalid.
+	Load func(contents map[string][]byte) error
 }
 
 // NewFsCredsWatcher constructs a FsCredsWatcher instance
-func NewFsCredsWatcher(certRootPath string, updateEvent chan<- struct{}, errEvent chan<- error) *FsCredsWatcher {
-	return &FsCredsWatcher{certRootPath, "", "", updateEvent, errEvent}
+func NewFsCredsWatcher() *FsCredsWatcher {
+	return &FsCredsWatcher{nil, DefaultCredsWatcherDebounce}
+}
+
+// NewKeyPairFileSet returns a FileSet which loads a PEM-encoded certificate
+// and private key into certVal, as a *tls.Certificate, once they have been
+// checked to form a valid key pair.
+func NewKeyPairFileSet(name, dir, certFilePath, keyFilePath string, certVal *atomic.Value) FileSet {
+	return FileSet{
+		Name:  name,
+		Dir:   dir,
+		Paths: []string{certFilePath, keyFilePath},
+		Load: func(contents map[string][]byte) error {
+			cert, err := tls.X509KeyPair(contents[certFilePath], contents[keyFilePath])
+			if err != nil {
+				return err
+			}
+			certVal.Store(&cert)
+			return nil
+		},
+	}
 }
 
-// WithFilePaths completes the FsCredsWatcher instance with the cert and key files locations
-func (fscw *FsCredsWatcher) WithFilePaths(certFilePath, keyFilePath string) *FsCredsWatcher {
-	fscw.certFilePath = certFilePath
-	fscw.keyFilePath = keyFilePath
+// WithFileSet adds a set of files to be reloaded when they are updated
+func (fscw *FsCredsWatcher) WithFileSet(set FileSet) *FsCredsWatcher {
+	fscw.fileSets = append(fscw.fileSets, &set)
 	return fscw
 }
 
-// StartWatching starts watching the filesystem for cert updates
+// WithDebounce sets the time to wait for the filesystem to settle before
+// reloading credentials
+func (fscw *FsCredsWatcher) WithDebounce(debounce time.Duration) *FsCredsWatcher {
+	fscw.debounce = debounce
+	return fscw
+}
+
+// LoadFileSets loads all the file sets, failing on the first one which can't
+// be loaded. It is meant to be called before StartWatching.
+func (fscw *FsCredsWatcher) LoadFileSets() error {
+	for _, set := range fscw.fileSets {
+		if err := fscw.reload(set); err != nil {
+			return fmt.Errorf("failed to load %s: %w", set.Name, err)
+		}
+	}
+	return nil
+}
+
+// StartWatching starts watching the filesystem for cert updates until the
+// context is canceled. Errors reported while watching are logged and don't
+// stop the watch.
 func (fscw *FsCredsWatcher) StartWatching(ctx context.Context) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -42,71 +103,100 @@ func (fscw *FsCredsWatcher) StartWatching(ctx context.Context) error {
 	}
 	defer watcher.Close()
 
-	// no point of proceeding if we fail to watch this
-	if err := watcher.Add(fscw.certRootPath); err != nil {
-		return err
+	// no point of proceeding if we fail to watch these
+	for _, dir := range fscw.watchedDirs() {
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
 	}
 
-LOOP:
+	// Updates are only acted upon once no event has been received for the
+	// debounce duration, as a Kubernetes volume update (writing a new data
+	// directory and swapping the ..data symlink) produces several events.
+	var (
+		settled       <-chan time.Time
+		updatedSets   = map[*FileSet]struct{}{}
+		pendingUpdate = func() {
+			settled = time.After(fscw.debounce)
+		}
+	)
+
 	for {
 		select {
 		case event := <-watcher.Events:
 			log.Debugf("Received event: %v", event)
-			// Watching the folder for create events as this indicates
-			// that the secret has been updated.
-			if event.Op&fsnotify.Create == fsnotify.Create &&
-				event.Name == filepath.Join(fscw.certRootPath, dataDirectoryLnName) {
-				fscw.EventChan <- struct{}{}
+			for _, set := range fscw.fileSets {
+				if set.updatedBy(event) {
+					updatedSets[set] = struct{}{}
+					pendingUpdate()
+				}
+			}
+		case <-settled:
+			settled = nil
+			for set := range updatedSets {
+				if err := fscw.reload(set); err != nil {
+					log.Warnf("Skipping update of %s as it could not be loaded: %s", set.Name, err)
+				} else {
+					log.Infof("Updated %s", set.Name)
+				}
 			}
+			updatedSets = map[*FileSet]struct{}{}
 		case err := <-watcher.Errors:
-			fscw.ErrorChan <- err
-			log.Warnf("Error while watching %s: %s", fscw.certRootPath, err)
-			break LOOP
+			// fsnotify reports errors such as event queue overflows, after
+			// which the watches are still in place.
+			log.Warnf("Error while watching credentials: %s", err)
 		case <-ctx.Done():
-			if err := ctx.Err(); err != nil {
-				fscw.ErrorChan <- err
-			}
-			break LOOP
+			return nil
 		}
 	}
-
-	return nil
 }
 
-// UpdateCert reads the cert and key files and stores the key pair in certVal
-func (fscw *FsCredsWatcher) UpdateCert(certVal *atomic.Value) error {
-	creds, err := ReadPEMCreds(fscw.keyFilePath, fscw.certFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read cert from disk: %w", err)
+// reload reads the files of a set and loads them, recording the outcome
+func (fscw *FsCredsWatcher) reload(set *FileSet) error {
+	contents := make(map[string][]byte, len(set.Paths))
+	for _, path := range set.Paths {
+		b, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			observeReload(set.Name, err)
+			return err
+		}
+		contents[path] = b
 	}
+	err := set.Load(contents)
+	observeReload(set.Name, err)
+	return err
+}
 
-	certPEM := creds.EncodePEM()
-	keyPEM := creds.EncodePrivateKeyPEM()
-	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
-	if err != nil {
-		return err
+func (fscw *FsCredsWatcher) watchedDirs() []string {
+	var dirs []string
+	seen := map[string]struct{}{}
+	for _, set := range fscw.fileSets {
+		if _, ok := seen[filepath.Clean(set.Dir)]; !ok {
+			dirs = append(dirs, set.Dir)
+			seen[filepath.Clean(set.Dir)] = struct{}{}
+		}
 	}
-	certVal.Store(&cert)
-	return nil
+	return dirs
 }
 
-// ProcessEvents reads from the update and error channels and reloads the certs when necessary
-func (fscw *FsCredsWatcher) ProcessEvents(
-	log *log.Entry,
-	certVal *atomic.Value,
-	updateEvent <-chan struct{},
-	errEvent <-chan error,
-) {
-	for {
-		select {
-		case <-updateEvent:
-			if err := fscw.UpdateCert(certVal); err != nil {
-				log.Warnf("Skipping update as cert could not be read from disk: %s", err)
-			} else {
-				log.Infof("Updated certificate")
-			}
-		case err := <-errEvent:
-			log.Warnf("Received error from fs watcher: %s", err)
+// updatedBy returns true if the event is a swap of the set's directory
+// contents, as done by Kubernetes, or a write to one of its files.
+func (set *FileSet) updatedBy(event fsnotify.Event) bool {
+	if isDataDirectorySwap(event, set.Dir) {
+		return true
+	}
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return false
+	}
+	for _, path := range set.Paths {
+		if filepath.Clean(path) == filepath.Clean(event.Name) {
+			return true
 		}
 	}
+	return false
+}
+
+func isDataDirectorySwap(event fsnotify.Event, dir string) bool {
+	return event.Op&fsnotify.Create == fsnotify.Create &&
+		event.Name == filepath.Join(dir, dataDirectoryLnName)
 }
@@ -0,0 +1,151 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFsCredsWatcherFileSets(t *testing.T) {
+	dir := t.TempDir()
+	crtPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCred := func(t *testing.T, name string) {
+		t.Helper()
+		ca, err := GenerateRootCAWithDefaults(name)
+		if err != nil {
+			t.Fatalf("Failed to create CA: %s", err)
+		}
+		if err := os.WriteFile(keyPath, []byte(ca.Cred.EncodePrivateKeyPEM()), 0600); err != nil {
+			t.Fatalf("Failed to write key: %s", err)
+		}
+		if err := os.WriteFile(crtPath, []byte(ca.Cred.EncodeCertificatePEM()), 0600); err != nil {
+			t.Fatalf("Failed to write certificate: %s", err)
+		}
+	}
+	loaded := func(certVal *atomic.Value) string {
+		cert, ok := certVal.Load().(*tls.Certificate)
+		if !ok || len(cert.Certificate) == 0 {
+			return ""
+		}
+		crt, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("Failed to decode loaded certificate: %s", err)
+		}
+		return crt.Subject.CommonName
+	}
+
+	writeCred(t, "first")
+	var certVal atomic.Value
+	var loads atomic.Int32
+	keyPair := NewKeyPairFileSet("test", dir, crtPath, keyPath, &certVal)
+	load := keyPair.Load
+	keyPair.Load = func(contents map[string][]byte) error {
+		loads.Add(1)
+		return load(contents)
+	}
+	watcher := NewFsCredsWatcher().
+		WithFileSet(keyPair).
+		WithDebounce(100 * time.Millisecond)
+
+	if err := watcher.LoadFileSets(); err != nil {
+		t.Fatalf("Failed to load file sets: %s", err)
+	}
+	if cn := loaded(&certVal); cn != "first" {
+		t.Fatalf("Expected the first certificate to be loaded, got %q", cn)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := watcher.StartWatching(ctx); err != nil {
+			t.Errorf("Failed to watch: %s", err)
+		}
+	}()
+	// Give the watcher time to watch the directory.
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("reloads once updates have settled", func(t *testing.T) {
+		before := loads.Load()
+		writeCred(t, "second")
+		deadline := time.Now().Add(5 * time.Second)
+		for loaded(&certVal) != "second" {
+			if time.Now().After(deadline) {
+				t.Fatal("Timed out waiting for the certificate to be reloaded")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := loads.Load() - before; n != 1 {
+			t.Errorf("Expected the key pair to be loaded once, was loaded %d times", n)
+		}
+	})
+
+	t.Run("keeps the current credentials when the update is invalid", func(t *testing.T) {
+		before := loads.Load()
+		if err := os.WriteFile(crtPath, []byte("not a certificate"), 0600); err != nil {
+			t.Fatalf("Failed to write certificate: %s", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for loads.Load() == before {
+			if time.Now().After(deadline) {
+				t.Fatal("Timed out waiting for the reload attempt")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if cn := loaded(&certVal); cn != "second" {
+			t.Errorf("Expected the second certificate to still be loaded, got %q", cn)
+		}
+	})
+}
+
+func TestFsCredsWatcherLoadFileSets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.pem")
+	if err := os.WriteFile(path, []byte("content"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+
+	var got string
+	watcher := NewFsCredsWatcher().
+		WithFileSet(FileSet{
+			Name:  "valid",
+			Dir:   dir,
+			Paths: []string{path},
+			Load: func(contents map[string][]byte) error {
+				got = string(contents[path])
+				return nil
+			},
+		})
+	if err := watcher.LoadFileSets(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got != "content" {
+		t.Errorf("Expected the file contents to be loaded, got %q", got)
+	}
+
+	watcher.WithFileSet(FileSet{
+		Name:  "invalid",
+		Dir:   dir,
+		Paths: []string{path},
+		Load:  func(map[string][]byte) error { return errors.New("invalid") },
+	})
+	if err := watcher.LoadFileSets(); err == nil {
+		t.Error("Expected an error for an invalid file set")
+	}
+
+	watcher = NewFsCredsWatcher().
+		WithFileSet(FileSet{
+			Name:  "missing",
+			Dir:   dir,
+			Paths: []string{filepath.Join(dir, "missing.pem")},
+			Load:  func(map[string][]byte) error { return nil },
+		})
+	if err := watcher.LoadFileSets(); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,134 @@
+package tls
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type (
+	// ExternalSigner signs digests with a private key which is held by an
+	// external signing service, such as a KMS or an HSM fronted by a PKCS#11
+	// bridge, so that the key never has to be loaded by Linkerd.
+	//
+	// The signing service must accept POST requests on its URL with a JSON
+	// body of the form:
+	//
+	//	{"keyID": "<key id>", "hash": "SHA-256", "digest": "<base64 digest>"}
+	//
+	// and respond with a 200 status code and a JSON body of the form:
+	//
+	//	{"signature": "<base64 signature>"}
+	//
+	// where the signature is in the format expected by crypto/x509 for the
+	// key's algorithm (e.g. an ASN.1 DER-encoded signature for ECDSA keys).
+	ExternalSigner struct {
+		url    string
+		keyID  string
+		client *http.Client
+	}
+
+	// ExternalSignRequest is the body of a request to an external signer.
+	ExternalSignRequest struct {
+		KeyID  string `json:"keyID"`
+		Hash   string `json:"hash"`
+		Digest []byte `json:"digest"`
+	}
+
+	// ExternalSignResponse is the body of a response from an external signer.
+	ExternalSignResponse struct {
+		Signature []byte `json:"signature"`
+	}
+
+	// externalKey is a GenericPrivateKey whose signatures are produced by an
+	// ExternalSigner. Its public key is taken from the certificate it is
+	// paired with; x509.CreateCertificate verifies every signature against
+	// it, so a signer holding a different key is caught on first use.
+	externalKey struct {
+		public crypto.PublicKey
+		signer *ExternalSigner
+	}
+)
+
+// DefaultExternalSignerTimeout is the default timeout of requests to an
+// external signer.
+const DefaultExternalSignerTimeout = 10 * time.Second
+
+// NewExternalSigner returns an ExternalSigner sending requests to the given
+// URL to sign with the given key. If client is nil, an HTTP client with
+// DefaultExternalSignerTimeout is used.
+func NewExternalSigner(url, keyID string, client *http.Client) *ExternalSigner {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultExternalSignerTimeout}
+	}
+	return &ExternalSigner{url, keyID, client}
+}
+
+// SignDigest asks the external signer to sign the given digest.
+func (s *ExternalSigner) SignDigest(digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	body, err := json.Marshal(ExternalSignRequest{
+		KeyID:  s.keyID,
+		Hash:   opts.HashFunc().String(),
+		Digest: digest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("external signer request failed: %w", err)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		return nil, fmt.Errorf("external signer returned %s: %s", rsp.Status, bytes.TrimSpace(msg))
+	}
+
+	var signed ExternalSignResponse
+	if err := json.NewDecoder(rsp.Body).Decode(&signed); err != nil {
+		return nil, fmt.Errorf("invalid external signer response: %w", err)
+	}
+	if len(signed.Signature) == 0 {
+		return nil, errors.New("external signer returned an empty signature")
+	}
+	return signed.Signature, nil
+}
+
+// NewExternalCred returns a Cred for the given certificate whose private key
+// is held by an external signer. The Cred can sign certificates, e.g. as part
+// of a CA, but its private key cannot be encoded.
+func NewExternalCred(crt Crt, signer *ExternalSigner) Cred {
+	return Cred{
+		PrivateKey: externalKey{crt.Certificate.PublicKey, signer},
+		Crt:        crt,
+	}
+}
+
+func (k externalKey) Public() crypto.PublicKey {
+	return k.public
+}
+
+func (k externalKey) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return k.signer.SignDigest(digest, opts)
+}
+
+func (k externalKey) matchesCertificate(c *x509.Certificate) bool {
+	pub, ok := k.public.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(c.PublicKey)
+}
+
+func (k externalKey) marshal() ([]byte, error) {
+	return nil, errors.New("the private key is held by an external signer")
+}
+
+func (k externalKey) pemType() string {
+	return "PRIVATE KEY"
+}
@@ -0,0 +1,82 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newSignerStub(t *testing.T, key *ecdsa.PrivateKey) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ExternalSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.KeyID != "issuer" || req.Hash != "SHA-256" {
+			http.Error(w, "unknown key", http.StatusNotFound)
+			return
+		}
+		sig, err := ecdsa.SignASN1(rand.Reader, key, req.Digest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(ExternalSignResponse{Signature: sig}); err != nil {
+			t.Errorf("failed to encode response: %s", err)
+		}
+	}))
+}
+
+func TestExternalSigner(t *testing.T) {
+	root := newRoot(t)
+	issuer, err := root.GenerateCA("issuer", -1)
+	if err != nil {
+		t.Fatalf("failed to create issuer: %s", err)
+	}
+	issuerKey := issuer.Cred.PrivateKey.(privateKeyEC).PrivateKey
+
+	t.Run("issues certificates signed by the external signer", func(t *testing.T) {
+		stub := newSignerStub(t, issuerKey)
+		defer stub.Close()
+
+		cred := NewExternalCred(issuer.Cred.Crt, NewExternalSigner(stub.URL, "issuer", nil))
+		ca := NewCA(cred, Validity{Lifetime: time.Hour})
+		crt, err := ca.GenerateEndEntityCred("endentity.test")
+		if err != nil {
+			t.Fatalf("failed to issue certificate: %s", err)
+		}
+		if err := crt.Verify(root.Cred.Crt.CertPool(), "endentity.test", time.Time{}); err != nil {
+			t.Fatalf("failed to verify issued certificate: %s", err)
+		}
+	})
+
+	t.Run("fails when the signer returns an error", func(t *testing.T) {
+		stub := newSignerStub(t, issuerKey)
+		defer stub.Close()
+
+		cred := NewExternalCred(issuer.Cred.Crt, NewExternalSigner(stub.URL, "unknown", nil))
+		if _, err := NewCA(cred, Validity{}).GenerateEndEntityCred("endentity.test"); err == nil {
+			t.Fatal("expected an error from the external signer")
+		}
+	})
+
+	t.Run("fails when the signer holds another key", func(t *testing.T) {
+		otherKey, err := GenerateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %s", err)
+		}
+		stub := newSignerStub(t, otherKey)
+		defer stub.Close()
+
+		cred := NewExternalCred(issuer.Cred.Crt, NewExternalSigner(stub.URL, "issuer", nil))
+		if _, err := NewCA(cred, Validity{}).GenerateEndEntityCred("endentity.test"); err == nil {
+			t.Fatal("expected signature verification to fail")
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// KeyAlgorithm identifies the algorithm, and its parameters, of the key pairs
+// used by issuers and end-entities.
+type KeyAlgorithm string
+
+const (
+	// KeyAlgorithmECDSAP256 is ECDSA over the P-256 curve.
+	KeyAlgorithmECDSAP256 KeyAlgorithm = "ecdsa-p256"
+
+	// KeyAlgorithmRSA2048 is RSA with a 2048 bit modulus.
+	KeyAlgorithmRSA2048 KeyAlgorithm = "rsa-2048"
+
+	// KeyAlgorithmRSA4096 is RSA with a 4096 bit modulus.
+	KeyAlgorithmRSA4096 KeyAlgorithm = "rsa-4096"
+
+	// KeyAlgorithmEd25519 is Ed25519.
+	KeyAlgorithmEd25519 KeyAlgorithm = "ed25519"
+
+	// DefaultKeyAlgorithm is the algorithm used when none is configured.
+	DefaultKeyAlgorithm = KeyAlgorithmECDSAP256
+)
+
+// KeyAlgorithms returns all the supported key algorithms.
+func KeyAlgorithms() []KeyAlgorithm {
+	return []KeyAlgorithm{KeyAlgorithmECDSAP256, KeyAlgorithmRSA2048, KeyAlgorithmRSA4096, KeyAlgorithmEd25519}
+}
+
+// ParseKeyAlgorithm parses the name of a supported key algorithm. An empty
+// name is parsed as the DefaultKeyAlgorithm.
+func ParseKeyAlgorithm(name string) (KeyAlgorithm, error) {
+	if name == "" {
+		return DefaultKeyAlgorithm, nil
+	}
+	for _, alg := range KeyAlgorithms() {
+		if KeyAlgorithm(strings.ToLower(name)) == alg {
+			return alg, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported key algorithm %q, must be one of: %s", name, joinKeyAlgorithms(KeyAlgorithms()))
+}
+
+// ParseKeyAlgorithms parses a comma-separated list of key algorithm names. An
+// empty list is parsed as nil, which allows all the supported key algorithms
+// when given to CheckKeyAlgorithm.
+func ParseKeyAlgorithms(names string) ([]KeyAlgorithm, error) {
+	var algs []KeyAlgorithm
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		alg, err := ParseKeyAlgorithm(name)
+		if err != nil {
+			return nil, err
+		}
+		algs = append(algs, alg)
+	}
+	return algs, nil
+}
+
+// GenerateKeyWithAlgorithm creates a new private key of the given algorithm
+// from the default random source.
+func GenerateKeyWithAlgorithm(alg KeyAlgorithm) (crypto.Signer, error) {
+	switch alg {
+	case KeyAlgorithmECDSAP256, "":
+		return GenerateKey()
+	case KeyAlgorithmRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyAlgorithmRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", alg)
+	}
+}
+
+// PublicKeyAlgorithm returns the algorithm of the given public key, failing if
+// it's not one of the supported key algorithms.
+func PublicKeyAlgorithm(pub crypto.PublicKey) (KeyAlgorithm, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return "", fmt.Errorf("must use P-256 curve for public key, instead P-%d was used", k.Params().BitSize)
+		}
+		return KeyAlgorithmECDSAP256, nil
+	case *rsa.PublicKey:
+		switch k.N.BitLen() {
+		case 2048:
+			return KeyAlgorithmRSA2048, nil
+		case 4096:
+			return KeyAlgorithmRSA4096, nil
+		default:
+			return "", fmt.Errorf("RSA must use a 2048 or 4096 bit public key, instead %d bit public key was used", k.N.BitLen())
+		}
+	case ed25519.PublicKey:
+		return KeyAlgorithmEd25519, nil
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// CheckKeyAlgorithm ensures that the given public key uses one of the allowed
+// key algorithms. An empty list allows all the supported key algorithms.
+func CheckKeyAlgorithm(pub crypto.PublicKey, allowed []KeyAlgorithm) error {
+	alg, err := PublicKeyAlgorithm(pub)
+	if err != nil {
+		return err
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, a := range allowed {
+		if a == alg {
+			return nil
+		}
+	}
+	return fmt.Errorf("key algorithm %s is not allowed, must be one of: %s", alg, joinKeyAlgorithms(allowed))
+}
+
+// signatureAlgorithm returns the algorithm used by a key pair with the given
+// public key to sign certificates.
+//
+// SHA-256 is used with ECDSA because any larger digest would be truncated to
+// 256 bits anyway since a P-256 scalar is only 256 bits long, and with RSA for
+// consistency.
+func signatureAlgorithm(pub crypto.PublicKey) x509.SignatureAlgorithm {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return x509.SHA256WithRSA
+	case ed25519.PublicKey:
+		return x509.PureEd25519
+	default:
+		return x509.ECDSAWithSHA256
+	}
+}
+
+func joinKeyAlgorithms(algs []KeyAlgorithm) string {
+	names := make([]string, len(algs))
+	for i, alg := range algs {
+		names[i] = string(alg)
+	}
+	return strings.Join(names, ", ")
+}
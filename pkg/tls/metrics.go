@@ -0,0 +1,35 @@
+package tls
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	labelName    = "name"
+	labelOutcome = "outcome"
+
+	reloadOutcomeSuccess = "success"
+	reloadOutcomeFailure = "failure"
+)
+
+var (
+	credsReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "creds_reloads_total",
+		Help: "A counter for the number of reloads of the credentials watched on the filesystem, by file set and outcome.",
+	}, []string{labelName, labelOutcome})
+
+	credsLastReload = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "creds_last_reload_timestamp_seconds",
+		Help: "The timestamp of the last successful reload of the credentials watched on the filesystem, by file set.",
+	}, []string{labelName})
+)
+
+func observeReload(name string, err error) {
+	if err != nil {
+		credsReloads.WithLabelValues(name, reloadOutcomeFailure).Inc()
+		return
+	}
+	credsReloads.WithLabelValues(name, reloadOutcomeSuccess).Inc()
+	credsLastReload.WithLabelValues(name).SetToCurrentTime()
+}
@@ -8,7 +8,7 @@ mod server;
 pub mod server_authorization;
 mod workload;
 
-pub use index::{metrics, Index, SharedIndex};
+pub use index::{metrics, Index, SharedIndex, IDENTITY_DENY_LIST_NAME};
 
 #[cfg(test)]
 mod tests;
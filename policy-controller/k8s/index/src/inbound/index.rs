@@ -46,6 +46,14 @@ pub mod metrics;
 
 pub type SharedIndex = Arc<RwLock<Index>>;
 
+/// The name of the `ConfigMap`, in the control plane namespace, listing the
+/// identities revoked by the identity controller.
+pub const IDENTITY_DENY_LIST_NAME: &str = "linkerd-identity-deny-list";
+
+/// The deny-list `ConfigMap` key holding the whitespace-separated revoked
+/// identities.
+const IDENTITY_DENY_LIST_IDENTITIES_KEY: &str = "identities";
+
 /// Holds all indexing state. Owned and updated by a single task that processes
 /// watch events, publishing results to the shared lookup map for quick lookups
 /// in the API server.
@@ -70,6 +78,13 @@ struct NamespaceIndex {
 #[derive(Debug, Default)]
 struct AuthenticationNsIndex {
     by_ns: HashMap<String, AuthenticationIndex>,
+
+    /// Identities revoked by the identity deny-list `ConfigMap`. These are
+    /// removed from the exact identity matches of all authorizations. Suffix
+    /// matches (e.g. the `all-authenticated` default policy) can't exclude an
+    /// identity, so they continue to admit revoked identities until their
+    /// certificates expire.
+    revoked_identities: HashSet<String>,
 }
 
 /// Holds `Pod`, `ExternalWorkload`, `Server`, and `ServerAuthorization` indices for a single namespace.
@@ -261,6 +276,10 @@ impl Index {
             .get_or_default_with_reindex(namespace, &self.authentications, f)
     }
 
+    fn is_identity_deny_list(&self, ns: &str, name: &str) -> bool {
+        ns == self.cluster_info.control_plane_ns && name == IDENTITY_DENY_LIST_NAME
+    }
+
     fn reindex_all(&mut self) {
         tracing::debug!("Reindexing all namespaces");
         for ns in self.namespaces.by_ns.values_mut() {
@@ -941,6 +960,45 @@ impl kubert::index::IndexNamespacedResource<k8s::policy::NetworkAuthentication>
     }
 }
 
+impl kubert::index::IndexNamespacedResource<k8s::ConfigMap> for Index {
+    fn apply(&mut self, cm: k8s::ConfigMap) {
+        let ns = cm.namespace().unwrap();
+        let name = cm.name_unchecked();
+        let _span = info_span!("apply", %ns, %name).entered();
+
+        if !self.is_identity_deny_list(&ns, &name) {
+            return;
+        }
+
+        let identities = cm
+            .data
+            .as_ref()
+            .and_then(|data| data.get(IDENTITY_DENY_LIST_IDENTITIES_KEY))
+            .map(|ids| ids.split_whitespace().map(ToString::to_string).collect())
+            .unwrap_or_default();
+        if self.authentications.update_revoked_identities(identities) {
+            tracing::info!(
+                identities = self.authentications.revoked_identities.len(),
+                "Identity deny-list updated"
+            );
+            self.reindex_all();
+        }
+    }
+
+    fn delete(&mut self, ns: String, name: String) {
+        let _span = info_span!("delete", %ns, %name).entered();
+
+        if self.is_identity_deny_list(&ns, &name)
+            && self
+                .authentications
+                .update_revoked_identities(HashSet::default())
+        {
+            tracing::info!("Identity deny-list deleted");
+            self.reindex_all();
+        }
+    }
+}
+
 impl kubert::index::IndexNamespacedResource<k8s::policy::HttpLocalRateLimitPolicy> for Index {
     fn apply(&mut self, policy: k8s::policy::HttpLocalRateLimitPolicy) {
         let ns = policy.namespace().unwrap();
@@ -1825,7 +1883,7 @@ impl PolicyIndex {
             if saz.server_selector.selects(server_name, &server.labels) {
                 authzs.insert(
                     AuthorizationRef::ServerAuthorization(name.to_string()),
-                    saz.authz.clone(),
+                    authentications.without_revoked(saz.authz.clone()),
                 );
             }
         }
@@ -2115,7 +2173,7 @@ impl PolicyIndex {
             }
         }
 
-        Ok(ClientAuthorization {
+        Ok(all_authentications.without_revoked(ClientAuthorization {
             // If MTLS identities are configured, use them. Otherwise, do not require
             // authentication.
             authentication: identities
@@ -2135,7 +2193,7 @@ impl PolicyIndex {
                     },
                 ]
             }),
-        })
+        }))
     }
 
     fn update_http_route(&mut self, gkn: GroupKindName, route: RouteBinding<HttpRoute>) -> bool {
@@ -2172,6 +2230,26 @@ impl PolicyIndex {
 // === impl AuthenticationNsIndex ===
 
 impl AuthenticationNsIndex {
+    fn update_revoked_identities(&mut self, identities: HashSet<String>) -> bool {
+        if self.revoked_identities == identities {
+            return false;
+        }
+        self.revoked_identities = identities;
+        true
+    }
+
+    /// Removes revoked identities from the authorization's exact identity
+    /// matches.
+    fn without_revoked(&self, mut authz: ClientAuthorization) -> ClientAuthorization {
+        if let ClientAuthentication::TlsAuthenticated(ref mut identities) = authz.authentication {
+            identities.retain(|id| match id {
+                IdentityMatch::Exact(name) => !self.revoked_identities.contains(name),
+                IdentityMatch::Suffix(_) => true,
+            });
+        }
+        authz
+    }
+
     fn update_meshtls(
         &mut self,
         namespace: String,
@@ -1,6 +1,65 @@
 use super::*;
 use ahash::AHashSet;
 
+// === apply ===
+
+#[test]
+fn meshtls_authn_with_spiffe_id() {
+    // The URI SAN issued by the identity controller to the sa-0 service
+    // account of ns-1 when SPIFFE IDs are enabled.
+    const SPIFFE_ID: &str = "spiffe://cluster.local/ns/ns-1/sa/sa-0";
+
+    let test = TestConfig::default();
+
+    let mut pod = mk_pod("ns-0", "pod-0", Some(("container-0", None)));
+    pod.labels_mut()
+        .insert("app".to_string(), "app-0".to_string());
+    test.index.write().apply(pod);
+    test.index.write().apply(mk_server(
+        "ns-0",
+        "srv-8080",
+        Port::Number(8080.try_into().unwrap()),
+        None,
+        Some(("app", "app-0")),
+        Some(k8s::policy::server::ProxyProtocol::Http1),
+    ));
+    test.index.write().apply(mk_authorization_policy(
+        "ns-0",
+        "authz-policy-0",
+        Some("srv-8080"),
+        vec![NamespacedTargetRef {
+            group: Some("policy.linkerd.io".to_string()),
+            kind: "MeshTLSAuthentication".to_string(),
+            name: "mtls-authn-0".to_string(),
+            namespace: None,
+        }],
+    ));
+    test.index.write().apply(mk_meshtls_authentication(
+        "ns-0",
+        "mtls-authn-0",
+        vec![SPIFFE_ID.to_string()],
+        vec![],
+    ));
+
+    let mut rx = test
+        .index
+        .write()
+        .pod_server_rx("ns-0", "pod-0", 8080.try_into().unwrap())
+        .expect("pod-0.ns-0 should exist");
+    let server = rx.borrow_and_update();
+    let authz = server
+        .authorizations
+        .get(&AuthorizationRef::AuthorizationPolicy(
+            "authz-policy-0".to_string(),
+        ))
+        .expect("authz-policy-0 should be present");
+    assert_eq!(
+        authz.authentication,
+        ClientAuthentication::TlsAuthenticated(vec![IdentityMatch::Exact(SPIFFE_ID.to_string())]),
+        "SPIFFE IDs should be matched exactly, like the URI SANs of client certificates"
+    );
+}
+
 // === delete ===
 
 #[test]
@@ -78,6 +78,99 @@ fn link_server_authz(selector: ServerSelector) {
         )));
 }
 
+#[test]
+fn drops_revoked_identities() {
+    let test = TestConfig::default();
+
+    test.index
+        .write()
+        .apply(mk_pod("ns-0", "pod-0", Some(("container-0", None))));
+    let mut rx = test
+        .index
+        .write()
+        .pod_server_rx("ns-0", "pod-0", 8080.try_into().unwrap())
+        .expect("pod-0.ns-0 should exist");
+
+    test.index.write().apply(mk_server(
+        "ns-0",
+        "srv-8080",
+        Port::Number(8080.try_into().unwrap()),
+        None,
+        None,
+        Some(k8s::policy::server::ProxyProtocol::Http1),
+    ));
+    test.index.write().apply(mk_server_authz(
+        "ns-0",
+        "authz-foo",
+        ServerSelector::Name("srv-8080".to_string()),
+        k8s::policy::server_authorization::Client {
+            networks: None,
+            unauthenticated: false,
+            mesh_tls: Some(k8s::policy::server_authorization::MeshTls {
+                identities: Some(vec!["foo.bar".to_string(), "baz.qux".to_string()]),
+                ..Default::default()
+            }),
+        },
+    ));
+    rx.borrow_and_update();
+
+    let authentication = |rx: &mut tokio::sync::watch::Receiver<InboundServer>| {
+        rx.borrow_and_update()
+            .authorizations
+            .get(&AuthorizationRef::ServerAuthorization(
+                "authz-foo".to_string(),
+            ))
+            .expect("authz-foo should be present")
+            .authentication
+            .clone()
+    };
+
+    // Deny-lists outside of the control plane namespace are ignored.
+    test.index
+        .write()
+        .apply(mk_deny_list("ns-0", "foo.bar\nbaz.qux"));
+    assert!(!rx.has_changed().unwrap());
+
+    test.index
+        .write()
+        .apply(mk_deny_list("linkerd", "foo.bar\n"));
+    assert!(rx.has_changed().unwrap());
+    assert_eq!(
+        authentication(&mut rx),
+        ClientAuthentication::TlsAuthenticated(vec![IdentityMatch::Exact("baz.qux".to_string())]),
+    );
+
+    <Index as IndexNamespacedResource<k8s::ConfigMap>>::delete(
+        &mut test.index.write(),
+        "linkerd".to_string(),
+        crate::inbound::IDENTITY_DENY_LIST_NAME.to_string(),
+    );
+    assert!(rx.has_changed().unwrap());
+    assert_eq!(
+        authentication(&mut rx),
+        ClientAuthentication::TlsAuthenticated(vec![
+            IdentityMatch::Exact("foo.bar".to_string()),
+            IdentityMatch::Exact("baz.qux".to_string()),
+        ]),
+    );
+}
+
+fn mk_deny_list(ns: impl ToString, identities: impl ToString) -> k8s::ConfigMap {
+    k8s::ConfigMap {
+        metadata: k8s::ObjectMeta {
+            namespace: Some(ns.to_string()),
+            name: Some(crate::inbound::IDENTITY_DENY_LIST_NAME.to_string()),
+            ..Default::default()
+        },
+        data: Some(
+            Some(("identities".to_string(), identities.to_string()))
+                .into_iter()
+                .collect(),
+        ),
+        ..Default::default()
+    }
+}
+
 fn mk_server_authz(
     ns: impl ToString,
     name: impl ToString,
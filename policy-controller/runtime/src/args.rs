@@ -265,6 +265,18 @@ impl Args {
                 .instrument(info_span!("networkauthentications")),
         );
 
+        let identity_deny_list = runtime.watch_namespaced::<k8s::ConfigMap>(
+            &control_plane_namespace,
+            watcher::Config::default().fields(&format!(
+                "metadata.name={}",
+                index::inbound::IDENTITY_DENY_LIST_NAME
+            )),
+        );
+        tokio::spawn(
+            kubert::index::namespaced(inbound_index.clone(), identity_deny_list)
+                .instrument(info_span!("identitydenylist")),
+        );
+
         let ratelimit_policies = guarded_watch::<k8s::policy::HttpLocalRateLimitPolicy, _>(
             &mut runtime,
             watcher::Config::default(),
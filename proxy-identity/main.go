@@ -1,7 +1,7 @@
 package main
 
 import (
-	"crypto/ecdsa"
+	"crypto"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -20,6 +20,7 @@ const (
 	envDir          = "LINKERD2_PROXY_IDENTITY_DIR"
 	envLocalName    = "LINKERD2_PROXY_IDENTITY_LOCAL_NAME"
 	envTrustAnchors = "LINKERD2_PROXY_IDENTITY_TRUST_ANCHORS"
+	envKeyAlgorithm = "LINKERD2_PROXY_IDENTITY_KEY_ALGORITHM"
 )
 
 func main() {
@@ -33,7 +34,12 @@ func main() {
 		log.Fatalf("Failed to load trust anchors: %s", err)
 	}
 
-	key, err := generateAndStoreKey(keyPath)
+	alg, err := tls.ParseKeyAlgorithm(os.Getenv(envKeyAlgorithm))
+	if err != nil {
+		log.Fatalf("Invalid key algorithm: %s", err)
+	}
+
+	key, err := generateAndStoreKey(keyPath, alg)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -102,12 +108,12 @@ func checkNotExists(p string) (err error) {
 	return
 }
 
-func generateAndStoreKey(p string) (key *ecdsa.PrivateKey, err error) {
+func generateAndStoreKey(p string, alg tls.KeyAlgorithm) (key crypto.Signer, err error) {
 	// Generate a private key and store it read-only. This is written to the
 	// file-system so that the proxy may read this key at startup. The
 	// destination path should generally be tmpfs so that the key material is
 	// not written to disk.
-	key, err = tls.GenerateKey()
+	key, err = tls.GenerateKeyWithAlgorithm(alg)
 	if err != nil {
 		return
 	}
@@ -117,7 +123,7 @@ func generateAndStoreKey(p string) (key *ecdsa.PrivateKey, err error) {
 	return
 }
 
-func generateAndStoreCSR(p, id string, key *ecdsa.PrivateKey) ([]byte, error) {
+func generateAndStoreCSR(p, id string, key crypto.Signer) ([]byte, error) {
 	if id == "" {
 		return nil, errors.New("a non-empty identity is required")
 	}
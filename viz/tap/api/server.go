@@ -42,16 +42,6 @@ func NewServer(
 	grpcTapServer pb.TapServer,
 	disableCommonNames bool,
 ) (*Server, error) {
-	updateEvent := make(chan struct{})
-	errEvent := make(chan error)
-	watcher := pkgTls.NewFsCredsWatcher(pkgk8s.MountPathTLSBase, updateEvent, errEvent).
-		WithFilePaths(pkgk8s.MountPathTLSCrtPEM, pkgk8s.MountPathTLSKeyPEM)
-	go func() {
-		if err := watcher.StartWatching(ctx); err != nil {
-			log.Fatalf("Failed to start creds watcher: %s", err)
-		}
-	}()
-
 	clientCAPem, allowedNames, usernameHeader, groupHeader, extraHeaderPrefix, err := serverAuth(ctx, k8sAPI)
 	if err != nil {
 		return nil, err
@@ -106,11 +96,16 @@ func NewServer(
 	s.Handler = prometheus.WithTelemetry(s)
 	httpServer.TLSConfig.GetCertificate = s.getCertificate
 
-	if err := watcher.UpdateCert(s.certValue); err != nil {
+	watcher := pkgTls.NewFsCredsWatcher().
+		WithFileSet(pkgTls.NewKeyPairFileSet("tap certificate", pkgk8s.MountPathTLSBase, pkgk8s.MountPathTLSCrtPEM, pkgk8s.MountPathTLSKeyPEM, s.certValue))
+	if err := watcher.LoadFileSets(); err != nil {
 		return nil, fmt.Errorf("failed to initialized certificate: %w", err)
 	}
-
-	go watcher.ProcessEvents(log, s.certValue, updateEvent, errEvent)
+	go func() {
+		if err := watcher.StartWatching(ctx); err != nil {
+			log.Fatalf("Failed to start creds watcher: %s", err)
+		}
+	}()
 
 	return s, nil
 }
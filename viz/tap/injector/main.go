@@ -23,6 +23,7 @@ func Main(args []string) {
 		context.Background(),
 		[]k8s.APIResource{k8s.NS},
 		Mutate(*tapSvcName),
+		nil,
 		"tap-injector",
 		*metricsAddr,
 		*addr,